@@ -16,6 +16,16 @@ const (
 	MissingRole          = "The user does not have the required role to access this resource."
 )
 
+// Context key of the authenticated user name
+const userNameContextKey = "userName"
+
+// UserName returns the name (JWT `name` claim) of the user that made the request, or an empty string
+// when the authentication is disabled.
+func UserName(c *fiber.Ctx) string {
+	name, _ := c.Locals(userNameContextKey).(string)
+	return name
+}
+
 func authorizationHandler(jwtContextKey string, roles []string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		token := c.Locals(jwtContextKey).(*jwt.Token)
@@ -53,6 +63,8 @@ func authorizationHandler(jwtContextKey string, roles []string) fiber.Handler {
 			return presenter.ForbiddenResponse(c, NotAuthorizedMessage, MissingRole)
 		}
 
+		c.Locals(userNameContextKey, name)
+
 		return c.Next()
 	}
 }
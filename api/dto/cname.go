@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// Cname answers its aliases with a CNAME to the target. The warnings are only set in the responses, for the CNAMEs
+// that were saved but that dnsmasq may not answer.
+type Cname struct {
+	Aliases  []string `validate:"required,min=1,dive,hostname_rfc1123"`
+	Target   string   `validate:"required,hostname_rfc1123"`
+	TTL      int      `json:",omitempty" validate:"gte=0"`
+	Warnings []string `json:",omitempty" validate:"-"`
+}
+
+func NewCname(cname *model.Cname) *Cname {
+	return &Cname{
+		Aliases: cname.Aliases,
+		Target:  cname.Target,
+		TTL:     cname.TTL,
+	}
+}
+
+func (c *Cname) ToModel() *model.Cname {
+	return &model.Cname{
+		Aliases: c.Aliases,
+		Target:  c.Target,
+		TTL:     c.TTL,
+	}
+}
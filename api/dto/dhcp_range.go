@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"net"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// DhcpRange is a subnet served by dnsmasq and, unless it is a static, proxy or router advertisement only range,
+// the pool of addresses assigned between Start and End. The IPv6 ranges built on an interface (`constructor:`) only
+// hold the host part of their addresses. The warnings are only set in the responses, for the static hosts that
+// the change left out of every served subnet.
+type DhcpRange struct {
+	SetTag       string   `json:",omitempty"`
+	MatchTags    []string `json:",omitempty" validate:"dive,required"`
+	Start        string   `validate:"required,ip"`
+	End          string   `json:",omitempty" validate:"omitempty,ip"`
+	Interface    string   `json:",omitempty"`
+	Modes        []string `json:",omitempty" validate:"dive,oneof=static proxy ra-only ra-names ra-stateless slaac ra-advrouter off-link"`
+	Netmask      string   `json:",omitempty" validate:"omitempty,ipv4"`
+	Broadcast    string   `json:",omitempty" validate:"omitempty,ipv4"`
+	PrefixLength int      `json:",omitempty" validate:"gte=0,lte=128"`
+	LeaseTime    string   `json:",omitempty"`
+	Warnings     []string `json:",omitempty" validate:"-"`
+}
+
+func NewDhcpRange(dhcpRange *model.DhcpRange) *DhcpRange {
+	return &DhcpRange{
+		SetTag:       dhcpRange.SetTag,
+		MatchTags:    dhcpRange.MatchTags,
+		Start:        dhcpRange.Start.String(),
+		End:          formatIP(dhcpRange.End),
+		Interface:    dhcpRange.Interface,
+		Modes:        dhcpRange.Modes,
+		Netmask:      formatIP(dhcpRange.Netmask),
+		Broadcast:    formatIP(dhcpRange.Broadcast),
+		PrefixLength: dhcpRange.PrefixLength,
+		LeaseTime:    dhcpRange.LeaseTime,
+	}
+}
+
+func (r *DhcpRange) ToModel() *model.DhcpRange {
+	return &model.DhcpRange{
+		SetTag:       r.SetTag,
+		MatchTags:    r.MatchTags,
+		Start:        net.ParseIP(r.Start),
+		End:          net.ParseIP(r.End),
+		Interface:    r.Interface,
+		Modes:        r.Modes,
+		Netmask:      net.ParseIP(r.Netmask),
+		Broadcast:    net.ParseIP(r.Broadcast),
+		PrefixLength: r.PrefixLength,
+		LeaseTime:    r.LeaseTime,
+	}
+}
+
+// formatIP renders an optional address, missing (nil) addresses are left out.
+func formatIP(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
@@ -0,0 +1,19 @@
+package dto
+
+import "github.com/gringolito/dnsmasq-manager/pkg/model"
+
+type Diagnostic struct {
+	File  string
+	Line  int
+	Text  string
+	Error string
+}
+
+func NewDiagnostic(diagnostic *model.Diagnostic) *Diagnostic {
+	return &Diagnostic{
+		File:  diagnostic.File,
+		Line:  diagnostic.Line,
+		Text:  diagnostic.Text,
+		Error: diagnostic.Err.Error(),
+	}
+}
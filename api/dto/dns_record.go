@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"net"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// DnsRecord must have an IPv4 address, an IPv6 address or, for a host record, both. An address record may answer
+// nxdomain or null instead. The TTL is only allowed on the host records.
+type DnsRecord struct {
+	Type        string   `validate:"required,oneof=host address"`
+	Names       []string `validate:"required,min=1,dive,hostname_rfc1123"`
+	IPAddress   string   `json:",omitempty" validate:"required_without_all=IPv6Address Answer,omitempty,ipv4"`
+	IPv6Address string   `json:",omitempty" validate:"omitempty,ipv6"`
+	TTL         int      `json:",omitempty" validate:"gte=0"`
+	Answer      string   `json:",omitempty" validate:"omitempty,oneof=nxdomain null"`
+}
+
+func NewDnsRecord(record *model.DnsRecord) *DnsRecord {
+	dto := &DnsRecord{
+		Type:   record.Type,
+		Names:  record.Names,
+		TTL:    record.TTL,
+		Answer: record.Answer,
+	}
+	if record.IPAddress != nil {
+		dto.IPAddress = record.IPAddress.String()
+	}
+	if record.IPv6Address != nil {
+		dto.IPv6Address = record.IPv6Address.String()
+	}
+
+	return dto
+}
+
+func (r *DnsRecord) ToModel() *model.DnsRecord {
+	return &model.DnsRecord{
+		Type:        r.Type,
+		Names:       r.Names,
+		IPAddress:   net.ParseIP(r.IPAddress),
+		IPv6Address: net.ParseIP(r.IPv6Address),
+		TTL:         r.TTL,
+		Answer:      r.Answer,
+	}
+}
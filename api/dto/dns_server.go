@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// DnsServer is an upstream resolver, without domains, or a server the queries of its domains are forwarded to. An
+// empty address keeps the domains local, and `#` forwards them to the upstream resolvers. The scope is only set in
+// the responses.
+type DnsServer struct {
+	Domains []string `validate:"dive,required"`
+	Address string   `validate:"eq=|eq=#|ip"`
+	Port    int      `json:",omitempty" validate:"gte=0,lte=65535"`
+	Source  string   `json:",omitempty"`
+	Scope   string   `validate:"-"`
+}
+
+func NewDnsServer(server *model.DnsServer) *DnsServer {
+	domains := server.Domains
+	if domains == nil {
+		domains = []string{}
+	}
+
+	return &DnsServer{
+		Domains: domains,
+		Address: server.Address,
+		Port:    server.Port,
+		Source:  server.Source,
+		Scope:   server.Scope(),
+	}
+}
+
+func (s *DnsServer) ToModel() *model.DnsServer {
+	var domains []string
+	if len(s.Domains) > 0 {
+		domains = s.Domains
+	}
+
+	return &model.DnsServer{
+		Domains: domains,
+		Address: s.Address,
+		Port:    s.Port,
+		Source:  s.Source,
+	}
+}
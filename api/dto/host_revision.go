@@ -0,0 +1,22 @@
+package dto
+
+import "github.com/gringolito/dnsmasq-manager/pkg/model"
+
+// HostRevision is the state of the static hosts after a committed change.
+type HostRevision struct {
+	Revision    uint64
+	CommittedAt string
+	Hosts       []StaticDhcpHost
+}
+
+func NewHostRevision(revision *model.HostRevision) *HostRevision {
+	dto := &HostRevision{
+		Revision:    revision.Number,
+		CommittedAt: formatTime(revision.CommittedAt),
+		Hosts:       make([]StaticDhcpHost, 0, len(revision.Hosts)),
+	}
+	for _, host := range revision.Hosts {
+		dto.Hosts = append(dto.Hosts, *NewStaticDhcpHost(&host))
+	}
+	return dto
+}
@@ -0,0 +1,107 @@
+package dto
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// SrvRecord answers the SRV queries of its name (e.g. _ldap._tcp.lan) with the target and the port of the service,
+// or tells that the service is not available when it has no target.
+type SrvRecord struct {
+	Name     string `validate:"required"`
+	Target   string `validate:"omitempty,hostname_rfc1123"`
+	Port     int    `validate:"required_with=Target,omitempty,gte=1,lte=65535"`
+	Priority int    `validate:"gte=0,lte=65535"`
+	Weight   int    `validate:"gte=0,lte=65535"`
+}
+
+// MxRecord answers the MX queries of its name with the mail server of the target, or with the mx-target of dnsmasq
+// (or its own host name) when it has no target.
+type MxRecord struct {
+	Name       string `validate:"required"`
+	Target     string `validate:"omitempty,hostname_rfc1123"`
+	Preference int    `validate:"gte=0,lte=65535"`
+}
+
+// TxtRecord answers the TXT queries of its name with its strings, of up to 255 bytes each.
+type TxtRecord struct {
+	Name string   `validate:"required"`
+	Text []string `validate:"required,min=1"`
+}
+
+// PtrRecord answers the PTR queries of its name (e.g. a reverse name or a DNS-SD service) with the target.
+type PtrRecord struct {
+	Name   string `validate:"required"`
+	Target string `validate:"required"`
+}
+
+// NewResourceRecord returns the DTO of the type of the record.
+func NewResourceRecord(record *model.ResourceRecord) interface{} {
+	switch record.Type {
+	case model.ResourceRecordTypeSrv:
+		return &SrvRecord{
+			Name:     record.Name,
+			Target:   record.Target,
+			Port:     record.Port,
+			Priority: record.Priority,
+			Weight:   record.Weight,
+		}
+	case model.ResourceRecordTypeMx:
+		return &MxRecord{
+			Name:       record.Name,
+			Target:     record.Target,
+			Preference: record.Priority,
+		}
+	case model.ResourceRecordTypeTxt:
+		return &TxtRecord{
+			Name: record.Name,
+			Text: record.Text,
+		}
+	default:
+		return &PtrRecord{
+			Name:   record.Name,
+			Target: record.Target,
+		}
+	}
+}
+
+func (r *SrvRecord) ToModel() *model.ResourceRecord {
+	return &model.ResourceRecord{
+		Type:     model.ResourceRecordTypeSrv,
+		Name:     r.Name,
+		Target:   r.Target,
+		Port:     r.Port,
+		Priority: r.Priority,
+		Weight:   r.Weight,
+	}
+}
+
+// ToModel returns the MX record, with the default preference when it has neither a target nor a preference.
+func (r *MxRecord) ToModel() *model.ResourceRecord {
+	preference := r.Preference
+	if r.Target == "" && preference == 0 {
+		preference = model.DefaultMxPreference
+	}
+
+	return &model.ResourceRecord{
+		Type:     model.ResourceRecordTypeMx,
+		Name:     r.Name,
+		Target:   r.Target,
+		Priority: preference,
+	}
+}
+
+func (r *TxtRecord) ToModel() *model.ResourceRecord {
+	return &model.ResourceRecord{
+		Type: model.ResourceRecordTypeTxt,
+		Name: r.Name,
+		Text: r.Text,
+	}
+}
+
+func (r *PtrRecord) ToModel() *model.ResourceRecord {
+	return &model.ResourceRecord{
+		Type:   model.ResourceRecordTypePtr,
+		Name:   r.Name,
+		Target: r.Target,
+	}
+}
@@ -0,0 +1,18 @@
+package dto
+
+import "github.com/gringolito/dnsmasq-manager/pkg/host"
+
+// SearchResult is a host matching a search, along with how well (Score) and where (Field) it matches.
+type SearchResult struct {
+	StaticDhcpHost
+	Score int
+	Field string
+}
+
+func NewSearchResult(result *host.SearchResult) *SearchResult {
+	return &SearchResult{
+		StaticDhcpHost: *NewStaticDhcpHost(&result.Host),
+		Score:          result.Score,
+		Field:          result.Field,
+	}
+}
@@ -2,30 +2,72 @@ package dto
 
 import (
 	"net"
+	"time"
 
 	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/oui"
 )
 
+// StaticDhcpHost must have an IPv4 address, an IPv6 address or both (dual-stack).
+//
+// Vendor, CreatedAt, UpdatedAt and CreatedBy are set by the manager, they are ignored in the requests.
 type StaticDhcpHost struct {
-	MacAddress string `validate:"required,mac"`
-	IPAddress  string `validate:"required,ipv4"`
-	HostName   string `validate:"required,hostname"`
+	MacAddress  string   `validate:"required,mac"`
+	Vendor      string   `json:",omitempty"`
+	IPAddress   string   `validate:"required_without=IPv6Address,omitempty,ipv4"`
+	IPv6Address string   `json:",omitempty" validate:"omitempty,ipv6"`
+	HostName    string   `validate:"required,hostname"`
+	Description string   `json:",omitempty"`
+	Owner       string   `json:",omitempty"`
+	Labels      []string `json:",omitempty" validate:"dive,required"`
+	CreatedAt   string   `json:",omitempty"`
+	UpdatedAt   string   `json:",omitempty"`
+	CreatedBy   string   `json:",omitempty"`
 }
 
 func NewStaticDhcpHost(host *model.StaticDhcpHost) *StaticDhcpHost {
-	return &StaticDhcpHost{
-		MacAddress: host.MacAddress.String(),
-		IPAddress:  host.IPAddress.String(),
-		HostName:   host.HostName,
+	dto := &StaticDhcpHost{
+		MacAddress:  host.MacAddress.String(),
+		Vendor:      oui.Vendor(host.MacAddress),
+		HostName:    host.HostName,
+		Description: host.Metadata.Description,
+		Owner:       host.Metadata.Owner,
+		Labels:      host.Metadata.Labels,
+		CreatedAt:   formatTime(host.Metadata.CreatedAt),
+		UpdatedAt:   formatTime(host.Metadata.UpdatedAt),
+		CreatedBy:   host.Metadata.CreatedBy,
 	}
+	// Hand-written hosts may not have an IPv4 address (e.g. ignored or IPv6 only hosts)
+	if host.IPAddress != nil {
+		dto.IPAddress = host.IPAddress.String()
+	}
+	if host.IPv6Address != nil {
+		dto.IPv6Address = host.IPv6Address.String()
+	}
+
+	return dto
 }
 
 func (h *StaticDhcpHost) ToModel() *model.StaticDhcpHost {
 	mac, _ := net.ParseMAC(h.MacAddress)
 
 	return &model.StaticDhcpHost{
-		MacAddress: mac,
-		IPAddress:  net.ParseIP(h.IPAddress),
-		HostName:   h.HostName,
+		MacAddress:  mac,
+		IPAddress:   net.ParseIP(h.IPAddress),
+		IPv6Address: net.ParseIP(h.IPv6Address),
+		HostName:    h.HostName,
+		Metadata: model.HostMetadata{
+			Description: h.Description,
+			Owner:       h.Owner,
+			Labels:      h.Labels,
+		},
+	}
+}
+
+// formatTime renders a timestamp as RFC 3339, unknown (zero) timestamps are left out.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
 	}
+	return t.Format(time.RFC3339)
 }
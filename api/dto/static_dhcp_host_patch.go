@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"net"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// StaticDhcpHostPatch only changes the fields present in the request. An empty address removes it from the host,
+// as long as the host is left with at least one address.
+type StaticDhcpHostPatch struct {
+	IPAddress   *string `validate:"omitempty,eq=|ipv4"`
+	IPv6Address *string `validate:"omitempty,eq=|ipv6"`
+	HostName    *string `validate:"omitempty,hostname"`
+	Description *string
+	Owner       *string
+	Labels      *[]string `validate:"omitempty,dive,required"`
+}
+
+func (p *StaticDhcpHostPatch) ToModel() *model.StaticDhcpHostPatch {
+	return &model.StaticDhcpHostPatch{
+		IPAddress:   parseOptionalIP(p.IPAddress),
+		IPv6Address: parseOptionalIP(p.IPv6Address),
+		HostName:    p.HostName,
+		Description: p.Description,
+		Owner:       p.Owner,
+		Labels:      p.Labels,
+	}
+}
+
+// parseOptionalIP keeps an absent address absent, while an empty one becomes a nil IP.
+func parseOptionalIP(address *string) *net.IP {
+	if address == nil {
+		return nil
+	}
+	ip := net.ParseIP(*address)
+	return &ip
+}
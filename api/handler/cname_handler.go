@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api"
+	"github.com/gringolito/dnsmasq-manager/api/dto"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/api/scope"
+	"github.com/gringolito/dnsmasq-manager/api/validation"
+	"github.com/gringolito/dnsmasq-manager/pkg/cname"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"log/slog"
+)
+
+// Error messages
+const (
+	CnameNotFoundMessage   = "No CNAME found for the given alias."
+	DuplicatedAliasMessage = "The alias is already in use."
+	CnameLoopMessage       = "The CNAME would make a loop."
+)
+
+// Details
+const (
+	NoMatchingAlias = "The DNS server could not find a CNAME that answers the given alias. " +
+		"The alias that was provided was: %s."
+	AliasInUseByCname = "The alias that was provided is already answered by the CNAME to %s. " +
+		"Please try again with a different alias, or change that CNAME first. The alias that was provided was: %s."
+	AliasInUseByHost = "The alias that was provided is the hostname of the static host with the MAC address %s, " +
+		"which dnsmasq would answer instead of the CNAME. The alias that was provided was: %s."
+	AliasInUseByDnsRecord = "The alias that was provided is answered by the %s record of %s, which dnsmasq would " +
+		"answer instead of the CNAME. The alias that was provided was: %s."
+	CnameLoop = "Following the targets of the CNAME leads back to one of its aliases: %s. " +
+		"Please point the CNAME to another target."
+	InvalidCname          = "The CNAME can't be written as a dnsmasq entry: %s."
+	CnameCouldNotBeParsed = "The request could not be processed because the CNAME could not be parsed. " +
+		"Please check the request and try again."
+)
+
+// cnameServiceErrorResponse answers a request whose CNAME service call failed, see serviceErrorResponse.
+func cnameServiceErrorResponse(c *fiber.Ctx, err error) error {
+	message, details := cnameErrorDetails(err)
+	return presenter.ServiceErrorResponse(c, err, message, details)
+}
+
+// cnameChangeErrorResponse answers a request whose change of a CNAME failed, see presenter.ChangeErrorResponse.
+func cnameChangeErrorResponse(c *fiber.Ctx, err error, cn *model.Cname, warnings []string) error {
+	message, details := cnameErrorDetails(err)
+	return presenter.ChangeErrorResponse(c, err, message, details, cnameResponse(cn, warnings))
+}
+
+// cnameErrorDetails returns the message and the details telling the client what went wrong with its request.
+func cnameErrorDetails(err error) (string, interface{}) {
+	if message, details, ok := fileErrorDetails("CNAMEs", err); ok {
+		return message, details
+	}
+
+	var notFoundErr *cname.NotFoundError
+	var duplicatedErr *cname.DuplicatedEntryError
+	var clashErr *cname.NameClashError
+	var loopErr *cname.LoopError
+
+	switch {
+	case errors.As(err, &notFoundErr):
+		return CnameNotFoundMessage, fmt.Sprintf(NoMatchingAlias, notFoundErr.Alias)
+
+	case errors.As(err, &duplicatedErr):
+		slog.Debug("Could not change the CNAMEs because a conflict was detected",
+			slog.String("error", err.Error()),
+		)
+		return DuplicatedAliasMessage, fmt.Sprintf(AliasInUseByCname, duplicatedErr.Cname.Target, duplicatedErr.Alias)
+
+	case errors.As(err, &clashErr):
+		slog.Debug("Could not change the CNAMEs because a conflict was detected",
+			slog.String("error", err.Error()),
+		)
+		if clashErr.Host != nil {
+			return DuplicatedAliasMessage, fmt.Sprintf(AliasInUseByHost, clashErr.Host.MacAddress.String(), clashErr.Alias)
+		}
+		return DuplicatedAliasMessage, fmt.Sprintf(AliasInUseByDnsRecord, clashErr.Record.Type,
+			strings.Join(clashErr.Record.Names, ", "), clashErr.Alias)
+
+	case errors.As(err, &loopErr):
+		return CnameLoopMessage, fmt.Sprintf(CnameLoop, strings.Join(loopErr.Chain, " -> "))
+
+	case errors.Is(err, errkind.ErrValidation):
+		return InvalidRequestBodyMessage, fmt.Sprintf(InvalidCname, strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
+
+	// Internal server errors only tell the request ID
+	return "", nil
+}
+
+func getCnameFromBody(c *fiber.Ctx) *model.Cname {
+	cname := new(dto.Cname)
+	if err := c.BodyParser(cname); err != nil {
+		slog.Debug("Failed to parse CNAME from the body",
+			slog.String("error", err.Error()),
+		)
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, CnameCouldNotBeParsed)
+		return nil
+	}
+
+	if errors := validation.Validate(cname); errors != nil {
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, errors)
+		return nil
+	}
+
+	return cname.ToModel()
+}
+
+// cnameResponse returns a changed CNAME along with the warnings of the service.
+func cnameResponse(cname *model.Cname, warnings []string) *dto.Cname {
+	response := dto.NewCname(cname)
+	response.Warnings = warnings
+	return response
+}
+
+func GetAllCnames(service cname.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cnames, err := service.FetchAll()
+		if err != nil {
+			return cnameServiceErrorResponse(c, err)
+		}
+
+		response := make([]dto.Cname, 0, len(*cnames))
+		for _, cn := range *cnames {
+			response = append(response, *dto.NewCname(&cn))
+		}
+
+		return c.Status(http.StatusOK).JSON(response)
+	}
+}
+
+// GetCname returns the CNAME answering the alias in the path, regardless of the case.
+func GetCname(service cname.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		alias := c.Params("alias")
+		cn, err := service.FetchByAlias(alias)
+		if err != nil {
+			return cnameServiceErrorResponse(c, err)
+		}
+		if cn == nil {
+			return presenter.NotFoundResponse(c, CnameNotFoundMessage, fmt.Sprintf(NoMatchingAlias, alias))
+		}
+
+		return c.Status(http.StatusOK).JSON(dto.NewCname(cn))
+	}
+}
+
+func AddCname(service cname.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cn := getCnameFromBody(c)
+		if cn == nil {
+			// The errors was already handled by the getCnameFromBody()
+			return nil
+		}
+
+		warnings, err := service.Insert(cn)
+		if err != nil {
+			return cnameChangeErrorResponse(c, err, cn, warnings)
+		}
+
+		return c.Status(http.StatusCreated).JSON(cnameResponse(cn, warnings))
+	}
+}
+
+// UpdateCname replaces the CNAME answering the alias in the path, which may be renamed by the body.
+func UpdateCname(service cname.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cn := getCnameFromBody(c)
+		if cn == nil {
+			// The errors was already handled by the getCnameFromBody()
+			return nil
+		}
+
+		warnings, err := service.Update(c.Params("alias"), cn)
+		if err != nil {
+			return cnameChangeErrorResponse(c, err, cn, warnings)
+		}
+
+		return c.Status(http.StatusOK).JSON(cnameResponse(cn, warnings))
+	}
+}
+
+func RemoveCname(service cname.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cn, err := service.RemoveByAlias(c.Params("alias"))
+		if err != nil && cn != nil {
+			return cnameChangeErrorResponse(c, err, cn, nil)
+		}
+		if err != nil {
+			return cnameServiceErrorResponse(c, err)
+		}
+		if cn == nil {
+			return c.SendStatus(http.StatusNoContent)
+		}
+
+		return c.Status(http.StatusOK).JSON(dto.NewCname(cn))
+	}
+}
+
+// RouteStaticCnames routes the CNAMEs API, which shares the scopes of the static DNS records.
+func RouteStaticCnames(router api.Router, service cname.Service) {
+	router.AddApiV1Route("/static/cname", func(r fiber.Router) {
+		r.Get("", router.AuthenticationHandler(scope.DnsCanRead...), GetAllCnames(service)).Name("get_all")
+		r.Get("/:alias", router.AuthenticationHandler(scope.DnsCanRead...), GetCname(service)).Name("get")
+		r.Post("", router.AuthenticationHandler(scope.DnsCanWrite...), AddCname(service)).Name("add")
+		r.Put("/:alias", router.AuthenticationHandler(scope.DnsCanWrite...), UpdateCname(service)).Name("update")
+		r.Delete("/:alias", router.AuthenticationHandler(scope.DnsCanWrite...), RemoveCname(service)).Name("remove")
+	}, "static.cname.")
+}
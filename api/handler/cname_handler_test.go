@@ -0,0 +1,525 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gringolito/dnsmasq-manager/api"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/api/scope"
+	"github.com/gringolito/dnsmasq-manager/config"
+	"github.com/gringolito/dnsmasq-manager/pkg/cname"
+	cnamemock "github.com/gringolito/dnsmasq-manager/pkg/cname/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"github.com/gringolito/dnsmasq-manager/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	ValidCnameJSON           = `{"Aliases":["files.lan","smb.lan"], "Target":"nas.lan", "TTL":300}`
+	ExternalCnameJSON        = `{"Aliases":["docs.lan"], "Target":"wiki.example.com"}`
+	InvalidAliasCnameJSON    = `{"Aliases":["B@r"], "Target":"nas.lan"}`
+	NoAliasCnameJSON         = `{"Aliases":[], "Target":"nas.lan"}`
+	NoTargetCnameJSON        = `{"Aliases":["files.lan"]}`
+	SelfTargetCnameJSON      = `{"Aliases":["nas.lan"], "Target":"nas.lan"}`
+	ExternalCnameWarningJSON = `{"Aliases":["docs.lan"], "Target":"wiki.example.com", "Warnings":["The target wiki.example.com is not a static host.*"]}`
+	AllCnamesJSON            = `[` + ValidCnameJSON + `,` + ExternalCnameJSON + `]`
+)
+
+var ValidCname = model.Cname{Aliases: []string{"files.lan", "smb.lan"}, Target: "nas.lan", TTL: 300}
+var ExternalCname = model.Cname{Aliases: []string{"docs.lan"}, Target: "wiki.example.com"}
+var SelfTargetCname = model.Cname{Aliases: []string{"nas.lan"}, Target: "nas.lan"}
+var AllCnames = []model.Cname{ValidCname, ExternalCname}
+
+var voidCnameMock = func(mock *cnamemock.ServiceMock) {}
+
+func setupCnameTest(t *testing.T, mockSetup func(mock *cnamemock.ServiceMock)) *fiber.App {
+	app := setupApp()
+	config := setupConfig(t)
+	serviceMock := &cnamemock.ServiceMock{}
+	router := setupRouter(app, config)
+	RouteStaticCnames(router, serviceMock)
+	mockSetup(serviceMock)
+	return app
+}
+
+func TestStaticCnameApi(t *testing.T) {
+	var testCases = []struct {
+		name               string
+		httpMethod         string
+		route              string
+		requestBody        io.Reader
+		expectedStatusCode int
+		expectedResponse   string
+		expectedHeaders    map[string]string
+		mockSetup          func(s *cnamemock.ServiceMock)
+	}{
+		{
+			name:               "GetAllCnamesSuccess",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/cname",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   AllCnamesJSON,
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(&AllCnames, nil)
+			},
+		},
+		{
+			name:               "GetAllCnamesEmpty",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/cname",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `[]`,
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(&[]model.Cname{}, nil)
+			},
+		},
+		{
+			name:               "GetAllCnamesServiceError",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/cname",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, ".*"),
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(nil, errors.New("an error"))
+			},
+		},
+		{
+			name:               "GetAllCnamesStorageUnavailable",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/cname",
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse:   tests.ErrorJSON(http.StatusServiceUnavailable, presenter.StorageUnavailableCode, fmt.Sprintf(StorageUnavailableMessage, "CNAMEs"), ".*disk failure.*"),
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(nil, &errkind.StorageError{Err: errors.New("disk failure")})
+			},
+		},
+		{
+			name:               "GetCnameSuccess",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/cname/smb.lan",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidCnameJSON,
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("FetchByAlias", "smb.lan").Once().Return(&ValidCname, nil)
+			},
+		},
+		{
+			name:               "GetCnameNotFound",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/cname/foo.lan",
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, CnameNotFoundMessage, fmt.Sprintf(NoMatchingAlias, "foo.lan")),
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("FetchByAlias", "foo.lan").Once().Return(nil, nil)
+			},
+		},
+		{
+			name:               "AddCnameSuccess",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			requestBody:        strings.NewReader(ValidCnameJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidCnameJSON,
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("Insert", &ValidCname).Once().Return(nil, nil)
+			},
+		},
+		{
+			name:               "AddCnameUnresolvableTarget",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			requestBody:        strings.NewReader(ExternalCnameJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ExternalCnameWarningJSON,
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("Insert", &ExternalCname).Once().Return([]string{fmt.Sprintf(cname.UnresolvableTargetWarning, "wiki.example.com")}, nil)
+			},
+		},
+		{
+			name:               "AddCnameInvalidJSON",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			requestBody:        strings.NewReader(InvalidJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, CnameCouldNotBeParsed),
+			mockSetup:          voidCnameMock,
+		},
+		{
+			name:               "AddCnameInvalidAlias",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			requestBody:        strings.NewReader(InvalidAliasCnameJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "Aliases.0.", "The Aliases.0. field must be of type hostname_rfc1123.", "B@r"),
+			mockSetup:          voidCnameMock,
+		},
+		{
+			name:               "AddCnameMissingAlias",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			requestBody:        strings.NewReader(NoAliasCnameJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse: fmt.Sprintf(`{
+				"error": "%s",
+				"code": "validation_failed",
+				"message": "%s",
+				"details": [{
+					"field": "Aliases",
+					"reason": "The Aliases field must have at least 1 elements.",
+					"value": []
+				}]
+			}`, http.StatusText(http.StatusUnprocessableEntity), InvalidRequestBodyMessage),
+			mockSetup: voidCnameMock,
+		},
+		{
+			name:               "AddCnameMissingTarget",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			requestBody:        strings.NewReader(NoTargetCnameJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "Target", "The Target field is required.", ""),
+			mockSetup:          voidCnameMock,
+		},
+		{
+			name:               "AddCnameSelfTarget",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			requestBody:        strings.NewReader(SelfTargetCnameJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, ".*own target.*"),
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("Insert", &SelfTargetCname).Once().Return(nil, &errkind.ValidationError{Err: model.ErrCnameSelfTarget})
+			},
+		},
+		{
+			name:               "AddCnameLoop",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			requestBody:        strings.NewReader(ValidCnameJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, CnameLoopMessage, fmt.Sprintf(CnameLoop, "files.lan -> nas.lan -> files.lan")),
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("Insert", &ValidCname).Once().Return(nil, &cname.LoopError{Chain: []string{"files.lan", "nas.lan", "files.lan"}})
+			},
+		},
+		{
+			name:               "AddCnameDuplicatedAlias",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			requestBody:        strings.NewReader(ValidCnameJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedAliasMessage, fmt.Sprintf(AliasInUseByCname, "wiki.example.com", "smb.lan")),
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("Insert", &ValidCname).Once().Return(nil, &cname.DuplicatedEntryError{Alias: "smb.lan", Cname: &ExternalCname})
+			},
+		},
+		{
+			name:               "AddCnameStaticHostClash",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			requestBody:        strings.NewReader(ValidCnameJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedAliasMessage, fmt.Sprintf(AliasInUseByHost, ValidMACAddress, "files.lan")),
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("Insert", &ValidCname).Once().Return(nil, &cname.NameClashError{Alias: "files.lan", Host: &ValidHost})
+			},
+		},
+		{
+			name:               "AddCnameDnsRecordClash",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			requestBody:        strings.NewReader(ValidCnameJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedAliasMessage, fmt.Sprintf(AliasInUseByDnsRecord, "address", "printer.lan", "files.lan")),
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("Insert", &ValidCname).Once().Return(nil, &cname.NameClashError{Alias: "files.lan", Record: &AddressDnsRecord})
+			},
+		},
+		{
+			name:               "AddCnameRejectedConfig",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			requestBody:        strings.NewReader(ValidCnameJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse: fmt.Sprintf(`{
+				"error": "%s",
+				"code": "validation_failed",
+				"message": "%s",
+				"details": {
+					"error": "exit status 1",
+					"output": "dnsmasq: bad option at line 1 of candidate"
+				}
+			}`, http.StatusText(http.StatusUnprocessableEntity), RejectedConfigMessage),
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("Insert", &ValidCname).Once().Return(nil, &errkind.ValidationError{Err: &storage.ValidationError{Err: errors.New("exit status 1"), Output: "dnsmasq: bad option at line 1 of candidate"}})
+			},
+		},
+		{
+			name:               "AddCnameReloadFailed",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			requestBody:        strings.NewReader(ValidCnameJSON),
+			expectedStatusCode: http.StatusBadGateway,
+			expectedResponse:   tests.ReloadFailedJSON(ReloadFailedMessage, ".*exit status 1.*", ValidCnameJSON),
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("Insert", &ValidCname).Once().Return(nil, &dnsmasq.ReloadError{Err: errors.New("exit status 1")})
+			},
+		},
+		{
+			name:               "AddCnameFileLocked",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			requestBody:        strings.NewReader(ValidCnameJSON),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse:   tests.ErrorJSON(http.StatusServiceUnavailable, presenter.LockTimeoutCode, fmt.Sprintf(FileLockedMessage, "CNAMEs"), fmt.Sprintf(FileLockTimeout, "CNAMEs")),
+			expectedHeaders:    map[string]string{fiber.HeaderRetryAfter: "1"},
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("Insert", &ValidCname).Once().Return(nil, &errkind.StorageError{Err: &storage.LockTimeoutError{File: "/etc/dnsmasq.d/06-dns-cnames.conf", Timeout: time.Second}})
+			},
+		},
+		{
+			name:               "UpdateCnameSuccess",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/cname/files.lan",
+			requestBody:        strings.NewReader(ValidCnameJSON),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidCnameJSON,
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("Update", "files.lan", &ValidCname).Once().Return(nil, nil)
+			},
+		},
+		{
+			name:               "UpdateCnameUnresolvableTarget",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/cname/docs.lan",
+			requestBody:        strings.NewReader(ExternalCnameJSON),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ExternalCnameWarningJSON,
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("Update", "docs.lan", &ExternalCname).Once().Return([]string{fmt.Sprintf(cname.UnresolvableTargetWarning, "wiki.example.com")}, nil)
+			},
+		},
+		{
+			name:               "UpdateCnameNotFound",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/cname/foo.lan",
+			requestBody:        strings.NewReader(ValidCnameJSON),
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, CnameNotFoundMessage, fmt.Sprintf(NoMatchingAlias, "foo.lan")),
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("Update", "foo.lan", &ValidCname).Once().Return(nil, &cname.NotFoundError{Alias: "foo.lan"})
+			},
+		},
+		{
+			name:               "UpdateCnameInvalidJSON",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/cname/files.lan",
+			requestBody:        strings.NewReader(InvalidJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, CnameCouldNotBeParsed),
+			mockSetup:          voidCnameMock,
+		},
+		{
+			name:               "RemoveCnameSuccess",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/cname/files.lan",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidCnameJSON,
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("RemoveByAlias", "files.lan").Once().Return(&ValidCname, nil)
+			},
+		},
+		{
+			name:               "RemoveCnameReloadFailed",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/cname/files.lan",
+			expectedStatusCode: http.StatusBadGateway,
+			expectedResponse:   tests.ReloadFailedJSON(ReloadFailedMessage, ".*exit status 1.*", ValidCnameJSON),
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("RemoveByAlias", "files.lan").Once().Return(&ValidCname, &dnsmasq.ReloadError{Err: errors.New("exit status 1")})
+			},
+		},
+		{
+			name:               "RemoveCnameNoContent",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/cname/foo.lan",
+			expectedStatusCode: http.StatusNoContent,
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("RemoveByAlias", "foo.lan").Once().Return(nil, nil)
+			},
+		},
+		{
+			name:               "RemoveCnameServiceError",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/cname/files.lan",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, ".*"),
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("RemoveByAlias", "files.lan").Once().Return(nil, errors.New("an error"))
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		description := fmt.Sprintf("%s %s %d", test.httpMethod, test.route, test.expectedStatusCode)
+		t.Run(test.name, func(t *testing.T) {
+			app := setupCnameTest(t, test.mockSetup)
+
+			request := httptest.NewRequest(test.httpMethod, test.route, test.requestBody)
+			request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			response, err := app.Test(request)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedStatusCode, response.StatusCode, "%s: returned wrong HTTP status code", description)
+
+			for header, value := range test.expectedHeaders {
+				assert.Equal(t, value, response.Header.Get(header), "%s: unexpected %s header", description, header)
+			}
+
+			responseBody := tests.GetBody(response)
+			if test.expectedResponse == "" {
+				assert.Empty(t, responseBody, "%s: unexpected HTTP response body", description)
+				return
+			}
+			if !tests.JSONMatches(test.expectedResponse, string(responseBody)) {
+				assert.JSONEq(t, test.expectedResponse, string(responseBody), "%s: unexpected HTTP response body", description)
+			}
+		})
+	}
+}
+
+func TestStaticCnameApiWithAuth(t *testing.T) {
+	const (
+		AuthMethod = config.AuthHS256
+		AuthKey    = "FooBar77"
+	)
+
+	token := func(scopes string) *jwtTokenConfig {
+		return &jwtTokenConfig{
+			SigningKey: AuthKey,
+			Claims: jwt.MapClaims{
+				"name":  "unit-tests",
+				"exp":   time.Now().Add(time.Minute * 30).Unix(),
+				"scope": scopes,
+			},
+		}
+	}
+
+	var testCases = []struct {
+		name               string
+		httpMethod         string
+		route              string
+		token              *jwtTokenConfig
+		requestBody        io.Reader
+		expectedStatusCode int
+		expectedResponse   string
+		mockSetup          func(s *cnamemock.ServiceMock)
+	}{
+		{
+			name:               "GetAllNoAuthentication",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/cname",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
+			mockSetup:          voidCnameMock,
+		},
+		{
+			name:               "GetAllDhcpScope",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/cname",
+			token:              token(scope.DhcpWrite),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidCnameMock,
+		},
+		{
+			name:               "GetAllAuthorized",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/cname",
+			token:              token(scope.DnsRead),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   AllCnamesJSON,
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(&AllCnames, nil)
+			},
+		},
+		{
+			name:               "AddReadOnlyScope",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			token:              token(scope.DnsRead),
+			requestBody:        strings.NewReader(ValidCnameJSON),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidCnameMock,
+		},
+		{
+			name:               "AddAuthorized",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/cname",
+			token:              token(scope.DnsWrite),
+			requestBody:        strings.NewReader(ValidCnameJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidCnameJSON,
+			mockSetup: func(mock *cnamemock.ServiceMock) {
+				mock.On("Insert", &ValidCname).Once().Return(nil, nil)
+			},
+		},
+		{
+			name:               "RemoveReadOnlyScope",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/cname/files.lan",
+			token:              token(scope.DnsRead),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidCnameMock,
+		},
+	}
+
+	os.Setenv("DMM_AUTH_METHOD", AuthMethod)
+	os.Setenv("DMM_AUTH_KEY", AuthKey)
+
+	for _, test := range testCases {
+		description := fmt.Sprintf("%s %s %d", test.httpMethod, test.route, test.expectedStatusCode)
+
+		t.Run(test.name, func(t *testing.T) {
+			app := setupCnameTest(t, test.mockSetup)
+
+			request := httptest.NewRequest(test.httpMethod, test.route, test.requestBody)
+			request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			if test.token != nil {
+				jwtToken := createJwtToken(t, test.token)
+				request.Header.Set(fiber.HeaderAuthorization, "Bearer "+jwtToken)
+			}
+
+			response, err := app.Test(request)
+			require.NoError(t, err, "app.Test() request failed")
+
+			assert.Equal(t, test.expectedStatusCode, response.StatusCode, "%s: returned wrong HTTP status code", description)
+
+			responseBody := tests.GetBody(response)
+			if !tests.JSONMatches(test.expectedResponse, string(responseBody)) {
+				assert.JSONEq(t, test.expectedResponse, string(responseBody), "%s: unexpected HTTP response body", description)
+			}
+		})
+	}
+
+	os.Unsetenv("DMM_AUTH_METHOD")
+	os.Unsetenv("DMM_AUTH_KEY")
+}
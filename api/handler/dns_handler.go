@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api"
+	"github.com/gringolito/dnsmasq-manager/api/dto"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/api/scope"
+	"github.com/gringolito/dnsmasq-manager/api/validation"
+	"github.com/gringolito/dnsmasq-manager/pkg/dns"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"log/slog"
+)
+
+// Error messages
+const (
+	DnsRecordNotFoundMessage = "No static DNS record found for the given name."
+	DuplicatedDnsNameMessage = "The DNS name is already in use."
+)
+
+// Details
+const (
+	NoMatchingDnsName = "The DNS server could not find a static record that answers the given name. " +
+		"The name that was provided was: %s."
+	DnsNameInUseByRecord = "The name that was provided is already answered by the %s record of %s. " +
+		"Please try again with a different name, or change that record first. The name that was provided was: %s."
+	InvalidDnsRecord          = "The DNS record can't be written as a dnsmasq entry: %s."
+	DnsRecordCouldNotBeParsed = "The request could not be processed because the DNS record could not be parsed. " +
+		"Please check the request and try again."
+)
+
+// dnsServiceErrorResponse answers a request whose DNS service call failed, see serviceErrorResponse.
+func dnsServiceErrorResponse(c *fiber.Ctx, err error) error {
+	message, details := dnsErrorDetails(err)
+	return presenter.ServiceErrorResponse(c, err, message, details)
+}
+
+// dnsChangeErrorResponse answers a request whose change of a DNS record failed, see presenter.ChangeErrorResponse.
+func dnsChangeErrorResponse(c *fiber.Ctx, err error, record *model.DnsRecord) error {
+	message, details := dnsErrorDetails(err)
+	return presenter.ChangeErrorResponse(c, err, message, details, dto.NewDnsRecord(record))
+}
+
+// dnsErrorDetails returns the message and the details telling the client what went wrong with its request.
+func dnsErrorDetails(err error) (string, interface{}) {
+	if message, details, ok := fileErrorDetails("static DNS records", err); ok {
+		return message, details
+	}
+
+	var notFoundErr *dns.NotFoundError
+	var duplicatedErr *dns.DuplicatedEntryError
+
+	switch {
+	case errors.As(err, &notFoundErr):
+		return DnsRecordNotFoundMessage, fmt.Sprintf(NoMatchingDnsName, notFoundErr.Name)
+
+	case errors.As(err, &duplicatedErr):
+		slog.Debug("Could not change the static DNS records because a conflict was detected",
+			slog.String("error", err.Error()),
+		)
+		return DuplicatedDnsNameMessage, fmt.Sprintf(DnsNameInUseByRecord, duplicatedErr.Record.Type,
+			strings.Join(duplicatedErr.Record.Names, ", "), duplicatedErr.Name)
+
+	case errors.Is(err, errkind.ErrValidation):
+		return InvalidRequestBodyMessage, fmt.Sprintf(InvalidDnsRecord, strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
+
+	// Internal server errors only tell the request ID
+	return "", nil
+}
+
+func getDnsRecordFromBody(c *fiber.Ctx) *model.DnsRecord {
+	record := new(dto.DnsRecord)
+	if err := c.BodyParser(record); err != nil {
+		slog.Debug("Failed to parse DNS record from the body",
+			slog.String("error", err.Error()),
+		)
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, DnsRecordCouldNotBeParsed)
+		return nil
+	}
+
+	if errors := validation.Validate(record); errors != nil {
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, errors)
+		return nil
+	}
+
+	return record.ToModel()
+}
+
+func GetAllDnsRecords(service dns.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		records, err := service.FetchAll()
+		if err != nil {
+			return dnsServiceErrorResponse(c, err)
+		}
+
+		response := make([]dto.DnsRecord, 0, len(*records))
+		for _, r := range *records {
+			response = append(response, *dto.NewDnsRecord(&r))
+		}
+
+		return c.Status(http.StatusOK).JSON(response)
+	}
+}
+
+// GetDnsRecord returns the record answering the name in the path, regardless of the case.
+func GetDnsRecord(service dns.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		name := c.Params("name")
+		record, err := service.FetchByName(name)
+		if err != nil {
+			return dnsServiceErrorResponse(c, err)
+		}
+		if record == nil {
+			return presenter.NotFoundResponse(c, DnsRecordNotFoundMessage, fmt.Sprintf(NoMatchingDnsName, name))
+		}
+
+		return c.Status(http.StatusOK).JSON(dto.NewDnsRecord(record))
+	}
+}
+
+func AddDnsRecord(service dns.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		record := getDnsRecordFromBody(c)
+		if record == nil {
+			// The errors was already handled by the getDnsRecordFromBody()
+			return nil
+		}
+
+		if err := service.Insert(record); err != nil {
+			return dnsChangeErrorResponse(c, err, record)
+		}
+
+		return c.Status(http.StatusCreated).JSON(dto.NewDnsRecord(record))
+	}
+}
+
+// UpdateDnsRecord replaces the record answering the name in the path, which may be renamed by the body.
+func UpdateDnsRecord(service dns.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		record := getDnsRecordFromBody(c)
+		if record == nil {
+			// The errors was already handled by the getDnsRecordFromBody()
+			return nil
+		}
+
+		if err := service.Update(c.Params("name"), record); err != nil {
+			return dnsChangeErrorResponse(c, err, record)
+		}
+
+		return c.Status(http.StatusOK).JSON(dto.NewDnsRecord(record))
+	}
+}
+
+func RemoveDnsRecord(service dns.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		record, err := service.RemoveByName(c.Params("name"))
+		if err != nil && record != nil {
+			return dnsChangeErrorResponse(c, err, record)
+		}
+		if err != nil {
+			return dnsServiceErrorResponse(c, err)
+		}
+		if record == nil {
+			return c.SendStatus(http.StatusNoContent)
+		}
+
+		return c.Status(http.StatusOK).JSON(dto.NewDnsRecord(record))
+	}
+}
+
+func RouteStaticDns(router api.Router, service dns.Service) {
+	router.AddApiV1Route("/static/dns", func(r fiber.Router) {
+		r.Get("", router.AuthenticationHandler(scope.DnsCanRead...), GetAllDnsRecords(service)).Name("get_all")
+		r.Get("/:name", router.AuthenticationHandler(scope.DnsCanRead...), GetDnsRecord(service)).Name("get")
+		r.Post("", router.AuthenticationHandler(scope.DnsCanWrite...), AddDnsRecord(service)).Name("add")
+		r.Put("/:name", router.AuthenticationHandler(scope.DnsCanWrite...), UpdateDnsRecord(service)).Name("update")
+		r.Delete("/:name", router.AuthenticationHandler(scope.DnsCanWrite...), RemoveDnsRecord(service)).Name("remove")
+	}, "static.dns.")
+}
@@ -0,0 +1,485 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gringolito/dnsmasq-manager/api"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/api/scope"
+	"github.com/gringolito/dnsmasq-manager/config"
+	"github.com/gringolito/dnsmasq-manager/pkg/dns"
+	dnsmock "github.com/gringolito/dnsmasq-manager/pkg/dns/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"github.com/gringolito/dnsmasq-manager/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	ValidDnsRecordJSON       = `{"Type":"host", "Names":["nas.lan","nas"], "IPAddress":"192.168.1.10", "IPv6Address":"2001:db8::10", "TTL":300}`
+	AddressDnsRecordJSON     = `{"Type":"address", "Names":["printer.lan"], "IPAddress":"192.168.1.20"}`
+	InvalidTypeDnsRecordJSON = `{"Type":"cname", "Names":["nas.lan"], "IPAddress":"192.168.1.10"}`
+	InvalidNameDnsRecordJSON = `{"Type":"host", "Names":["B@r"], "IPAddress":"192.168.1.10"}`
+	NoAddressDnsRecordJSON   = `{"Type":"host", "Names":["nas.lan"]}`
+	BlockedDnsRecordJSON     = `{"Type":"address", "Names":["ads.example"], "Answer":"null"}`
+	AddressTTLDnsRecordJSON  = `{"Type":"address", "Names":["printer.lan"], "IPAddress":"192.168.1.20", "TTL":60}`
+	AllDnsRecordsJSON        = `[` + ValidDnsRecordJSON + `,` + AddressDnsRecordJSON + `]`
+)
+
+var ValidDnsRecord = model.DnsRecord{Type: model.DnsRecordTypeHost, Names: []string{"nas.lan", "nas"}, IPAddress: net.ParseIP("192.168.1.10"), IPv6Address: net.ParseIP("2001:db8::10"), TTL: 300}
+var AddressDnsRecord = model.DnsRecord{Type: model.DnsRecordTypeAddress, Names: []string{"printer.lan"}, IPAddress: net.ParseIP("192.168.1.20")}
+var AddressTTLDnsRecord = model.DnsRecord{Type: model.DnsRecordTypeAddress, Names: []string{"printer.lan"}, IPAddress: net.ParseIP("192.168.1.20"), TTL: 60}
+var BlockedDnsRecord = model.DnsRecord{Type: model.DnsRecordTypeAddress, Names: []string{"ads.example"}, Answer: model.DnsRecordAnswerNull}
+var AllDnsRecords = []model.DnsRecord{ValidDnsRecord, AddressDnsRecord}
+
+var voidDnsMock = func(mock *dnsmock.ServiceMock) {}
+
+func setupDnsTest(t *testing.T, mockSetup func(mock *dnsmock.ServiceMock)) *fiber.App {
+	app := setupApp()
+	config := setupConfig(t)
+	serviceMock := &dnsmock.ServiceMock{}
+	router := setupRouter(app, config)
+	RouteStaticDns(router, serviceMock)
+	mockSetup(serviceMock)
+	return app
+}
+
+func TestStaticDnsApi(t *testing.T) {
+	var testCases = []struct {
+		name               string
+		httpMethod         string
+		route              string
+		requestBody        io.Reader
+		expectedStatusCode int
+		expectedResponse   string
+		expectedHeaders    map[string]string
+		mockSetup          func(s *dnsmock.ServiceMock)
+	}{
+		{
+			name:               "GetAllDnsRecordsSuccess",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/dns",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   AllDnsRecordsJSON,
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(&AllDnsRecords, nil)
+			},
+		},
+		{
+			name:               "GetAllDnsRecordsEmpty",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/dns",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `[]`,
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(&[]model.DnsRecord{}, nil)
+			},
+		},
+		{
+			name:               "GetAllDnsRecordsServiceError",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/dns",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, ".*"),
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(nil, errors.New("an error"))
+			},
+		},
+		{
+			name:               "GetAllDnsRecordsStorageUnavailable",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/dns",
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse:   tests.ErrorJSON(http.StatusServiceUnavailable, presenter.StorageUnavailableCode, fmt.Sprintf(StorageUnavailableMessage, "static DNS records"), ".*disk failure.*"),
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(nil, &errkind.StorageError{Err: errors.New("disk failure")})
+			},
+		},
+		{
+			name:               "GetDnsRecordSuccess",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/dns/nas",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidDnsRecordJSON,
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("FetchByName", "nas").Once().Return(&ValidDnsRecord, nil)
+			},
+		},
+		{
+			name:               "GetDnsRecordNotFound",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/dns/foo.lan",
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, DnsRecordNotFoundMessage, fmt.Sprintf(NoMatchingDnsName, "foo.lan")),
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("FetchByName", "foo.lan").Once().Return(nil, nil)
+			},
+		},
+		{
+			name:               "AddDnsRecordSuccess",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/dns",
+			requestBody:        strings.NewReader(ValidDnsRecordJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidDnsRecordJSON,
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("Insert", &ValidDnsRecord).Once().Return(nil)
+			},
+		},
+		{
+			name:               "AddBlockedDomain",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/dns",
+			requestBody:        strings.NewReader(BlockedDnsRecordJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   BlockedDnsRecordJSON,
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("Insert", &BlockedDnsRecord).Once().Return(nil)
+			},
+		},
+		{
+			name:               "AddDnsRecordInvalidJSON",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/dns",
+			requestBody:        strings.NewReader(InvalidJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, DnsRecordCouldNotBeParsed),
+			mockSetup:          voidDnsMock,
+		},
+		{
+			name:               "AddDnsRecordInvalidType",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/dns",
+			requestBody:        strings.NewReader(InvalidTypeDnsRecordJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "Type", "The Type field must be one of: host, address.", "cname"),
+			mockSetup:          voidDnsMock,
+		},
+		{
+			name:               "AddDnsRecordInvalidName",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/dns",
+			requestBody:        strings.NewReader(InvalidNameDnsRecordJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "Names.0.", "The Names.0. field must be of type hostname_rfc1123.", "B@r"),
+			mockSetup:          voidDnsMock,
+		},
+		{
+			name:               "AddDnsRecordMissingAddress",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/dns",
+			requestBody:        strings.NewReader(NoAddressDnsRecordJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "IPAddress", "The IPAddress field is required when none of IPv6Address, Answer is present.", ""),
+			mockSetup:          voidDnsMock,
+		},
+		{
+			name:               "AddDnsRecordInvalidRecord",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/dns",
+			requestBody:        strings.NewReader(AddressTTLDnsRecordJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, ".*TTL.*"),
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("Insert", &AddressTTLDnsRecord).Once().Return(&errkind.ValidationError{Err: model.ErrDnsRecordAddressTTL})
+			},
+		},
+		{
+			name:               "AddDnsRecordDuplicatedName",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/dns",
+			requestBody:        strings.NewReader(AddressDnsRecordJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedDnsNameMessage, fmt.Sprintf(DnsNameInUseByRecord, "host", "nas.lan, nas", "printer.lan")),
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("Insert", &AddressDnsRecord).Once().Return(&dns.DuplicatedEntryError{Name: "printer.lan", Record: &ValidDnsRecord})
+			},
+		},
+		{
+			name:               "AddDnsRecordRejectedConfig",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/dns",
+			requestBody:        strings.NewReader(AddressDnsRecordJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse: fmt.Sprintf(`{
+				"error": "%s",
+				"code": "validation_failed",
+				"message": "%s",
+				"details": {
+					"error": "exit status 1",
+					"output": "dnsmasq: bad option at line 1 of candidate"
+				}
+			}`, http.StatusText(http.StatusUnprocessableEntity), RejectedConfigMessage),
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("Insert", &AddressDnsRecord).Once().Return(&errkind.ValidationError{Err: &storage.ValidationError{Err: errors.New("exit status 1"), Output: "dnsmasq: bad option at line 1 of candidate"}})
+			},
+		},
+		{
+			name:               "AddDnsRecordReloadFailed",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/dns",
+			requestBody:        strings.NewReader(AddressDnsRecordJSON),
+			expectedStatusCode: http.StatusBadGateway,
+			expectedResponse:   tests.ReloadFailedJSON(ReloadFailedMessage, ".*exit status 1.*", AddressDnsRecordJSON),
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("Insert", &AddressDnsRecord).Once().Return(&dnsmasq.ReloadError{Err: errors.New("exit status 1")})
+			},
+		},
+		{
+			name:               "AddDnsRecordFileLocked",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/dns",
+			requestBody:        strings.NewReader(AddressDnsRecordJSON),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse:   tests.ErrorJSON(http.StatusServiceUnavailable, presenter.LockTimeoutCode, fmt.Sprintf(FileLockedMessage, "static DNS records"), fmt.Sprintf(FileLockTimeout, "static DNS records")),
+			expectedHeaders:    map[string]string{fiber.HeaderRetryAfter: "1"},
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("Insert", &AddressDnsRecord).Once().Return(&errkind.StorageError{Err: &storage.LockTimeoutError{File: "/etc/dnsmasq.d/05-dns-static-records.conf", Timeout: time.Second}})
+			},
+		},
+		{
+			name:               "UpdateDnsRecordSuccess",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/dns/nas",
+			requestBody:        strings.NewReader(ValidDnsRecordJSON),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidDnsRecordJSON,
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("Update", "nas", &ValidDnsRecord).Once().Return(nil)
+			},
+		},
+		{
+			name:               "UpdateDnsRecordNotFound",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/dns/foo.lan",
+			requestBody:        strings.NewReader(ValidDnsRecordJSON),
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, DnsRecordNotFoundMessage, fmt.Sprintf(NoMatchingDnsName, "foo.lan")),
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("Update", "foo.lan", &ValidDnsRecord).Once().Return(&dns.NotFoundError{Name: "foo.lan"})
+			},
+		},
+		{
+			name:               "UpdateDnsRecordInvalidJSON",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/dns/nas",
+			requestBody:        strings.NewReader(InvalidJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, DnsRecordCouldNotBeParsed),
+			mockSetup:          voidDnsMock,
+		},
+		{
+			name:               "RemoveDnsRecordSuccess",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/dns/nas",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidDnsRecordJSON,
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("RemoveByName", "nas").Once().Return(&ValidDnsRecord, nil)
+			},
+		},
+		{
+			name:               "RemoveDnsRecordReloadFailed",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/dns/nas",
+			expectedStatusCode: http.StatusBadGateway,
+			expectedResponse:   tests.ReloadFailedJSON(ReloadFailedMessage, ".*exit status 1.*", ValidDnsRecordJSON),
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("RemoveByName", "nas").Once().Return(&ValidDnsRecord, &dnsmasq.ReloadError{Err: errors.New("exit status 1")})
+			},
+		},
+		{
+			name:               "RemoveDnsRecordNoContent",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/dns/foo.lan",
+			expectedStatusCode: http.StatusNoContent,
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("RemoveByName", "foo.lan").Once().Return(nil, nil)
+			},
+		},
+		{
+			name:               "RemoveDnsRecordServiceError",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/dns/nas",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, ".*"),
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("RemoveByName", "nas").Once().Return(nil, errors.New("an error"))
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		description := fmt.Sprintf("%s %s %d", test.httpMethod, test.route, test.expectedStatusCode)
+		t.Run(test.name, func(t *testing.T) {
+			app := setupDnsTest(t, test.mockSetup)
+
+			request := httptest.NewRequest(test.httpMethod, test.route, test.requestBody)
+			request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			response, err := app.Test(request)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedStatusCode, response.StatusCode, "%s: returned wrong HTTP status code", description)
+
+			for header, value := range test.expectedHeaders {
+				assert.Equal(t, value, response.Header.Get(header), "%s: unexpected %s header", description, header)
+			}
+
+			responseBody := tests.GetBody(response)
+			if test.expectedResponse == "" {
+				assert.Empty(t, responseBody, "%s: unexpected HTTP response body", description)
+				return
+			}
+			if !tests.JSONMatches(test.expectedResponse, string(responseBody)) {
+				assert.JSONEq(t, test.expectedResponse, string(responseBody), "%s: unexpected HTTP response body", description)
+			}
+		})
+	}
+}
+
+func TestStaticDnsApiWithAuth(t *testing.T) {
+	const (
+		AuthMethod = config.AuthHS256
+		AuthKey    = "FooBar77"
+	)
+
+	token := func(scopes string) *jwtTokenConfig {
+		return &jwtTokenConfig{
+			SigningKey: AuthKey,
+			Claims: jwt.MapClaims{
+				"name":  "unit-tests",
+				"exp":   time.Now().Add(time.Minute * 30).Unix(),
+				"scope": scopes,
+			},
+		}
+	}
+
+	var testCases = []struct {
+		name               string
+		httpMethod         string
+		route              string
+		token              *jwtTokenConfig
+		requestBody        io.Reader
+		expectedStatusCode int
+		expectedResponse   string
+		mockSetup          func(s *dnsmock.ServiceMock)
+	}{
+		{
+			name:               "GetAllNoAuthentication",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/dns",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
+			mockSetup:          voidDnsMock,
+		},
+		{
+			name:               "GetAllDhcpScope",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/dns",
+			token:              token(scope.DhcpWrite),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidDnsMock,
+		},
+		{
+			name:               "GetAllAuthorized",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/dns",
+			token:              token(scope.DnsRead),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   AllDnsRecordsJSON,
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(&AllDnsRecords, nil)
+			},
+		},
+		{
+			name:               "GetWriteScope",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/dns/nas",
+			token:              token(scope.DnsWrite),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidDnsRecordJSON,
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("FetchByName", "nas").Once().Return(&ValidDnsRecord, nil)
+			},
+		},
+		{
+			name:               "AddReadOnlyScope",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/dns",
+			token:              token(scope.DnsRead),
+			requestBody:        strings.NewReader(ValidDnsRecordJSON),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidDnsMock,
+		},
+		{
+			name:               "AddAuthorized",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/dns",
+			token:              token(scope.DnsWrite),
+			requestBody:        strings.NewReader(ValidDnsRecordJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidDnsRecordJSON,
+			mockSetup: func(mock *dnsmock.ServiceMock) {
+				mock.On("Insert", &ValidDnsRecord).Once().Return(nil)
+			},
+		},
+		{
+			name:               "RemoveReadOnlyScope",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/dns/nas",
+			token:              token(scope.DnsRead),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidDnsMock,
+		},
+	}
+
+	os.Setenv("DMM_AUTH_METHOD", AuthMethod)
+	os.Setenv("DMM_AUTH_KEY", AuthKey)
+
+	for _, test := range testCases {
+		description := fmt.Sprintf("%s %s %d", test.httpMethod, test.route, test.expectedStatusCode)
+
+		t.Run(test.name, func(t *testing.T) {
+			app := setupDnsTest(t, test.mockSetup)
+
+			request := httptest.NewRequest(test.httpMethod, test.route, test.requestBody)
+			request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			if test.token != nil {
+				jwtToken := createJwtToken(t, test.token)
+				request.Header.Set(fiber.HeaderAuthorization, "Bearer "+jwtToken)
+			}
+
+			response, err := app.Test(request)
+			require.NoError(t, err, "app.Test() request failed")
+
+			assert.Equal(t, test.expectedStatusCode, response.StatusCode, "%s: returned wrong HTTP status code", description)
+
+			responseBody := tests.GetBody(response)
+			if !tests.JSONMatches(test.expectedResponse, string(responseBody)) {
+				assert.JSONEq(t, test.expectedResponse, string(responseBody), "%s: unexpected HTTP response body", description)
+			}
+		})
+	}
+
+	os.Unsetenv("DMM_AUTH_METHOD")
+	os.Unsetenv("DMM_AUTH_KEY")
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"log/slog"
+)
+
+// Error messages shared by the resources kept in a dnsmasq file, the files ones are formatted with the plural name
+// of the resource (e.g. "DNS servers")
+const (
+	FileLockedMessage         = "The %s file is locked by another process."
+	StorageUnavailableMessage = "The %s storage is unavailable."
+	ReloadFailedMessage       = "The change was saved, but dnsmasq could not be reloaded."
+	RejectedConfigMessage     = "The change was rejected by the dnsmasq configuration validator."
+)
+
+// Details shared by the resources kept in a dnsmasq file, formatted with the plural name of the resource
+const (
+	FileLockTimeout = "The request could not be completed because another process (e.g. another manager instance or an " +
+		"operator script) is changing the %s file. Please try again later."
+	ReloadFailed = "The %s file was updated, but the change will only take effect after dnsmasq is reloaded. " +
+		"Please check the dnsmasq service. The reload failed with: %s."
+	StorageUnavailable = "The %s could not be read or written. Please check the storage of the server and try " +
+		"again later. The storage failed with: %s."
+)
+
+// ValidatorOutput is the detail of a change rejected by the validator
+type ValidatorOutput struct {
+	Error  string `json:"error"`
+	Output string `json:"output"`
+}
+
+// fileErrorDetails returns the message and the details of the errors shared by the resources kept in a dnsmasq
+// file, i.e. a change rejected by the validator, a locked or unavailable file and a failed reload. It returns false
+// for any other error, which is up to the handler of the resource.
+func fileErrorDetails(resource string, err error) (string, interface{}, bool) {
+	var validationErr *storage.ValidationError
+	var lockErr *storage.LockTimeoutError
+	var reloadErr *dnsmasq.ReloadError
+
+	switch {
+	case errors.As(err, &validationErr):
+		slog.Info("The new file was rejected by the validator",
+			slog.String("resource", resource),
+			slog.String("error", err.Error()),
+			slog.String("output", validationErr.Output),
+		)
+		return RejectedConfigMessage, ValidatorOutput{
+			Error:  validationErr.Err.Error(),
+			Output: validationErr.Output,
+		}, true
+
+	case errors.As(err, &lockErr):
+		slog.Warn("Could not lock the file",
+			slog.String("resource", resource),
+			slog.String("error", err.Error()),
+		)
+		return fmt.Sprintf(FileLockedMessage, resource), fmt.Sprintf(FileLockTimeout, resource), true
+
+	case errors.Is(err, errkind.ErrStorageUnavailable):
+		slog.Error("Could not access the storage",
+			slog.String("resource", resource),
+			slog.String("error", err.Error()),
+		)
+		return fmt.Sprintf(StorageUnavailableMessage, resource), fmt.Sprintf(StorageUnavailable, resource, err.Error()), true
+
+	case errors.As(err, &reloadErr):
+		slog.Error("Could not reload dnsmasq",
+			slog.String("error", err.Error()),
+			slog.String("output", reloadErr.Output),
+		)
+		reason := reloadErr.Error()
+		if reloadErr.Output != "" {
+			reason += ": " + reloadErr.Output
+		}
+		return ReloadFailedMessage, fmt.Sprintf(ReloadFailed, resource, reason), true
+	}
+
+	return "", nil, false
+}
@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gringolito/dnsmasq-manager/api"
@@ -11,6 +15,7 @@ import (
 	"github.com/gringolito/dnsmasq-manager/api/presenter"
 	"github.com/gringolito/dnsmasq-manager/api/scope"
 	"github.com/gringolito/dnsmasq-manager/api/validation"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
 	"github.com/gringolito/dnsmasq-manager/pkg/host"
 	"github.com/gringolito/dnsmasq-manager/pkg/model"
 	"log/slog"
@@ -25,6 +30,10 @@ const (
 	InvalidIPAddressMessage     = "The IP address is invalid."
 	DuplicatedMacAddressMessage = "A host with the same MAC address already exists."
 	DuplicatedIPAddressMessage  = "The IP address is already in use."
+	DuplicatedHostNameMessage   = "The hostname is already in use."
+	InvalidSubnetAddressMessage = "The host addresses are not valid for the DHCP subnets served by dnsmasq."
+	VersionMismatchMessage      = "The DHCP static hosts were changed by another request."
+	NoHistoryMessage            = "No history of the DHCP static hosts is kept."
 )
 
 // Details
@@ -35,7 +44,10 @@ const (
 	NoMatchingMacAddress = "The DHCP server could not find a static host that matches the given MAC address. " +
 		"This could be because the host does not exist, or because the DHCP server is not configured to statically " +
 		"assign an IP address to the given host. The MAC address that was provided was: %s."
-	MissingQueryParameter = "The request did not specify either the `mac` or `ip` query parameter. " +
+	NoMatchingHostName = "The DHCP server could not find a static host that matches the given hostname. " +
+		"This could be because the host does not exist, or because the DHCP server is not configured to statically " +
+		"assign an IP address to the given host. The hostname that was provided was: %s."
+	MissingQueryParameter = "The request did not specify either the `mac`, `ip` or `hostname` query parameter. " +
 		"Please specify one of these parameters in order to proceed."
 	MalformedMacAddress = "The MAC address that was provided is not in the correct format. " +
 		"Please check the format of the MAC address and try again. The MAC address that was provided was: %s."
@@ -43,10 +55,192 @@ const (
 		"Please check the format of the IP address and try again. The IP address that was provided was: %s."
 	IPAddressAlreadyInUse = "The IP address that was provided is already in use by another host. " +
 		"Please try again with a different IP address. The IP address that was provided was: %s."
+	IPAddressInUseByHost = "The IP address that was provided is already in use by the host %s (%s). " +
+		"Please try again with a different IP address, or change that host first. The IP address that was provided was: %s."
 	MacAddressAlreadyInUse = "The MAC address that was provided is already in use by another host: %s."
-	HostCouldNotBeParsed   = "The request could not be processed because the host could not be parsed. Please check the request and try again."
+	HostNameInUseByHost    = "The hostname that was provided is already in use by the host %s (%s). " +
+		"Please try again with a different hostname, or change that host first. The hostname that was provided was: %s."
+	MissingMacQueryParameter = "The request did not specify either the `mac` or `hostname` query parameter. " +
+		"Please specify the MAC address, or the hostname, of the host to be changed in order to proceed."
+	HostWithoutIPAddress = "The change would leave the host without any IP address. " +
+		"Please keep either the IPv4 or the IPv6 address of the host."
+	HostWithoutHostName = "The change would leave the host without a hostname. Please keep the hostname of the host."
+	MalformedSubnet     = "The subnet that was provided is not in CIDR notation (e.g. 192.168.1.0/24). " +
+		"The subnet that was provided was: %s."
+	MalformedHostNamePattern = "The hostname pattern that was provided is malformed, it must be a glob pattern " +
+		"(e.g. printer-*). The pattern that was provided was: %s."
+	MalformedMacPrefix = "The MAC address prefix that was provided is not in the correct format (e.g. 00:11:22). " +
+		"The prefix that was provided was: %s."
+	UnknownSortKey = "The hosts can only be sorted by `ip`, `hostname` or `mac`, prefixed by `-` for the " +
+		"descending order. The sort key that was provided was: %s."
+	MalformedPagination = "The `limit` query parameter must be a positive integer, and the `offset` one a " +
+		"non-negative integer. The %s that was provided was: %s."
+	MissingSearchQuery = "The request did not specify the `q` query parameter. " +
+		"Please specify a MAC address, an IP address or a hostname, complete or partial, in order to proceed."
+	HostCouldNotBeParsed = "The request could not be processed because the host could not be parsed. Please check the request and try again."
+	IfMatchMismatch      = "The `If-Match` header does not match the current version of the resource, which was changed " +
+		"(or removed) since it was read. Please fetch it again, review the changes and retry the request."
+	NoHistory = "Only the `bolt` storage backend keeps the revisions of the static hosts. Please set " +
+		"`storage.backend` to `bolt` in order to keep them."
 )
 
+// Header telling the number of hosts matching a listing, regardless of the pagination
+const TotalCountHeader = "X-Total-Count"
+
+// FieldError is the detail of a host field rejected by the service, in the same format of the request
+// validation errors
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+	Value  string `json:"value"`
+}
+
+// serviceErrorResponse answers a request whose service call failed, with the status and the error code of the
+// error kind (see presenter.ErrorKind).
+func serviceErrorResponse(c *fiber.Ctx, err error) error {
+	message, details := errorDetails(c, err)
+	return presenter.ServiceErrorResponse(c, err, message, details)
+}
+
+// changeErrorResponse answers a request whose change of a host failed, see presenter.ChangeErrorResponse. The
+// entity tag of a host saved before dnsmasq failed to reload is set as well.
+func changeErrorResponse(c *fiber.Ctx, err error, h *model.StaticDhcpHost) error {
+	if h == nil || !errors.Is(err, errkind.ErrReloadFailed) {
+		return serviceErrorResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderETag, entityTag(h.Version()))
+	message, details := errorDetails(c, err)
+	return presenter.ChangeErrorResponse(c, err, message, details, dto.NewStaticDhcpHost(h))
+}
+
+// removeErrorResponse answers a request whose removal of a host failed, see presenter.ChangeErrorResponse. A host
+// removed before dnsmasq failed to reload has no entity tag anymore.
+func removeErrorResponse(c *fiber.Ctx, err error, h *model.StaticDhcpHost) error {
+	if h == nil {
+		return serviceErrorResponse(c, err)
+	}
+
+	message, details := errorDetails(c, err)
+	return presenter.ChangeErrorResponse(c, err, message, details, dto.NewStaticDhcpHost(h))
+}
+
+// errorDetails returns the message and the details telling the client what went wrong with its request.
+func errorDetails(c *fiber.Ctx, err error) (string, interface{}) {
+	if message, details, ok := fileErrorDetails("DHCP static hosts", err); ok {
+		return message, details
+	}
+
+	var notFoundErr *host.NotFoundError
+	var duplicatedErr *host.DuplicatedEntryError
+	var subnetErr *host.SubnetError
+	var mismatchErr *model.VersionMismatchError
+
+	switch {
+	case errors.As(err, &notFoundErr):
+		if notFoundErr.Field == "hostname" {
+			return StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingHostName, notFoundErr.Value)
+		}
+		return StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingMacAddress, notFoundErr.Value)
+
+	case errors.As(err, &duplicatedErr):
+		slog.Debug("Could not change the static hosts because a conflict was detected",
+			slog.String("error", err.Error()),
+		)
+		return duplicatedEntryDetails(duplicatedErr)
+
+	case errors.As(err, &subnetErr):
+		slog.Debug("The static host addresses do not fit in the served subnets",
+			slog.String("error", err.Error()),
+		)
+		details := make([]FieldError, 0, len(subnetErr.Violations))
+		for _, violation := range subnetErr.Violations {
+			details = append(details, FieldError{Field: violation.Field, Reason: violation.Reason, Value: violation.Value})
+		}
+		return InvalidSubnetAddressMessage, details
+
+	case errors.Is(err, host.ErrNoHistory):
+		return NoHistoryMessage, NoHistory
+
+	case errors.Is(err, model.ErrDHCPHostMissingIPAddress):
+		return InvalidRequestBodyMessage, HostWithoutIPAddress
+
+	case errors.Is(err, model.ErrDHCPHostMissingHostName):
+		return InvalidRequestBodyMessage, HostWithoutHostName
+
+	case errors.As(err, &mismatchErr):
+		slog.Debug("The change was based on an outdated version",
+			slog.String("error", err.Error()),
+		)
+		if mismatchErr.Current != "" {
+			c.Set(fiber.HeaderETag, entityTag(mismatchErr.Current))
+		}
+		return VersionMismatchMessage, IfMatchMismatch
+	}
+
+	// Internal server errors only tell the request ID
+	return "", nil
+}
+
+// duplicatedEntryDetails tells which value of the host is already in use, and by which host when it is known.
+func duplicatedEntryDetails(err *host.DuplicatedEntryError) (string, interface{}) {
+	switch {
+	case err.Field == "MAC":
+		return DuplicatedMacAddressMessage, fmt.Sprintf(MacAddressAlreadyInUse, err.Value)
+	case err.Field == "hostname":
+		return DuplicatedHostNameMessage, fmt.Sprintf(HostNameInUseByHost, err.Host.HostName, err.Host.MacAddress.String(), err.Value)
+	case err.Host != nil:
+		return DuplicatedIPAddressMessage, fmt.Sprintf(IPAddressInUseByHost, err.Host.HostName, err.Host.MacAddress.String(), err.Value)
+	default:
+		return DuplicatedIPAddressMessage, fmt.Sprintf(IPAddressAlreadyInUse, err.Value)
+	}
+}
+
+// entityTag quotes a version, making it a strong ETag.
+func entityTag(version string) string {
+	return `"` + version + `"`
+}
+
+// ifMatch returns the versions listed by the `If-Match` header. It uses the strong comparison, so weak tags are
+// kept as they are, never matching any version.
+func ifMatch(c *fiber.Ctx) model.Versions {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return nil
+	}
+
+	versions := model.Versions{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != model.AnyVersion && len(tag) > 1 && strings.HasPrefix(tag, `"`) && strings.HasSuffix(tag, `"`) {
+			tag = tag[1 : len(tag)-1]
+		}
+		versions = append(versions, tag)
+	}
+
+	return versions
+}
+
+// notModified tells the version of the representation in the ETag header, and reports whether the client
+// already has it according to the `If-None-Match` header (using the weak comparison).
+func notModified(c *fiber.Ctx, version string) bool {
+	c.Set(fiber.HeaderETag, entityTag(version))
+
+	header := c.Get(fiber.HeaderIfNoneMatch)
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == model.AnyVersion || tag == entityTag(version) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func getHostFromBody(c *fiber.Ctx) *model.StaticDhcpHost {
 	host := new(dto.StaticDhcpHost)
 	if err := c.BodyParser(host); err != nil {
@@ -74,14 +268,139 @@ func toStaticDhcpHostsDto(hosts *[]model.StaticDhcpHost) *[]dto.StaticDhcpHost {
 	return &response
 }
 
+// Maximum length of a MAC address prefix
+const macPrefixMaxLength = 6
+
+// hostsQuery builds the query of a listing out of its query parameters:
+//   - `owner`, `createdBy` and `label` (which may be repeated, matching the hosts having all of them) for the
+//     metadata
+//   - `subnet` (in CIDR notation), `hostname` (a glob pattern), `macPrefix` (e.g. a vendor OUI), `vendor` (part
+//     of the vendor name) and `tag` (which may be repeated too)
+//   - `sort`, one of `ip`, `hostname` or `mac`, prefixed by `-` for the descending order
+//   - `offset` and `limit` for the pagination
+//
+// It returns nil if the query is invalid, the error was already answered then.
+func hostsQuery(c *fiber.Ctx) *host.Query {
+	filters := []host.Filter{}
+	if owner := c.Query("owner"); owner != "" {
+		filters = append(filters, host.OwnedBy(owner))
+	}
+	if createdBy := c.Query("createdBy"); createdBy != "" {
+		filters = append(filters, host.CreatedBy(createdBy))
+	}
+	for _, label := range c.Context().QueryArgs().PeekMulti("label") {
+		filters = append(filters, host.Labeled(string(label)))
+	}
+	for _, tag := range c.Context().QueryArgs().PeekMulti("tag") {
+		filters = append(filters, host.Tagged(string(tag)))
+	}
+
+	if subnet := c.Query("subnet"); subnet != "" {
+		_, ipNet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			presenter.BadRequestResponse(c, InvalidRequestMessage, fmt.Sprintf(MalformedSubnet, subnet))
+			return nil
+		}
+		filters = append(filters, host.InSubnet(ipNet))
+	}
+	if pattern := c.Query("hostname"); pattern != "" {
+		filter, err := host.HostNameMatches(pattern)
+		if err != nil {
+			presenter.BadRequestResponse(c, InvalidRequestMessage, fmt.Sprintf(MalformedHostNamePattern, pattern))
+			return nil
+		}
+		filters = append(filters, filter)
+	}
+	if macPrefix := c.Query("macPrefix"); macPrefix != "" {
+		prefix, err := hex.DecodeString(strings.NewReplacer(":", "", "-", "", ".", "").Replace(macPrefix))
+		if err != nil || len(prefix) == 0 || len(prefix) > macPrefixMaxLength {
+			presenter.BadRequestResponse(c, InvalidRequestMessage, fmt.Sprintf(MalformedMacPrefix, macPrefix))
+			return nil
+		}
+		filters = append(filters, host.MacPrefix(prefix))
+	}
+	if vendor := c.Query("vendor"); vendor != "" {
+		filters = append(filters, host.FromVendor(vendor))
+	}
+
+	query := &host.Query{Filter: host.All(filters...)}
+	if sort := c.Query("sort"); sort != "" {
+		order, err := host.OrderBy(strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-"))
+		if err != nil {
+			presenter.BadRequestResponse(c, InvalidRequestMessage, fmt.Sprintf(UnknownSortKey, sort))
+			return nil
+		}
+		query.Order = order
+	}
+
+	var ok bool
+	if query.Offset, ok = queryInt(c, "offset", 0); !ok {
+		presenter.BadRequestResponse(c, InvalidRequestMessage, fmt.Sprintf(MalformedPagination, "offset", c.Query("offset")))
+		return nil
+	}
+	if query.Limit, ok = queryInt(c, "limit", 1); !ok {
+		presenter.BadRequestResponse(c, InvalidRequestMessage, fmt.Sprintf(MalformedPagination, "limit", c.Query("limit")))
+		return nil
+	}
+
+	return query
+}
+
+// queryInt returns the integer value of a query parameter, 0 if missing. It reports whether the value is valid,
+// i.e. an integer no lower than minimum.
+func queryInt(c *fiber.Ctx, key string, minimum int) (int, bool) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, true
+	}
+
+	number, err := strconv.Atoi(value)
+	return number, err == nil && number >= minimum
+}
+
 func GetAllStaticHosts(service host.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		query := hostsQuery(c)
+		if query == nil {
+			// The errors was already handled by the hostsQuery()
+			return nil
+		}
+
+		hosts, err := service.FetchAll()
+		if err != nil {
+			return serviceErrorResponse(c, err)
+		}
+		// The whole collection is versioned, whatever the query is
+		if notModified(c, model.HostsVersion(*hosts)) {
+			return c.SendStatus(http.StatusNotModified)
+		}
+
+		page, total := query.Apply(*hosts)
+		c.Set(TotalCountHeader, strconv.Itoa(total))
+		return c.Status(http.StatusOK).JSON(toStaticDhcpHostsDto(&page))
+	}
+}
+
+// SearchStaticHosts returns the hosts matching the `q` query parameter, best matches first.
+func SearchStaticHosts(service host.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		query := strings.TrimSpace(c.Query("q"))
+		if query == "" {
+			return presenter.BadRequestResponse(c, InvalidRequestMessage, MissingSearchQuery)
+		}
+
 		hosts, err := service.FetchAll()
 		if err != nil {
-			return presenter.InternalServerErrorResponse(c)
+			return serviceErrorResponse(c, err)
+		}
+
+		results := host.Search(*hosts, query)
+		response := make([]dto.SearchResult, 0, len(results))
+		for _, result := range results {
+			response = append(response, *dto.NewSearchResult(&result))
 		}
 
-		return c.Status(http.StatusOK).JSON(toStaticDhcpHostsDto(hosts))
+		return c.Status(http.StatusOK).JSON(response)
 	}
 }
 
@@ -97,6 +416,11 @@ func GetStaticHost(service host.Service) fiber.Handler {
 			return getStaticHostByIP(service, c, ipAddress)
 		}
 
+		hostName := c.Query("hostname")
+		if len(hostName) > 0 {
+			return getStaticHostByHostName(service, c, hostName)
+		}
+
 		return presenter.BadRequestResponse(c, InvalidRequestMessage, MissingQueryParameter)
 	}
 }
@@ -113,11 +437,14 @@ func getStaticHostByMac(service host.Service, c *fiber.Ctx, macAddress string) e
 
 	host, err := service.FetchByMac(mac)
 	if err != nil {
-		return presenter.InternalServerErrorResponse(c)
+		return serviceErrorResponse(c, err)
 	}
 	if host == nil {
 		return presenter.NotFoundResponse(c, StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingMacAddress, macAddress))
 	}
+	if notModified(c, host.Version()) {
+		return c.SendStatus(http.StatusNotModified)
+	}
 
 	return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(host))
 }
@@ -133,15 +460,34 @@ func getStaticHostByIP(service host.Service, c *fiber.Ctx, ipAddress string) err
 
 	host, err := service.FetchByIP(ip)
 	if err != nil {
-		return presenter.InternalServerErrorResponse(c)
+		return serviceErrorResponse(c, err)
 	}
 	if host == nil {
 		return presenter.NotFoundResponse(c, StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingIPAddress, ipAddress))
 	}
+	if notModified(c, host.Version()) {
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(host))
+}
+
+func getStaticHostByHostName(service host.Service, c *fiber.Ctx, hostName string) error {
+	host, err := service.FetchByHostName(hostName)
+	if err != nil {
+		return serviceErrorResponse(c, err)
+	}
+	if host == nil {
+		return presenter.NotFoundResponse(c, StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingHostName, hostName))
+	}
+	if notModified(c, host.Version()) {
+		return c.SendStatus(http.StatusNotModified)
+	}
 
 	return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(host))
 }
 
+// AddStaticHost adds a new host. An `If-Match` header is checked against the version of the whole collection.
 func AddStaticHost(service host.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		h := getHostFromBody(c)
@@ -150,42 +496,114 @@ func AddStaticHost(service host.Service) fiber.Handler {
 			return nil
 		}
 
-		if err := service.Insert(h); err != nil {
-			if e, ok := err.(host.DuplicatedEntryError); ok {
-				slog.Debug("Could not add a new static host because a conflict was detected",
-					slog.Any("host", h),
-					slog.String("error", err.Error()),
-				)
-				if e.Field == "IP" {
-					return presenter.ConflictResponse(c, DuplicatedIPAddressMessage, fmt.Sprintf(IPAddressAlreadyInUse, h.IPAddress.String()))
-				} else {
-					return presenter.ConflictResponse(c, DuplicatedMacAddressMessage, fmt.Sprintf(MacAddressAlreadyInUse, h.MacAddress.String()))
-				}
-			} else {
-				return presenter.InternalServerErrorResponse(c)
-			}
+		h.Metadata.CreatedBy = api.UserName(c)
+		if err := service.Insert(h, ifMatch(c)); err != nil {
+			return changeErrorResponse(c, err, h)
 		}
 
+		c.Set(fiber.HeaderETag, entityTag(h.Version()))
 		return c.Status(http.StatusCreated).JSON(dto.NewStaticDhcpHost(h))
 	}
 }
 
+// UpdateStaticHost replaces an existing host, identified by the `mac` (or the `hostname`) query parameter, or by
+// the MAC address in the body when there is none.
 func UpdateStaticHost(service host.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		host := getHostFromBody(c)
-		if host == nil {
+		mac, ok := queryMac(c)
+		if !ok {
+			// The errors was already handled by the queryMac()
+			return nil
+		}
+		hostName := c.Query("hostname")
+
+		h := getHostFromBody(c)
+		if h == nil {
 			// The errors was already handled by the getHostFromBody()
 			return nil
 		}
 
-		if err := service.Update(host); err != nil {
-			return presenter.InternalServerErrorResponse(c)
+		var err error
+		switch {
+		case mac != nil:
+			err = service.UpdateByMac(mac, h, ifMatch(c))
+		case len(hostName) > 0:
+			err = service.UpdateByHostName(hostName, h, ifMatch(c))
+		default:
+			err = service.Update(h, ifMatch(c))
+		}
+		if err != nil {
+			return changeErrorResponse(c, err, h)
+		}
+
+		c.Set(fiber.HeaderETag, entityTag(h.Version()))
+		return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(h))
+	}
+}
+
+// PatchStaticHost changes only the fields present in the body of the host identified by the `mac` (or the
+// `hostname`) query parameter.
+func PatchStaticHost(service host.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		mac, ok := queryMac(c)
+		if !ok {
+			// The errors was already handled by the queryMac()
+			return nil
+		}
+		hostName := c.Query("hostname")
+		if mac == nil && len(hostName) == 0 {
+			return presenter.BadRequestResponse(c, InvalidRequestMessage, MissingMacQueryParameter)
+		}
+
+		patch := new(dto.StaticDhcpHostPatch)
+		if err := c.BodyParser(patch); err != nil {
+			slog.Debug("Failed to parse host patch from the body",
+				slog.String("error", err.Error()),
+			)
+			return presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, HostCouldNotBeParsed)
+		}
+
+		if errors := validation.Validate(patch); errors != nil {
+			return presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, errors)
+		}
+
+		var h *model.StaticDhcpHost
+		var err error
+		if mac != nil {
+			h, err = service.Patch(mac, patch.ToModel(), ifMatch(c))
+		} else {
+			h, err = service.PatchByHostName(hostName, patch.ToModel(), ifMatch(c))
+		}
+		if err != nil {
+			return changeErrorResponse(c, err, h)
 		}
 
-		return c.Status(http.StatusCreated).JSON(dto.NewStaticDhcpHost(host))
+		c.Set(fiber.HeaderETag, entityTag(h.Version()))
+		return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(h))
 	}
 }
 
+// queryMac parses the `mac` query parameter, nil when there is none. It answers the request and returns false when
+// the address is malformed.
+func queryMac(c *fiber.Ctx) (net.HardwareAddr, bool) {
+	macAddress := c.Query("mac")
+	if len(macAddress) == 0 {
+		return nil, true
+	}
+
+	mac, err := net.ParseMAC(macAddress)
+	if err != nil {
+		slog.Debug("Could not parse MAC address",
+			slog.String("macAddress", macAddress),
+			slog.String("error", err.Error()),
+		)
+		presenter.BadRequestResponse(c, InvalidMacAddressMessage, fmt.Sprintf(MalformedMacAddress, macAddress))
+		return nil, false
+	}
+
+	return mac, true
+}
+
 func RemoveStaticHost(service host.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		macAddress := c.Query("mac")
@@ -198,6 +616,11 @@ func RemoveStaticHost(service host.Service) fiber.Handler {
 			return removeStaticHostByIP(service, c, ipAddress)
 		}
 
+		hostName := c.Query("hostname")
+		if len(hostName) > 0 {
+			return removeStaticHostByHostName(service, c, hostName)
+		}
+
 		return presenter.BadRequestResponse(c, InvalidRequestMessage, MissingQueryParameter)
 	}
 }
@@ -212,9 +635,9 @@ func removeStaticHostByMac(service host.Service, c *fiber.Ctx, macAddress string
 		return presenter.BadRequestResponse(c, InvalidMacAddressMessage, fmt.Sprintf(MalformedMacAddress, macAddress))
 	}
 
-	host, err := service.RemoveByMac(mac)
+	host, err := service.RemoveByMac(mac, ifMatch(c))
 	if err != nil {
-		return presenter.InternalServerErrorResponse(c)
+		return removeErrorResponse(c, err, host)
 	}
 	if host == nil {
 		return c.SendStatus(http.StatusNoContent)
@@ -232,9 +655,21 @@ func removeStaticHostByIP(service host.Service, c *fiber.Ctx, ipAddress string)
 		return presenter.BadRequestResponse(c, InvalidIPAddressMessage, fmt.Sprintf(MalformedIPAddress, ipAddress))
 	}
 
-	host, err := service.RemoveByIP(ip)
+	host, err := service.RemoveByIP(ip, ifMatch(c))
+	if err != nil {
+		return removeErrorResponse(c, err, host)
+	}
+	if host == nil {
+		return c.SendStatus(http.StatusNoContent)
+	}
+
+	return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(host))
+}
+
+func removeStaticHostByHostName(service host.Service, c *fiber.Ctx, hostName string) error {
+	host, err := service.RemoveByHostName(hostName, ifMatch(c))
 	if err != nil {
-		return presenter.InternalServerErrorResponse(c)
+		return removeErrorResponse(c, err, host)
 	}
 	if host == nil {
 		return c.SendStatus(http.StatusNoContent)
@@ -243,12 +678,51 @@ func removeStaticHostByIP(service host.Service, c *fiber.Ctx, ipAddress string)
 	return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(host))
 }
 
+// GetStaticHostsDiagnostics reports every line of the static hosts file that could not be parsed, whatever
+// the configured parse mode is.
+func GetStaticHostsDiagnostics(service host.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		diagnostics, err := service.Diagnostics()
+		if err != nil {
+			return serviceErrorResponse(c, err)
+		}
+
+		response := make([]dto.Diagnostic, 0, len(diagnostics))
+		for _, d := range diagnostics {
+			response = append(response, *dto.NewDiagnostic(&d))
+		}
+
+		return c.Status(http.StatusOK).JSON(response)
+	}
+}
+
+// GetStaticHostsHistory lists the revisions of the static hosts, oldest first, as kept by the bolt storage backend.
+func GetStaticHostsHistory(service host.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		revisions, err := service.History()
+		if err != nil {
+			return serviceErrorResponse(c, err)
+		}
+
+		response := make([]dto.HostRevision, 0, len(revisions))
+		for _, revision := range revisions {
+			response = append(response, *dto.NewHostRevision(&revision))
+		}
+
+		return c.Status(http.StatusOK).JSON(response)
+	}
+}
+
 func RouteStaticHosts(router api.Router, service host.Service) {
 	router.AddApiV1Route("/static", func(r fiber.Router) {
 		r.Get("/hosts", router.AuthenticationHandler(scope.DhcpCanRead...), GetAllStaticHosts(service)).Name("get_all")
+		r.Get("/search", router.AuthenticationHandler(scope.DhcpCanRead...), SearchStaticHosts(service)).Name("search")
 		r.Get("/host", router.AuthenticationHandler(scope.DhcpCanRead...), GetStaticHost(service)).Name("get")
+		r.Get("/diagnostics", router.AuthenticationHandler(scope.DhcpCanRead...), GetStaticHostsDiagnostics(service)).Name("diagnostics")
+		r.Get("/history", router.AuthenticationHandler(scope.DhcpCanRead...), GetStaticHostsHistory(service)).Name("history")
 		r.Post("/host", router.AuthenticationHandler(scope.DhcpCanAdd...), AddStaticHost(service)).Name("add")
 		r.Put("/host", router.AuthenticationHandler(scope.DhcpCanChange...), UpdateStaticHost(service)).Name("update")
+		r.Patch("/host", router.AuthenticationHandler(scope.DhcpCanChange...), PatchStaticHost(service)).Name("patch")
 		r.Delete("/host", router.AuthenticationHandler(scope.DhcpCanChange...), RemoveStaticHost(service)).Name("remove")
 	}, "static.hosts.")
 }
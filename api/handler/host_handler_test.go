@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -18,29 +19,141 @@ import (
 	"github.com/gringolito/dnsmasq-manager/api/presenter"
 	"github.com/gringolito/dnsmasq-manager/api/scope"
 	"github.com/gringolito/dnsmasq-manager/config"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
 	"github.com/gringolito/dnsmasq-manager/pkg/host"
 	hostmock "github.com/gringolito/dnsmasq-manager/pkg/host/mock"
 	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
 	"github.com/gringolito/dnsmasq-manager/tests"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 const (
-	InvalidMACAddress     = "ab:cd:ef:gh:ij:kl"
-	ValidMACAddress       = "aa:bb:cc:dd:ee:ff"
-	InvalidIPAddress      = "1111"
-	ValidIPAddress        = "1.1.1.1"
-	InvalidHostName       = "B@r"
-	ValidHostJSON         = `{"HostName":"Foo", "IPAddress":"1.1.1.1", "MacAddress":"aa:bb:cc:dd:ee:ff"}`
-	InvalidJSON           = `"HostName":"Foo", "IPAddress":"1.1.1.1", "MacAddress":"aa:bb:cc:dd:ee:ff"`
-	MissingMACAddressJSON = `{"HostName":"Foo", "IPAddress":"1.1.1.1"}`
-	MissingIPAddressJSON  = `{"HostName":"Foo", "MacAddress":"aa:bb:cc:dd:ee:ff"}`
-	MissingHostNameJSON   = `{"IPAddress":"1.1.1.1", "MacAddress":"aa:bb:cc:dd:ee:ff"}`
-	InvalidMACAddressJSON = `{"HostName":"Foo", "IPAddress":"1.1.1.1", "MacAddress":"ab:cd:ef:gh:ij:kl"}`
-	InvalidIPAddressJSON  = `{"HostName":"Foo", "IPAddress":"1111", "MacAddress":"aa:bb:cc:dd:ee:ff"}`
-	InvalidHostNameJSON   = `{"HostName":"B@r", "IPAddress":"1.1.1.1", "MacAddress":"aa:bb:cc:dd:ee:ff"}`
-	AllHostsJSON          = `[
+	InvalidMACAddress          = "ab:cd:ef:gh:ij:kl"
+	ValidMACAddress            = "aa:bb:cc:dd:ee:ff"
+	InvalidIPAddress           = "1111"
+	ValidIPAddress             = "1.1.1.1"
+	InvalidHostName            = "B@r"
+	ValidHostJSON              = `{"HostName":"Foo", "IPAddress":"1.1.1.1", "MacAddress":"aa:bb:cc:dd:ee:ff"}`
+	InvalidJSON                = `"HostName":"Foo", "IPAddress":"1.1.1.1", "MacAddress":"aa:bb:cc:dd:ee:ff"`
+	MissingMACAddressJSON      = `{"HostName":"Foo", "IPAddress":"1.1.1.1"}`
+	MissingIPAddressJSON       = `{"HostName":"Foo", "MacAddress":"aa:bb:cc:dd:ee:ff"}`
+	MissingHostNameJSON        = `{"IPAddress":"1.1.1.1", "MacAddress":"aa:bb:cc:dd:ee:ff"}`
+	InvalidMACAddressJSON      = `{"HostName":"Foo", "IPAddress":"1.1.1.1", "MacAddress":"ab:cd:ef:gh:ij:kl"}`
+	InvalidIPAddressJSON       = `{"HostName":"Foo", "IPAddress":"1111", "MacAddress":"aa:bb:cc:dd:ee:ff"}`
+	InvalidHostNameJSON        = `{"HostName":"B@r", "IPAddress":"1.1.1.1", "MacAddress":"aa:bb:cc:dd:ee:ff"}`
+	ValidHostCreatedByUserJSON = `{"HostName":"Foo", "IPAddress":"1.1.1.1", "MacAddress":"aa:bb:cc:dd:ee:ff", "CreatedBy":"unit-tests"}`
+	ValidHostWithMetadataJSON  = `{"HostName":"Foo", "IPAddress":"1.1.1.1", "MacAddress":"aa:bb:cc:dd:ee:ff", "Description":"Living room NAS", "Owner":"alice", "Labels":["storage","lan"]}`
+	InvalidLabelJSON           = `{"HostName":"Foo", "IPAddress":"1.1.1.1", "MacAddress":"aa:bb:cc:dd:ee:ff", "Labels":["storage",""]}`
+	ValidIPv6Address           = "2001:db8::1"
+	InvalidIPv6Address         = "2001:db8::zz"
+	IPv6OnlyHostJSON           = `{"HostName":"Foo", "IPv6Address":"2001:db8::1", "MacAddress":"aa:bb:cc:dd:ee:ff"}`
+	DualStackHostJSON          = `{"HostName":"Foo", "IPAddress":"1.1.1.1", "IPv6Address":"2001:db8::1", "MacAddress":"aa:bb:cc:dd:ee:ff"}`
+	InvalidIPv6AddressJSON     = `{"HostName":"Foo", "IPv6Address":"2001:db8::zz", "MacAddress":"aa:bb:cc:dd:ee:ff"}`
+	IPv4AsIPv6AddressJSON      = `{"HostName":"Foo", "IPv6Address":"1.1.1.1", "MacAddress":"aa:bb:cc:dd:ee:ff"}`
+	HostNamePatchJSON          = `{"HostName":"Foo"}`
+	RemoveIPv6PatchJSON        = `{"IPv6Address":"", "Labels":[]}`
+	InvalidIPAddressPatchJSON  = `{"IPAddress":"1111"}`
+	EmptyHostNamePatchJSON     = `{"HostName":""}`
+	FooHostWithMetadataJSON    = `[
+		{
+			"MacAddress":"02:04:06:aa:bb:cc",
+			"IPAddress":"1.1.1.1",
+			"HostName":"Foo",
+			"Owner":"alice",
+			"Labels":["storage","lan"],
+			"CreatedAt":"2024-05-01T10:00:00Z",
+			"UpdatedAt":"2024-05-02T10:00:00Z",
+			"CreatedBy":"bob"
+		}
+	]`
+	LanHostsWithMetadataJSON = `[
+		{
+			"MacAddress":"02:04:06:aa:bb:cc",
+			"IPAddress":"1.1.1.1",
+			"HostName":"Foo",
+			"Owner":"alice",
+			"Labels":["storage","lan"],
+			"CreatedAt":"2024-05-01T10:00:00Z",
+			"UpdatedAt":"2024-05-02T10:00:00Z",
+			"CreatedBy":"bob"
+		},
+		{
+			"MacAddress":"02:04:06:dd:ee:ff",
+			"IPAddress":"1.1.1.2",
+			"HostName":"Bar",
+			"Owner":"bob",
+			"Labels":["lan"]
+		}
+	]`
+	BarHostWithMetadataJSON = `[
+		{
+			"MacAddress":"02:04:06:dd:ee:ff",
+			"IPAddress":"1.1.1.2",
+			"HostName":"Bar",
+			"Owner":"bob",
+			"Labels":["lan"]
+		}
+	]`
+	BazHostJSON = `[
+		{
+			"MacAddress":"02:04:06:12:34:56",
+			"IPAddress":"1.1.1.3",
+			"HostName":"Baz"
+		}
+	]`
+	BarBazHostsJSON = `[
+		{
+			"MacAddress":"02:04:06:dd:ee:ff",
+			"IPAddress":"1.1.1.2",
+			"HostName":"Bar",
+			"Owner":"bob",
+			"Labels":["lan"]
+		},
+		{
+			"MacAddress":"02:04:06:12:34:56",
+			"IPAddress":"1.1.1.3",
+			"HostName":"Baz"
+		}
+	]`
+	SearchBaHostsJSON = `[
+		{
+			"MacAddress":"02:04:06:dd:ee:ff",
+			"IPAddress":"1.1.1.2",
+			"HostName":"Bar",
+			"Owner":"bob",
+			"Labels":["lan"],
+			"Score":80,
+			"Field":"HostName"
+		},
+		{
+			"MacAddress":"02:04:06:12:34:56",
+			"IPAddress":"1.1.1.3",
+			"HostName":"Baz",
+			"Score":80,
+			"Field":"HostName"
+		}
+	]`
+	SearchBazHostJSON = `[
+		{
+			"MacAddress":"02:04:06:12:34:56",
+			"IPAddress":"1.1.1.3",
+			"HostName":"Baz",
+			"Score":100,
+			"Field":"MacAddress"
+		}
+	]`
+	EspressifHostJSON = `[
+		{
+			"MacAddress":"24:0a:c4:12:34:56",
+			"Vendor":"Espressif Inc.",
+			"IPAddress":"1.1.1.4",
+			"HostName":"Sensor"
+		}
+	]`
+	AllHostsJSON = `[
 		{
 			"MacAddress":"02:04:06:aa:bb:cc",
 			"IPAddress":"1.1.1.1",
@@ -55,6 +168,23 @@ const (
 )
 
 var ValidHost = model.StaticDhcpHost{MacAddress: tests.ParseMAC(ValidMACAddress), IPAddress: net.ParseIP(ValidIPAddress), HostName: "Foo"}
+var ValidHostCreatedByUser = model.StaticDhcpHost{MacAddress: tests.ParseMAC(ValidMACAddress), IPAddress: net.ParseIP(ValidIPAddress), HostName: "Foo", Metadata: model.HostMetadata{CreatedBy: "unit-tests"}}
+var ValidHostWithMetadata = model.StaticDhcpHost{MacAddress: tests.ParseMAC(ValidMACAddress), IPAddress: net.ParseIP(ValidIPAddress), HostName: "Foo", Metadata: model.HostMetadata{Description: "Living room NAS", Owner: "alice", Labels: []string{"storage", "lan"}}}
+var AllHostsWithMetadata = []model.StaticDhcpHost{
+	{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "Foo", Metadata: model.HostMetadata{
+		Owner: "alice", Labels: []string{"storage", "lan"}, CreatedBy: "bob",
+		CreatedAt: time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC), UpdatedAt: time.Date(2024, time.May, 2, 10, 0, 0, 0, time.UTC),
+	}},
+	{MacAddress: tests.ParseMAC("02:04:06:dd:ee:ff"), IPAddress: net.ParseIP("1.1.1.2"), HostName: "Bar", Metadata: model.HostMetadata{Owner: "bob", Labels: []string{"lan"}}},
+	{MacAddress: tests.ParseMAC("02:04:06:12:34:56"), IPAddress: net.ParseIP("1.1.1.3"), HostName: "Baz"},
+}
+var VendorHosts = []model.StaticDhcpHost{
+	{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "Foo"},
+	{MacAddress: tests.ParseMAC("24:0a:c4:12:34:56"), IPAddress: net.ParseIP("1.1.1.4"), HostName: "Sensor"},
+}
+var IPv6OnlyHost = model.StaticDhcpHost{MacAddress: tests.ParseMAC(ValidMACAddress), IPv6Address: net.ParseIP(ValidIPv6Address), HostName: "Foo"}
+var DualStackHost = model.StaticDhcpHost{MacAddress: tests.ParseMAC(ValidMACAddress), IPAddress: net.ParseIP(ValidIPAddress), IPv6Address: net.ParseIP(ValidIPv6Address), HostName: "Foo"}
+var HostNamePatch = model.StaticDhcpHostPatch{HostName: &ValidHost.HostName}
 var AllHosts = []model.StaticDhcpHost{
 	{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "Foo"},
 	{MacAddress: tests.ParseMAC("02:04:06:dd:ee:ff"), IPAddress: net.ParseIP("1.1.1.2"), HostName: "Bar"},
@@ -63,10 +193,10 @@ var AllHosts = []model.StaticDhcpHost{
 var voidMock = func(mock *hostmock.ServiceMock) {}
 
 func setupTest(t *testing.T, mockSetup func(mock *hostmock.ServiceMock)) *fiber.App {
-	app := tests.SetupApp()
-	config := tests.SetupConfig(t)
+	app := setupApp()
+	config := setupConfig(t)
 	serviceMock := &hostmock.ServiceMock{}
-	router := tests.SetupRouter(app, config)
+	router := setupRouter(app, config)
 	RouteStaticHosts(router, serviceMock)
 	mockSetup(serviceMock)
 	return app
@@ -94,6 +224,7 @@ func TestStaticHostsApi(t *testing.T) {
 		requestBody        io.Reader
 		expectedStatusCode int
 		expectedResponse   string
+		expectedHeaders    map[string]string
 		mockSetup          func(s *hostmock.ServiceMock)
 	}{
 		{
@@ -106,22 +237,318 @@ func TestStaticHostsApi(t *testing.T) {
 				mock.On("FetchAll").Once().Return(&AllHosts, nil)
 			},
 		},
+		{
+			name:               "GetAllStaticHostsFilteredByOwner",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?owner=alice",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   FooHostWithMetadataJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				hosts := slices.Clone(AllHostsWithMetadata)
+				mock.On("FetchAll").Once().Return(&hosts, nil)
+			},
+		},
+		{
+			name:               "GetAllStaticHostsFilteredByLabel",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?label=lan",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   LanHostsWithMetadataJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				hosts := slices.Clone(AllHostsWithMetadata)
+				mock.On("FetchAll").Once().Return(&hosts, nil)
+			},
+		},
+		{
+			name:               "GetAllStaticHostsFilteredByLabels",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?label=lan&label=storage",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   FooHostWithMetadataJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				hosts := slices.Clone(AllHostsWithMetadata)
+				mock.On("FetchAll").Once().Return(&hosts, nil)
+			},
+		},
+		{
+			name:               "GetAllStaticHostsFilteredByCreatedBy",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?createdBy=bob",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   FooHostWithMetadataJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				hosts := slices.Clone(AllHostsWithMetadata)
+				mock.On("FetchAll").Once().Return(&hosts, nil)
+			},
+		},
+		{
+			name:               "GetAllStaticHostsSortedPage",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?sort=-hostname&offset=1&limit=1",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   BazHostJSON,
+			expectedHeaders:    map[string]string{TotalCountHeader: "3"},
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				hosts := slices.Clone(AllHostsWithMetadata)
+				mock.On("FetchAll").Once().Return(&hosts, nil)
+			},
+		},
+		{
+			name:               "GetAllStaticHostsFilteredBySubnetAndMacPrefix",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?subnet=1.1.1.0/30&macPrefix=02-04-06-DD",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   BarHostWithMetadataJSON,
+			expectedHeaders:    map[string]string{TotalCountHeader: "1"},
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				hosts := slices.Clone(AllHostsWithMetadata)
+				mock.On("FetchAll").Once().Return(&hosts, nil)
+			},
+		},
+		{
+			name:               "GetAllStaticHostsFilteredByHostName",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?hostname=ba*&sort=hostname",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   BarBazHostsJSON,
+			expectedHeaders:    map[string]string{TotalCountHeader: "2"},
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				hosts := slices.Clone(AllHostsWithMetadata)
+				mock.On("FetchAll").Once().Return(&hosts, nil)
+			},
+		},
+		{
+			name:               "GetAllStaticHostsByVendor",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?vendor=espressif",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   EspressifHostJSON,
+			expectedHeaders:    map[string]string{TotalCountHeader: "1"},
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				hosts := slices.Clone(VendorHosts)
+				mock.On("FetchAll").Once().Return(&hosts, nil)
+			},
+		},
+		{
+			name:               "GetAllStaticHostsInvalidSubnet",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?subnet=1.1.1.0",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidRequestMessage, fmt.Sprintf(MalformedSubnet, "1.1.1.0")),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "GetAllStaticHostsInvalidHostNamePattern",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?hostname=ba[",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidRequestMessage, fmt.Sprintf(MalformedHostNamePattern, "ba[")),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "GetAllStaticHostsInvalidMacPrefix",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?macPrefix=02:04:0g",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidRequestMessage, fmt.Sprintf(MalformedMacPrefix, "02:04:0g")),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "GetAllStaticHostsUnknownSortKey",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?sort=-owner",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidRequestMessage, fmt.Sprintf(UnknownSortKey, "-owner")),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "GetAllStaticHostsInvalidLimit",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?limit=0",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidRequestMessage, fmt.Sprintf(MalformedPagination, "limit", "0")),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "GetAllStaticHostsInvalidOffset",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?offset=-1",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidRequestMessage, fmt.Sprintf(MalformedPagination, "offset", "-1")),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "GetAllStaticHostsFilteredNoMatch",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?owner=carol",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `[]`,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				hosts := slices.Clone(AllHostsWithMetadata)
+				mock.On("FetchAll").Once().Return(&hosts, nil)
+			},
+		},
 		{
 			name:               "GetAllStaticHostsServiceError",
 			httpMethod:         http.MethodGet,
 			route:              "/api/v1/static/hosts",
 			expectedStatusCode: http.StatusInternalServerError,
-			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(nil, errors.New("an error"))
+			},
+		},
+		{
+			name:               "GetAllStaticHostsFileLocked",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts",
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse:   tests.ErrorJSON(http.StatusServiceUnavailable, presenter.LockTimeoutCode, fmt.Sprintf(FileLockedMessage, "DHCP static hosts"), fmt.Sprintf(FileLockTimeout, "DHCP static hosts")),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(nil, &errkind.StorageError{Err: &storage.LockTimeoutError{File: "/etc/dnsmasq.d/04-dhcp-static-leases.conf", Timeout: time.Second}})
+			},
+		},
+		{
+			name:               "GetAllStaticHostsStorageUnavailable",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts",
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse: tests.ErrorJSON(http.StatusServiceUnavailable, presenter.StorageUnavailableCode, fmt.Sprintf(StorageUnavailableMessage, "DHCP static hosts"),
+				fmt.Sprintf(StorageUnavailable, "DHCP static hosts", "open /etc/dnsmasq.d/04-dhcp-static-leases.conf: permission denied")),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(nil, &errkind.StorageError{Err: &os.PathError{Op: "open", Path: "/etc/dnsmasq.d/04-dhcp-static-leases.conf", Err: os.ErrPermission}})
+			},
+		},
+		{
+			name:               "SearchStaticHostsByHostName",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/search?q=BA",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   SearchBaHostsJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(&AllHostsWithMetadata, nil)
+			},
+		},
+		{
+			name:               "SearchStaticHostsByMacAddress",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/search?q=0204.0612.3456",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   SearchBazHostJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(&AllHostsWithMetadata, nil)
+			},
+		},
+		{
+			name:               "SearchStaticHostsNoMatch",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/search?q=printer",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `[]`,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(&AllHostsWithMetadata, nil)
+			},
+		},
+		{
+			name:               "SearchStaticHostsMissingQuery",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/search?q=%20",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidRequestMessage, MissingSearchQuery),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "SearchStaticHostsServiceError",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/search?q=foo",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
 			mockSetup: func(mock *hostmock.ServiceMock) {
 				mock.On("FetchAll").Once().Return(nil, errors.New("an error"))
 			},
 		},
+		{
+			name:               "GetDiagnosticsSuccess",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/diagnostics",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: `[
+				{
+					"File":"/etc/dnsmasq.d/04-dhcp-static-leases.conf",
+					"Line":3,
+					"Text":"dhcp-host=ab:cd:ef:gh:ij:kl,1.1.1.1,Jung",
+					"Error":"address ab:cd:ef:gh:ij:kl: invalid MAC address"
+				}
+			]`,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Diagnostics").Once().Return([]model.Diagnostic{{
+					File: "/etc/dnsmasq.d/04-dhcp-static-leases.conf",
+					Line: 3,
+					Text: "dhcp-host=ab:cd:ef:gh:ij:kl,1.1.1.1,Jung",
+					Err:  &net.AddrError{Err: "invalid MAC address", Addr: "ab:cd:ef:gh:ij:kl"},
+				}}, nil)
+			},
+		},
+		{
+			name:               "GetDiagnosticsNoProblems",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/diagnostics",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `[]`,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Diagnostics").Once().Return([]model.Diagnostic{}, nil)
+			},
+		},
+		{
+			name:               "GetDiagnosticsServiceError",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/diagnostics",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Diagnostics").Once().Return(nil, errors.New("an error"))
+			},
+		},
+		{
+			name:               "GetHistorySuccess",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/history",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `[{"Revision":1, "CommittedAt":"2024-05-01T10:00:00Z", "Hosts":[` + ValidHostJSON + `]}]`,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("History").Once().Return([]model.HostRevision{{
+					Number:      1,
+					CommittedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+					Hosts:       []model.StaticDhcpHost{ValidHost},
+				}}, nil)
+			},
+		},
+		{
+			name:               "GetHistoryNotKept",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/history",
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, NoHistoryMessage, NoHistory),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("History").Once().Return(nil, host.ErrNoHistory)
+			},
+		},
+		{
+			name:               "GetHistoryServiceError",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/history",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("History").Once().Return(nil, errors.New("an error"))
+			},
+		},
 		{
 			name:               "GetStaticHostNoQueryParameter",
 			httpMethod:         http.MethodGet,
 			route:              "/api/v1/static/host",
 			expectedStatusCode: http.StatusBadRequest,
-			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, InvalidRequestMessage, MissingQueryParameter),
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidRequestMessage, MissingQueryParameter),
 			mockSetup:          voidMock,
 		},
 		{
@@ -139,7 +566,7 @@ func TestStaticHostsApi(t *testing.T) {
 			httpMethod:         http.MethodGet,
 			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", InvalidMACAddress),
 			expectedStatusCode: http.StatusBadRequest,
-			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, InvalidMacAddressMessage, fmt.Sprintf(MalformedMacAddress, InvalidMACAddress)),
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidMacAddressMessage, fmt.Sprintf(MalformedMacAddress, InvalidMACAddress)),
 			mockSetup:          voidMock,
 		},
 		{
@@ -147,7 +574,7 @@ func TestStaticHostsApi(t *testing.T) {
 			httpMethod:         http.MethodGet,
 			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
 			expectedStatusCode: http.StatusNotFound,
-			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingMacAddress, ValidMACAddress)),
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingMacAddress, ValidMACAddress)),
 			mockSetup: func(mock *hostmock.ServiceMock) {
 				mock.On("FetchByMac", tests.ParseMAC(ValidMACAddress)).Once().Return(nil, nil)
 			},
@@ -157,7 +584,7 @@ func TestStaticHostsApi(t *testing.T) {
 			httpMethod:         http.MethodGet,
 			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
 			expectedStatusCode: http.StatusInternalServerError,
-			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
 			mockSetup: func(mock *hostmock.ServiceMock) {
 				mock.On("FetchByMac", tests.ParseMAC(ValidMACAddress)).Once().Return(nil, errors.New("an error"))
 			},
@@ -167,7 +594,7 @@ func TestStaticHostsApi(t *testing.T) {
 			httpMethod:         http.MethodGet,
 			route:              fmt.Sprintf("/api/v1/static/host?ip=%s", InvalidIPAddress),
 			expectedStatusCode: http.StatusBadRequest,
-			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, InvalidIPAddressMessage, fmt.Sprintf(MalformedIPAddress, InvalidIPAddress)),
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidIPAddressMessage, fmt.Sprintf(MalformedIPAddress, InvalidIPAddress)),
 			mockSetup:          voidMock,
 		},
 		{
@@ -180,22 +607,60 @@ func TestStaticHostsApi(t *testing.T) {
 				mock.On("FetchByIP", net.ParseIP(ValidIPAddress)).Once().Return(&ValidHost, nil)
 			},
 		},
+		{
+			name:               "GetStaticHostByIPv6Success",
+			httpMethod:         http.MethodGet,
+			route:              fmt.Sprintf("/api/v1/static/host?ip=%s", ValidIPv6Address),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   DualStackHostJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("FetchByIP", net.ParseIP(ValidIPv6Address)).Once().Return(&DualStackHost, nil)
+			},
+		},
+		{
+			name:               "GetStaticHostByIPInvalidIPv6Address",
+			httpMethod:         http.MethodGet,
+			route:              fmt.Sprintf("/api/v1/static/host?ip=%s", InvalidIPv6Address),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidIPAddressMessage, fmt.Sprintf(MalformedIPAddress, InvalidIPv6Address)),
+			mockSetup:          voidMock,
+		},
 		{
 			name:               "GetStaticHostByIPNotFound",
 			httpMethod:         http.MethodGet,
 			route:              fmt.Sprintf("/api/v1/static/host?ip=%s", ValidIPAddress),
 			expectedStatusCode: http.StatusNotFound,
-			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingIPAddress, ValidIPAddress)),
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingIPAddress, ValidIPAddress)),
 			mockSetup: func(mock *hostmock.ServiceMock) {
 				mock.On("FetchByIP", net.ParseIP(ValidIPAddress)).Once().Return(nil, nil)
 			},
 		},
+		{
+			name:               "GetStaticHostByHostNameSuccess",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/host?hostname=foo",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidHostJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("FetchByHostName", "foo").Once().Return(&ValidHost, nil)
+			},
+		},
+		{
+			name:               "GetStaticHostByHostNameNotFound",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/host?hostname=Qux",
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingHostName, "Qux")),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("FetchByHostName", "Qux").Once().Return(nil, nil)
+			},
+		},
 		{
 			name:               "GetStaticHostByIPServiceError",
 			httpMethod:         http.MethodGet,
 			route:              fmt.Sprintf("/api/v1/static/host?ip=%s", ValidIPAddress),
 			expectedStatusCode: http.StatusInternalServerError,
-			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
 			mockSetup: func(mock *hostmock.ServiceMock) {
 				mock.On("FetchByIP", net.ParseIP(ValidIPAddress)).Once().Return(nil, errors.New("an error"))
 			},
@@ -208,16 +673,76 @@ func TestStaticHostsApi(t *testing.T) {
 			expectedStatusCode: http.StatusCreated,
 			expectedResponse:   ValidHostJSON,
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("Insert", &ValidHost).Once().Return(nil)
+				mock.On("Insert", &ValidHost, model.Versions(nil)).Once().Return(nil)
+			},
+		},
+		{
+			name:               "PostStaticHostIPv6Only",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(IPv6OnlyHostJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   `{"HostName":"Foo", "IPAddress":"", "IPv6Address":"2001:db8::1", "MacAddress":"aa:bb:cc:dd:ee:ff"}`,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Insert", &IPv6OnlyHost, model.Versions(nil)).Once().Return(nil)
+			},
+		},
+		{
+			name:               "PostStaticHostDualStack",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(DualStackHostJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   DualStackHostJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Insert", &DualStackHost, model.Versions(nil)).Once().Return(nil)
+			},
+		},
+		{
+			name:               "PostStaticHostInvalidIPv6Address",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(InvalidIPv6AddressJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "IPv6Address", "The IPv6Address field must be of type ipv6.", InvalidIPv6Address),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "PostStaticHostIPv4AsIPv6Address",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(IPv4AsIPv6AddressJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "IPv6Address", "The IPv6Address field must be of type ipv6.", ValidIPAddress),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "PostStaticHostWithMetadata",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(ValidHostWithMetadataJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidHostWithMetadataJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Insert", &ValidHostWithMetadata, model.Versions(nil)).Once().Return(nil)
 			},
 		},
+		{
+			name:               "PostStaticHostEmptyLabel",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(InvalidLabelJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "Labels\\\\[1\\\\]", "The Labels\\\\[1\\\\] field is required.", ""),
+			mockSetup:          voidMock,
+		},
 		{
 			name:               "PostStaticHostInvalidJSON",
 			httpMethod:         http.MethodPost,
 			route:              "/api/v1/static/host",
 			requestBody:        strings.NewReader(InvalidJSON),
 			expectedStatusCode: http.StatusUnprocessableEntity,
-			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, InvalidRequestBodyMessage, HostCouldNotBeParsed),
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, HostCouldNotBeParsed),
 			mockSetup:          voidMock,
 		},
 		{
@@ -235,7 +760,7 @@ func TestStaticHostsApi(t *testing.T) {
 			route:              "/api/v1/static/host",
 			requestBody:        strings.NewReader(MissingIPAddressJSON),
 			expectedStatusCode: http.StatusUnprocessableEntity,
-			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "IPAddress", "The IPAddress field is required.", ""),
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "IPAddress", "The IPAddress field is required when IPv6Address is not present.", ""),
 			mockSetup:          voidMock,
 		},
 		{
@@ -280,116 +805,446 @@ func TestStaticHostsApi(t *testing.T) {
 			route:              "/api/v1/static/host",
 			requestBody:        strings.NewReader(ValidHostJSON),
 			expectedStatusCode: http.StatusConflict,
-			expectedResponse:   tests.ErrorJSON(http.StatusConflict, DuplicatedIPAddressMessage, fmt.Sprintf(IPAddressAlreadyInUse, ValidIPAddress)),
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedIPAddressMessage, fmt.Sprintf(IPAddressAlreadyInUse, ValidIPAddress)),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Insert", &ValidHost, model.Versions(nil)).Once().Return(&host.DuplicatedEntryError{Field: "IP", Value: ValidIPAddress})
+			},
+		},
+		{
+			name:               "PostStaticHostDuplicatedIPv6Address",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(DualStackHostJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedIPAddressMessage, fmt.Sprintf(IPAddressAlreadyInUse, ValidIPv6Address)),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Insert", &DualStackHost, model.Versions(nil)).Once().Return(&host.DuplicatedEntryError{Field: "IP", Value: ValidIPv6Address})
+			},
+		},
+		{
+			name:               "PostStaticHostDuplicatedMACAddress",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedMacAddressMessage, fmt.Sprintf(MacAddressAlreadyInUse, ValidMACAddress)),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Insert", &ValidHost, model.Versions(nil)).Once().Return(&host.DuplicatedEntryError{Field: "MAC", Value: ValidMACAddress})
+			},
+		},
+		{
+			name:               "PostStaticHostDuplicatedHostName",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedHostNameMessage, fmt.Sprintf(HostNameInUseByHost, "Bar", "02:04:06:dd:ee:ff", "Foo")),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Insert", &ValidHost, model.Versions(nil)).Once().Return(&host.DuplicatedEntryError{Field: "hostname", Value: "Foo", Host: &AllHosts[1]})
+			},
+		},
+		{
+			name:               "PostStaticHostServiceError",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Insert", &ValidHost, model.Versions(nil)).Once().Return(errors.New("an error"))
+			},
+		},
+		{
+			name:               "PostStaticHostFileLocked",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse:   tests.ErrorJSON(http.StatusServiceUnavailable, presenter.LockTimeoutCode, fmt.Sprintf(FileLockedMessage, "DHCP static hosts"), fmt.Sprintf(FileLockTimeout, "DHCP static hosts")),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Insert", &ValidHost, model.Versions(nil)).Once().Return(&errkind.StorageError{Err: fmt.Errorf("saving host: %w", &storage.LockTimeoutError{File: "/etc/dnsmasq.d/04-dhcp-static-leases.conf", Timeout: time.Second})})
+			},
+		},
+		{
+			name:               "PostStaticHostReloadFailed",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusBadGateway,
+			expectedHeaders:    map[string]string{fiber.HeaderETag: entityTag(ValidHost.Version())},
+			expectedResponse: tests.ReloadFailedJSON(ReloadFailedMessage,
+				fmt.Sprintf(ReloadFailed, "DHCP static hosts", "dnsmasq reload (command) failed: exit status 1: Job for dnsmasq.service failed"), ValidHostJSON),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Insert", &ValidHost, model.Versions(nil)).Once().Return(&dnsmasq.ReloadError{
+					Method: dnsmasq.ReloadCommand,
+					Err:    errors.New("exit status 1"),
+					Output: "Job for dnsmasq.service failed",
+				})
+			},
+		},
+		{
+			name:               "PostStaticHostRejectedByValidator",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse: fmt.Sprintf(`{
+				"error": "%s",
+				"code": "validation_failed",
+				"message": "%s",
+				"details": {
+					"error": "exit status 1",
+					"output": "dnsmasq: bad DHCP host name at line 2 of candidate"
+				}
+			}`, http.StatusText(http.StatusUnprocessableEntity), RejectedConfigMessage),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Insert", &ValidHost, model.Versions(nil)).Once().Return(&errkind.ValidationError{Err: &storage.ValidationError{
+					File:   "/etc/dnsmasq.d/04-dhcp-static-leases.conf",
+					Err:    errors.New("exit status 1"),
+					Output: "dnsmasq: bad DHCP host name at line 2 of candidate",
+				}})
+			},
+		},
+		{
+			name:               "PostStaticHostOutsideSubnets",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse: tests.ValidationErrorJSON(InvalidSubnetAddressMessage, "IPAddress",
+				fmt.Sprintf(host.OutsideSubnetsReason, "IPAddress", ValidIPAddress, "192.168.1.0/24"), ValidIPAddress),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Insert", &ValidHost, model.Versions(nil)).Once().Return(&host.SubnetError{Violations: []host.SubnetViolation{{
+					Field:  "IPAddress",
+					Value:  ValidIPAddress,
+					Reason: fmt.Sprintf(host.OutsideSubnetsReason, "IPAddress", ValidIPAddress, "192.168.1.0/24"),
+				}}})
+			},
+		},
+		{
+			name:               "PutStaticHostSuccess",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidHostJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Update", &ValidHost, model.Versions(nil)).Once().Return(nil)
+			},
+		},
+		{
+			name:               "PutStaticHostInvalidJSON",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(InvalidJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, HostCouldNotBeParsed),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "PutStaticHostMissingMACAddress",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(MissingMACAddressJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "MacAddress", "The MacAddress field is required.", ""),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "PutStaticHostMissingIPAddress",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(MissingIPAddressJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "IPAddress", "The IPAddress field is required when IPv6Address is not present.", ""),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "PutStaticHostMissingHostName",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(MissingHostNameJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "HostName", "The HostName field is required.", ""),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "PutStaticHostInvalidMACAddress",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(InvalidMACAddressJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "MacAddress", "The MacAddress field must be of type mac.", InvalidMACAddress),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "PutStaticHostInvalidIPAddress",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(InvalidIPAddressJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "IPAddress", "The IPAddress field must be of type ipv4.", InvalidIPAddress),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "PutStaticHostInvalidHostName",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(InvalidHostNameJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "HostName", "The HostName field must be of type hostname.", InvalidHostName),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "PutStaticHostServiceError",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Update", &ValidHost, model.Versions(nil)).Once().Return(errors.New("an error"))
+			},
+		},
+		{
+			name:               "PutStaticHostRejectedByValidator",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse: fmt.Sprintf(`{
+				"error": "%s",
+				"code": "validation_failed",
+				"message": "%s",
+				"details": {
+					"error": "exit status 1",
+					"output": ""
+				}
+			}`, http.StatusText(http.StatusUnprocessableEntity), RejectedConfigMessage),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Update", &ValidHost, model.Versions(nil)).Once().Return(&errkind.ValidationError{Err: fmt.Errorf("saving host: %w", &storage.ValidationError{
+					Err: errors.New("exit status 1"),
+				})})
+			},
+		},
+		{
+			name:               "PutStaticHostNotFound",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingMacAddress, ValidMACAddress)),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Update", &ValidHost, model.Versions(nil)).Once().Return(&host.NotFoundError{Field: "MAC", Value: ValidMACAddress})
+			},
+		},
+		{
+			name:               "PutStaticHostIPAddressInUse",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host",
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedIPAddressMessage, fmt.Sprintf(IPAddressInUseByHost, "Bar", "02:04:06:dd:ee:ff", ValidIPAddress)),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Update", &ValidHost, model.Versions(nil)).Once().Return(&host.DuplicatedEntryError{Field: "IP", Value: ValidIPAddress, Host: &AllHosts[1]})
+			},
+		},
+		{
+			name:               "PutStaticHostByMACSuccess",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host?mac=02:04:06:dd:ee:ff",
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidHostJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("UpdateByMac", tests.ParseMAC("02:04:06:dd:ee:ff"), &ValidHost, model.Versions(nil)).Once().Return(nil)
+			},
+		},
+		{
+			name:               "PutStaticHostByHostNameSuccess",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host?hostname=foo",
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidHostJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("UpdateByHostName", "foo", &ValidHost, model.Versions(nil)).Once().Return(nil)
+			},
+		},
+		{
+			name:               "PutStaticHostInvalidMACAddress",
+			httpMethod:         http.MethodPut,
+			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", InvalidMACAddress),
+			requestBody:        strings.NewReader(ValidHostJSON),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidMacAddressMessage, fmt.Sprintf(MalformedMacAddress, InvalidMACAddress)),
+			mockSetup:          voidMock,
+		},
+		{
+			name:               "PatchStaticHostSuccess",
+			httpMethod:         http.MethodPatch,
+			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
+			requestBody:        strings.NewReader(HostNamePatchJSON),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidHostJSON,
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("Insert", &ValidHost).Once().Return(host.DuplicatedEntryError{Field: "IP", Value: ValidIPAddress})
+				mock.On("Patch", tests.ParseMAC(ValidMACAddress), &HostNamePatch, model.Versions(nil)).Once().Return(&ValidHost, nil)
 			},
 		},
 		{
-			name:               "PostStaticHostDuplicatedMACAddress",
-			httpMethod:         http.MethodPost,
-			route:              "/api/v1/static/host",
-			requestBody:        strings.NewReader(ValidHostJSON),
-			expectedStatusCode: http.StatusConflict,
-			expectedResponse:   tests.ErrorJSON(http.StatusConflict, DuplicatedMacAddressMessage, fmt.Sprintf(MacAddressAlreadyInUse, ValidMACAddress)),
+			name:               "PatchStaticHostReloadFailed",
+			httpMethod:         http.MethodPatch,
+			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
+			requestBody:        strings.NewReader(HostNamePatchJSON),
+			expectedStatusCode: http.StatusBadGateway,
+			expectedHeaders:    map[string]string{fiber.HeaderETag: entityTag(ValidHost.Version())},
+			expectedResponse: tests.ReloadFailedJSON(ReloadFailedMessage,
+				fmt.Sprintf(ReloadFailed, "DHCP static hosts", "dnsmasq reload (signal) failed: no such process"), ValidHostJSON),
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("Insert", &ValidHost).Once().Return(host.DuplicatedEntryError{Field: "MAC", Value: ValidMACAddress})
+				mock.On("Patch", tests.ParseMAC(ValidMACAddress), &HostNamePatch, model.Versions(nil)).Once().Return(&ValidHost, &dnsmasq.ReloadError{
+					Method: dnsmasq.ReloadSignal,
+					Err:    errors.New("no such process"),
+				})
 			},
 		},
 		{
-			name:               "PostStaticHostServiceError",
-			httpMethod:         http.MethodPost,
-			route:              "/api/v1/static/host",
-			requestBody:        strings.NewReader(ValidHostJSON),
-			expectedStatusCode: http.StatusInternalServerError,
-			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
+			name:               "PatchStaticHostByHostNameSuccess",
+			httpMethod:         http.MethodPatch,
+			route:              "/api/v1/static/host?hostname=foo",
+			requestBody:        strings.NewReader(HostNamePatchJSON),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidHostJSON,
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("Insert", &ValidHost).Once().Return(errors.New("an error"))
+				mock.On("PatchByHostName", "foo", &HostNamePatch, model.Versions(nil)).Once().Return(&ValidHost, nil)
 			},
 		},
 		{
-			name:               "PutStaticHostSuccess",
-			httpMethod:         http.MethodPut,
-			route:              "/api/v1/static/host",
-			requestBody:        strings.NewReader(ValidHostJSON),
-			expectedStatusCode: http.StatusCreated,
+			name:               "PatchStaticHostRemoveFields",
+			httpMethod:         http.MethodPatch,
+			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
+			requestBody:        strings.NewReader(RemoveIPv6PatchJSON),
+			expectedStatusCode: http.StatusOK,
 			expectedResponse:   ValidHostJSON,
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("Update", &ValidHost).Once().Return(nil)
+				var noIPAddress net.IP
+				patch := &model.StaticDhcpHostPatch{IPv6Address: &noIPAddress, Labels: &[]string{}}
+				mock.On("Patch", tests.ParseMAC(ValidMACAddress), patch, model.Versions(nil)).Once().Return(&ValidHost, nil)
 			},
 		},
 		{
-			name:               "PutStaticHostInvalidJSON",
-			httpMethod:         http.MethodPut,
-			route:              "/api/v1/static/host",
-			requestBody:        strings.NewReader(InvalidJSON),
+			name:               "PatchStaticHostInsidePool",
+			httpMethod:         http.MethodPatch,
+			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
+			requestBody:        strings.NewReader(HostNamePatchJSON),
 			expectedStatusCode: http.StatusUnprocessableEntity,
-			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, InvalidRequestBodyMessage, HostCouldNotBeParsed),
-			mockSetup:          voidMock,
+			expectedResponse: tests.ValidationErrorJSON(InvalidSubnetAddressMessage, "IPAddress",
+				fmt.Sprintf(host.InsidePoolReason, "IPAddress", ValidIPAddress, "1.1.1.1", "1.1.1.100", "1.1.1.0/24"), ValidIPAddress),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Patch", tests.ParseMAC(ValidMACAddress), &HostNamePatch, model.Versions(nil)).Once().Return(nil, &host.SubnetError{Violations: []host.SubnetViolation{{
+					Field:  "IPAddress",
+					Value:  ValidIPAddress,
+					Reason: fmt.Sprintf(host.InsidePoolReason, "IPAddress", ValidIPAddress, "1.1.1.1", "1.1.1.100", "1.1.1.0/24"),
+				}}})
+			},
 		},
 		{
-			name:               "PutStaticHostMissingMACAddress",
-			httpMethod:         http.MethodPut,
+			name:               "PatchStaticHostNoQueryParameter",
+			httpMethod:         http.MethodPatch,
 			route:              "/api/v1/static/host",
-			requestBody:        strings.NewReader(MissingMACAddressJSON),
-			expectedStatusCode: http.StatusUnprocessableEntity,
-			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "MacAddress", "The MacAddress field is required.", ""),
+			requestBody:        strings.NewReader(HostNamePatchJSON),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidRequestMessage, MissingMacQueryParameter),
 			mockSetup:          voidMock,
 		},
 		{
-			name:               "PutStaticHostMissingIPAddress",
-			httpMethod:         http.MethodPut,
-			route:              "/api/v1/static/host",
-			requestBody:        strings.NewReader(MissingIPAddressJSON),
-			expectedStatusCode: http.StatusUnprocessableEntity,
-			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "IPAddress", "The IPAddress field is required.", ""),
+			name:               "PatchStaticHostInvalidMACAddress",
+			httpMethod:         http.MethodPatch,
+			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", InvalidMACAddress),
+			requestBody:        strings.NewReader(HostNamePatchJSON),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidMacAddressMessage, fmt.Sprintf(MalformedMacAddress, InvalidMACAddress)),
 			mockSetup:          voidMock,
 		},
 		{
-			name:               "PutStaticHostMissingHostName",
-			httpMethod:         http.MethodPut,
-			route:              "/api/v1/static/host",
-			requestBody:        strings.NewReader(MissingHostNameJSON),
+			name:               "PatchStaticHostInvalidJSON",
+			httpMethod:         http.MethodPatch,
+			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
+			requestBody:        strings.NewReader(InvalidJSON),
 			expectedStatusCode: http.StatusUnprocessableEntity,
-			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "HostName", "The HostName field is required.", ""),
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, HostCouldNotBeParsed),
 			mockSetup:          voidMock,
 		},
 		{
-			name:               "PutStaticHostInvalidMACAddress",
-			httpMethod:         http.MethodPut,
-			route:              "/api/v1/static/host",
-			requestBody:        strings.NewReader(InvalidMACAddressJSON),
+			name:               "PatchStaticHostInvalidIPAddress",
+			httpMethod:         http.MethodPatch,
+			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
+			requestBody:        strings.NewReader(InvalidIPAddressPatchJSON),
 			expectedStatusCode: http.StatusUnprocessableEntity,
-			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "MacAddress", "The MacAddress field must be of type mac.", InvalidMACAddress),
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "IPAddress", "The IPAddress field must be empty or of type ipv4.", InvalidIPAddress),
 			mockSetup:          voidMock,
 		},
 		{
-			name:               "PutStaticHostInvalidIPAddress",
-			httpMethod:         http.MethodPut,
-			route:              "/api/v1/static/host",
-			requestBody:        strings.NewReader(InvalidIPAddressJSON),
+			name:               "PatchStaticHostEmptyHostName",
+			httpMethod:         http.MethodPatch,
+			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
+			requestBody:        strings.NewReader(EmptyHostNamePatchJSON),
 			expectedStatusCode: http.StatusUnprocessableEntity,
-			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "IPAddress", "The IPAddress field must be of type ipv4.", InvalidIPAddress),
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "HostName", "The HostName field must be of type hostname.", ""),
 			mockSetup:          voidMock,
 		},
 		{
-			name:               "PutStaticHostInvalidHostName",
-			httpMethod:         http.MethodPut,
-			route:              "/api/v1/static/host",
-			requestBody:        strings.NewReader(InvalidHostNameJSON),
-			expectedStatusCode: http.StatusUnprocessableEntity,
-			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "HostName", "The HostName field must be of type hostname.", InvalidHostName),
-			mockSetup:          voidMock,
+			name:               "PatchStaticHostNotFound",
+			httpMethod:         http.MethodPatch,
+			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
+			requestBody:        strings.NewReader(HostNamePatchJSON),
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingMacAddress, ValidMACAddress)),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Patch", tests.ParseMAC(ValidMACAddress), &HostNamePatch, model.Versions(nil)).Once().Return(nil, &host.NotFoundError{Field: "MAC", Value: ValidMACAddress})
+			},
 		},
 		{
-			name:               "PutStaticHostServiceError",
-			httpMethod:         http.MethodPut,
-			route:              "/api/v1/static/host",
-			requestBody:        strings.NewReader(ValidHostJSON),
-			expectedStatusCode: http.StatusInternalServerError,
-			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
+			name:               "PatchStaticHostIPAddressInUse",
+			httpMethod:         http.MethodPatch,
+			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
+			requestBody:        strings.NewReader(HostNamePatchJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedIPAddressMessage, fmt.Sprintf(IPAddressInUseByHost, "Bar", "02:04:06:dd:ee:ff", ValidIPAddress)),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Patch", tests.ParseMAC(ValidMACAddress), &HostNamePatch, model.Versions(nil)).Once().Return(nil, &host.DuplicatedEntryError{Field: "IP", Value: ValidIPAddress, Host: &AllHosts[1]})
+			},
+		},
+		{
+			name:               "PatchStaticHostByHostNameNotFound",
+			httpMethod:         http.MethodPatch,
+			route:              "/api/v1/static/host?hostname=Qux",
+			requestBody:        strings.NewReader(HostNamePatchJSON),
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingHostName, "Qux")),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("PatchByHostName", "Qux", &HostNamePatch, model.Versions(nil)).Once().Return(nil, &host.NotFoundError{Field: "hostname", Value: "Qux"})
+			},
+		},
+		{
+			name:               "PatchStaticHostHostNameInUse",
+			httpMethod:         http.MethodPatch,
+			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
+			requestBody:        strings.NewReader(HostNamePatchJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedHostNameMessage, fmt.Sprintf(HostNameInUseByHost, "Bar", "02:04:06:dd:ee:ff", "Foo")),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Patch", tests.ParseMAC(ValidMACAddress), &HostNamePatch, model.Versions(nil)).Once().Return(nil, &host.DuplicatedEntryError{Field: "hostname", Value: "Foo", Host: &AllHosts[1]})
+			},
+		},
+		{
+			name:               "PatchStaticHostWithoutIPAddress",
+			httpMethod:         http.MethodPatch,
+			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
+			requestBody:        strings.NewReader(HostNamePatchJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, HostWithoutIPAddress),
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("Update", &ValidHost).Once().Return(errors.New("an error"))
+				mock.On("Patch", tests.ParseMAC(ValidMACAddress), &HostNamePatch, model.Versions(nil)).Once().Return(nil, &errkind.ValidationError{Err: model.ErrDHCPHostMissingIPAddress})
 			},
 		},
 		{
@@ -397,7 +1252,7 @@ func TestStaticHostsApi(t *testing.T) {
 			httpMethod:         http.MethodDelete,
 			route:              "/api/v1/static/host",
 			expectedStatusCode: http.StatusBadRequest,
-			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, InvalidRequestMessage, MissingQueryParameter),
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidRequestMessage, MissingQueryParameter),
 			mockSetup:          voidMock,
 		},
 		{
@@ -407,7 +1262,21 @@ func TestStaticHostsApi(t *testing.T) {
 			expectedStatusCode: http.StatusOK,
 			expectedResponse:   ValidHostJSON,
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("RemoveByMac", tests.ParseMAC(ValidMACAddress)).Once().Return(&ValidHost, nil)
+				mock.On("RemoveByMac", tests.ParseMAC(ValidMACAddress), model.Versions(nil)).Once().Return(&ValidHost, nil)
+			},
+		},
+		{
+			name:               "DeleteStaticHostByMACReloadFailed",
+			httpMethod:         http.MethodDelete,
+			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
+			expectedStatusCode: http.StatusBadGateway,
+			expectedResponse: tests.ReloadFailedJSON(ReloadFailedMessage,
+				fmt.Sprintf(ReloadFailed, "DHCP static hosts", "dnsmasq reload (signal) failed: no such process"), ValidHostJSON),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("RemoveByMac", tests.ParseMAC(ValidMACAddress), model.Versions(nil)).Once().Return(&ValidHost, &dnsmasq.ReloadError{
+					Method: dnsmasq.ReloadSignal,
+					Err:    errors.New("no such process"),
+				})
 			},
 		},
 		{
@@ -415,7 +1284,7 @@ func TestStaticHostsApi(t *testing.T) {
 			httpMethod:         http.MethodDelete,
 			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", InvalidMACAddress),
 			expectedStatusCode: http.StatusBadRequest,
-			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, InvalidMacAddressMessage, fmt.Sprintf(MalformedMacAddress, InvalidMACAddress)),
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidMacAddressMessage, fmt.Sprintf(MalformedMacAddress, InvalidMACAddress)),
 			mockSetup:          voidMock,
 		},
 		{
@@ -425,7 +1294,7 @@ func TestStaticHostsApi(t *testing.T) {
 			expectedStatusCode: http.StatusNoContent,
 			expectedResponse:   "",
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("RemoveByMac", tests.ParseMAC(ValidMACAddress)).Once().Return(nil, nil)
+				mock.On("RemoveByMac", tests.ParseMAC(ValidMACAddress), model.Versions(nil)).Once().Return(nil, nil)
 			},
 		},
 		{
@@ -433,9 +1302,9 @@ func TestStaticHostsApi(t *testing.T) {
 			httpMethod:         http.MethodDelete,
 			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
 			expectedStatusCode: http.StatusInternalServerError,
-			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("RemoveByMac", tests.ParseMAC(ValidMACAddress)).Once().Return(nil, errors.New("an error"))
+				mock.On("RemoveByMac", tests.ParseMAC(ValidMACAddress), model.Versions(nil)).Once().Return(nil, errors.New("an error"))
 			},
 		},
 		{
@@ -443,7 +1312,7 @@ func TestStaticHostsApi(t *testing.T) {
 			httpMethod:         http.MethodDelete,
 			route:              fmt.Sprintf("/api/v1/static/host?ip=%s", InvalidIPAddress),
 			expectedStatusCode: http.StatusBadRequest,
-			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, InvalidIPAddressMessage, fmt.Sprintf(MalformedIPAddress, InvalidIPAddress)),
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidIPAddressMessage, fmt.Sprintf(MalformedIPAddress, InvalidIPAddress)),
 			mockSetup:          voidMock,
 		},
 		{
@@ -453,7 +1322,7 @@ func TestStaticHostsApi(t *testing.T) {
 			expectedStatusCode: http.StatusOK,
 			expectedResponse:   ValidHostJSON,
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("RemoveByIP", net.ParseIP(ValidIPAddress)).Once().Return(&ValidHost, nil)
+				mock.On("RemoveByIP", net.ParseIP(ValidIPAddress), model.Versions(nil)).Once().Return(&ValidHost, nil)
 			},
 		},
 		{
@@ -463,7 +1332,27 @@ func TestStaticHostsApi(t *testing.T) {
 			expectedStatusCode: http.StatusNoContent,
 			expectedResponse:   "",
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("RemoveByIP", net.ParseIP(ValidIPAddress)).Once().Return(nil, nil)
+				mock.On("RemoveByIP", net.ParseIP(ValidIPAddress), model.Versions(nil)).Once().Return(nil, nil)
+			},
+		},
+		{
+			name:               "DeleteStaticHostByHostNameSuccess",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/host?hostname=foo",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidHostJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("RemoveByHostName", "foo", model.Versions(nil)).Once().Return(&ValidHost, nil)
+			},
+		},
+		{
+			name:               "DeleteStaticHostByHostNameNotFound",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/host?hostname=Qux",
+			expectedStatusCode: http.StatusNoContent,
+			expectedResponse:   "",
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("RemoveByHostName", "Qux", model.Versions(nil)).Once().Return(nil, nil)
 			},
 		},
 		{
@@ -471,9 +1360,19 @@ func TestStaticHostsApi(t *testing.T) {
 			httpMethod:         http.MethodDelete,
 			route:              fmt.Sprintf("/api/v1/static/host?ip=%s", ValidIPAddress),
 			expectedStatusCode: http.StatusInternalServerError,
-			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, fmt.Sprintf(presenter.InternalServerError, tests.UUIDRegexMatch)),
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("RemoveByIP", net.ParseIP(ValidIPAddress), model.Versions(nil)).Once().Return(nil, errors.New("an error"))
+			},
+		},
+		{
+			name:               "DeleteStaticHostByIPFileLocked",
+			httpMethod:         http.MethodDelete,
+			route:              fmt.Sprintf("/api/v1/static/host?ip=%s", ValidIPAddress),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse:   tests.ErrorJSON(http.StatusServiceUnavailable, presenter.LockTimeoutCode, fmt.Sprintf(FileLockedMessage, "DHCP static hosts"), fmt.Sprintf(FileLockTimeout, "DHCP static hosts")),
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("RemoveByIP", net.ParseIP(ValidIPAddress)).Once().Return(nil, errors.New("an error"))
+				mock.On("RemoveByIP", net.ParseIP(ValidIPAddress), model.Versions(nil)).Once().Return(nil, &errkind.StorageError{Err: &storage.LockTimeoutError{File: "/etc/dnsmasq.d/04-dhcp-static-leases.conf", Timeout: time.Second}})
 			},
 		},
 	}
@@ -491,6 +1390,10 @@ func TestStaticHostsApi(t *testing.T) {
 
 			assert.Equal(t, test.expectedStatusCode, response.StatusCode, "%s: returned wrong HTTP status code", description)
 
+			for header, value := range test.expectedHeaders {
+				assert.Equal(t, value, response.Header.Get(header), "%s: unexpected %s header", description, header)
+			}
+
 			responseBody := tests.GetBody(response)
 			if !tests.JSONMatches(test.expectedResponse, string(responseBody)) {
 				assert.JSONEq(t, test.expectedResponse, string(responseBody), "%s: unexpected HTTP response body", description)
@@ -499,6 +1402,169 @@ func TestStaticHostsApi(t *testing.T) {
 	}
 }
 
+func TestStaticHostsApiConditionalRequests(t *testing.T) {
+	collectionTag := `"` + model.HostsVersion(AllHosts) + `"`
+	hostTag := `"` + ValidHost.Version() + `"`
+
+	var testCases = []struct {
+		name               string
+		httpMethod         string
+		route              string
+		requestBody        string
+		requestHeaders     map[string]string
+		expectedStatusCode int
+		expectedETag       string
+		mockSetup          func(s *hostmock.ServiceMock)
+	}{
+		{
+			name:               "GetAllStaticHostsETag",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts",
+			expectedStatusCode: http.StatusOK,
+			expectedETag:       collectionTag,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(&AllHosts, nil)
+			},
+		},
+		{
+			name:               "GetAllStaticHostsNotModified",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts?owner=alice",
+			requestHeaders:     map[string]string{fiber.HeaderIfNoneMatch: `"0123", ` + collectionTag},
+			expectedStatusCode: http.StatusNotModified,
+			expectedETag:       collectionTag,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				hosts := slices.Clone(AllHosts)
+				mock.On("FetchAll").Once().Return(&hosts, nil)
+			},
+		},
+		{
+			name:               "GetAllStaticHostsNotModifiedWeakTag",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts",
+			requestHeaders:     map[string]string{fiber.HeaderIfNoneMatch: "W/" + collectionTag},
+			expectedStatusCode: http.StatusNotModified,
+			expectedETag:       collectionTag,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(&AllHosts, nil)
+			},
+		},
+		{
+			name:               "GetAllStaticHostsModified",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/hosts",
+			requestHeaders:     map[string]string{fiber.HeaderIfNoneMatch: hostTag},
+			expectedStatusCode: http.StatusOK,
+			expectedETag:       collectionTag,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(&AllHosts, nil)
+			},
+		},
+		{
+			name:               "GetStaticHostByMacNotModified",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/host?mac=" + ValidMACAddress,
+			requestHeaders:     map[string]string{fiber.HeaderIfNoneMatch: "*"},
+			expectedStatusCode: http.StatusNotModified,
+			expectedETag:       hostTag,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("FetchByMac", tests.ParseMAC(ValidMACAddress)).Once().Return(&ValidHost, nil)
+			},
+		},
+		{
+			name:               "GetStaticHostByIPETag",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/host?ip=" + ValidIPAddress,
+			expectedStatusCode: http.StatusOK,
+			expectedETag:       hostTag,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("FetchByIP", net.ParseIP(ValidIPAddress)).Once().Return(&ValidHost, nil)
+			},
+		},
+		{
+			name:               "PostStaticHostIfMatchCollection",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/host",
+			requestBody:        ValidHostJSON,
+			requestHeaders:     map[string]string{fiber.HeaderIfMatch: collectionTag},
+			expectedStatusCode: http.StatusCreated,
+			expectedETag:       hostTag,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Insert", &ValidHost, model.Versions{model.HostsVersion(AllHosts)}).Once().Return(nil)
+			},
+		},
+		{
+			name:               "PutStaticHostIfMatch",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host",
+			requestBody:        ValidHostJSON,
+			requestHeaders:     map[string]string{fiber.HeaderIfMatch: `"0123", "4567"`},
+			expectedStatusCode: http.StatusOK,
+			expectedETag:       hostTag,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Update", &ValidHost, model.Versions{"0123", "4567"}).Once().Return(nil)
+			},
+		},
+		{
+			name:               "PutStaticHostPreconditionFailed",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/host",
+			requestBody:        ValidHostJSON,
+			requestHeaders:     map[string]string{fiber.HeaderIfMatch: `"0123"`},
+			expectedStatusCode: http.StatusPreconditionFailed,
+			expectedETag:       `"4567"`,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Update", &ValidHost, model.Versions{"0123"}).Once().Return(&model.VersionMismatchError{Current: "4567"})
+			},
+		},
+		{
+			name:               "PatchStaticHostWeakIfMatch",
+			httpMethod:         http.MethodPatch,
+			route:              "/api/v1/static/host?mac=" + ValidMACAddress,
+			requestBody:        HostNamePatchJSON,
+			requestHeaders:     map[string]string{fiber.HeaderIfMatch: `W/"0123"`},
+			expectedStatusCode: http.StatusPreconditionFailed,
+			expectedETag:       `"4567"`,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Patch", tests.ParseMAC(ValidMACAddress), &HostNamePatch, model.Versions{`W/"0123"`}).Once().Return(nil, &model.VersionMismatchError{Current: "4567"})
+			},
+		},
+		{
+			name:               "DeleteMissingStaticHostIfMatch",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/host?ip=" + ValidIPAddress,
+			requestHeaders:     map[string]string{fiber.HeaderIfMatch: "*"},
+			expectedStatusCode: http.StatusPreconditionFailed,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("RemoveByIP", net.ParseIP(ValidIPAddress), model.Versions{model.AnyVersion}).Once().Return(nil, &model.VersionMismatchError{})
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		description := fmt.Sprintf("%s %s %d", test.httpMethod, test.route, test.expectedStatusCode)
+
+		t.Run(test.name, func(t *testing.T) {
+			app := setupTest(t, test.mockSetup)
+
+			request := httptest.NewRequest(test.httpMethod, test.route, strings.NewReader(test.requestBody))
+			request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			for header, value := range test.requestHeaders {
+				request.Header.Set(header, value)
+			}
+
+			response, err := app.Test(request)
+			require.NoError(t, err, "app.Test() request failed")
+
+			assert.Equal(t, test.expectedStatusCode, response.StatusCode, "%s: returned wrong HTTP status code", description)
+			assert.Equal(t, test.expectedETag, response.Header.Get(fiber.HeaderETag), "%s: returned wrong ETag", description)
+			if test.expectedStatusCode == http.StatusNotModified {
+				assert.Empty(t, tests.GetBody(response), "%s: unexpected HTTP response body", description)
+			}
+		})
+	}
+}
+
 func TestStaticHostsApiWithAuth(t *testing.T) {
 	const (
 		AuthMethod = config.AuthHS256
@@ -520,7 +1586,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 			httpMethod:         http.MethodGet,
 			route:              "/api/v1/static/hosts",
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
 			mockSetup:          voidMock,
 		},
 		{
@@ -535,7 +1601,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 				},
 			},
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, api.UnauthorizedMessage, api.InvalidOrExpiredJWT),
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.InvalidOrExpiredJWT),
 			mockSetup:          voidMock,
 		},
 		{
@@ -550,7 +1616,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 				},
 			},
 			expectedStatusCode: http.StatusForbidden,
-			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, api.NotAuthorizedMessage, api.MalformedJwt),
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, api.MalformedJwt),
 			mockSetup:          voidMock,
 		},
 		{
@@ -565,7 +1631,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 				},
 			},
 			expectedStatusCode: http.StatusForbidden,
-			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, api.NotAuthorizedMessage, api.MalformedJwt),
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, api.MalformedJwt),
 			mockSetup:          voidMock,
 		},
 		{
@@ -581,7 +1647,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 				},
 			},
 			expectedStatusCode: http.StatusForbidden,
-			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, api.NotAuthorizedMessage, api.MalformedJwt),
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, api.MalformedJwt),
 			mockSetup:          voidMock,
 		},
 		{
@@ -607,7 +1673,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 			httpMethod:         http.MethodGet,
 			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
 			mockSetup:          voidMock,
 		},
 		{
@@ -622,7 +1688,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 				},
 			},
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, api.UnauthorizedMessage, api.InvalidOrExpiredJWT),
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.InvalidOrExpiredJWT),
 			mockSetup:          voidMock,
 		},
 		{
@@ -637,7 +1703,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 				},
 			},
 			expectedStatusCode: http.StatusForbidden,
-			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, api.NotAuthorizedMessage, api.MalformedJwt),
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, api.MalformedJwt),
 			mockSetup:          voidMock,
 		},
 		{
@@ -664,7 +1730,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 			route:              "/api/v1/static/host",
 			requestBody:        strings.NewReader(ValidHostJSON),
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
 			mockSetup:          voidMock,
 		},
 		{
@@ -680,7 +1746,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 			},
 			requestBody:        strings.NewReader(ValidHostJSON),
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, api.UnauthorizedMessage, api.InvalidOrExpiredJWT),
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.InvalidOrExpiredJWT),
 			mockSetup:          voidMock,
 		},
 		{
@@ -696,7 +1762,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 			},
 			requestBody:        strings.NewReader(ValidHostJSON),
 			expectedStatusCode: http.StatusForbidden,
-			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, api.NotAuthorizedMessage, api.MalformedJwt),
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, api.MalformedJwt),
 			mockSetup:          voidMock,
 		},
 		{
@@ -713,7 +1779,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 			},
 			requestBody:        strings.NewReader(ValidHostJSON),
 			expectedStatusCode: http.StatusForbidden,
-			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, api.NotAuthorizedMessage, api.MissingRole),
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, api.MissingRole),
 			mockSetup:          voidMock,
 		},
 		{
@@ -730,9 +1796,9 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 			},
 			requestBody:        strings.NewReader(ValidHostJSON),
 			expectedStatusCode: http.StatusCreated,
-			expectedResponse:   ValidHostJSON,
+			expectedResponse:   ValidHostCreatedByUserJSON,
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("Insert", &ValidHost).Once().Return(nil)
+				mock.On("Insert", &ValidHostCreatedByUser, model.Versions(nil)).Once().Return(nil)
 			},
 		},
 		{
@@ -741,7 +1807,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 			route:              "/api/v1/static/host",
 			requestBody:        strings.NewReader(ValidHostJSON),
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
 			mockSetup:          voidMock,
 		},
 		{
@@ -757,7 +1823,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 			},
 			requestBody:        strings.NewReader(ValidHostJSON),
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, api.UnauthorizedMessage, api.InvalidOrExpiredJWT),
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.InvalidOrExpiredJWT),
 			mockSetup:          voidMock,
 		},
 		{
@@ -773,7 +1839,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 			},
 			requestBody:        strings.NewReader(ValidHostJSON),
 			expectedStatusCode: http.StatusForbidden,
-			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, api.NotAuthorizedMessage, api.MalformedJwt),
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, api.MalformedJwt),
 			mockSetup:          voidMock,
 		},
 		{
@@ -790,7 +1856,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 			},
 			requestBody:        strings.NewReader(ValidHostJSON),
 			expectedStatusCode: http.StatusForbidden,
-			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, api.NotAuthorizedMessage, api.MissingRole),
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, api.MissingRole),
 			mockSetup:          voidMock,
 		},
 		{
@@ -806,10 +1872,46 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 				},
 			},
 			requestBody:        strings.NewReader(ValidHostJSON),
-			expectedStatusCode: http.StatusCreated,
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidHostJSON,
+			mockSetup: func(mock *hostmock.ServiceMock) {
+				mock.On("Update", &ValidHost, model.Versions(nil)).Once().Return(nil)
+			},
+		},
+		{
+			name:       "PatchUnauthorized",
+			httpMethod: http.MethodPatch,
+			route:      fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
+			token: &jwtTokenConfig{
+				SigningKey: AuthKey,
+				Claims: jwt.MapClaims{
+					"name":  "unit-tests",
+					"exp":   time.Now().Add(time.Minute * 30).Unix(),
+					"scope": scope.DhcpWrite,
+				},
+			},
+			requestBody:        strings.NewReader(HostNamePatchJSON),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, api.MissingRole),
+			mockSetup:          voidMock,
+		},
+		{
+			name:       "PatchAuthorized",
+			httpMethod: http.MethodPatch,
+			route:      fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
+			token: &jwtTokenConfig{
+				SigningKey: AuthKey,
+				Claims: jwt.MapClaims{
+					"name":  "unit-tests",
+					"exp":   time.Now().Add(time.Minute * 30).Unix(),
+					"scope": scope.DhcpAdmin,
+				},
+			},
+			requestBody:        strings.NewReader(HostNamePatchJSON),
+			expectedStatusCode: http.StatusOK,
 			expectedResponse:   ValidHostJSON,
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("Update", &ValidHost).Once().Return(nil)
+				mock.On("Patch", tests.ParseMAC(ValidMACAddress), &HostNamePatch, model.Versions(nil)).Once().Return(&ValidHost, nil)
 			},
 		},
 		{
@@ -817,7 +1919,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 			httpMethod:         http.MethodDelete,
 			route:              fmt.Sprintf("/api/v1/static/host?mac=%s", ValidMACAddress),
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
 			mockSetup:          voidMock,
 		},
 		{
@@ -832,7 +1934,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 				},
 			},
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, api.UnauthorizedMessage, api.InvalidOrExpiredJWT),
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.InvalidOrExpiredJWT),
 			mockSetup:          voidMock,
 		},
 		{
@@ -847,7 +1949,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 				},
 			},
 			expectedStatusCode: http.StatusForbidden,
-			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, api.NotAuthorizedMessage, api.MalformedJwt),
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, api.MalformedJwt),
 			mockSetup:          voidMock,
 		},
 		{
@@ -863,7 +1965,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 				},
 			},
 			expectedStatusCode: http.StatusForbidden,
-			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, api.NotAuthorizedMessage, api.MissingRole),
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, api.MissingRole),
 			mockSetup:          voidMock,
 		},
 		{
@@ -881,7 +1983,7 @@ func TestStaticHostsApiWithAuth(t *testing.T) {
 			expectedStatusCode: http.StatusOK,
 			expectedResponse:   ValidHostJSON,
 			mockSetup: func(mock *hostmock.ServiceMock) {
-				mock.On("RemoveByMac", tests.ParseMAC(ValidMACAddress)).Once().Return(&ValidHost, nil)
+				mock.On("RemoveByMac", tests.ParseMAC(ValidMACAddress), model.Versions(nil)).Once().Return(&ValidHost, nil)
 			},
 		},
 	}
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api"
+	"github.com/gringolito/dnsmasq-manager/api/dto"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/api/scope"
+	"github.com/gringolito/dnsmasq-manager/api/validation"
+	"github.com/gringolito/dnsmasq-manager/pkg/dhcp"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"log/slog"
+)
+
+// Error messages
+const (
+	RangeNotFoundMessage    = "No DHCP range found for the given start address."
+	DuplicatedRangeMessage  = "The DHCP range is already in use."
+	OverlappingRangeMessage = "The DHCP range overlaps another range."
+)
+
+// Details
+const (
+	NoMatchingRange = "The DHCP server could not find the DHCP range starting at %s. Please check the request and " +
+		"try again."
+	RangeInUse = "There is already a DHCP range starting at %s. Please try again with a different range, or change " +
+		"that one instead."
+	RangeOverlap = "The DHCP range claims addresses of the range starting at %s, either in its pool or in its " +
+		"subnet. Please try again with a different range, or change that one first."
+	InvalidRange          = "The DHCP range can't be written as a dnsmasq entry: %s."
+	RangeCouldNotBeParsed = "The request could not be processed because the DHCP range could not be parsed. " +
+		"Please check the request and try again."
+)
+
+// describeRange tells where a range starts, e.g. "::1 on eth0" for the ranges built on an interface.
+func describeRange(start string, iface string) string {
+	if iface == "" {
+		return start
+	}
+	return fmt.Sprintf("%s on %s", start, iface)
+}
+
+// rangeServiceErrorResponse answers a request whose DHCP ranges service call failed, see serviceErrorResponse.
+func rangeServiceErrorResponse(c *fiber.Ctx, err error) error {
+	message, details := rangeErrorDetails(err)
+	return presenter.ServiceErrorResponse(c, err, message, details)
+}
+
+// rangeChangeErrorResponse answers a request whose change of a DHCP range failed, see presenter.ChangeErrorResponse.
+func rangeChangeErrorResponse(c *fiber.Ctx, err error, r *model.DhcpRange, warnings []string) error {
+	message, details := rangeErrorDetails(err)
+	return presenter.ChangeErrorResponse(c, err, message, details, rangeResponse(r, warnings))
+}
+
+// rangeErrorDetails returns the message and the details telling the client what went wrong with its request.
+func rangeErrorDetails(err error) (string, interface{}) {
+	if message, details, ok := fileErrorDetails("DHCP ranges", err); ok {
+		return message, details
+	}
+
+	var notFoundErr *dhcp.NotFoundError
+	var duplicatedErr *dhcp.DuplicatedEntryError
+	var overlapErr *dhcp.OverlapError
+
+	switch {
+	case errors.As(err, &notFoundErr):
+		return RangeNotFoundMessage, fmt.Sprintf(NoMatchingRange, describeRange(notFoundErr.Key.Start.String(), notFoundErr.Key.Interface))
+
+	case errors.As(err, &duplicatedErr):
+		slog.Debug("Could not change the DHCP ranges because a conflict was detected",
+			slog.String("error", err.Error()),
+		)
+		return DuplicatedRangeMessage, fmt.Sprintf(RangeInUse, describeRange(duplicatedErr.Range.Start.String(), duplicatedErr.Range.Interface))
+
+	case errors.As(err, &overlapErr):
+		slog.Debug("Could not change the DHCP ranges because an overlap was detected",
+			slog.String("error", err.Error()),
+		)
+		return OverlappingRangeMessage, fmt.Sprintf(RangeOverlap, describeRange(overlapErr.Range.Start.String(), overlapErr.Range.Interface))
+
+	case errors.Is(err, errkind.ErrValidation):
+		return InvalidRequestBodyMessage, fmt.Sprintf(InvalidRange, strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
+
+	// Internal server errors only tell the request ID
+	return "", nil
+}
+
+// getRangeKey returns the key of the range in the path, along with the interface query parameter of the ranges
+// built on one. It answers not found when the start is not an IP address.
+func getRangeKey(c *fiber.Ctx) *model.DhcpRangeKey {
+	start := c.Params("start")
+	key := &model.DhcpRangeKey{
+		Start:     net.ParseIP(start),
+		Interface: c.Query("interface"),
+	}
+	if key.Start == nil {
+		presenter.NotFoundResponse(c, RangeNotFoundMessage, fmt.Sprintf(NoMatchingRange, describeRange(start, key.Interface)))
+		return nil
+	}
+
+	return key
+}
+
+func getRangeFromBody(c *fiber.Ctx) *model.DhcpRange {
+	body := new(dto.DhcpRange)
+	if err := c.BodyParser(body); err != nil {
+		slog.Debug("Failed to parse DHCP range from the body",
+			slog.String("error", err.Error()),
+		)
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, RangeCouldNotBeParsed)
+		return nil
+	}
+
+	if errors := validation.Validate(body); errors != nil {
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, errors)
+		return nil
+	}
+
+	return body.ToModel()
+}
+
+// rangeResponse returns a changed range along with the warnings of the service.
+func rangeResponse(dhcpRange *model.DhcpRange, warnings []string) *dto.DhcpRange {
+	response := dto.NewDhcpRange(dhcpRange)
+	response.Warnings = warnings
+	return response
+}
+
+func GetAllRanges(service dhcp.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ranges, err := service.FetchAll()
+		if err != nil {
+			return rangeServiceErrorResponse(c, err)
+		}
+
+		response := make([]dto.DhcpRange, 0, len(*ranges))
+		for _, r := range *ranges {
+			response = append(response, *dto.NewDhcpRange(&r))
+		}
+
+		return c.Status(http.StatusOK).JSON(response)
+	}
+}
+
+func GetRange(service dhcp.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := getRangeKey(c)
+		if key == nil {
+			// The errors was already handled by the getRangeKey()
+			return nil
+		}
+
+		r, err := service.FetchByKey(*key)
+		if err != nil {
+			return rangeServiceErrorResponse(c, err)
+		}
+		if r == nil {
+			return presenter.NotFoundResponse(c, RangeNotFoundMessage, fmt.Sprintf(NoMatchingRange, describeRange(key.Start.String(), key.Interface)))
+		}
+
+		return c.Status(http.StatusOK).JSON(dto.NewDhcpRange(r))
+	}
+}
+
+func AddRange(service dhcp.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		r := getRangeFromBody(c)
+		if r == nil {
+			// The errors was already handled by the getRangeFromBody()
+			return nil
+		}
+
+		err := service.Insert(r)
+		if err != nil {
+			return rangeChangeErrorResponse(c, err, r, nil)
+		}
+
+		return c.Status(http.StatusCreated).JSON(dto.NewDhcpRange(r))
+	}
+}
+
+// UpdateRange replaces the range with the key in the path, whose key may be changed by the body.
+func UpdateRange(service dhcp.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := getRangeKey(c)
+		if key == nil {
+			// The errors was already handled by the getRangeKey()
+			return nil
+		}
+		r := getRangeFromBody(c)
+		if r == nil {
+			// The errors was already handled by the getRangeFromBody()
+			return nil
+		}
+
+		warnings, err := service.Update(*key, r)
+		if err != nil {
+			return rangeChangeErrorResponse(c, err, r, warnings)
+		}
+
+		return c.Status(http.StatusOK).JSON(rangeResponse(r, warnings))
+	}
+}
+
+func RemoveRange(service dhcp.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := getRangeKey(c)
+		if key == nil {
+			// The errors was already handled by the getRangeKey()
+			return nil
+		}
+
+		r, warnings, err := service.RemoveByKey(*key)
+		if err != nil && r != nil {
+			return rangeChangeErrorResponse(c, err, r, warnings)
+		}
+		if err != nil {
+			return rangeServiceErrorResponse(c, err)
+		}
+		if r == nil {
+			return c.SendStatus(http.StatusNoContent)
+		}
+
+		return c.Status(http.StatusOK).JSON(rangeResponse(r, warnings))
+	}
+}
+
+// RouteDhcpRanges routes the DHCP ranges API. A range is identified by its start address, along with the interface
+// query parameter of the ranges built on one.
+func RouteDhcpRanges(router api.Router, service dhcp.Service) {
+	router.AddApiV1Route("/dhcp/ranges", func(r fiber.Router) {
+		r.Get("", router.AuthenticationHandler(scope.DhcpCanRead...), GetAllRanges(service)).Name("get_all")
+		r.Get("/:start", router.AuthenticationHandler(scope.DhcpCanRead...), GetRange(service)).Name("get")
+		r.Post("", router.AuthenticationHandler(scope.DhcpCanAdd...), AddRange(service)).Name("add")
+		r.Put("/:start", router.AuthenticationHandler(scope.DhcpCanChange...), UpdateRange(service)).Name("update")
+		r.Delete("/:start", router.AuthenticationHandler(scope.DhcpCanChange...), RemoveRange(service)).Name("remove")
+	}, "dhcp.ranges.")
+}
@@ -0,0 +1,527 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gringolito/dnsmasq-manager/api"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/api/scope"
+	"github.com/gringolito/dnsmasq-manager/config"
+	"github.com/gringolito/dnsmasq-manager/pkg/dhcp"
+	dhcpmock "github.com/gringolito/dnsmasq-manager/pkg/dhcp/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"github.com/gringolito/dnsmasq-manager/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	ValidRangeJSON       = `{"SetTag":"lan", "Start":"192.168.1.100", "End":"192.168.1.200", "Netmask":"255.255.255.0", "LeaseTime":"12h"}`
+	MovedRangeJSON       = `{"Start":"192.168.5.100", "End":"192.168.5.200", "LeaseTime":"12h"}`
+	GuestRangeJSON       = `{"MatchTags":["guest"], "Start":"::", "Interface":"eth1", "Modes":["ra-stateless"]}`
+	InvalidModeRangeJSON = `{"Start":"2001:db8::", "Modes":["stateless"]}`
+	InvalidStartJSON     = `{"Start":"192.168.1", "End":"192.168.1.200"}`
+	BadLeaseRangeJSON    = `{"Start":"192.168.1.100", "End":"192.168.1.200", "LeaseTime":"12 hours"}`
+	AllRangesJSON        = `[` + ValidRangeJSON + `,` + GuestRangeJSON + `]`
+)
+
+var ValidRange = model.DhcpRange{SetTag: "lan", Start: net.ParseIP("192.168.1.100"), End: net.ParseIP("192.168.1.200"), Netmask: net.ParseIP("255.255.255.0"), LeaseTime: "12h"}
+var MovedRange = model.DhcpRange{Start: net.ParseIP("192.168.5.100"), End: net.ParseIP("192.168.5.200"), LeaseTime: "12h"}
+var GuestRange = model.DhcpRange{MatchTags: []string{"guest"}, Start: net.ParseIP("::"), Interface: "eth1", Modes: []string{"ra-stateless"}}
+var BadLeaseRange = model.DhcpRange{Start: net.ParseIP("192.168.1.100"), End: net.ParseIP("192.168.1.200"), LeaseTime: "12 hours"}
+
+var OrphanedNasWarning = fmt.Sprintf(dhcp.OrphanedHostWarning, "nas", "192.168.1.10")
+
+var voidRangeMock = func(mock *dhcpmock.ServiceMock) {}
+
+func setupRangeTest(t *testing.T, mockSetup func(mock *dhcpmock.ServiceMock)) *fiber.App {
+	app := setupApp()
+	config := setupConfig(t)
+	serviceMock := &dhcpmock.ServiceMock{}
+	router := setupRouter(app, config)
+	RouteDhcpRanges(router, serviceMock)
+	mockSetup(serviceMock)
+	return app
+}
+
+func TestDhcpRangeApi(t *testing.T) {
+	var testCases = []struct {
+		name               string
+		httpMethod         string
+		route              string
+		requestBody        io.Reader
+		expectedStatusCode int
+		expectedResponse   string
+		expectedHeaders    map[string]string
+		mockSetup          func(s *dhcpmock.ServiceMock)
+	}{
+		{
+			name:               "GetAllRangesSuccess",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dhcp/ranges",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   AllRangesJSON,
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(&[]model.DhcpRange{ValidRange, GuestRange}, nil)
+			},
+		},
+		{
+			name:               "GetAllRangesStorageUnavailable",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dhcp/ranges",
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse:   tests.ErrorJSON(http.StatusServiceUnavailable, presenter.StorageUnavailableCode, fmt.Sprintf(StorageUnavailableMessage, "DHCP ranges"), ".*disk failure.*"),
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(nil, &errkind.StorageError{Err: errors.New("disk failure")})
+			},
+		},
+		{
+			name:               "GetRangeSuccess",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dhcp/ranges/192.168.1.100",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidRangeJSON,
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("FetchByKey", model.DhcpRangeKey{Start: net.ParseIP("192.168.1.100")}).Once().Return(&ValidRange, nil)
+			},
+		},
+		{
+			name:               "GetConstructedRangeSuccess",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dhcp/ranges/::?interface=eth1",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   GuestRangeJSON,
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("FetchByKey", GuestRange.Key()).Once().Return(&GuestRange, nil)
+			},
+		},
+		{
+			name:               "GetRangeNotFound",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dhcp/ranges/2001:db8::100",
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, RangeNotFoundMessage, fmt.Sprintf(NoMatchingRange, "2001:db8::100")),
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("FetchByKey", model.DhcpRangeKey{Start: net.ParseIP("2001:db8::100")}).Once().Return(nil, nil)
+			},
+		},
+		{
+			name:               "GetRangeInvalidStart",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dhcp/ranges/lan?interface=eth0",
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, RangeNotFoundMessage, fmt.Sprintf(NoMatchingRange, "lan on eth0")),
+			mockSetup:          voidRangeMock,
+		},
+		{
+			name:               "AddRangeSuccess",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dhcp/ranges",
+			requestBody:        strings.NewReader(ValidRangeJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidRangeJSON,
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("Insert", &ValidRange).Once().Return(nil)
+			},
+		},
+		{
+			name:               "AddConstructedRangeSuccess",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dhcp/ranges",
+			requestBody:        strings.NewReader(GuestRangeJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   GuestRangeJSON,
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("Insert", &GuestRange).Once().Return(nil)
+			},
+		},
+		{
+			name:               "AddRangeInvalidJSON",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dhcp/ranges",
+			requestBody:        strings.NewReader(InvalidJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, RangeCouldNotBeParsed),
+			mockSetup:          voidRangeMock,
+		},
+		{
+			name:               "AddRangeInvalidMode",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dhcp/ranges",
+			requestBody:        strings.NewReader(InvalidModeRangeJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse: fmt.Sprintf(`{
+				"error": "%s",
+				"code": "validation_failed",
+				"message": "%s",
+				"details": [{
+					"field": "Modes[0]",
+					"reason": "The Modes[0] field must be one of: static, proxy, ra-only, ra-names, ra-stateless, slaac, ra-advrouter, off-link.",
+					"value": "stateless"
+				}]
+			}`, http.StatusText(http.StatusUnprocessableEntity), InvalidRequestBodyMessage),
+			mockSetup: voidRangeMock,
+		},
+		{
+			name:               "AddRangeInvalidStart",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dhcp/ranges",
+			requestBody:        strings.NewReader(InvalidStartJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse: fmt.Sprintf(`{
+				"error": "%s",
+				"code": "validation_failed",
+				"message": "%s",
+				"details": [{
+					"field": "Start",
+					"reason": "The Start field must be of type ip.",
+					"value": "192.168.1"
+				}]
+			}`, http.StatusText(http.StatusUnprocessableEntity), InvalidRequestBodyMessage),
+			mockSetup: voidRangeMock,
+		},
+		{
+			name:               "AddRangeInvalidLeaseTime",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dhcp/ranges",
+			requestBody:        strings.NewReader(BadLeaseRangeJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, ".*12 hours is not a lease time.*"),
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("Insert", &BadLeaseRange).Once().Return(&errkind.ValidationError{Err: BadLeaseRange.Validate()})
+			},
+		},
+		{
+			name:               "AddRangeDuplicated",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dhcp/ranges",
+			requestBody:        strings.NewReader(ValidRangeJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedRangeMessage, fmt.Sprintf(RangeInUse, "192.168.1.100")),
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("Insert", &ValidRange).Once().Return(&dhcp.DuplicatedEntryError{Range: &ValidRange})
+			},
+		},
+		{
+			name:               "AddRangeOverlap",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dhcp/ranges",
+			requestBody:        strings.NewReader(GuestRangeJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, OverlappingRangeMessage, fmt.Sprintf(RangeOverlap, "::100 on eth1")),
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				overlapped := model.DhcpRange{Start: net.ParseIP("::100"), End: net.ParseIP("::1ff"), Interface: "eth1", Modes: []string{"ra-stateless"}}
+				mock.On("Insert", &GuestRange).Once().Return(&dhcp.OverlapError{Range: &overlapped})
+			},
+		},
+		{
+			name:               "AddRangeRejectedConfig",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dhcp/ranges",
+			requestBody:        strings.NewReader(ValidRangeJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse: fmt.Sprintf(`{
+				"error": "%s",
+				"code": "validation_failed",
+				"message": "%s",
+				"details": {
+					"error": "exit status 1",
+					"output": "dnsmasq: bad dhcp-range at line 1 of candidate"
+				}
+			}`, http.StatusText(http.StatusUnprocessableEntity), RejectedConfigMessage),
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("Insert", &ValidRange).Once().Return(&errkind.ValidationError{Err: &storage.ValidationError{Err: errors.New("exit status 1"), Output: "dnsmasq: bad dhcp-range at line 1 of candidate"}})
+			},
+		},
+		{
+			name:               "AddRangeReloadFailed",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dhcp/ranges",
+			requestBody:        strings.NewReader(ValidRangeJSON),
+			expectedStatusCode: http.StatusBadGateway,
+			expectedResponse:   tests.ReloadFailedJSON(ReloadFailedMessage, ".*exit status 1.*", ValidRangeJSON),
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("Insert", &ValidRange).Once().Return(&dnsmasq.ReloadError{Err: errors.New("exit status 1")})
+			},
+		},
+		{
+			name:               "AddRangeFileLocked",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dhcp/ranges",
+			requestBody:        strings.NewReader(ValidRangeJSON),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse:   tests.ErrorJSON(http.StatusServiceUnavailable, presenter.LockTimeoutCode, fmt.Sprintf(FileLockedMessage, "DHCP ranges"), fmt.Sprintf(FileLockTimeout, "DHCP ranges")),
+			expectedHeaders:    map[string]string{fiber.HeaderRetryAfter: "1"},
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("Insert", &ValidRange).Once().Return(&errkind.StorageError{Err: &storage.LockTimeoutError{File: "/etc/dnsmasq.d/03-dhcp-ranges.conf", Timeout: time.Second}})
+			},
+		},
+		{
+			name:               "UpdateRangeSuccess",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/dhcp/ranges/192.168.1.100",
+			requestBody:        strings.NewReader(ValidRangeJSON),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidRangeJSON,
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("Update", ValidRange.Key(), &ValidRange).Once().Return(nil, nil)
+			},
+		},
+		{
+			name:               "UpdateRangeOrphanedHost",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/dhcp/ranges/192.168.1.100",
+			requestBody:        strings.NewReader(MovedRangeJSON),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   fmt.Sprintf(`{"Start":"192.168.5.100", "End":"192.168.5.200", "LeaseTime":"12h", "Warnings":[%q]}`, OrphanedNasWarning),
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("Update", ValidRange.Key(), &MovedRange).Once().Return([]string{OrphanedNasWarning}, nil)
+			},
+		},
+		{
+			name:               "UpdateRangeReloadFailed",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/dhcp/ranges/192.168.1.100",
+			requestBody:        strings.NewReader(MovedRangeJSON),
+			expectedStatusCode: http.StatusBadGateway,
+			expectedResponse: tests.ReloadFailedJSON(ReloadFailedMessage, fmt.Sprintf(ReloadFailed, "DHCP ranges", "dnsmasq reload () failed: exit status 1"),
+				fmt.Sprintf(`{"Start":"192.168.5.100", "End":"192.168.5.200", "LeaseTime":"12h", "Warnings":[%q]}`, OrphanedNasWarning)),
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("Update", ValidRange.Key(), &MovedRange).Once().Return([]string{OrphanedNasWarning}, &dnsmasq.ReloadError{Err: errors.New("exit status 1")})
+			},
+		},
+		{
+			name:               "UpdateRangeNotFound",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/dhcp/ranges/::?interface=eth1",
+			requestBody:        strings.NewReader(GuestRangeJSON),
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, RangeNotFoundMessage, fmt.Sprintf(NoMatchingRange, ":: on eth1")),
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("Update", GuestRange.Key(), &GuestRange).Once().Return(nil, &dhcp.NotFoundError{Key: GuestRange.Key()})
+			},
+		},
+		{
+			name:               "UpdateRangeInvalidJSON",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/dhcp/ranges/192.168.1.100",
+			requestBody:        strings.NewReader(InvalidJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, RangeCouldNotBeParsed),
+			mockSetup:          voidRangeMock,
+		},
+		{
+			name:               "RemoveRangeSuccess",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/dhcp/ranges/192.168.1.100",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   fmt.Sprintf(`{"SetTag":"lan", "Start":"192.168.1.100", "End":"192.168.1.200", "Netmask":"255.255.255.0", "LeaseTime":"12h", "Warnings":[%q]}`, OrphanedNasWarning),
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("RemoveByKey", ValidRange.Key()).Once().Return(&ValidRange, []string{OrphanedNasWarning}, nil)
+			},
+		},
+		{
+			name:               "RemoveRangeReloadFailed",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/dhcp/ranges/192.168.1.100",
+			expectedStatusCode: http.StatusBadGateway,
+			expectedResponse:   tests.ReloadFailedJSON(ReloadFailedMessage, ".*exit status 1.*", ValidRangeJSON),
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("RemoveByKey", ValidRange.Key()).Once().Return(&ValidRange, nil, &dnsmasq.ReloadError{Err: errors.New("exit status 1")})
+			},
+		},
+		{
+			name:               "RemoveRangeNoContent",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/dhcp/ranges/10.0.0.10",
+			expectedStatusCode: http.StatusNoContent,
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("RemoveByKey", model.DhcpRangeKey{Start: net.ParseIP("10.0.0.10")}).Once().Return(nil, nil, nil)
+			},
+		},
+		{
+			name:               "RemoveRangeServiceError",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/dhcp/ranges/192.168.1.100",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, ".*"),
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("RemoveByKey", ValidRange.Key()).Once().Return(nil, nil, errors.New("an error"))
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		description := fmt.Sprintf("%s %s %d", test.httpMethod, test.route, test.expectedStatusCode)
+		t.Run(test.name, func(t *testing.T) {
+			app := setupRangeTest(t, test.mockSetup)
+
+			request := httptest.NewRequest(test.httpMethod, test.route, test.requestBody)
+			request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			response, err := app.Test(request)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedStatusCode, response.StatusCode, "%s: returned wrong HTTP status code", description)
+
+			for header, value := range test.expectedHeaders {
+				assert.Equal(t, value, response.Header.Get(header), "%s: unexpected %s header", description, header)
+			}
+
+			responseBody := tests.GetBody(response)
+			if test.expectedResponse == "" {
+				assert.Empty(t, responseBody, "%s: unexpected HTTP response body", description)
+				return
+			}
+			if !tests.JSONMatches(test.expectedResponse, string(responseBody)) {
+				assert.JSONEq(t, test.expectedResponse, string(responseBody), "%s: unexpected HTTP response body", description)
+			}
+		})
+	}
+}
+
+func TestDhcpRangeApiWithAuth(t *testing.T) {
+	const (
+		AuthMethod = config.AuthHS256
+		AuthKey    = "FooBar77"
+	)
+
+	token := func(scopes string) *jwtTokenConfig {
+		return &jwtTokenConfig{
+			SigningKey: AuthKey,
+			Claims: jwt.MapClaims{
+				"name":  "unit-tests",
+				"exp":   time.Now().Add(time.Minute * 30).Unix(),
+				"scope": scopes,
+			},
+		}
+	}
+
+	var testCases = []struct {
+		name               string
+		httpMethod         string
+		route              string
+		token              *jwtTokenConfig
+		requestBody        io.Reader
+		expectedStatusCode int
+		expectedResponse   string
+		mockSetup          func(s *dhcpmock.ServiceMock)
+	}{
+		{
+			name:               "GetAllNoAuthentication",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dhcp/ranges",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
+			mockSetup:          voidRangeMock,
+		},
+		{
+			name:               "GetAllDnsScope",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dhcp/ranges",
+			token:              token(scope.DnsWrite),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidRangeMock,
+		},
+		{
+			name:               "GetAllAuthorized",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dhcp/ranges",
+			token:              token(scope.DhcpRead),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   AllRangesJSON,
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("FetchAll").Once().Return(&[]model.DhcpRange{ValidRange, GuestRange}, nil)
+			},
+		},
+		{
+			name:               "AddReadOnlyScope",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dhcp/ranges",
+			token:              token(scope.DhcpRead),
+			requestBody:        strings.NewReader(ValidRangeJSON),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidRangeMock,
+		},
+		{
+			name:               "AddAuthorized",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dhcp/ranges",
+			token:              token(scope.DhcpWrite),
+			requestBody:        strings.NewReader(ValidRangeJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidRangeJSON,
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("Insert", &ValidRange).Once().Return(nil)
+			},
+		},
+		{
+			name:               "RemoveWriteScope",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/dhcp/ranges/192.168.1.100",
+			token:              token(scope.DhcpWrite),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidRangeMock,
+		},
+		{
+			name:               "RemoveAuthorized",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/dhcp/ranges/192.168.1.100",
+			token:              token(scope.DhcpAdmin),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidRangeJSON,
+			mockSetup: func(mock *dhcpmock.ServiceMock) {
+				mock.On("RemoveByKey", ValidRange.Key()).Once().Return(&ValidRange, nil, nil)
+			},
+		},
+	}
+
+	os.Setenv("DMM_AUTH_METHOD", AuthMethod)
+	os.Setenv("DMM_AUTH_KEY", AuthKey)
+
+	for _, test := range testCases {
+		description := fmt.Sprintf("%s %s %d", test.httpMethod, test.route, test.expectedStatusCode)
+
+		t.Run(test.name, func(t *testing.T) {
+			app := setupRangeTest(t, test.mockSetup)
+
+			request := httptest.NewRequest(test.httpMethod, test.route, test.requestBody)
+			request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			if test.token != nil {
+				jwtToken := createJwtToken(t, test.token)
+				request.Header.Set(fiber.HeaderAuthorization, "Bearer "+jwtToken)
+			}
+
+			response, err := app.Test(request)
+			require.NoError(t, err, "app.Test() request failed")
+
+			assert.Equal(t, test.expectedStatusCode, response.StatusCode, "%s: returned wrong HTTP status code", description)
+
+			responseBody := tests.GetBody(response)
+			if !tests.JSONMatches(test.expectedResponse, string(responseBody)) {
+				assert.JSONEq(t, test.expectedResponse, string(responseBody), "%s: unexpected HTTP response body", description)
+			}
+		})
+	}
+
+	os.Unsetenv("DMM_AUTH_METHOD")
+	os.Unsetenv("DMM_AUTH_KEY")
+}
@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api"
+	"github.com/gringolito/dnsmasq-manager/api/dto"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/api/scope"
+	"github.com/gringolito/dnsmasq-manager/api/validation"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/record"
+	"log/slog"
+)
+
+// Error messages
+const (
+	RecordNotFoundMessage   = "No record found for the given key."
+	DuplicatedRecordMessage = "The record already exists."
+)
+
+// Details
+const (
+	NoMatchingRecord = "The DNS server could not find the %s. Please check the request and try again."
+	RecordInUse      = "There is already the %s. Please try again with a different record, or change that one " +
+		"instead."
+	InvalidRecord          = "The record can't be written as a dnsmasq entry: %s."
+	RecordCouldNotBeParsed = "The request could not be processed because the record could not be parsed. " +
+		"Please check the request and try again."
+)
+
+// recordBody is the request body of a record type, see dto.SrvRecord, dto.MxRecord, dto.TxtRecord and
+// dto.PtrRecord.
+type recordBody interface {
+	ToModel() *model.ResourceRecord
+}
+
+// recordResource describes the API resource of a record type.
+type recordResource struct {
+	recordType string
+	// Path of a single record, made of the fields of its key (see model.ResourceRecordKey). The SRV and MX records
+	// without target are identified by their name only
+	path string
+	body func() recordBody
+}
+
+var recordResources = []recordResource{
+	{
+		recordType: model.ResourceRecordTypeSrv,
+		path:       "/:name/:target?/:port?",
+		body:       func() recordBody { return new(dto.SrvRecord) },
+	},
+	{
+		recordType: model.ResourceRecordTypeMx,
+		path:       "/:name/:target?",
+		body:       func() recordBody { return new(dto.MxRecord) },
+	},
+	{
+		recordType: model.ResourceRecordTypeTxt,
+		path:       "/:name",
+		body:       func() recordBody { return new(dto.TxtRecord) },
+	},
+	{
+		recordType: model.ResourceRecordTypePtr,
+		path:       "/:name",
+		body:       func() recordBody { return new(dto.PtrRecord) },
+	},
+}
+
+// describeRecord tells which record a key identifies, e.g. "SRV record of _ldap._tcp.lan on ldap.lan:389".
+func describeRecord(key model.ResourceRecordKey) string {
+	description := fmt.Sprintf("%s record of %s", strings.ToUpper(key.Type), key.Name)
+	switch {
+	case key.Target == "" && (key.Type == model.ResourceRecordTypeSrv || key.Type == model.ResourceRecordTypeMx):
+		description += " without target"
+	case key.Type == model.ResourceRecordTypeSrv:
+		description += fmt.Sprintf(" on %s:%d", key.Target, key.Port)
+	case key.Type == model.ResourceRecordTypeMx:
+		description += fmt.Sprintf(" on %s", key.Target)
+	}
+	return description
+}
+
+// recordServiceErrorResponse answers a request whose records service call failed, see serviceErrorResponse.
+func recordServiceErrorResponse(c *fiber.Ctx, err error) error {
+	message, details := recordErrorDetails(err)
+	return presenter.ServiceErrorResponse(c, err, message, details)
+}
+
+// recordChangeErrorResponse answers a request whose change of a record failed, see presenter.ChangeErrorResponse.
+func recordChangeErrorResponse(c *fiber.Ctx, err error, r *model.ResourceRecord) error {
+	message, details := recordErrorDetails(err)
+	return presenter.ChangeErrorResponse(c, err, message, details, dto.NewResourceRecord(r))
+}
+
+// recordErrorDetails returns the message and the details telling the client what went wrong with its request.
+func recordErrorDetails(err error) (string, interface{}) {
+	if message, details, ok := fileErrorDetails("records", err); ok {
+		return message, details
+	}
+
+	var notFoundErr *record.NotFoundError
+	var duplicatedErr *record.DuplicatedEntryError
+
+	switch {
+	case errors.As(err, &notFoundErr):
+		return RecordNotFoundMessage, fmt.Sprintf(NoMatchingRecord, describeRecord(notFoundErr.Key))
+
+	case errors.As(err, &duplicatedErr):
+		slog.Debug("Could not change the records because a conflict was detected",
+			slog.String("error", err.Error()),
+		)
+		return DuplicatedRecordMessage, fmt.Sprintf(RecordInUse, describeRecord(duplicatedErr.Record.Key()))
+
+	case errors.Is(err, errkind.ErrValidation):
+		return InvalidRequestBodyMessage, fmt.Sprintf(InvalidRecord, strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
+
+	// Internal server errors only tell the request ID
+	return "", nil
+}
+
+// getRecordKey returns the key of the record in the path, answering not found when the port is not a number.
+func getRecordKey(c *fiber.Ctx, resource recordResource) *model.ResourceRecordKey {
+	key := &model.ResourceRecordKey{
+		Type:   resource.recordType,
+		Name:   c.Params("name"),
+		Target: c.Params("target"),
+	}
+	if port := c.Params("port"); port != "" {
+		var err error
+		key.Port, err = strconv.Atoi(port)
+		if err != nil {
+			description := fmt.Sprintf("SRV record of %s on %s:%s", key.Name, key.Target, port)
+			presenter.NotFoundResponse(c, RecordNotFoundMessage, fmt.Sprintf(NoMatchingRecord, description))
+			return nil
+		}
+	}
+
+	return key
+}
+
+func getRecordFromBody(c *fiber.Ctx, resource recordResource) *model.ResourceRecord {
+	body := resource.body()
+	if err := c.BodyParser(body); err != nil {
+		slog.Debug("Failed to parse record from the body",
+			slog.String("type", resource.recordType),
+			slog.String("error", err.Error()),
+		)
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, RecordCouldNotBeParsed)
+		return nil
+	}
+
+	if errors := validation.Validate(body); errors != nil {
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, errors)
+		return nil
+	}
+
+	return body.ToModel()
+}
+
+func GetAllRecords(service record.Service, resource recordResource) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		records, err := service.FetchAll(resource.recordType)
+		if err != nil {
+			return recordServiceErrorResponse(c, err)
+		}
+
+		response := make([]interface{}, 0, len(*records))
+		for _, r := range *records {
+			response = append(response, dto.NewResourceRecord(&r))
+		}
+
+		return c.Status(http.StatusOK).JSON(response)
+	}
+}
+
+// GetRecord returns the record with the key in the path, regardless of the case.
+func GetRecord(service record.Service, resource recordResource) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := getRecordKey(c, resource)
+		if key == nil {
+			// The errors was already handled by the getRecordKey()
+			return nil
+		}
+
+		r, err := service.FetchByKey(*key)
+		if err != nil {
+			return recordServiceErrorResponse(c, err)
+		}
+		if r == nil {
+			return presenter.NotFoundResponse(c, RecordNotFoundMessage, fmt.Sprintf(NoMatchingRecord, describeRecord(*key)))
+		}
+
+		return c.Status(http.StatusOK).JSON(dto.NewResourceRecord(r))
+	}
+}
+
+func AddRecord(service record.Service, resource recordResource) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		r := getRecordFromBody(c, resource)
+		if r == nil {
+			// The errors was already handled by the getRecordFromBody()
+			return nil
+		}
+
+		err := service.Insert(r)
+		if err != nil {
+			return recordChangeErrorResponse(c, err, r)
+		}
+
+		return c.Status(http.StatusCreated).JSON(dto.NewResourceRecord(r))
+	}
+}
+
+// UpdateRecord replaces the record with the key in the path, whose key may be changed by the body.
+func UpdateRecord(service record.Service, resource recordResource) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := getRecordKey(c, resource)
+		if key == nil {
+			// The errors was already handled by the getRecordKey()
+			return nil
+		}
+		r := getRecordFromBody(c, resource)
+		if r == nil {
+			// The errors was already handled by the getRecordFromBody()
+			return nil
+		}
+
+		err := service.Update(*key, r)
+		if err != nil {
+			return recordChangeErrorResponse(c, err, r)
+		}
+
+		return c.Status(http.StatusOK).JSON(dto.NewResourceRecord(r))
+	}
+}
+
+func RemoveRecord(service record.Service, resource recordResource) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := getRecordKey(c, resource)
+		if key == nil {
+			// The errors was already handled by the getRecordKey()
+			return nil
+		}
+
+		r, err := service.RemoveByKey(*key)
+		if err != nil && r != nil {
+			return recordChangeErrorResponse(c, err, r)
+		}
+		if err != nil {
+			return recordServiceErrorResponse(c, err)
+		}
+		if r == nil {
+			return c.SendStatus(http.StatusNoContent)
+		}
+
+		return c.Status(http.StatusOK).JSON(dto.NewResourceRecord(r))
+	}
+}
+
+// RouteStaticRecords routes an API per record type (e.g. /static/srv), which share the scopes of the static DNS
+// records.
+func RouteStaticRecords(router api.Router, service record.Service) {
+	for _, resource := range recordResources {
+		router.AddApiV1Route("/static/"+resource.recordType, func(r fiber.Router) {
+			r.Get("", router.AuthenticationHandler(scope.DnsCanRead...), GetAllRecords(service, resource)).Name("get_all")
+			r.Get(resource.path, router.AuthenticationHandler(scope.DnsCanRead...), GetRecord(service, resource)).Name("get")
+			r.Post("", router.AuthenticationHandler(scope.DnsCanWrite...), AddRecord(service, resource)).Name("add")
+			r.Put(resource.path, router.AuthenticationHandler(scope.DnsCanWrite...), UpdateRecord(service, resource)).Name("update")
+			r.Delete(resource.path, router.AuthenticationHandler(scope.DnsCanWrite...), RemoveRecord(service, resource)).Name("remove")
+		}, "static."+resource.recordType+".")
+	}
+}
@@ -0,0 +1,579 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gringolito/dnsmasq-manager/api"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/api/scope"
+	"github.com/gringolito/dnsmasq-manager/config"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/record"
+	recordmock "github.com/gringolito/dnsmasq-manager/pkg/record/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"github.com/gringolito/dnsmasq-manager/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	ValidSrvRecordJSON       = `{"Name":"_ldap._tcp.lan", "Target":"ldap.lan", "Port":389, "Priority":10, "Weight":50}`
+	MovedSrvRecordJSON       = `{"Name":"_ldap._tcp.lan", "Target":"ldap.lan", "Port":636, "Priority":10, "Weight":50}`
+	InvalidPortSrvRecordJSON = `{"Name":"_ldap._tcp.lan", "Target":"ldap.lan", "Port":70000}`
+	InvalidNameSrvRecordJSON = `{"Name":"ldap.lan", "Target":"ldap.lan", "Port":389}`
+	ValidMxRecordJSON        = `{"Name":"example.lan", "Target":"mail.example.lan", "Preference":10}`
+	ValidTxtRecordJSON       = `{"Name":"example.lan", "Text":["v=spf1 a -all", "hello, \"world\""]}`
+	NoTextTxtRecordJSON      = `{"Name":"example.lan", "Text":[]}`
+	ValidPtrRecordJSON       = `{"Name":"10.1.168.192.in-addr.arpa", "Target":"nas.lan"}`
+	AllSrvRecordsJSON        = `[` + ValidSrvRecordJSON + `]`
+	UnavailableSrvRecordJSON = `{"Name":"_ldap._tcp.lan", "Target":"", "Port":0, "Priority":0, "Weight":0}`
+	DefaultMxRecordJSON      = `{"Name":"example.lan", "Target":"", "Preference":1}`
+	NoPortSrvRecordJSON      = `{"Name":"_ldap._tcp.lan", "Target":"ldap.lan"}`
+)
+
+var ValidSrvRecord = model.ResourceRecord{Type: model.ResourceRecordTypeSrv, Name: "_ldap._tcp.lan", Target: "ldap.lan", Port: 389, Priority: 10, Weight: 50}
+var MovedSrvRecord = model.ResourceRecord{Type: model.ResourceRecordTypeSrv, Name: "_ldap._tcp.lan", Target: "ldap.lan", Port: 636, Priority: 10, Weight: 50}
+var InvalidNameSrvRecord = model.ResourceRecord{Type: model.ResourceRecordTypeSrv, Name: "ldap.lan", Target: "ldap.lan", Port: 389}
+var UnavailableSrvRecord = model.ResourceRecord{Type: model.ResourceRecordTypeSrv, Name: "_ldap._tcp.lan"}
+var DefaultMxRecord = model.ResourceRecord{Type: model.ResourceRecordTypeMx, Name: "example.lan", Priority: model.DefaultMxPreference}
+var ValidMxRecord = model.ResourceRecord{Type: model.ResourceRecordTypeMx, Name: "example.lan", Target: "mail.example.lan", Priority: 10}
+var ValidTxtRecord = model.ResourceRecord{Type: model.ResourceRecordTypeTxt, Name: "example.lan", Text: []string{"v=spf1 a -all", `hello, "world"`}}
+var ValidPtrRecord = model.ResourceRecord{Type: model.ResourceRecordTypePtr, Name: "10.1.168.192.in-addr.arpa", Target: "nas.lan"}
+
+var voidRecordMock = func(mock *recordmock.ServiceMock) {}
+
+func setupRecordTest(t *testing.T, mockSetup func(mock *recordmock.ServiceMock)) *fiber.App {
+	app := setupApp()
+	config := setupConfig(t)
+	serviceMock := &recordmock.ServiceMock{}
+	router := setupRouter(app, config)
+	RouteStaticRecords(router, serviceMock)
+	mockSetup(serviceMock)
+	return app
+}
+
+func TestStaticRecordApi(t *testing.T) {
+	var testCases = []struct {
+		name               string
+		httpMethod         string
+		route              string
+		requestBody        io.Reader
+		expectedStatusCode int
+		expectedResponse   string
+		expectedHeaders    map[string]string
+		mockSetup          func(s *recordmock.ServiceMock)
+	}{
+		{
+			name:               "GetAllSrvRecordsSuccess",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/srv",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   AllSrvRecordsJSON,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("FetchAll", model.ResourceRecordTypeSrv).Once().Return(&[]model.ResourceRecord{ValidSrvRecord}, nil)
+			},
+		},
+		{
+			name:               "GetAllPtrRecordsEmpty",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/ptr",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `[]`,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("FetchAll", model.ResourceRecordTypePtr).Once().Return(&[]model.ResourceRecord{}, nil)
+			},
+		},
+		{
+			name:               "GetAllTxtRecordsStorageUnavailable",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/txt",
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse:   tests.ErrorJSON(http.StatusServiceUnavailable, presenter.StorageUnavailableCode, fmt.Sprintf(StorageUnavailableMessage, "records"), ".*disk failure.*"),
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("FetchAll", model.ResourceRecordTypeTxt).Once().Return(nil, &errkind.StorageError{Err: errors.New("disk failure")})
+			},
+		},
+		{
+			name:               "GetSrvRecordSuccess",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/srv/_ldap._tcp.lan/ldap.lan/389",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidSrvRecordJSON,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("FetchByKey", ValidSrvRecord.Key()).Once().Return(&ValidSrvRecord, nil)
+			},
+		},
+		{
+			name:               "GetSrvRecordInvalidPort",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/srv/_ldap._tcp.lan/ldap.lan/ldap",
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, RecordNotFoundMessage, fmt.Sprintf(NoMatchingRecord, "SRV record of _ldap._tcp.lan on ldap.lan:ldap")),
+			mockSetup:          voidRecordMock,
+		},
+		{
+			name:               "GetMxRecordSuccess",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/mx/example.lan/mail.example.lan",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidMxRecordJSON,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("FetchByKey", ValidMxRecord.Key()).Once().Return(&ValidMxRecord, nil)
+			},
+		},
+		{
+			name:               "GetSrvRecordWithoutTarget",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/srv/_ldap._tcp.lan",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   UnavailableSrvRecordJSON,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("FetchByKey", UnavailableSrvRecord.Key()).Once().Return(&UnavailableSrvRecord, nil)
+			},
+		},
+		{
+			name:               "GetMxRecordWithoutTargetNotFound",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/mx/example.lan",
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, RecordNotFoundMessage, fmt.Sprintf(NoMatchingRecord, "MX record of example.lan without target")),
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("FetchByKey", DefaultMxRecord.Key()).Once().Return(nil, nil)
+			},
+		},
+		{
+			name:               "GetTxtRecordNotFound",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/txt/foo.lan",
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, RecordNotFoundMessage, fmt.Sprintf(NoMatchingRecord, "TXT record of foo.lan")),
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("FetchByKey", model.ResourceRecordKey{Type: model.ResourceRecordTypeTxt, Name: "foo.lan"}).Once().Return(nil, nil)
+			},
+		},
+		{
+			name:               "AddSrvRecordSuccess",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/srv",
+			requestBody:        strings.NewReader(ValidSrvRecordJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidSrvRecordJSON,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("Insert", &ValidSrvRecord).Once().Return(nil)
+			},
+		},
+		{
+			name:               "AddMxRecordSuccess",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/mx",
+			requestBody:        strings.NewReader(ValidMxRecordJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidMxRecordJSON,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("Insert", &ValidMxRecord).Once().Return(nil)
+			},
+		},
+		{
+			name:               "AddSrvRecordWithoutTargetSuccess",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/srv",
+			requestBody:        strings.NewReader(`{"Name":"_ldap._tcp.lan"}`),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   UnavailableSrvRecordJSON,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("Insert", &UnavailableSrvRecord).Once().Return(nil)
+			},
+		},
+		{
+			name:               "AddMxRecordWithoutTargetSuccess",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/mx",
+			requestBody:        strings.NewReader(`{"Name":"example.lan"}`),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   DefaultMxRecordJSON,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("Insert", &DefaultMxRecord).Once().Return(nil)
+			},
+		},
+		{
+			name:               "AddTxtRecordSuccess",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/txt",
+			requestBody:        strings.NewReader(ValidTxtRecordJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   `{"Name":"example.lan", "Text":["v=spf1 a -all", "hello, .world."]}`,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("Insert", &ValidTxtRecord).Once().Return(nil)
+			},
+		},
+		{
+			name:               "AddPtrRecordSuccess",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/ptr",
+			requestBody:        strings.NewReader(ValidPtrRecordJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidPtrRecordJSON,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("Insert", &ValidPtrRecord).Once().Return(nil)
+			},
+		},
+		{
+			name:               "AddRecordInvalidJSON",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/ptr",
+			requestBody:        strings.NewReader(InvalidJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, RecordCouldNotBeParsed),
+			mockSetup:          voidRecordMock,
+		},
+		{
+			name:               "AddSrvRecordInvalidPort",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/srv",
+			requestBody:        strings.NewReader(InvalidPortSrvRecordJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse: fmt.Sprintf(`{
+				"error": "%s",
+				"code": "validation_failed",
+				"message": "%s",
+				"details": [{
+					"field": "Port",
+					"reason": "The Port field must be less than or equal to 65535.",
+					"value": 70000
+				}]
+			}`, http.StatusText(http.StatusUnprocessableEntity), InvalidRequestBodyMessage),
+			mockSetup: voidRecordMock,
+		},
+		{
+			name:               "AddSrvRecordMissingPort",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/srv",
+			requestBody:        strings.NewReader(NoPortSrvRecordJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse: fmt.Sprintf(`{
+				"error": "%s",
+				"code": "validation_failed",
+				"message": "%s",
+				"details": [{
+					"field": "Port",
+					"reason": "The Port field is required when Target is present.",
+					"value": 0
+				}]
+			}`, http.StatusText(http.StatusUnprocessableEntity), InvalidRequestBodyMessage),
+			mockSetup: voidRecordMock,
+		},
+		{
+			name:               "AddSrvRecordInvalidName",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/srv",
+			requestBody:        strings.NewReader(InvalidNameSrvRecordJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, ".*_service._protocol.*"),
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("Insert", &InvalidNameSrvRecord).Once().Return(&errkind.ValidationError{Err: model.ErrResourceRecordInvalidSrvName})
+			},
+		},
+		{
+			name:               "AddTxtRecordMissingText",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/txt",
+			requestBody:        strings.NewReader(NoTextTxtRecordJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse: fmt.Sprintf(`{
+				"error": "%s",
+				"code": "validation_failed",
+				"message": "%s",
+				"details": [{
+					"field": "Text",
+					"reason": "The Text field must have at least 1 elements.",
+					"value": []
+				}]
+			}`, http.StatusText(http.StatusUnprocessableEntity), InvalidRequestBodyMessage),
+			mockSetup: voidRecordMock,
+		},
+		{
+			name:               "AddSrvRecordDuplicated",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/srv",
+			requestBody:        strings.NewReader(ValidSrvRecordJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedRecordMessage, fmt.Sprintf(RecordInUse, "SRV record of _ldap._tcp.lan on ldap.lan:389")),
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("Insert", &ValidSrvRecord).Once().Return(&record.DuplicatedEntryError{Record: &ValidSrvRecord})
+			},
+		},
+		{
+			name:               "AddMxRecordRejectedConfig",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/mx",
+			requestBody:        strings.NewReader(ValidMxRecordJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse: fmt.Sprintf(`{
+				"error": "%s",
+				"code": "validation_failed",
+				"message": "%s",
+				"details": {
+					"error": "exit status 1",
+					"output": "dnsmasq: bad option at line 1 of candidate"
+				}
+			}`, http.StatusText(http.StatusUnprocessableEntity), RejectedConfigMessage),
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("Insert", &ValidMxRecord).Once().Return(&errkind.ValidationError{Err: &storage.ValidationError{Err: errors.New("exit status 1"), Output: "dnsmasq: bad option at line 1 of candidate"}})
+			},
+		},
+		{
+			name:               "AddPtrRecordReloadFailed",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/ptr",
+			requestBody:        strings.NewReader(ValidPtrRecordJSON),
+			expectedStatusCode: http.StatusBadGateway,
+			expectedResponse:   tests.ReloadFailedJSON(ReloadFailedMessage, ".*exit status 1.*", ValidPtrRecordJSON),
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("Insert", &ValidPtrRecord).Once().Return(&dnsmasq.ReloadError{Err: errors.New("exit status 1")})
+			},
+		},
+		{
+			name:               "AddTxtRecordFileLocked",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/txt",
+			requestBody:        strings.NewReader(ValidTxtRecordJSON),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse:   tests.ErrorJSON(http.StatusServiceUnavailable, presenter.LockTimeoutCode, fmt.Sprintf(FileLockedMessage, "records"), fmt.Sprintf(FileLockTimeout, "records")),
+			expectedHeaders:    map[string]string{fiber.HeaderRetryAfter: "1"},
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("Insert", &ValidTxtRecord).Once().Return(&errkind.StorageError{Err: &storage.LockTimeoutError{File: "/etc/dnsmasq.d/07-dns-records.conf", Timeout: time.Second}})
+			},
+		},
+		{
+			name:               "UpdateSrvRecordSuccess",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/srv/_ldap._tcp.lan/ldap.lan/389",
+			requestBody:        strings.NewReader(MovedSrvRecordJSON),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   MovedSrvRecordJSON,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("Update", ValidSrvRecord.Key(), &MovedSrvRecord).Once().Return(nil)
+			},
+		},
+		{
+			name:               "UpdatePtrRecordNotFound",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/ptr/10.1.168.192.in-addr.arpa",
+			requestBody:        strings.NewReader(ValidPtrRecordJSON),
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   tests.ErrorJSON(http.StatusNotFound, presenter.NotFoundCode, RecordNotFoundMessage, fmt.Sprintf(NoMatchingRecord, "PTR record of 10.1.168.192.in-addr.arpa")),
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("Update", ValidPtrRecord.Key(), &ValidPtrRecord).Once().Return(&record.NotFoundError{Key: ValidPtrRecord.Key()})
+			},
+		},
+		{
+			name:               "UpdateRecordInvalidJSON",
+			httpMethod:         http.MethodPut,
+			route:              "/api/v1/static/txt/example.lan",
+			requestBody:        strings.NewReader(InvalidJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, RecordCouldNotBeParsed),
+			mockSetup:          voidRecordMock,
+		},
+		{
+			name:               "RemoveMxRecordSuccess",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/mx/example.lan/mail.example.lan",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   ValidMxRecordJSON,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("RemoveByKey", ValidMxRecord.Key()).Once().Return(&ValidMxRecord, nil)
+			},
+		},
+		{
+			name:               "RemoveMxRecordReloadFailed",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/mx/example.lan/mail.example.lan",
+			expectedStatusCode: http.StatusBadGateway,
+			expectedResponse:   tests.ReloadFailedJSON(ReloadFailedMessage, ".*exit status 1.*", ValidMxRecordJSON),
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("RemoveByKey", ValidMxRecord.Key()).Once().Return(&ValidMxRecord, &dnsmasq.ReloadError{Err: errors.New("exit status 1")})
+			},
+		},
+		{
+			name:               "RemoveTxtRecordNoContent",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/txt/foo.lan",
+			expectedStatusCode: http.StatusNoContent,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("RemoveByKey", model.ResourceRecordKey{Type: model.ResourceRecordTypeTxt, Name: "foo.lan"}).Once().Return(nil, nil)
+			},
+		},
+		{
+			name:               "RemoveRecordServiceError",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/ptr/10.1.168.192.in-addr.arpa",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, ".*"),
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("RemoveByKey", ValidPtrRecord.Key()).Once().Return(nil, errors.New("an error"))
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		description := fmt.Sprintf("%s %s %d", test.httpMethod, test.route, test.expectedStatusCode)
+		t.Run(test.name, func(t *testing.T) {
+			app := setupRecordTest(t, test.mockSetup)
+
+			request := httptest.NewRequest(test.httpMethod, test.route, test.requestBody)
+			request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			response, err := app.Test(request)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedStatusCode, response.StatusCode, "%s: returned wrong HTTP status code", description)
+
+			for header, value := range test.expectedHeaders {
+				assert.Equal(t, value, response.Header.Get(header), "%s: unexpected %s header", description, header)
+			}
+
+			responseBody := tests.GetBody(response)
+			if test.expectedResponse == "" {
+				assert.Empty(t, responseBody, "%s: unexpected HTTP response body", description)
+				return
+			}
+			if !tests.JSONMatches(test.expectedResponse, string(responseBody)) {
+				assert.JSONEq(t, test.expectedResponse, string(responseBody), "%s: unexpected HTTP response body", description)
+			}
+		})
+	}
+}
+
+func TestStaticRecordApiWithAuth(t *testing.T) {
+	const (
+		AuthMethod = config.AuthHS256
+		AuthKey    = "FooBar77"
+	)
+
+	token := func(scopes string) *jwtTokenConfig {
+		return &jwtTokenConfig{
+			SigningKey: AuthKey,
+			Claims: jwt.MapClaims{
+				"name":  "unit-tests",
+				"exp":   time.Now().Add(time.Minute * 30).Unix(),
+				"scope": scopes,
+			},
+		}
+	}
+
+	var testCases = []struct {
+		name               string
+		httpMethod         string
+		route              string
+		token              *jwtTokenConfig
+		requestBody        io.Reader
+		expectedStatusCode int
+		expectedResponse   string
+		mockSetup          func(s *recordmock.ServiceMock)
+	}{
+		{
+			name:               "GetAllNoAuthentication",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/srv",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
+			mockSetup:          voidRecordMock,
+		},
+		{
+			name:               "GetAllDhcpScope",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/srv",
+			token:              token(scope.DhcpWrite),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidRecordMock,
+		},
+		{
+			name:               "GetAllAuthorized",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/static/srv",
+			token:              token(scope.DnsRead),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   AllSrvRecordsJSON,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("FetchAll", model.ResourceRecordTypeSrv).Once().Return(&[]model.ResourceRecord{ValidSrvRecord}, nil)
+			},
+		},
+		{
+			name:               "AddReadOnlyScope",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/mx",
+			token:              token(scope.DnsRead),
+			requestBody:        strings.NewReader(ValidMxRecordJSON),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidRecordMock,
+		},
+		{
+			name:               "AddAuthorized",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/static/mx",
+			token:              token(scope.DnsWrite),
+			requestBody:        strings.NewReader(ValidMxRecordJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidMxRecordJSON,
+			mockSetup: func(mock *recordmock.ServiceMock) {
+				mock.On("Insert", &ValidMxRecord).Once().Return(nil)
+			},
+		},
+		{
+			name:               "RemoveReadOnlyScope",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/static/txt/example.lan",
+			token:              token(scope.DnsRead),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidRecordMock,
+		},
+	}
+
+	os.Setenv("DMM_AUTH_METHOD", AuthMethod)
+	os.Setenv("DMM_AUTH_KEY", AuthKey)
+
+	for _, test := range testCases {
+		description := fmt.Sprintf("%s %s %d", test.httpMethod, test.route, test.expectedStatusCode)
+
+		t.Run(test.name, func(t *testing.T) {
+			app := setupRecordTest(t, test.mockSetup)
+
+			request := httptest.NewRequest(test.httpMethod, test.route, test.requestBody)
+			request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			if test.token != nil {
+				jwtToken := createJwtToken(t, test.token)
+				request.Header.Set(fiber.HeaderAuthorization, "Bearer "+jwtToken)
+			}
+
+			response, err := app.Test(request)
+			require.NoError(t, err, "app.Test() request failed")
+
+			assert.Equal(t, test.expectedStatusCode, response.StatusCode, "%s: returned wrong HTTP status code", description)
+
+			responseBody := tests.GetBody(response)
+			if !tests.JSONMatches(test.expectedResponse, string(responseBody)) {
+				assert.JSONEq(t, test.expectedResponse, string(responseBody), "%s: unexpected HTTP response body", description)
+			}
+		})
+	}
+
+	os.Unsetenv("DMM_AUTH_METHOD")
+	os.Unsetenv("DMM_AUTH_KEY")
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api"
+	"github.com/gringolito/dnsmasq-manager/api/dto"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/api/scope"
+	"github.com/gringolito/dnsmasq-manager/api/validation"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/server"
+	"log/slog"
+)
+
+// Error messages
+const (
+	DuplicatedServerMessage = "The DNS server already exists."
+)
+
+// Details
+const (
+	ServerInUse                 = "There is already the same DNS server entry: %s. Please remove it first if it must be replaced."
+	DomainKeptLocalAndForwarded = "A domain can't be both kept local and forwarded, and the DNS server entry %s does " +
+		"the opposite for it. Please remove that entry first. The domain was: %s."
+	InvalidServer          = "The DNS server can't be written as a dnsmasq entry: %s."
+	ServerCouldNotBeParsed = "The request could not be processed because the DNS server could not be parsed. " +
+		"Please check the request and try again."
+	MissingServerQueryParameter = "The request did not specify either the `domain` or `address` query parameter. " +
+		"Please specify at least one of these parameters in order to proceed."
+)
+
+// serverServiceErrorResponse answers a request whose DNS servers service call failed, see serviceErrorResponse.
+func serverServiceErrorResponse(c *fiber.Ctx, err error) error {
+	message, details := serverErrorDetails(err)
+	return presenter.ServiceErrorResponse(c, err, message, details)
+}
+
+// serverChangeErrorResponse answers a request whose change of a DNS server failed, see presenter.ChangeErrorResponse.
+func serverChangeErrorResponse(c *fiber.Ctx, err error, s *model.DnsServer) error {
+	message, details := serverErrorDetails(err)
+	return presenter.ChangeErrorResponse(c, err, message, details, dto.NewDnsServer(s))
+}
+
+// serversRemovalErrorResponse answers a request whose removal of DNS servers failed, see
+// presenter.ChangeErrorResponse.
+func serversRemovalErrorResponse(c *fiber.Ctx, err error, removed *[]model.DnsServer) error {
+	message, details := serverErrorDetails(err)
+	return presenter.ChangeErrorResponse(c, err, message, details, toDnsServersDto(removed))
+}
+
+// serverErrorDetails returns the message and the details telling the client what went wrong with its request.
+func serverErrorDetails(err error) (string, interface{}) {
+	if message, details, ok := fileErrorDetails("DNS servers", err); ok {
+		return message, details
+	}
+
+	var duplicatedErr *server.DuplicatedEntryError
+
+	switch {
+	case errors.As(err, &duplicatedErr):
+		slog.Debug("Could not change the DNS servers because a conflict was detected",
+			slog.String("error", err.Error()),
+		)
+		entry, _ := duplicatedErr.Server.ToConfig()
+		if duplicatedErr.Domain != "" {
+			return DuplicatedServerMessage, fmt.Sprintf(DomainKeptLocalAndForwarded, entry, duplicatedErr.Domain)
+		}
+		return DuplicatedServerMessage, fmt.Sprintf(ServerInUse, entry)
+
+	case errors.Is(err, errkind.ErrValidation):
+		return InvalidRequestBodyMessage, fmt.Sprintf(InvalidServer, strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
+
+	// Internal server errors only tell the request ID
+	return "", nil
+}
+
+func getServerFromBody(c *fiber.Ctx) *model.DnsServer {
+	dnsServer := new(dto.DnsServer)
+	if err := c.BodyParser(dnsServer); err != nil {
+		slog.Debug("Failed to parse DNS server from the body",
+			slog.String("error", err.Error()),
+		)
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, ServerCouldNotBeParsed)
+		return nil
+	}
+
+	if errors := validation.Validate(dnsServer); errors != nil {
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, errors)
+		return nil
+	}
+
+	return dnsServer.ToModel()
+}
+
+func toDnsServersDto(servers *[]model.DnsServer) []dto.DnsServer {
+	response := make([]dto.DnsServer, 0, len(*servers))
+	for _, s := range *servers {
+		response = append(response, *dto.NewDnsServer(&s))
+	}
+	return response
+}
+
+// GetAllServers lists the DNS servers, only the ones forwarding the domain of the `domain` query parameter when
+// it is given.
+func GetAllServers(service server.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		servers, err := service.FetchAll(c.Query("domain"))
+		if err != nil {
+			return serverServiceErrorResponse(c, err)
+		}
+
+		return c.Status(http.StatusOK).JSON(toDnsServersDto(servers))
+	}
+}
+
+func AddServer(service server.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		s := getServerFromBody(c)
+		if s == nil {
+			// The errors was already handled by the getServerFromBody()
+			return nil
+		}
+
+		err := service.Insert(s)
+		if err != nil {
+			return serverChangeErrorResponse(c, err, s)
+		}
+
+		return c.Status(http.StatusCreated).JSON(dto.NewDnsServer(s))
+	}
+}
+
+// RemoveServers stops using the servers matching the `domain` and `address` query parameters, at least one of
+// them is required. A server shared with other domains is kept for them.
+func RemoveServers(service server.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		domain := c.Query("domain")
+		address := c.Query("address")
+		if domain == "" && address == "" {
+			return presenter.BadRequestResponse(c, InvalidRequestMessage, MissingServerQueryParameter)
+		}
+
+		removed, err := service.Remove(domain, address)
+		if err != nil && removed != nil {
+			return serversRemovalErrorResponse(c, err, removed)
+		}
+		if err != nil {
+			return serverServiceErrorResponse(c, err)
+		}
+		if removed == nil {
+			return c.SendStatus(http.StatusNoContent)
+		}
+
+		return c.Status(http.StatusOK).JSON(toDnsServersDto(removed))
+	}
+}
+
+// RouteDnsServers routes the DNS servers API, which shares the scopes of the static DNS records.
+func RouteDnsServers(router api.Router, service server.Service) {
+	router.AddApiV1Route("/dns/servers", func(r fiber.Router) {
+		r.Get("", router.AuthenticationHandler(scope.DnsCanRead...), GetAllServers(service)).Name("get_all")
+		r.Post("", router.AuthenticationHandler(scope.DnsCanWrite...), AddServer(service)).Name("add")
+		r.Delete("", router.AuthenticationHandler(scope.DnsCanWrite...), RemoveServers(service)).Name("remove")
+	}, "dns.servers.")
+}
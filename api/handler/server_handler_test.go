@@ -0,0 +1,422 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gringolito/dnsmasq-manager/api"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/api/scope"
+	"github.com/gringolito/dnsmasq-manager/config"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/server"
+	servermock "github.com/gringolito/dnsmasq-manager/pkg/server/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"github.com/gringolito/dnsmasq-manager/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	ValidUpstreamJSON         = `{"Domains":[], "Address":"1.1.1.1", "Scope":"upstream"}`
+	ValidForwarderJSON        = `{"Domains":["corp.example"], "Address":"10.0.0.53", "Port":5353, "Scope":"domain"}`
+	ValidReverseForwarderJSON = `{"Domains":["3.168.192.in-addr.arpa"], "Address":"10.0.0.53", "Scope":"reverse"}`
+	ValidLocalDomainJSON      = `{"Domains":["lan"], "Address":"", "Scope":"domain"}`
+	InvalidAddressServerJSON  = `{"Domains":["corp.example"], "Address":"dns.corp.example"}`
+	InvalidZoneServerJSON     = `{"Domains":["300.168.192.in-addr.arpa"], "Address":"10.0.0.53"}`
+)
+
+var ValidUpstream = model.DnsServer{Address: "1.1.1.1"}
+var ValidForwarder = model.DnsServer{Domains: []string{"corp.example"}, Address: "10.0.0.53", Port: 5353}
+var ValidReverseForwarder = model.DnsServer{Domains: []string{"3.168.192.in-addr.arpa"}, Address: "10.0.0.53"}
+var ValidLocalDomain = model.DnsServer{Domains: []string{"lan"}}
+var InvalidZoneServer = model.DnsServer{Domains: []string{"300.168.192.in-addr.arpa"}, Address: "10.0.0.53"}
+
+var voidServerMock = func(mock *servermock.ServiceMock) {}
+
+func setupServerTest(t *testing.T, mockSetup func(mock *servermock.ServiceMock)) *fiber.App {
+	app := setupApp()
+	config := setupConfig(t)
+	serviceMock := &servermock.ServiceMock{}
+	router := setupRouter(app, config)
+	RouteDnsServers(router, serviceMock)
+	mockSetup(serviceMock)
+	return app
+}
+
+func TestDnsServerApi(t *testing.T) {
+	var testCases = []struct {
+		name               string
+		httpMethod         string
+		route              string
+		requestBody        io.Reader
+		expectedStatusCode int
+		expectedResponse   string
+		expectedHeaders    map[string]string
+		mockSetup          func(s *servermock.ServiceMock)
+	}{
+		{
+			name:               "GetAllSuccess",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dns/servers",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `[` + ValidUpstreamJSON + `,` + ValidForwarderJSON + `,` + ValidReverseForwarderJSON + `,` + ValidLocalDomainJSON + `]`,
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("FetchAll", "").Once().Return(&[]model.DnsServer{ValidUpstream, ValidForwarder, ValidReverseForwarder, ValidLocalDomain}, nil)
+			},
+		},
+		{
+			name:               "GetAllByDomain",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dns/servers?domain=corp.example",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `[` + ValidForwarderJSON + `]`,
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("FetchAll", "corp.example").Once().Return(&[]model.DnsServer{ValidForwarder}, nil)
+			},
+		},
+		{
+			name:               "GetAllStorageUnavailable",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dns/servers",
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse:   tests.ErrorJSON(http.StatusServiceUnavailable, presenter.StorageUnavailableCode, fmt.Sprintf(StorageUnavailableMessage, "DNS servers"), ".*disk failure.*"),
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("FetchAll", "").Once().Return(nil, &errkind.StorageError{Err: errors.New("disk failure")})
+			},
+		},
+		{
+			name:               "AddUpstreamSuccess",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dns/servers",
+			requestBody:        strings.NewReader(`{"Address":"1.1.1.1"}`),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidUpstreamJSON,
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("Insert", &ValidUpstream).Once().Return(nil)
+			},
+		},
+		{
+			name:               "AddForwarderSuccess",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dns/servers",
+			requestBody:        strings.NewReader(ValidForwarderJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidForwarderJSON,
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("Insert", &ValidForwarder).Once().Return(nil)
+			},
+		},
+		{
+			name:               "AddLocalDomainSuccess",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dns/servers",
+			requestBody:        strings.NewReader(`{"Domains":["lan"]}`),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidLocalDomainJSON,
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("Insert", &ValidLocalDomain).Once().Return(nil)
+			},
+		},
+		{
+			name:               "AddServerInvalidJSON",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dns/servers",
+			requestBody:        strings.NewReader(InvalidJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, ServerCouldNotBeParsed),
+			mockSetup:          voidServerMock,
+		},
+		{
+			name:               "AddServerInvalidAddress",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dns/servers",
+			requestBody:        strings.NewReader(InvalidAddressServerJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ValidationErrorJSON(InvalidRequestBodyMessage, "Address", "The Address field must be empty, # or an IP address.", "dns.corp.example"),
+			mockSetup:          voidServerMock,
+		},
+		{
+			name:               "AddServerInvalidReverseZone",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dns/servers",
+			requestBody:        strings.NewReader(InvalidZoneServerJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnprocessableEntity, presenter.ValidationCode, InvalidRequestBodyMessage, ".*reverse zones.*"),
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("Insert", &InvalidZoneServer).Once().Return(&errkind.ValidationError{Err: model.ErrDnsServerInvalidReverseZone})
+			},
+		},
+		{
+			name:               "AddServerDuplicated",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dns/servers",
+			requestBody:        strings.NewReader(ValidForwarderJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedServerMessage, fmt.Sprintf(ServerInUse, "server=/corp.example/10.0.0.53#5353")),
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("Insert", &ValidForwarder).Once().Return(&server.DuplicatedEntryError{Server: &ValidForwarder})
+			},
+		},
+		{
+			name:               "AddServerForwardingLocalDomain",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dns/servers",
+			requestBody:        strings.NewReader(ValidForwarderJSON),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   tests.ErrorJSON(http.StatusConflict, presenter.DuplicateCode, DuplicatedServerMessage, fmt.Sprintf(DomainKeptLocalAndForwarded, "server=/corp.example/", "corp.example")),
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("Insert", &ValidForwarder).Once().Return(&server.DuplicatedEntryError{Server: &model.DnsServer{Domains: []string{"corp.example"}}, Domain: "corp.example"})
+			},
+		},
+		{
+			name:               "AddServerRejectedConfig",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dns/servers",
+			requestBody:        strings.NewReader(ValidForwarderJSON),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponse: fmt.Sprintf(`{
+				"error": "%s",
+				"code": "validation_failed",
+				"message": "%s",
+				"details": {
+					"error": "exit status 1",
+					"output": "dnsmasq: bad option at line 1 of candidate"
+				}
+			}`, http.StatusText(http.StatusUnprocessableEntity), RejectedConfigMessage),
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("Insert", &ValidForwarder).Once().Return(&errkind.ValidationError{Err: &storage.ValidationError{Err: errors.New("exit status 1"), Output: "dnsmasq: bad option at line 1 of candidate"}})
+			},
+		},
+		{
+			name:               "AddServerReloadFailed",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dns/servers",
+			requestBody:        strings.NewReader(ValidForwarderJSON),
+			expectedStatusCode: http.StatusBadGateway,
+			expectedResponse:   tests.ReloadFailedJSON(ReloadFailedMessage, ".*exit status 1.*", ValidForwarderJSON),
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("Insert", &ValidForwarder).Once().Return(&dnsmasq.ReloadError{Err: errors.New("exit status 1")})
+			},
+		},
+		{
+			name:               "AddServerFileLocked",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dns/servers",
+			requestBody:        strings.NewReader(ValidForwarderJSON),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse:   tests.ErrorJSON(http.StatusServiceUnavailable, presenter.LockTimeoutCode, fmt.Sprintf(FileLockedMessage, "DNS servers"), fmt.Sprintf(FileLockTimeout, "DNS servers")),
+			expectedHeaders:    map[string]string{fiber.HeaderRetryAfter: "1"},
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("Insert", &ValidForwarder).Once().Return(&errkind.StorageError{Err: &storage.LockTimeoutError{File: "/etc/dnsmasq.d/08-dns-servers.conf", Timeout: time.Second}})
+			},
+		},
+		{
+			name:               "RemoveByDomainSuccess",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/dns/servers?domain=corp.example",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `[` + ValidForwarderJSON + `]`,
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("Remove", "corp.example", "").Once().Return(&[]model.DnsServer{ValidForwarder}, nil)
+			},
+		},
+		{
+			name:               "RemoveByDomainReloadFailed",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/dns/servers?domain=corp.example",
+			expectedStatusCode: http.StatusBadGateway,
+			expectedResponse:   tests.ReloadFailedJSON(ReloadFailedMessage, ".*exit status 1.*", `[`+ValidForwarderJSON+`]`),
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("Remove", "corp.example", "").Once().Return(&[]model.DnsServer{ValidForwarder}, &dnsmasq.ReloadError{Err: errors.New("exit status 1")})
+			},
+		},
+		{
+			name:               "RemoveByDomainAndAddressNoContent",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/dns/servers?domain=corp.example&address=10.0.0.54",
+			expectedStatusCode: http.StatusNoContent,
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("Remove", "corp.example", "10.0.0.54").Once().Return(nil, nil)
+			},
+		},
+		{
+			name:               "RemoveMissingQueryParameter",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/dns/servers",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   tests.ErrorJSON(http.StatusBadRequest, presenter.InvalidRequestCode, InvalidRequestMessage, MissingServerQueryParameter),
+			mockSetup:          voidServerMock,
+		},
+		{
+			name:               "RemoveServiceError",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/dns/servers?address=1.1.1.1",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponse:   tests.ErrorJSON(http.StatusInternalServerError, presenter.InternalErrorCode, presenter.ServerErrorMessage, ".*"),
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("Remove", "", "1.1.1.1").Once().Return(nil, errors.New("an error"))
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		description := fmt.Sprintf("%s %s %d", test.httpMethod, test.route, test.expectedStatusCode)
+		t.Run(test.name, func(t *testing.T) {
+			app := setupServerTest(t, test.mockSetup)
+
+			request := httptest.NewRequest(test.httpMethod, test.route, test.requestBody)
+			request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			response, err := app.Test(request)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedStatusCode, response.StatusCode, "%s: returned wrong HTTP status code", description)
+
+			for header, value := range test.expectedHeaders {
+				assert.Equal(t, value, response.Header.Get(header), "%s: unexpected %s header", description, header)
+			}
+
+			responseBody := tests.GetBody(response)
+			if test.expectedResponse == "" {
+				assert.Empty(t, responseBody, "%s: unexpected HTTP response body", description)
+				return
+			}
+			if !tests.JSONMatches(test.expectedResponse, string(responseBody)) {
+				assert.JSONEq(t, test.expectedResponse, string(responseBody), "%s: unexpected HTTP response body", description)
+			}
+		})
+	}
+}
+
+func TestDnsServerApiWithAuth(t *testing.T) {
+	const (
+		AuthMethod = config.AuthHS256
+		AuthKey    = "FooBar77"
+	)
+
+	token := func(scopes string) *jwtTokenConfig {
+		return &jwtTokenConfig{
+			SigningKey: AuthKey,
+			Claims: jwt.MapClaims{
+				"name":  "unit-tests",
+				"exp":   time.Now().Add(time.Minute * 30).Unix(),
+				"scope": scopes,
+			},
+		}
+	}
+
+	var testCases = []struct {
+		name               string
+		httpMethod         string
+		route              string
+		token              *jwtTokenConfig
+		requestBody        io.Reader
+		expectedStatusCode int
+		expectedResponse   string
+		mockSetup          func(s *servermock.ServiceMock)
+	}{
+		{
+			name:               "GetAllNoAuthentication",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dns/servers",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedResponse:   tests.ErrorJSON(http.StatusUnauthorized, presenter.UnauthorizedCode, api.UnauthorizedMessage, api.MissingOrMalformedJWT),
+			mockSetup:          voidServerMock,
+		},
+		{
+			name:               "GetAllDhcpScope",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dns/servers",
+			token:              token(scope.DhcpAdmin),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidServerMock,
+		},
+		{
+			name:               "GetAllAuthorized",
+			httpMethod:         http.MethodGet,
+			route:              "/api/v1/dns/servers",
+			token:              token(scope.DnsRead),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `[` + ValidUpstreamJSON + `]`,
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("FetchAll", "").Once().Return(&[]model.DnsServer{ValidUpstream}, nil)
+			},
+		},
+		{
+			name:               "AddReadOnlyScope",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dns/servers",
+			token:              token(scope.DnsRead),
+			requestBody:        strings.NewReader(ValidForwarderJSON),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidServerMock,
+		},
+		{
+			name:               "AddAuthorized",
+			httpMethod:         http.MethodPost,
+			route:              "/api/v1/dns/servers",
+			token:              token(scope.DnsWrite),
+			requestBody:        strings.NewReader(ValidForwarderJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   ValidForwarderJSON,
+			mockSetup: func(mock *servermock.ServiceMock) {
+				mock.On("Insert", &ValidForwarder).Once().Return(nil)
+			},
+		},
+		{
+			name:               "RemoveReadOnlyScope",
+			httpMethod:         http.MethodDelete,
+			route:              "/api/v1/dns/servers?domain=corp.example",
+			token:              token(scope.DnsRead),
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponse:   tests.ErrorJSON(http.StatusForbidden, presenter.ForbiddenCode, api.NotAuthorizedMessage, ".*"),
+			mockSetup:          voidServerMock,
+		},
+	}
+
+	os.Setenv("DMM_AUTH_METHOD", AuthMethod)
+	os.Setenv("DMM_AUTH_KEY", AuthKey)
+
+	for _, test := range testCases {
+		description := fmt.Sprintf("%s %s %d", test.httpMethod, test.route, test.expectedStatusCode)
+
+		t.Run(test.name, func(t *testing.T) {
+			app := setupServerTest(t, test.mockSetup)
+
+			request := httptest.NewRequest(test.httpMethod, test.route, test.requestBody)
+			request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			if test.token != nil {
+				jwtToken := createJwtToken(t, test.token)
+				request.Header.Set(fiber.HeaderAuthorization, "Bearer "+jwtToken)
+			}
+
+			response, err := app.Test(request)
+			require.NoError(t, err, "app.Test() request failed")
+
+			assert.Equal(t, test.expectedStatusCode, response.StatusCode, "%s: returned wrong HTTP status code", description)
+
+			responseBody := tests.GetBody(response)
+			if !tests.JSONMatches(test.expectedResponse, string(responseBody)) {
+				assert.JSONEq(t, test.expectedResponse, string(responseBody), "%s: unexpected HTTP response body", description)
+			}
+		})
+	}
+
+	os.Unsetenv("DMM_AUTH_METHOD")
+	os.Unsetenv("DMM_AUTH_KEY")
+}
@@ -1,4 +1,4 @@
-package tests
+package handler
 
 import (
 	"testing"
@@ -9,7 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func SetupConfig(t *testing.T) *config.Config {
+func setupConfig(t *testing.T) *config.Config {
 	configName := "unittest"
 	cfg, err := config.Init(configName)
 	require.NoError(t, err)
@@ -17,14 +17,14 @@ func SetupConfig(t *testing.T) *config.Config {
 	return cfg
 }
 
-func SetupApp() *fiber.App {
+func setupApp() *fiber.App {
 	return fiber.New(fiber.Config{
 		CaseSensitive:     true,
 		EnablePrintRoutes: true,
 	})
 }
 
-func SetupRouter(app *fiber.App, cfg *config.Config) api.Router {
+func setupRouter(app *fiber.App, cfg *config.Config) api.Router {
 	middleware, _ := api.NewMiddleware(nil, cfg)
 	return api.NewRouter(app, middleware)
 }
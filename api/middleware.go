@@ -7,8 +7,8 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/gofiber/fiber/v2/utils"
 	"github.com/google/uuid"
-	"github.com/gringolito/fiberslog"
 	"github.com/gringolito/dnsmasq-manager/config"
+	"github.com/gringolito/fiberslog"
 	"log/slog"
 )
 
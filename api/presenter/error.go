@@ -1,29 +1,136 @@
 package presenter
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
 )
 
 type errorMessage struct {
 	Error   string      `json:"error"`
+	Code    string      `json:"code"`
 	Message string      `json:"message"`
 	Details interface{} `json:"details"`
+	// Resource saved by a change whose reload failed
+	Resource interface{} `json:"resource,omitempty"`
 }
 
+// Error codes, machine-readable kinds of the error responses which never change
+const (
+	InvalidRequestCode     = "invalid_request"
+	UnauthorizedCode       = "unauthorized"
+	ForbiddenCode          = "forbidden"
+	NotFoundCode           = "not_found"
+	DuplicateCode          = "duplicate"
+	VersionMismatchCode    = "version_mismatch"
+	ValidationCode         = "validation_failed"
+	InternalErrorCode      = "internal_error"
+	ReloadFailedCode       = "reload_failed"
+	StorageUnavailableCode = "storage_unavailable"
+	LockTimeoutCode        = "lock_timeout"
+)
+
+var statusCodes = map[int]string{
+	http.StatusBadRequest:          InvalidRequestCode,
+	http.StatusUnauthorized:        UnauthorizedCode,
+	http.StatusForbidden:           ForbiddenCode,
+	http.StatusNotFound:            NotFoundCode,
+	http.StatusConflict:            DuplicateCode,
+	http.StatusPreconditionFailed:  VersionMismatchCode,
+	http.StatusUnprocessableEntity: ValidationCode,
+	http.StatusInternalServerError: InternalErrorCode,
+	http.StatusBadGateway:          ReloadFailedCode,
+	http.StatusServiceUnavailable:  StorageUnavailableCode,
+}
+
+// Seconds a client should wait before retrying a request that could not take the storage lock
+const LockRetryAfter = 1
+
 const ServerErrorMessage = "An error occurred on the server."
 const InternalServerError = "An internal server error occurred. Please contact the administrator and provide the following request ID: %s."
 
+// statusCode returns the error code of the responses with the given status, when the kind of the error is
+// not known.
+func statusCode(httpStatus int) string {
+	if code, ok := statusCodes[httpStatus]; ok {
+		return code
+	}
+	return InternalErrorCode
+}
+
 func ErrorResponse(c *fiber.Ctx, httpStatus int, message string, details interface{}) error {
+	return codeErrorResponse(c, httpStatus, statusCode(httpStatus), message, details)
+}
+
+func codeErrorResponse(c *fiber.Ctx, httpStatus int, code string, message string, details interface{}) error {
 	return c.Status(httpStatus).JSON(errorMessage{
 		Error:   http.StatusText(httpStatus),
+		Code:    code,
 		Message: message,
 		Details: details,
 	})
 }
 
+// ErrorKind maps an error returned by a service to the HTTP status and the error code of its kind (see
+// errkind). The errors without any kind are internal server errors.
+func ErrorKind(err error) (int, string) {
+	switch {
+	case errors.Is(err, errkind.ErrNotFound):
+		return http.StatusNotFound, NotFoundCode
+	case errors.Is(err, errkind.ErrDuplicate):
+		return http.StatusConflict, DuplicateCode
+	case errors.Is(err, errkind.ErrValidation):
+		return http.StatusUnprocessableEntity, ValidationCode
+	case errors.Is(err, errkind.ErrLockTimeout):
+		return http.StatusServiceUnavailable, LockTimeoutCode
+	case errors.Is(err, errkind.ErrStorageUnavailable):
+		return http.StatusServiceUnavailable, StorageUnavailableCode
+	case errors.Is(err, errkind.ErrVersionMismatch):
+		return http.StatusPreconditionFailed, VersionMismatchCode
+	case errors.Is(err, errkind.ErrReloadFailed):
+		return http.StatusBadGateway, ReloadFailedCode
+	default:
+		return http.StatusInternalServerError, InternalErrorCode
+	}
+}
+
+// ServiceErrorResponse answers a request whose service call failed with the status and the code of the error
+// kind, see ErrorKind. The clients are told to retry after a lock timeout, and the internal server errors only
+// carry the request ID, whatever the message and the details are.
+func ServiceErrorResponse(c *fiber.Ctx, err error, message string, details interface{}) error {
+	httpStatus, code := ErrorKind(err)
+	switch code {
+	case InternalErrorCode:
+		return InternalServerErrorResponse(c)
+	case LockTimeoutCode:
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(LockRetryAfter))
+	}
+
+	return codeErrorResponse(c, httpStatus, code, message, details)
+}
+
+// ChangeErrorResponse answers a request whose change failed, see ServiceErrorResponse. A change saved before dnsmasq
+// failed to reload is answered with the saved resource as well, as the clients would otherwise not know what the
+// dnsmasq files now hold.
+func ChangeErrorResponse(c *fiber.Ctx, err error, message string, details interface{}, resource interface{}) error {
+	httpStatus, code := ErrorKind(err)
+	if code != ReloadFailedCode {
+		return ServiceErrorResponse(c, err, message, details)
+	}
+
+	return c.Status(httpStatus).JSON(errorMessage{
+		Error:    http.StatusText(httpStatus),
+		Code:     code,
+		Message:  message,
+		Details:  details,
+		Resource: resource,
+	})
+}
+
 func InternalServerErrorResponse(c *fiber.Ctx) error {
 	requestId, ok := c.Locals("requestid").(string)
 	if !ok {
@@ -36,10 +143,6 @@ func UnprocessableEntityResponse(c *fiber.Ctx, message string, details interface
 	return ErrorResponse(c, http.StatusUnprocessableEntity, message, details)
 }
 
-func ConflictResponse(c *fiber.Ctx, message string, details string) error {
-	return ErrorResponse(c, http.StatusConflict, message, details)
-}
-
 func BadRequestResponse(c *fiber.Ctx, message string, details string) error {
 	return ErrorResponse(c, http.StatusBadRequest, message, details)
 }
@@ -2,13 +2,20 @@ package presenter
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -31,6 +38,7 @@ func TestInternalServerErrorResponseWithoutRequestId(t *testing.T) {
 	var result map[string]interface{}
 	require.NoError(t, json.Unmarshal(body, &result))
 	assert.Equal(t, http.StatusText(http.StatusInternalServerError), result["error"])
+	assert.Equal(t, InternalErrorCode, result["code"])
 	assert.Equal(t, ServerErrorMessage, result["message"])
 	assert.Equal(t, fmt.Sprintf(InternalServerError, "unknown"), result["details"])
 }
@@ -56,6 +64,69 @@ func TestInternalServerErrorResponseWithRequestId(t *testing.T) {
 	var result map[string]interface{}
 	require.NoError(t, json.Unmarshal(body, &result))
 	assert.Equal(t, http.StatusText(http.StatusInternalServerError), result["error"])
+	assert.Equal(t, InternalErrorCode, result["code"])
 	assert.Equal(t, ServerErrorMessage, result["message"])
 	assert.Equal(t, fmt.Sprintf(InternalServerError, testRequestId), result["details"])
 }
+
+func TestErrorKind(t *testing.T) {
+	testCases := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "NotFound", err: fmt.Errorf("finding: %w", errkind.ErrNotFound), expectedStatus: http.StatusNotFound, expectedCode: NotFoundCode},
+		{name: "Duplicate", err: fmt.Errorf("saving: %w", errkind.ErrDuplicate), expectedStatus: http.StatusConflict, expectedCode: DuplicateCode},
+		{name: "Validation", err: &errkind.ValidationError{Err: model.ErrDHCPHostMissingIPAddress}, expectedStatus: http.StatusUnprocessableEntity, expectedCode: ValidationCode},
+		{name: "RejectedByValidator", err: &storage.ValidationError{Err: errors.New("exit status 1")}, expectedStatus: http.StatusUnprocessableEntity, expectedCode: ValidationCode},
+		{name: "StorageUnavailable", err: &errkind.StorageError{Err: os.ErrPermission}, expectedStatus: http.StatusServiceUnavailable, expectedCode: StorageUnavailableCode},
+		{name: "LockTimeout", err: &storage.LockTimeoutError{Timeout: time.Second}, expectedStatus: http.StatusServiceUnavailable, expectedCode: LockTimeoutCode},
+		{name: "WrappedLockTimeout", err: &errkind.StorageError{Err: &storage.LockTimeoutError{Timeout: time.Second}}, expectedStatus: http.StatusServiceUnavailable, expectedCode: LockTimeoutCode},
+		{name: "VersionMismatch", err: &model.VersionMismatchError{}, expectedStatus: http.StatusPreconditionFailed, expectedCode: VersionMismatchCode},
+		{name: "ReloadFailed", err: &dnsmasq.ReloadError{Err: errors.New("no such process")}, expectedStatus: http.StatusBadGateway, expectedCode: ReloadFailedCode},
+		{name: "Unknown", err: errors.New("an error"), expectedStatus: http.StatusInternalServerError, expectedCode: InternalErrorCode},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			status, code := ErrorKind(test.err)
+			assert.Equal(t, test.expectedStatus, status, "ErrorKind() returned an unexpected status")
+			assert.Equal(t, test.expectedCode, code, "ErrorKind() returned an unexpected code")
+		})
+	}
+}
+
+func TestServiceErrorResponse(t *testing.T) {
+	testCases := []struct {
+		name               string
+		err                error
+		expectedStatus     int
+		expectedCode       string
+		expectedMessage    string
+		expectedRetryAfter string
+	}{
+		{name: "NotFound", err: errkind.ErrNotFound, expectedStatus: http.StatusNotFound, expectedCode: NotFoundCode, expectedMessage: "a message"},
+		{name: "LockTimeout", err: &storage.LockTimeoutError{}, expectedStatus: http.StatusServiceUnavailable, expectedCode: LockTimeoutCode, expectedMessage: "a message", expectedRetryAfter: "1"},
+		{name: "Internal", err: errors.New("an error"), expectedStatus: http.StatusInternalServerError, expectedCode: InternalErrorCode, expectedMessage: ServerErrorMessage},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/test", func(c *fiber.Ctx) error {
+				return ServiceErrorResponse(c, test.err, "a message", "some details")
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/test", nil))
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedStatus, resp.StatusCode)
+			assert.Equal(t, test.expectedRetryAfter, resp.Header.Get(fiber.HeaderRetryAfter))
+
+			var result map[string]interface{}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, test.expectedCode, result["code"])
+			assert.Equal(t, test.expectedMessage, result["message"])
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package scope
+
+const (
+	DnsRead  = "dns:read"
+	DnsWrite = "dns:write"
+)
+
+var DnsCanRead = []string{DnsRead, DnsWrite}
+var DnsCanWrite = []string{DnsWrite}
@@ -2,6 +2,7 @@ package validation
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -14,9 +15,28 @@ type error struct {
 
 func newError(err validator.FieldError) error {
 	var reason string
-	if err.Tag() == "required" {
+	switch err.Tag() {
+	case "required":
 		reason = fmt.Sprintf("The %s field is required.", err.Field())
-	} else {
+	case "required_with":
+		reason = fmt.Sprintf("The %s field is required when %s is present.", err.Field(), err.Param())
+	case "required_without":
+		reason = fmt.Sprintf("The %s field is required when %s is not present.", err.Field(), err.Param())
+	case "required_without_all":
+		reason = fmt.Sprintf("The %s field is required when none of %s is present.", err.Field(), strings.ReplaceAll(err.Param(), " ", ", "))
+	case "oneof":
+		reason = fmt.Sprintf("The %s field must be one of: %s.", err.Field(), strings.ReplaceAll(err.Param(), " ", ", "))
+	case "min":
+		reason = fmt.Sprintf("The %s field must have at least %s elements.", err.Field(), err.Param())
+	case "gte":
+		reason = fmt.Sprintf("The %s field must be greater than or equal to %s.", err.Field(), err.Param())
+	case "lte":
+		reason = fmt.Sprintf("The %s field must be less than or equal to %s.", err.Field(), err.Param())
+	case "eq=|ipv4", "eq=|ipv6":
+		reason = fmt.Sprintf("The %s field must be empty or of type %s.", err.Field(), strings.TrimPrefix(err.Tag(), "eq=|"))
+	case "eq=|eq=#|ip":
+		reason = fmt.Sprintf("The %s field must be empty, # or an IP address.", err.Field())
+	default:
 		reason = fmt.Sprintf("The %s field must be of type %s.", err.Field(), err.Tag())
 	}
 
@@ -0,0 +1,88 @@
+// oui-generate regenerates the OUI table embedded in the manager out of the IEEE registry CSV files, which the
+// operator downloads from https://standards-oui.ieee.org (oui.csv, and optionally mam.csv and oui36.csv for the
+// MA-M and MA-S assignments). The table committed to the repository is only a sample of common vendors, the
+// manager can also read the full registry at startup instead (see host.vendors.file).
+//
+// Usage:
+//
+//	go run ./cmd/oui-generate -input oui.csv [-input mam.csv -input oui36.csv] [-output pkg/oui/vendors.csv]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/oui"
+)
+
+type inputFiles []string
+
+func (f *inputFiles) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *inputFiles) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func main() {
+	inputs := inputFiles{}
+	flag.Var(&inputs, "input", "IEEE registry CSV file, may be repeated (default oui.csv)")
+	output := flag.String("output", "pkg/oui/vendors.csv", "embedded OUI table to write")
+	flag.Parse()
+
+	if len(inputs) == 0 {
+		inputs = append(inputs, "oui.csv")
+	}
+
+	if err := generate(inputs, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "oui-generate: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+// generate merges the registries into the table, which is written atomically so that a failure does not leave
+// a truncated table behind.
+func generate(inputs []string, output string) error {
+	databases := []*oui.Database{}
+	for _, input := range inputs {
+		file, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		database, err := oui.Read(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", input, err)
+		}
+		databases = append(databases, database)
+	}
+	database := oui.Merge(databases...)
+
+	temporary, err := os.CreateTemp(filepath.Dir(output), "."+filepath.Base(output)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+
+	if err := database.Write(temporary); err != nil {
+		temporary.Close()
+		return err
+	}
+	if err := temporary.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temporary.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(temporary.Name(), output); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %d assignments\n", output, database.Len())
+	return nil
+}
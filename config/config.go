@@ -2,6 +2,7 @@ package config
 
 import (
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 	"log/slog"
@@ -35,10 +36,49 @@ const (
 	LogFormatPlainText = "text"
 )
 
+// Dnsmasq.Reload.Method constants
+const (
+	ReloadNone    = "none"
+	ReloadSignal  = "signal"
+	ReloadCommand = "command"
+)
+
+// Storage.ParseMode constants
+const (
+	ParseModeStrict  = "strict"
+	ParseModeLenient = "lenient"
+)
+
+// Storage.Backend constants
+const (
+	StorageBackendFile = "file"
+	StorageBackendBolt = "bolt"
+)
+
+// Host.Static.PoolPolicy constants
+const (
+	PoolPolicyAllow  = "allow"
+	PoolPolicyWarn   = "warn"
+	PoolPolicyReject = "reject"
+)
+
 // Other default constants
 const (
+	DefaultDhcpRangesFile     = "/etc/dnsmasq.d/03-dhcp-ranges.conf"
 	DefaultDhcpStaticHostFile = "/etc/dnsmasq.d/04-dhcp-static-leases.conf"
+	DefaultDnsStaticFile      = "/etc/dnsmasq.d/05-dns-static-records.conf"
+	DefaultDnsCnameFile       = "/etc/dnsmasq.d/06-dns-cnames.conf"
+	DefaultDnsRecordsFile     = "/etc/dnsmasq.d/07-dns-records.conf"
+	DefaultDnsServersFile     = "/etc/dnsmasq.d/08-dns-servers.conf"
+	DefaultDnsmasqConfDir     = "/etc/dnsmasq.d"
 	DefaultServerHttpPort     = 6904
+	DefaultStorageBackups     = 5
+	DefaultStorageLockTimeout = 5 * time.Second
+	DefaultStorageDatabase    = "/var/lib/dnsmasq-manager/static-hosts.db"
+	DefaultReloadPidFile      = "/run/dnsmasq/dnsmasq.pid"
+	DefaultReloadCommand      = "systemctl restart dnsmasq"
+	DefaultReloadDebounce     = 500 * time.Millisecond
+	DefaultValidateCommand    = "dnsmasq --test -C {file}"
 )
 
 type Config struct {
@@ -48,12 +88,55 @@ type Config struct {
 	}
 	Host struct {
 		Static struct {
+			File       string
+			Ranges     []string
+			PoolPolicy string
+		}
+		Vendors struct {
+			File string
+		}
+	}
+	Dhcp struct {
+		Ranges struct {
+			File string
+		}
+	}
+	Dns struct {
+		Static struct {
+			File string
+		}
+		Cname struct {
+			File string
+		}
+		Records struct {
+			File string
+		}
+		Servers struct {
 			File string
 		}
 	}
 	Server struct {
 		Port int
 	}
+	Storage struct {
+		Backups     int
+		LockTimeout time.Duration
+		ParseMode   string
+		Backend     string
+		Database    string
+	}
+	Dnsmasq struct {
+		ConfDir string
+		Reload  struct {
+			Method   string
+			PidFile  string
+			Command  string
+			Debounce time.Duration
+		}
+		Validate struct {
+			Command string
+		}
+	}
 	Log struct {
 		Level  string
 		File   string
@@ -66,7 +149,26 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("Auth.Method", NoAuth)
 	v.SetDefault("Auth.Key", "")
 	v.SetDefault("Host.Static.File", DefaultDhcpStaticHostFile)
+	v.SetDefault("Host.Static.Ranges", []string{})
+	v.SetDefault("Host.Static.PoolPolicy", PoolPolicyWarn)
+	v.SetDefault("Host.Vendors.File", "")
+	v.SetDefault("Dhcp.Ranges.File", DefaultDhcpRangesFile)
+	v.SetDefault("Dns.Static.File", DefaultDnsStaticFile)
+	v.SetDefault("Dns.Cname.File", DefaultDnsCnameFile)
+	v.SetDefault("Dns.Records.File", DefaultDnsRecordsFile)
+	v.SetDefault("Dns.Servers.File", DefaultDnsServersFile)
 	v.SetDefault("Server.Port", DefaultServerHttpPort)
+	v.SetDefault("Storage.Backups", DefaultStorageBackups)
+	v.SetDefault("Storage.LockTimeout", DefaultStorageLockTimeout)
+	v.SetDefault("Storage.ParseMode", ParseModeStrict)
+	v.SetDefault("Storage.Backend", StorageBackendFile)
+	v.SetDefault("Storage.Database", DefaultStorageDatabase)
+	v.SetDefault("Dnsmasq.ConfDir", DefaultDnsmasqConfDir)
+	v.SetDefault("Dnsmasq.Reload.Method", ReloadNone)
+	v.SetDefault("Dnsmasq.Reload.PidFile", DefaultReloadPidFile)
+	v.SetDefault("Dnsmasq.Reload.Command", DefaultReloadCommand)
+	v.SetDefault("Dnsmasq.Reload.Debounce", DefaultReloadDebounce)
+	v.SetDefault("Dnsmasq.Validate.Command", DefaultValidateCommand)
 	v.SetDefault("Log.Level", LogLevelInfo)
 	v.SetDefault("Log.File", "")
 	v.SetDefault("Log.Format", LogFormatJSON)
@@ -11,7 +11,15 @@ import (
 	"github.com/gringolito/dnsmasq-manager/api"
 	"github.com/gringolito/dnsmasq-manager/api/handler"
 	"github.com/gringolito/dnsmasq-manager/config"
+	"github.com/gringolito/dnsmasq-manager/pkg/cname"
+	"github.com/gringolito/dnsmasq-manager/pkg/dhcp"
+	"github.com/gringolito/dnsmasq-manager/pkg/dns"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
 	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"github.com/gringolito/dnsmasq-manager/pkg/oui"
+	"github.com/gringolito/dnsmasq-manager/pkg/record"
+	"github.com/gringolito/dnsmasq-manager/pkg/server"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
 	"log/slog"
 )
 
@@ -72,12 +80,127 @@ func setupLogger(cfg *config.Config) *slog.Logger {
 	return logger
 }
 
-func addStaticHostApi(router api.Router, cfg *config.Config) {
-	hostRepository := host.NewRepository(cfg.Host.Static.File)
-	hostService := host.NewService(hostRepository)
+func setupReloader(cfg *config.Config) (dnsmasq.Reloader, error) {
+	return dnsmasq.NewReloader(dnsmasq.ReloadOptions{
+		Method:   cfg.Dnsmasq.Reload.Method,
+		PidFile:  cfg.Dnsmasq.Reload.PidFile,
+		Command:  cfg.Dnsmasq.Reload.Command,
+		Debounce: cfg.Dnsmasq.Reload.Debounce,
+	})
+}
+
+func setupStorageOptions(cfg *config.Config) (storage.Options, error) {
+	options := storage.Options{
+		Backups:     cfg.Storage.Backups,
+		LockTimeout: cfg.Storage.LockTimeout,
+		Validator:   storage.NewCommandValidator(cfg.Dnsmasq.Validate.Command),
+	}
+
+	switch cfg.Storage.ParseMode {
+	case config.ParseModeStrict:
+	case config.ParseModeLenient:
+		options.Lenient = true
+	default:
+		return options, fmt.Errorf("unknown storage parse mode: %s", cfg.Storage.ParseMode)
+	}
+
+	return options, nil
+}
+
+func setupHostRepository(cfg *config.Config, options storage.Options) (host.Repository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageBackendFile:
+	case config.StorageBackendBolt:
+		return host.NewBoltRepository(cfg.Storage.Database, cfg.Host.Static.File, options)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+
+	hostRepository, err := host.NewCachedRepository(cfg.Host.Static.File, options)
+	if err != nil {
+		slog.Warn("Could not watch the static hosts file, caching disabled",
+			slog.String("file", cfg.Host.Static.File),
+			slog.String("error", err.Error()),
+		)
+		return host.NewRepository(cfg.Host.Static.File, options), nil
+	}
+
+	return hostRepository, nil
+}
+
+func setupSubnetChecker(cfg *config.Config) (host.SubnetChecker, error) {
+	return host.NewSubnetChecker(host.SubnetOptions{
+		Ranges:     cfg.Host.Static.Ranges,
+		ConfDir:    cfg.Dnsmasq.ConfDir,
+		PoolPolicy: cfg.Host.Static.PoolPolicy,
+	})
+}
+
+// setupVendors replaces the embedded OUI table by the IEEE registry file configured, if any.
+func setupVendors(cfg *config.Config) error {
+	if cfg.Host.Vendors.File == "" {
+		slog.Info("Using the embedded sample of MAC vendors, set host.vendors.file to read the IEEE registry",
+			slog.Int("assignments", oui.Default().Len()),
+		)
+		return nil
+	}
+
+	file, err := os.Open(cfg.Host.Vendors.File)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	database, err := oui.Read(file)
+	if err != nil {
+		return err
+	}
+	oui.SetDefault(database)
+	slog.Info("Loaded the MAC vendors",
+		slog.String("file", cfg.Host.Vendors.File),
+		slog.Int("assignments", database.Len()),
+	)
+
+	return nil
+}
+
+// The DHCP ranges are kept in their own file, checked against the hand-written ranges of the conf-dir and against
+// the static hosts they serve.
+func addDhcpRangesApi(router api.Router, rangeRepository dhcp.Repository, hostRepository host.Repository, reloader dnsmasq.Reloader) {
+	rangeService := dhcp.NewService(rangeRepository, hostRepository, reloader)
+	handler.RouteDhcpRanges(router, rangeService)
+}
+
+func addStaticHostApi(router api.Router, hostRepository host.Repository, reloader dnsmasq.Reloader, subnets host.SubnetChecker) {
+	hostService := host.NewService(hostRepository, reloader, subnets)
 	handler.RouteStaticHosts(router, hostService)
 }
 
+// The static DNS records are always kept in their own file, whatever the storage backend of the static hosts is.
+func addStaticDnsApi(router api.Router, dnsRepository dns.Repository, reloader dnsmasq.Reloader) {
+	dnsService := dns.NewService(dnsRepository, reloader)
+	handler.RouteStaticDns(router, dnsService)
+}
+
+// The CNAMEs are kept in their own file as well, their aliases are checked against the static hosts and DNS records.
+func addCnameApi(router api.Router, cnameRepository cname.Repository, hostRepository host.Repository, dnsRepository dns.Repository, reloader dnsmasq.Reloader, confDir string) {
+	cnameService := cname.NewService(cnameRepository, hostRepository, dnsRepository, reloader, confDir)
+	handler.RouteStaticCnames(router, cnameService)
+}
+
+// The SRV, MX, TXT and PTR records share a file of their own, each type being served by its own API.
+func addStaticRecordsApi(router api.Router, recordRepository record.Repository, reloader dnsmasq.Reloader) {
+	recordService := record.NewService(recordRepository, reloader)
+	handler.RouteStaticRecords(router, recordService)
+}
+
+// The upstream and conditional forwarding servers are kept in their own file too, apart from the server= lines of
+// the main dnsmasq configuration.
+func addDnsServersApi(router api.Router, serverRepository server.Repository, reloader dnsmasq.Reloader) {
+	serverService := server.NewService(serverRepository, reloader)
+	handler.RouteDnsServers(router, serverService)
+}
+
 func main() {
 	configName := "test"
 	cfg, err := config.Init(configName)
@@ -105,7 +228,38 @@ func main() {
 	router.AddMetricsRoute(monitor.Config{
 		Title: fmt.Sprintf("%s Monitor", AppName),
 	})
-	addStaticHostApi(router, cfg)
+
+	reloader, err := setupReloader(cfg)
+	if err != nil {
+		logger.Error(err.Error(), slog.String("reload.method", cfg.Dnsmasq.Reload.Method))
+		os.Exit(1)
+	}
+	options, err := setupStorageOptions(cfg)
+	if err != nil {
+		logger.Error(err.Error(), slog.String("storage.parseMode", cfg.Storage.ParseMode))
+		os.Exit(1)
+	}
+	hostRepository, err := setupHostRepository(cfg, options)
+	if err != nil {
+		logger.Error(err.Error(), slog.String("storage.backend", cfg.Storage.Backend))
+		os.Exit(1)
+	}
+	subnets, err := setupSubnetChecker(cfg)
+	if err != nil {
+		logger.Error(err.Error(), slog.String("host.static.poolPolicy", cfg.Host.Static.PoolPolicy))
+		os.Exit(1)
+	}
+	if err := setupVendors(cfg); err != nil {
+		logger.Error(err.Error(), slog.String("host.vendors.file", cfg.Host.Vendors.File))
+		os.Exit(1)
+	}
+	addDhcpRangesApi(router, dhcp.NewRepository(cfg.Dhcp.Ranges.File, cfg.Dnsmasq.ConfDir, options), hostRepository, reloader)
+	addStaticHostApi(router, hostRepository, reloader, subnets)
+	dnsRepository := dns.NewRepository(cfg.Dns.Static.File, options)
+	addStaticDnsApi(router, dnsRepository, reloader)
+	addCnameApi(router, cname.NewRepository(cfg.Dns.Cname.File, options), hostRepository, dnsRepository, reloader, cfg.Dnsmasq.ConfDir)
+	addStaticRecordsApi(router, record.NewRepository(cfg.Dns.Records.File, options), reloader)
+	addDnsServersApi(router, server.NewRepository(cfg.Dns.Servers.File, options), reloader)
 
 	if err := app.Listen(fmt.Sprintf(":%d", cfg.Server.Port)); err != nil {
 		logger.Error(err.Error(), slog.Int("listeningPort", cfg.Server.Port))
@@ -0,0 +1,78 @@
+package cname
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// DuplicatedEntryError is returned when an alias is already answered by another CNAME.
+type DuplicatedEntryError struct {
+	Alias string
+	// The CNAME already answering the alias
+	Cname *model.Cname
+}
+
+const duplicatedEntryErrorMessage = "Duplicated CNAME alias: %s"
+
+func (e DuplicatedEntryError) Error() string {
+	return fmt.Sprintf(duplicatedEntryErrorMessage, e.Alias)
+}
+
+func (e DuplicatedEntryError) Is(target error) bool {
+	return target == errkind.ErrDuplicate
+}
+
+// NameClashError is returned when an alias is the name of a static host (also qualified by the dnsmasq domain) or of
+// a static DNS record, which dnsmasq would answer instead of the CNAME. Only one of Host and Record is set.
+type NameClashError struct {
+	Alias  string
+	Host   *model.StaticDhcpHost
+	Record *model.DnsRecord
+}
+
+const nameClashErrorMessage = "CNAME alias %s is the name of a static %s"
+
+func (e NameClashError) Error() string {
+	if e.Host != nil {
+		return fmt.Sprintf(nameClashErrorMessage, e.Alias, "host")
+	}
+	return fmt.Sprintf(nameClashErrorMessage, e.Alias, "DNS record")
+}
+
+func (e NameClashError) Is(target error) bool {
+	return target == errkind.ErrDuplicate
+}
+
+// LoopError is returned when following the targets of a CNAME leads back to one of its aliases.
+type LoopError struct {
+	// Names from the alias to itself, e.g. [a b a] when b is an alias of a
+	Chain []string
+}
+
+const loopErrorMessage = "CNAME loop: %s"
+
+func (e LoopError) Error() string {
+	return fmt.Sprintf(loopErrorMessage, strings.Join(e.Chain, " -> "))
+}
+
+func (e LoopError) Is(target error) bool {
+	return target == errkind.ErrValidation
+}
+
+// NotFoundError is returned when the CNAME to be changed does not exist.
+type NotFoundError struct {
+	Alias string
+}
+
+const notFoundErrorMessage = "No CNAME found with alias: %s"
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf(notFoundErrorMessage, e.Alias)
+}
+
+func (e NotFoundError) Is(target error) bool {
+	return target == errkind.ErrNotFound
+}
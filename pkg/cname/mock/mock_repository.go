@@ -0,0 +1,56 @@
+package cnamemock
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type RepositoryMock struct {
+	mock.Mock
+}
+
+func (m *RepositoryMock) DeleteByAlias(alias string) (*model.Cname, error) {
+	args := m.Called(alias)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Cname), args.Error(1)
+}
+
+func (m *RepositoryMock) FindAll() (*[]model.Cname, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*[]model.Cname), args.Error(1)
+}
+
+func (m *RepositoryMock) FindByAlias(alias string) (*model.Cname, error) {
+	args := m.Called(alias)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Cname), args.Error(1)
+}
+
+func (m *RepositoryMock) ReplaceByAlias(alias string, cname *model.Cname) error {
+	args := m.Called(alias, cname)
+	return args.Error(0)
+}
+
+func (m *RepositoryMock) Save(cname *model.Cname) error {
+	args := m.Called(cname)
+	return args.Error(0)
+}
+
+// Transaction runs fn against the mock itself, so the calls made within the transaction are expected as any
+// other. The "Transaction" call stands for the commit, it is only made when fn succeeds.
+func (m *RepositoryMock) Transaction(fn func(tx model.CnameTransaction) error) error {
+	err := fn(m)
+	if err != nil {
+		return err
+	}
+
+	args := m.Called()
+	return args.Error(0)
+}
@@ -0,0 +1,50 @@
+package cnamemock
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type ServiceMock struct {
+	mock.Mock
+}
+
+func (m *ServiceMock) Insert(cname *model.Cname) ([]string, error) {
+	args := m.Called(cname)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *ServiceMock) Update(alias string, cname *model.Cname) ([]string, error) {
+	args := m.Called(alias, cname)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *ServiceMock) FetchAll() (*[]model.Cname, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*[]model.Cname), args.Error(1)
+}
+
+func (m *ServiceMock) FetchByAlias(alias string) (*model.Cname, error) {
+	args := m.Called(alias)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Cname), args.Error(1)
+}
+
+func (m *ServiceMock) RemoveByAlias(alias string) (*model.Cname, error) {
+	args := m.Called(alias)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Cname), args.Error(1)
+}
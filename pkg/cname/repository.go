@@ -0,0 +1,131 @@
+package cname
+
+import (
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+)
+
+type Repository interface {
+	DeleteByAlias(alias string) (*model.Cname, error)
+	FindAll() (*[]model.Cname, error)
+	// FindByAlias looks the CNAME up regardless of the case, as DNS names are
+	FindByAlias(alias string) (*model.Cname, error)
+	Save(cname *model.Cname) error
+	// Transaction runs fn as a single unit of work, holding the exclusive lock of the CNAMEs file. The changes
+	// made by fn are written at once when it returns nil, and thrown away otherwise.
+	Transaction(fn func(tx model.CnameTransaction) error) error
+}
+
+type repository struct {
+	document *storage.DocumentRepository[model.Cname]
+}
+
+// NewRepository returns the Repository of the CNAMEs kept in the given file, which is created by the first change
+// when it does not exist yet.
+func NewRepository(cnamesFilePath string, options storage.Options) Repository {
+	return &repository{
+		document: storage.NewDocumentRepository(cnamesFilePath, options, storage.EntryHooks[model.Cname]{
+			Name: "CNAME",
+			Is:   model.IsCname,
+			Parse: func(line string) (model.Cname, error) {
+				cname := model.Cname{}
+				err := cname.FromConfig(line)
+				return cname, err
+			},
+			Format: (*model.Cname).ToConfig,
+			Keys:   aliases,
+		}),
+	}
+}
+
+// aliases indexes the CNAMEs by their lower case aliases.
+func aliases(cname *model.Cname) []string {
+	keys := make([]string, 0, len(cname.Aliases))
+	for _, alias := range cname.Aliases {
+		keys = append(keys, strings.ToLower(alias))
+	}
+	return keys
+}
+
+func (r *repository) FindAll() (*[]model.Cname, error) {
+	entries, err := r.document.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	cnames := entries.All()
+	return &cnames, nil
+}
+
+func (r *repository) FindByAlias(alias string) (*model.Cname, error) {
+	entries, err := r.document.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	_, cname := entries.Lookup(strings.ToLower(alias))
+	return cname, nil
+}
+
+func (r *repository) Save(cname *model.Cname) error {
+	return r.Transaction(func(tx model.CnameTransaction) error {
+		return tx.Save(cname)
+	})
+}
+
+// DeleteByAlias removes the CNAME answering the given name, returning it even if the change could not be written.
+func (r *repository) DeleteByAlias(alias string) (*model.Cname, error) {
+	var deleted *model.Cname
+	err := r.Transaction(func(tx model.CnameTransaction) error {
+		var err error
+		deleted, err = tx.DeleteByAlias(alias)
+		return err
+	})
+
+	return deleted, err
+}
+
+func (r *repository) Transaction(fn func(tx model.CnameTransaction) error) error {
+	return r.document.Transaction(func(tx *storage.DocumentTransaction[model.Cname]) error {
+		return fn(&fileTransaction{tx})
+	})
+}
+
+// fileTransaction looks the CNAMEs up by alias in a storage.DocumentTransaction.
+type fileTransaction struct {
+	*storage.DocumentTransaction[model.Cname]
+}
+
+func (tx *fileTransaction) FindAll() (*[]model.Cname, error) {
+	cnames := tx.Entries().All()
+	return &cnames, nil
+}
+
+func (tx *fileTransaction) FindByAlias(alias string) (*model.Cname, error) {
+	_, cname := tx.Entries().Lookup(strings.ToLower(alias))
+	return cname, nil
+}
+
+func (tx *fileTransaction) Save(cname *model.Cname) error {
+	return tx.Append(cname)
+}
+
+func (tx *fileTransaction) ReplaceByAlias(alias string, cname *model.Cname) error {
+	i, _ := tx.Entries().Lookup(strings.ToLower(alias))
+	if i < 0 {
+		return nil
+	}
+
+	return tx.Replace(i, cname)
+}
+
+func (tx *fileTransaction) DeleteByAlias(alias string) (*model.Cname, error) {
+	i, cname := tx.Entries().Lookup(strings.ToLower(alias))
+	if cname == nil {
+		return nil, nil
+	}
+
+	return cname, tx.Remove(i)
+}
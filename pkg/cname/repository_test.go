@@ -0,0 +1,131 @@
+package cname
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var AllCnames = []model.Cname{
+	{Aliases: []string{"files.lan", "backup.lan"}, Target: "nas.lan"},
+	{Aliases: []string{"www.lan"}, Target: "web.lan", TTL: 300},
+}
+
+var NewCname = model.Cname{Aliases: []string{"print.lan"}, Target: "printer.lan"}
+
+const (
+	AllCnamesFileContent = `# CNAMEs
+cname=files.lan,backup.lan,nas.lan
+host-record=nas.lan,192.168.1.10
+cname=www.lan,web.lan,300
+`
+	AddedCnameFileContent = AllCnamesFileContent + `cname=print.lan,printer.lan
+`
+	DeletedCnameFileContent = `# CNAMEs
+host-record=nas.lan,192.168.1.10
+cname=www.lan,web.lan,300
+`
+	InvalidCnameFileContent = `cname=files.lan
+cname=www.lan,web.lan,300
+`
+)
+
+func setUpCnamesFile(t *testing.T, content string) string {
+	fileName := filepath.Join(t.TempDir(), "06-dns-cnames.conf")
+	require.NoError(t, os.WriteFile(fileName, []byte(content), 0644), "Failed to initialize the CNAMEs file")
+	return fileName
+}
+
+func assertFileContent(t *testing.T, expectedFileContent string, fileName string) {
+	actualFileData, err := os.ReadFile(fileName)
+	require.NoError(t, err, "Failed to open test file for validation")
+	assert.Equal(t, expectedFileContent, string(actualFileData), "CNAMEs file doesn't match")
+}
+
+func TestCnameRepositoryFind(t *testing.T) {
+	fileName := setUpCnamesFile(t, AllCnamesFileContent)
+	repository := NewRepository(fileName, storage.Options{})
+
+	cnames, err := repository.FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.Equal(t, AllCnames, *cnames, "FindAll() returned unexpected CNAMEs")
+
+	cname, err := repository.FindByAlias("BACKUP.lan")
+	assert.NoError(t, err, "FindByAlias() returned an unexpected error")
+	assert.Equal(t, &AllCnames[0], cname, "FindByAlias() returned an unexpected CNAME")
+
+	cname, err = repository.FindByAlias("nas.lan")
+	assert.NoError(t, err, "FindByAlias() returned an unexpected error")
+	assert.Nil(t, cname, "FindByAlias() found a CNAME by its target")
+	assertFileContent(t, AllCnamesFileContent, fileName)
+}
+
+func TestCnameRepositoryMissingFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "06-dns-cnames.conf")
+	repository := NewRepository(fileName, storage.Options{})
+
+	cnames, err := repository.FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.Empty(t, *cnames, "FindAll() returned unexpected CNAMEs")
+
+	assert.NoError(t, repository.Save(&NewCname), "Save() returned an unexpected error")
+	assertFileContent(t, "cname=print.lan,printer.lan", fileName)
+}
+
+func TestCnameRepositorySaveDelete(t *testing.T) {
+	fileName := setUpCnamesFile(t, AllCnamesFileContent)
+	repository := NewRepository(fileName, storage.Options{})
+
+	assert.NoError(t, repository.Save(&NewCname), "Save() returned an unexpected error")
+	assertFileContent(t, AddedCnameFileContent, fileName)
+
+	deleted, err := repository.DeleteByAlias("print.lan")
+	assert.NoError(t, err, "DeleteByAlias() returned an unexpected error")
+	assert.Equal(t, &NewCname, deleted, "DeleteByAlias() returned an unexpected CNAME")
+	assertFileContent(t, AllCnamesFileContent, fileName)
+
+	deleted, err = repository.DeleteByAlias("files.lan")
+	assert.NoError(t, err, "DeleteByAlias() returned an unexpected error")
+	assert.Equal(t, &AllCnames[0], deleted, "DeleteByAlias() returned an unexpected CNAME")
+	assertFileContent(t, DeletedCnameFileContent, fileName)
+
+	deleted, err = repository.DeleteByAlias("files.lan")
+	assert.NoError(t, err, "DeleteByAlias() returned an unexpected error")
+	assert.Nil(t, deleted, "DeleteByAlias() returned an unexpected CNAME")
+	assertFileContent(t, DeletedCnameFileContent, fileName)
+}
+
+func TestCnameRepositoryTransactionRollback(t *testing.T) {
+	fileName := setUpCnamesFile(t, AllCnamesFileContent)
+	repository := NewRepository(fileName, storage.Options{})
+	rollback := errors.New("rollback")
+
+	err := repository.Transaction(func(tx model.CnameTransaction) error {
+		if _, err := tx.DeleteByAlias("files.lan"); err != nil {
+			return err
+		}
+		cname, err := tx.FindByAlias("backup.lan")
+		assert.NoError(t, err, "FindByAlias() returned an unexpected error")
+		assert.Nil(t, cname, "FindByAlias() did NOT see the deletion")
+		return rollback
+	})
+	assert.ErrorIs(t, err, rollback, "Transaction() returned an unexpected error")
+	assertFileContent(t, AllCnamesFileContent, fileName)
+}
+
+func TestCnameRepositoryParseMode(t *testing.T) {
+	fileName := setUpCnamesFile(t, InvalidCnameFileContent)
+
+	_, err := NewRepository(fileName, storage.Options{}).FindAll()
+	assert.Error(t, err, "FindAll() did NOT returned an error in the strict mode")
+
+	cnames, err := NewRepository(fileName, storage.Options{Lenient: true}).FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error in the lenient mode")
+	assert.Equal(t, AllCnames[1:], *cnames, "FindAll() returned unexpected CNAMEs")
+}
@@ -0,0 +1,265 @@
+package cname
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dns"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"log/slog"
+)
+
+// Insert and Update return warnings about CNAMEs that were saved, but that dnsmasq may not answer.
+type Service interface {
+	Insert(cname *model.Cname) ([]string, error)
+	Update(alias string, cname *model.Cname) ([]string, error)
+	FetchAll() (*[]model.Cname, error)
+	FetchByAlias(alias string) (*model.Cname, error)
+	RemoveByAlias(alias string) (*model.Cname, error)
+}
+
+type service struct {
+	repository Repository
+	hosts      host.Repository
+	records    dns.Repository
+	reloader   dnsmasq.Reloader
+	// dnsmasq conf-dir, whose domain= directives are read on every check
+	confDir string
+}
+
+const UnresolvableTargetWarning = "The target %s is not a static host, a host record or a CNAME of them. dnsmasq " +
+	"only answers the aliases if it knows the target from elsewhere (e.g. /etc/hosts or a DHCP lease)."
+
+// NewService returns the CNAMEs Service. The aliases of the CNAMEs are checked against the names of the static
+// hosts (also qualified by the domains of the conf-dir) and of the static DNS records, and their targets are resolved
+// through them. The reloader is triggered
+// after every change written to the CNAMEs file, so dnsmasq picks it up.
+//
+// Every error matches one of the error kinds (errkind.ErrNotFound, errkind.ErrDuplicate, errkind.ErrValidation,
+// errkind.ErrStorageUnavailable or errkind.ErrLockTimeout), except the dnsmasq.ReloadError of a change that was saved.
+func NewService(repository Repository, hosts host.Repository, records dns.Repository, reloader dnsmasq.Reloader, confDir string) Service {
+	return &service{
+		repository: repository,
+		hosts:      hosts,
+		records:    records,
+		reloader:   reloader,
+		confDir:    confDir,
+	}
+}
+
+// Insert adds a new CNAME. It fails with a DuplicatedEntryError if one of its aliases is already answered by
+// another CNAME, with a NameClashError if it is the name of a static host or DNS record, and with a LoopError if
+// its target leads back to one of its aliases.
+func (s *service) Insert(cname *model.Cname) ([]string, error) {
+	if err := cname.Validate(); err != nil {
+		return nil, &errkind.ValidationError{Err: err}
+	}
+
+	var warnings []string
+	err := s.repository.Transaction(func(tx model.CnameTransaction) error {
+		var err error
+		warnings, err = s.check(tx, cname, nil)
+		if err != nil {
+			return err
+		}
+		return tx.Save(cname)
+	})
+	if err != nil {
+		return nil, errkind.Classify(err)
+	}
+
+	return warnings, s.reloader.Reload()
+}
+
+// Update replaces the existing CNAME answering the given alias, which may be renamed. It fails with a
+// NotFoundError if there is no such CNAME, and as Insert does if the new CNAME is not valid.
+func (s *service) Update(alias string, cname *model.Cname) ([]string, error) {
+	if err := cname.Validate(); err != nil {
+		return nil, &errkind.ValidationError{Err: err}
+	}
+
+	var warnings []string
+	err := s.repository.Transaction(func(tx model.CnameTransaction) error {
+		existing, err := tx.FindByAlias(alias)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return &NotFoundError{Alias: alias}
+		}
+
+		warnings, err = s.check(tx, cname, existing)
+		if err != nil {
+			return err
+		}
+		return tx.ReplaceByAlias(alias, cname)
+	})
+	if err != nil {
+		return nil, errkind.Classify(err)
+	}
+
+	return warnings, s.reloader.Reload()
+}
+
+// check ensures that the aliases of the CNAME are not answered by anything else, and that its target does not
+// lead back to them. The existing CNAME (nil for a new one) is the one it replaces, which is not taken into account.
+func (s *service) check(tx model.CnameTransaction, cname *model.Cname, existing *model.Cname) ([]string, error) {
+	for _, alias := range cname.Aliases {
+		if err := s.checkAlias(tx, alias, existing); err != nil {
+			return nil, err
+		}
+	}
+
+	target, err := s.follow(tx, cname, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvable, err := s.isLocal(target)
+	if err != nil {
+		return nil, err
+	}
+	if !resolvable {
+		slog.Warn("The CNAME target is not known to dnsmasq",
+			slog.Any("aliases", cname.Aliases),
+			slog.String("target", cname.Target),
+		)
+		return []string{fmt.Sprintf(UnresolvableTargetWarning, cname.Target)}, nil
+	}
+
+	return nil, nil
+}
+
+func (s *service) checkAlias(tx model.CnameTransaction, alias string, existing *model.Cname) error {
+	sameAliasCname, err := tx.FindByAlias(alias)
+	if err != nil {
+		return err
+	}
+	if sameAliasCname != nil && (existing == nil || !sameAliasCname.Equal(*existing)) {
+		return &DuplicatedEntryError{Alias: alias, Cname: sameAliasCname}
+	}
+
+	sameNameHost, err := s.findHost(alias)
+	if err != nil {
+		return err
+	}
+	if sameNameHost != nil {
+		return &NameClashError{Alias: alias, Host: sameNameHost}
+	}
+
+	sameNameRecord, err := s.records.FindByName(alias)
+	if err != nil {
+		return err
+	}
+	if sameNameRecord != nil {
+		return &NameClashError{Alias: alias, Record: sameNameRecord}
+	}
+
+	return nil
+}
+
+// follow walks the CNAMEs from the target of the given one, returning the name they end at. It fails with a
+// LoopError if they lead back to one of its aliases. The existing CNAME (nil for a new one) is replaced by the given
+// one, so the walk ends at the aliases it drops.
+func (s *service) follow(tx model.CnameTransaction, cname *model.Cname, existing *model.Cname) (string, error) {
+	chain := []string{cname.Target}
+	seen := map[string]bool{}
+	target := cname.Target
+	for {
+		if cname.HasAlias(target) {
+			return "", &LoopError{Chain: append([]string{target}, chain...)}
+		}
+		// A loop the CNAME is not part of was written by hand, its names are not resolvable anyway
+		if seen[strings.ToLower(target)] {
+			return target, nil
+		}
+		seen[strings.ToLower(target)] = true
+
+		next, err := tx.FindByAlias(target)
+		if err != nil {
+			return "", err
+		}
+		if next == nil || (existing != nil && next.Equal(*existing)) {
+			return target, nil
+		}
+		target = next.Target
+		chain = append(chain, target)
+	}
+}
+
+// isLocal reports whether dnsmasq answers the name by itself, as the name of a static host (also qualified by the
+// dnsmasq domain) or of a host record. The address records don't count, as dnsmasq
+// doesn't use them for the CNAME targets.
+func (s *service) isLocal(name string) (bool, error) {
+	sameNameHost, err := s.findHost(name)
+	if err != nil {
+		return false, err
+	}
+	if sameNameHost != nil {
+		return true, nil
+	}
+
+	record, err := s.records.FindByName(name)
+	if err != nil {
+		return false, err
+	}
+
+	return record != nil && record.Type == model.DnsRecordTypeHost, nil
+}
+
+// findHost returns the static host dnsmasq answers the name with, looked up by the name itself and, for a name
+// qualified by one of the dnsmasq domains, by the name without the domain.
+func (s *service) findHost(name string) (*model.StaticDhcpHost, error) {
+	domains, err := dnsmasq.Domains(s.confDir)
+	if err != nil {
+		return nil, err
+	}
+
+	hostNames := []string{name}
+	for _, domain := range domains {
+		suffix := "." + domain
+		if len(name) > len(suffix) && strings.EqualFold(name[len(name)-len(suffix):], suffix) {
+			hostNames = append(hostNames, name[:len(name)-len(suffix)])
+		}
+	}
+	for _, hostName := range hostNames {
+		sameNameHost, err := s.hosts.FindByHostName(hostName)
+		if err != nil || sameNameHost != nil {
+			return sameNameHost, err
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *service) FetchAll() (*[]model.Cname, error) {
+	cnames, err := s.repository.FindAll()
+	return cnames, errkind.Classify(err)
+}
+
+func (s *service) FetchByAlias(alias string) (*model.Cname, error) {
+	cname, err := s.repository.FindByAlias(alias)
+	return cname, errkind.Classify(err)
+}
+
+// RemoveByAlias deletes the CNAME answering the given alias, and reloads dnsmasq when a CNAME was actually removed.
+// The removed CNAME is returned even if the reload fails, since it is already gone from the CNAMEs file.
+func (s *service) RemoveByAlias(alias string) (*model.Cname, error) {
+	var removed *model.Cname
+	err := s.repository.Transaction(func(tx model.CnameTransaction) error {
+		var err error
+		removed, err = tx.DeleteByAlias(alias)
+		return err
+	})
+	if err != nil {
+		return nil, errkind.Classify(err)
+	}
+	if removed == nil {
+		return nil, nil
+	}
+
+	return removed, s.reloader.Reload()
+}
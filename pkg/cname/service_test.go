@@ -0,0 +1,259 @@
+package cname
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cnamemock "github.com/gringolito/dnsmasq-manager/pkg/cname/mock"
+	dnsmock "github.com/gringolito/dnsmasq-manager/pkg/dns/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	dnsmasqmock "github.com/gringolito/dnsmasq-manager/pkg/dnsmasq/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	hostmock "github.com/gringolito/dnsmasq-manager/pkg/host/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var ValidCname = model.Cname{Aliases: []string{"files.lan"}, Target: "nas.lan"}
+var OldCname = model.Cname{Aliases: []string{"files.lan"}, Target: "old-nas.lan"}
+var OtherCname = model.Cname{Aliases: []string{"FILES.lan"}, Target: "backup.lan"}
+var LoopCname = model.Cname{Aliases: []string{"nas.lan"}, Target: "files.lan"}
+var InvalidCname = model.Cname{Aliases: []string{"files.lan"}, Target: "files.lan"}
+var ForeignDomainCname = model.Cname{Aliases: []string{"nas.example.org"}, Target: "nas.lan"}
+var NasHost = model.StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("192.168.1.10"), HostName: "nas"}
+var NasRecord = model.DnsRecord{Type: model.DnsRecordTypeHost, Names: []string{"nas.lan"}, IPAddress: net.ParseIP("192.168.1.10")}
+var NasAddress = model.DnsRecord{Type: model.DnsRecordTypeAddress, Names: []string{"nas.lan"}, IPAddress: net.ParseIP("192.168.1.10")}
+
+type serviceMocks struct {
+	repository *cnamemock.RepositoryMock
+	hosts      *hostmock.RepositoryMock
+	records    *dnsmock.RepositoryMock
+}
+
+// freeAlias expects the lookups of an alias that is not used by anything else.
+func (m *serviceMocks) freeAlias(alias string) {
+	m.repository.On("FindByAlias", alias).Once().Return(nil, nil)
+	m.freeHostName(alias)
+	m.records.On("FindByName", alias).Once().Return(nil, nil)
+}
+
+// freeHostName expects the lookups of a name that is not the one of a static host, also without the dnsmasq domain.
+func (m *serviceMocks) freeHostName(name string) {
+	m.hosts.On("FindByHostName", name).Once().Return(nil, nil)
+	if hostName, qualified := strings.CutSuffix(name, ".lan"); qualified {
+		m.hosts.On("FindByHostName", hostName).Once().Return(nil, nil)
+	}
+}
+
+// setUpConfDir returns a dnsmasq conf-dir whose domain is lan.
+func setUpConfDir(t *testing.T) string {
+	confDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(confDir, "01-dns.conf"), []byte("domain=lan\n"), 0644), "Failed to create conf file")
+	return confDir
+}
+
+func TestCnameServiceChanges(t *testing.T) {
+	Insert := func(service Service) ([]string, error) { return service.Insert(&ValidCname) }
+	Update := func(service Service) ([]string, error) { return service.Update("files.lan", &ValidCname) }
+
+	var testCases = []struct {
+		name             string
+		method           func(service Service) ([]string, error)
+		on               func(mocks *serviceMocks)
+		expectedWarnings []string
+		expectedErr      error
+	}{
+		{
+			name:   "InsertTargetHostRecord",
+			method: Insert,
+			on: func(mocks *serviceMocks) {
+				mocks.freeAlias("files.lan")
+				mocks.repository.On("FindByAlias", "nas.lan").Once().Return(nil, nil)
+				mocks.hosts.On("FindByHostName", "nas.lan").Once().Return(nil, nil)
+				mocks.hosts.On("FindByHostName", "nas").Once().Return(nil, nil)
+				mocks.records.On("FindByName", "nas.lan").Once().Return(&NasRecord, nil)
+				mocks.repository.On("Save", &ValidCname).Once().Return(nil)
+				mocks.repository.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "InsertTargetQualifiedHost",
+			method: Insert,
+			on: func(mocks *serviceMocks) {
+				mocks.freeAlias("files.lan")
+				mocks.repository.On("FindByAlias", "nas.lan").Once().Return(nil, nil)
+				mocks.hosts.On("FindByHostName", "nas.lan").Once().Return(nil, nil)
+				mocks.hosts.On("FindByHostName", "nas").Once().Return(&NasHost, nil)
+				mocks.repository.On("Save", &ValidCname).Once().Return(nil)
+				mocks.repository.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "InsertTargetThroughCname",
+			method: Insert,
+			on: func(mocks *serviceMocks) {
+				mocks.freeAlias("files.lan")
+				mocks.repository.On("FindByAlias", "nas.lan").Once().Return(&model.Cname{Aliases: []string{"nas.lan"}, Target: "storage"}, nil)
+				mocks.repository.On("FindByAlias", "storage").Once().Return(nil, nil)
+				mocks.hosts.On("FindByHostName", "storage").Once().Return(&NasHost, nil)
+				mocks.repository.On("Save", &ValidCname).Once().Return(nil)
+				mocks.repository.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "InsertUnresolvableTarget",
+			method: Insert,
+			on: func(mocks *serviceMocks) {
+				mocks.freeAlias("files.lan")
+				mocks.repository.On("FindByAlias", "nas.lan").Once().Return(nil, nil)
+				mocks.hosts.On("FindByHostName", mock.Anything).Twice().Return(nil, nil)
+				mocks.records.On("FindByName", "nas.lan").Once().Return(&NasAddress, nil)
+				mocks.repository.On("Save", &ValidCname).Once().Return(nil)
+				mocks.repository.On("Transaction").Once().Return(nil)
+			},
+			expectedWarnings: []string{fmt.Sprintf(UnresolvableTargetWarning, "nas.lan")},
+		},
+		{
+			name:   "InsertDuplicatedAlias",
+			method: Insert,
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByAlias", "files.lan").Once().Return(&OtherCname, nil)
+			},
+			expectedErr: &DuplicatedEntryError{Alias: "files.lan", Cname: &OtherCname},
+		},
+		{
+			name:   "InsertHostNameClash",
+			method: Insert,
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByAlias", "files.lan").Once().Return(nil, nil)
+				mocks.hosts.On("FindByHostName", "files.lan").Once().Return(&NasHost, nil)
+			},
+			expectedErr: &NameClashError{Alias: "files.lan", Host: &NasHost},
+		},
+		{
+			name:   "InsertRecordNameClash",
+			method: Insert,
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByAlias", "files.lan").Once().Return(nil, nil)
+				mocks.freeHostName("files.lan")
+				mocks.records.On("FindByName", "files.lan").Once().Return(&NasRecord, nil)
+			},
+			expectedErr: &NameClashError{Alias: "files.lan", Record: &NasRecord},
+		},
+		{
+			// dnsmasq answers the static hosts qualified by its domain too
+			name:   "InsertQualifiedHostNameClash",
+			method: func(service Service) ([]string, error) { return service.Insert(&LoopCname) },
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByAlias", "nas.lan").Once().Return(nil, nil)
+				mocks.hosts.On("FindByHostName", "nas.lan").Once().Return(nil, nil)
+				mocks.hosts.On("FindByHostName", "nas").Once().Return(&NasHost, nil)
+			},
+			expectedErr: &NameClashError{Alias: "nas.lan", Host: &NasHost},
+		},
+		{
+			// Only the dnsmasq domain qualifies the static hosts
+			name:   "InsertForeignDomainAlias",
+			method: func(service Service) ([]string, error) { return service.Insert(&ForeignDomainCname) },
+			on: func(mocks *serviceMocks) {
+				mocks.freeAlias("nas.example.org")
+				mocks.repository.On("FindByAlias", "nas.lan").Once().Return(nil, nil)
+				mocks.hosts.On("FindByHostName", "nas.lan").Once().Return(nil, nil)
+				mocks.hosts.On("FindByHostName", "nas").Once().Return(&NasHost, nil)
+				mocks.repository.On("Save", &ForeignDomainCname).Once().Return(nil)
+				mocks.repository.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "InsertLoop",
+			method: Insert,
+			on: func(mocks *serviceMocks) {
+				mocks.freeAlias("files.lan")
+				mocks.repository.On("FindByAlias", "nas.lan").Once().Return(&LoopCname, nil)
+			},
+			expectedErr: &LoopError{Chain: []string{"files.lan", "nas.lan", "files.lan"}},
+		},
+		{
+			name:        "InsertInvalidCname",
+			method:      func(service Service) ([]string, error) { return service.Insert(&InvalidCname) },
+			on:          func(mocks *serviceMocks) {},
+			expectedErr: &errkind.ValidationError{Err: InvalidCname.Validate()},
+		},
+		{
+			name:   "UpdateSuccess",
+			method: Update,
+			on: func(mocks *serviceMocks) {
+				// The alias is still answered by the CNAME being replaced
+				mocks.repository.On("FindByAlias", "files.lan").Twice().Return(&OldCname, nil)
+				mocks.freeHostName("files.lan")
+				mocks.records.On("FindByName", "files.lan").Once().Return(nil, nil)
+				mocks.repository.On("FindByAlias", "nas.lan").Once().Return(nil, nil)
+				mocks.hosts.On("FindByHostName", "nas.lan").Once().Return(&NasHost, nil)
+				mocks.repository.On("ReplaceByAlias", "files.lan", &ValidCname).Once().Return(nil)
+				mocks.repository.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "UpdateNotFound",
+			method: Update,
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByAlias", "files.lan").Once().Return(nil, nil)
+			},
+			expectedErr: &NotFoundError{Alias: "files.lan"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := &serviceMocks{
+				repository: &cnamemock.RepositoryMock{},
+				hosts:      &hostmock.RepositoryMock{},
+				records:    &dnsmock.RepositoryMock{},
+			}
+			test.on(mocks)
+
+			service := NewService(mocks.repository, mocks.hosts, mocks.records, dnsmasq.NoReload(), setUpConfDir(t))
+			warnings, err := test.method(service)
+			assert.Equal(t, test.expectedErr, err, "unexpected error")
+			assert.Equal(t, test.expectedWarnings, warnings, "unexpected warnings")
+			mocks.repository.AssertExpectations(t)
+			mocks.hosts.AssertExpectations(t)
+			mocks.records.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCnameServiceRemove(t *testing.T) {
+	reloadErr := &dnsmasq.ReloadError{Err: errors.New("exit status 1")}
+	repositoryMock := &cnamemock.RepositoryMock{}
+	repositoryMock.On("DeleteByAlias", "files.lan").Once().Return(&ValidCname, nil)
+	repositoryMock.On("DeleteByAlias", "www.lan").Once().Return(nil, nil)
+	repositoryMock.On("Transaction").Twice().Return(nil)
+	reloaderMock := &dnsmasqmock.ReloaderMock{}
+	reloaderMock.On("Reload").Once().Return(reloadErr)
+
+	service := NewService(repositoryMock, &hostmock.RepositoryMock{}, &dnsmock.RepositoryMock{}, reloaderMock, setUpConfDir(t))
+	cname, err := service.RemoveByAlias("files.lan")
+	assert.Equal(t, &ValidCname, cname, "RemoveByAlias() did NOT return the removed CNAME")
+	assert.Equal(t, reloadErr, err, "RemoveByAlias() returned an unexpected error")
+
+	cname, err = service.RemoveByAlias("www.lan")
+	assert.NoError(t, err, "RemoveByAlias() returned an unexpected error")
+	assert.Nil(t, cname, "RemoveByAlias() returned an unexpected CNAME")
+	repositoryMock.AssertExpectations(t)
+	reloaderMock.AssertExpectations(t)
+}
+
+func TestCnameServiceErrorKinds(t *testing.T) {
+	assert.ErrorIs(t, &NameClashError{Alias: "nas", Host: &NasHost}, errkind.ErrDuplicate, "a NameClashError is NOT an errkind.ErrDuplicate")
+	assert.ErrorIs(t, &LoopError{Chain: []string{"a", "b", "a"}}, errkind.ErrValidation, "a LoopError is NOT an errkind.ErrValidation")
+	assert.Equal(t, "CNAME loop: a -> b -> a", LoopError{Chain: []string{"a", "b", "a"}}.Error(), "unexpected LoopError message")
+}
@@ -0,0 +1,55 @@
+package dhcp
+
+import (
+	"fmt"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// DuplicatedEntryError is returned when there is already a range with the key of a new range.
+type DuplicatedEntryError struct {
+	// The range that already has the key
+	Range *model.DhcpRange
+}
+
+const duplicatedEntryErrorMessage = "Duplicated DHCP range: %s"
+
+func (e DuplicatedEntryError) Error() string {
+	return fmt.Sprintf(duplicatedEntryErrorMessage, e.Range.Key())
+}
+
+func (e DuplicatedEntryError) Is(target error) bool {
+	return target == errkind.ErrDuplicate
+}
+
+// OverlapError is returned when a new range claims addresses of another range, see model.DhcpRange.Overlaps.
+type OverlapError struct {
+	// The range whose addresses are claimed, which may be kept in another file of the conf-dir
+	Range *model.DhcpRange
+}
+
+const overlapErrorMessage = "The DHCP range overlaps the range: %s"
+
+func (e OverlapError) Error() string {
+	return fmt.Sprintf(overlapErrorMessage, e.Range.Key())
+}
+
+func (e OverlapError) Is(target error) bool {
+	return target == errkind.ErrDuplicate
+}
+
+// NotFoundError is returned when the range to be changed does not exist.
+type NotFoundError struct {
+	Key model.DhcpRangeKey
+}
+
+const notFoundErrorMessage = "No DHCP range found with key: %s"
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf(notFoundErrorMessage, e.Key)
+}
+
+func (e NotFoundError) Is(target error) bool {
+	return target == errkind.ErrNotFound
+}
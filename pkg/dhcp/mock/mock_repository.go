@@ -0,0 +1,64 @@
+package dhcpmock
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type RepositoryMock struct {
+	mock.Mock
+}
+
+func (m *RepositoryMock) DeleteByKey(key model.DhcpRangeKey) (*model.DhcpRange, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DhcpRange), args.Error(1)
+}
+
+func (m *RepositoryMock) FindAll() (*[]model.DhcpRange, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*[]model.DhcpRange), args.Error(1)
+}
+
+func (m *RepositoryMock) FindByKey(key model.DhcpRangeKey) (*model.DhcpRange, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DhcpRange), args.Error(1)
+}
+
+func (m *RepositoryMock) FindOthers() ([]model.DhcpRange, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.DhcpRange), args.Error(1)
+}
+
+func (m *RepositoryMock) ReplaceByKey(key model.DhcpRangeKey, dhcpRange *model.DhcpRange) error {
+	args := m.Called(key, dhcpRange)
+	return args.Error(0)
+}
+
+func (m *RepositoryMock) Save(dhcpRange *model.DhcpRange) error {
+	args := m.Called(dhcpRange)
+	return args.Error(0)
+}
+
+// Transaction runs fn against the mock itself, so the calls made within the transaction are expected as any
+// other. The "Transaction" call stands for the commit, it is only made when fn succeeds.
+func (m *RepositoryMock) Transaction(fn func(tx model.DhcpRangeTransaction) error) error {
+	err := fn(m)
+	if err != nil {
+		return err
+	}
+
+	args := m.Called()
+	return args.Error(0)
+}
@@ -0,0 +1,51 @@
+package dhcpmock
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type ServiceMock struct {
+	mock.Mock
+}
+
+func (m *ServiceMock) Insert(dhcpRange *model.DhcpRange) error {
+	args := m.Called(dhcpRange)
+	return args.Error(0)
+}
+
+func (m *ServiceMock) Update(key model.DhcpRangeKey, dhcpRange *model.DhcpRange) ([]string, error) {
+	args := m.Called(key, dhcpRange)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *ServiceMock) FetchAll() (*[]model.DhcpRange, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*[]model.DhcpRange), args.Error(1)
+}
+
+func (m *ServiceMock) FetchByKey(key model.DhcpRangeKey) (*model.DhcpRange, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DhcpRange), args.Error(1)
+}
+
+func (m *ServiceMock) RemoveByKey(key model.DhcpRangeKey) (*model.DhcpRange, []string, error) {
+	args := m.Called(key)
+	var warnings []string
+	if args.Get(1) != nil {
+		warnings = args.Get(1).([]string)
+	}
+	if args.Get(0) == nil {
+		return nil, warnings, args.Error(2)
+	}
+	return args.Get(0).(*model.DhcpRange), warnings, args.Error(2)
+}
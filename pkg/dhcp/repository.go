@@ -0,0 +1,155 @@
+package dhcp
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"log/slog"
+)
+
+type Repository interface {
+	DeleteByKey(key model.DhcpRangeKey) (*model.DhcpRange, error)
+	FindAll() (*[]model.DhcpRange, error)
+	FindByKey(key model.DhcpRangeKey) (*model.DhcpRange, error)
+	// FindOthers returns the ranges of the other files of the conf-dir, which are edited by hand. The ranges that
+	// could not be parsed are skipped.
+	FindOthers() ([]model.DhcpRange, error)
+	Save(dhcpRange *model.DhcpRange) error
+	// Transaction runs fn as a single unit of work, holding the exclusive lock of the ranges file. The changes made
+	// by fn are written at once when it returns nil, and thrown away otherwise.
+	Transaction(fn func(tx model.DhcpRangeTransaction) error) error
+}
+
+type repository struct {
+	document *storage.DocumentRepository[model.DhcpRange]
+	confDir  string
+}
+
+// NewRepository returns the Repository of the DHCP ranges kept in the given file, which is created by the first
+// change when it does not exist yet. The ranges of the other files of the conf-dir are only read.
+func NewRepository(rangesFilePath string, confDir string, options storage.Options) Repository {
+	return &repository{
+		document: storage.NewDocumentRepository(rangesFilePath, options, storage.EntryHooks[model.DhcpRange]{
+			Name: "DHCP range",
+			Is:   model.IsDhcpRange,
+			Parse: func(line string) (model.DhcpRange, error) {
+				dhcpRange := model.DhcpRange{}
+				err := dhcpRange.FromConfig(line)
+				return dhcpRange, err
+			},
+			Format: (*model.DhcpRange).ToConfig,
+			Keys: func(dhcpRange *model.DhcpRange) []string {
+				return []string{dhcpRange.Key().String()}
+			},
+		}),
+		confDir: confDir,
+	}
+}
+
+func (r *repository) FindAll() (*[]model.DhcpRange, error) {
+	entries, err := r.document.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := entries.All()
+	return &ranges, nil
+}
+
+func (r *repository) FindByKey(key model.DhcpRangeKey) (*model.DhcpRange, error) {
+	entries, err := r.document.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	_, dhcpRange := entries.Lookup(key.String())
+	return dhcpRange, nil
+}
+
+func (r *repository) FindOthers() ([]model.DhcpRange, error) {
+	values, err := dnsmasq.ReadOptionsExcept(r.confDir, "dhcp-range", r.document.Path())
+	if err != nil {
+		slog.Error("Error reading the DHCP ranges of the conf-dir",
+			slog.String("confDir", r.confDir),
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	ranges := []model.DhcpRange{}
+	for _, value := range values {
+		dhcpRange := model.DhcpRange{}
+		err := dhcpRange.FromConfig("dhcp-range=" + value)
+		if err != nil {
+			slog.Warn("Ignoring a DHCP range that could not be parsed",
+				slog.String("confDir", r.confDir),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		ranges = append(ranges, dhcpRange)
+	}
+
+	return ranges, nil
+}
+
+func (r *repository) Save(dhcpRange *model.DhcpRange) error {
+	return r.Transaction(func(tx model.DhcpRangeTransaction) error {
+		return tx.Save(dhcpRange)
+	})
+}
+
+// DeleteByKey removes the range with the given key, returning it even if the change could not be written.
+func (r *repository) DeleteByKey(key model.DhcpRangeKey) (*model.DhcpRange, error) {
+	var deleted *model.DhcpRange
+	err := r.Transaction(func(tx model.DhcpRangeTransaction) error {
+		var err error
+		deleted, err = tx.DeleteByKey(key)
+		return err
+	})
+
+	return deleted, err
+}
+
+func (r *repository) Transaction(fn func(tx model.DhcpRangeTransaction) error) error {
+	return r.document.Transaction(func(tx *storage.DocumentTransaction[model.DhcpRange]) error {
+		return fn(&fileTransaction{tx})
+	})
+}
+
+// fileTransaction looks the ranges up by key in a storage.DocumentTransaction.
+type fileTransaction struct {
+	*storage.DocumentTransaction[model.DhcpRange]
+}
+
+func (tx *fileTransaction) FindAll() (*[]model.DhcpRange, error) {
+	ranges := tx.Entries().All()
+	return &ranges, nil
+}
+
+func (tx *fileTransaction) FindByKey(key model.DhcpRangeKey) (*model.DhcpRange, error) {
+	_, dhcpRange := tx.Entries().Lookup(key.String())
+	return dhcpRange, nil
+}
+
+func (tx *fileTransaction) Save(dhcpRange *model.DhcpRange) error {
+	return tx.Append(dhcpRange)
+}
+
+func (tx *fileTransaction) ReplaceByKey(key model.DhcpRangeKey, dhcpRange *model.DhcpRange) error {
+	i, _ := tx.Entries().Lookup(key.String())
+	if i < 0 {
+		return nil
+	}
+
+	return tx.Replace(i, dhcpRange)
+}
+
+func (tx *fileTransaction) DeleteByKey(key model.DhcpRangeKey) (*model.DhcpRange, error) {
+	i, dhcpRange := tx.Entries().Lookup(key.String())
+	if dhcpRange == nil {
+		return nil, nil
+	}
+
+	return dhcpRange, tx.Remove(i)
+}
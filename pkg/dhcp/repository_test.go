@@ -0,0 +1,156 @@
+package dhcp
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var AllRanges = []model.DhcpRange{
+	{SetTag: "lan", Start: net.ParseIP("192.168.1.100"), End: net.ParseIP("192.168.1.200"), Netmask: net.ParseIP("255.255.255.0"), LeaseTime: "12h"},
+	{Start: net.ParseIP("192.168.2.0"), Modes: []string{"static"}},
+	{Start: net.ParseIP("2001:db8::100"), End: net.ParseIP("2001:db8::1ff"), Modes: []string{"slaac"}, PrefixLength: 64},
+	{MatchTags: []string{"guest"}, Start: net.ParseIP("::"), Interface: "eth1", Modes: []string{"ra-stateless"}},
+}
+
+var NewRange = model.DhcpRange{Start: net.ParseIP("10.0.0.10"), End: net.ParseIP("10.0.0.50"), LeaseTime: "1h"}
+
+const (
+	AllRangesFileContent = `# LAN
+dhcp-range=set:lan,192.168.1.100,192.168.1.200,255.255.255.0,12h
+dhcp-range=192.168.2.0,static
+dhcp-option=option:router,192.168.1.1
+dhcp-range=2001:db8::100,2001:db8::1ff,slaac,64
+dhcp-range=tag:guest,::,constructor:eth1,ra-stateless
+`
+	AddedRangeFileContent = AllRangesFileContent + `dhcp-range=10.0.0.10,10.0.0.50,1h
+`
+	DeletedRangeFileContent = `# LAN
+dhcp-range=192.168.2.0,static
+dhcp-option=option:router,192.168.1.1
+dhcp-range=2001:db8::100,2001:db8::1ff,slaac,64
+dhcp-range=tag:guest,::,constructor:eth1,ra-stateless
+`
+	InvalidRangeFileContent = AllRangesFileContent + `dhcp-range=192.168.3.200,192.168.3.100
+`
+)
+
+func setUpRangesFile(t *testing.T, content string) string {
+	fileName := filepath.Join(t.TempDir(), "03-dhcp-ranges.conf")
+	require.NoError(t, os.WriteFile(fileName, []byte(content), 0644), "Failed to initialize the ranges file")
+	return fileName
+}
+
+func assertFileContent(t *testing.T, expectedFileContent string, fileName string) {
+	actualFileData, err := os.ReadFile(fileName)
+	require.NoError(t, err, "Failed to open test file for validation")
+	assert.Equal(t, expectedFileContent, string(actualFileData), "Ranges file doesn't match")
+}
+
+func TestRangeRepositoryFind(t *testing.T) {
+	fileName := setUpRangesFile(t, AllRangesFileContent)
+	repository := NewRepository(fileName, filepath.Dir(fileName), storage.Options{})
+
+	ranges, err := repository.FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.Equal(t, AllRanges, *ranges, "FindAll() returned unexpected ranges")
+
+	dhcpRange, err := repository.FindByKey(model.DhcpRangeKey{Start: net.ParseIP("2001:DB8:0::100")})
+	assert.NoError(t, err, "FindByKey() returned an unexpected error")
+	assert.Equal(t, &AllRanges[2], dhcpRange, "FindByKey() returned an unexpected range")
+
+	dhcpRange, err = repository.FindByKey(model.DhcpRangeKey{Start: net.ParseIP("::"), Interface: "eth1"})
+	assert.NoError(t, err, "FindByKey() returned an unexpected error")
+	assert.Equal(t, &AllRanges[3], dhcpRange, "FindByKey() returned an unexpected range")
+
+	dhcpRange, err = repository.FindByKey(model.DhcpRangeKey{Start: net.ParseIP("::"), Interface: "eth0"})
+	assert.NoError(t, err, "FindByKey() returned an unexpected error")
+	assert.Nil(t, dhcpRange, "FindByKey() found a range built on another interface")
+	assertFileContent(t, AllRangesFileContent, fileName)
+}
+
+func TestRangeRepositoryFindOthers(t *testing.T) {
+	fileName := setUpRangesFile(t, AllRangesFileContent)
+	confDir := filepath.Dir(fileName)
+	content := "dhcp-range=10.0.0.10,10.0.0.50\ndhcp-range=not-a-range\n"
+	require.NoError(t, os.WriteFile(filepath.Join(confDir, "01-dhcp.conf"), []byte(content), 0644), "Failed to create conf file")
+
+	others, err := NewRepository(fileName, confDir, storage.Options{}).FindOthers()
+	assert.NoError(t, err, "FindOthers() returned an unexpected error")
+	assert.Equal(t, []model.DhcpRange{{Start: net.ParseIP("10.0.0.10"), End: net.ParseIP("10.0.0.50")}}, others, "FindOthers() returned unexpected ranges")
+}
+
+func TestRangeRepositoryMissingFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "03-dhcp-ranges.conf")
+	repository := NewRepository(fileName, filepath.Join(t.TempDir(), "missing"), storage.Options{})
+
+	ranges, err := repository.FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.Empty(t, *ranges, "FindAll() returned unexpected ranges")
+
+	others, err := repository.FindOthers()
+	assert.NoError(t, err, "FindOthers() returned an unexpected error")
+	assert.Empty(t, others, "FindOthers() returned unexpected ranges")
+
+	assert.NoError(t, repository.Save(&NewRange), "Save() returned an unexpected error")
+	assertFileContent(t, "dhcp-range=10.0.0.10,10.0.0.50,1h", fileName)
+}
+
+func TestRangeRepositorySaveDelete(t *testing.T) {
+	fileName := setUpRangesFile(t, AllRangesFileContent)
+	repository := NewRepository(fileName, filepath.Dir(fileName), storage.Options{})
+
+	assert.NoError(t, repository.Save(&NewRange), "Save() returned an unexpected error")
+	assertFileContent(t, AddedRangeFileContent, fileName)
+
+	deleted, err := repository.DeleteByKey(NewRange.Key())
+	assert.NoError(t, err, "DeleteByKey() returned an unexpected error")
+	assert.Equal(t, &NewRange, deleted, "DeleteByKey() returned an unexpected range")
+	assertFileContent(t, AllRangesFileContent, fileName)
+
+	deleted, err = repository.DeleteByKey(AllRanges[0].Key())
+	assert.NoError(t, err, "DeleteByKey() returned an unexpected error")
+	assert.Equal(t, &AllRanges[0], deleted, "DeleteByKey() returned an unexpected range")
+	assertFileContent(t, DeletedRangeFileContent, fileName)
+
+	deleted, err = repository.DeleteByKey(AllRanges[0].Key())
+	assert.NoError(t, err, "DeleteByKey() returned an unexpected error")
+	assert.Nil(t, deleted, "DeleteByKey() returned an unexpected range")
+	assertFileContent(t, DeletedRangeFileContent, fileName)
+}
+
+func TestRangeRepositoryTransactionRollback(t *testing.T) {
+	fileName := setUpRangesFile(t, AllRangesFileContent)
+	repository := NewRepository(fileName, filepath.Dir(fileName), storage.Options{})
+	rollback := errors.New("rollback")
+
+	err := repository.Transaction(func(tx model.DhcpRangeTransaction) error {
+		if _, err := tx.DeleteByKey(AllRanges[1].Key()); err != nil {
+			return err
+		}
+		dhcpRange, err := tx.FindByKey(AllRanges[1].Key())
+		assert.NoError(t, err, "FindByKey() returned an unexpected error")
+		assert.Nil(t, dhcpRange, "FindByKey() did NOT see the deletion")
+		return rollback
+	})
+	assert.ErrorIs(t, err, rollback, "Transaction() returned an unexpected error")
+	assertFileContent(t, AllRangesFileContent, fileName)
+}
+
+func TestRangeRepositoryParseMode(t *testing.T) {
+	fileName := setUpRangesFile(t, InvalidRangeFileContent)
+
+	_, err := NewRepository(fileName, filepath.Dir(fileName), storage.Options{}).FindAll()
+	assert.Error(t, err, "FindAll() did NOT returned an error in the strict mode")
+
+	ranges, err := NewRepository(fileName, filepath.Dir(fileName), storage.Options{Lenient: true}).FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error in the lenient mode")
+	assert.Equal(t, AllRanges, *ranges, "FindAll() returned unexpected ranges")
+}
@@ -0,0 +1,230 @@
+package dhcp
+
+import (
+	"fmt"
+	"net"
+	"slices"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"log/slog"
+)
+
+// Update and RemoveByKey return warnings about the static hosts the change leaves out of every served subnet.
+type Service interface {
+	Insert(dhcpRange *model.DhcpRange) error
+	Update(key model.DhcpRangeKey, dhcpRange *model.DhcpRange) ([]string, error)
+	FetchAll() (*[]model.DhcpRange, error)
+	FetchByKey(key model.DhcpRangeKey) (*model.DhcpRange, error)
+	RemoveByKey(key model.DhcpRangeKey) (*model.DhcpRange, []string, error)
+}
+
+type service struct {
+	repository Repository
+	hosts      host.Repository
+	reloader   dnsmasq.Reloader
+}
+
+const OrphanedHostWarning = "The static host %s (%s) is no longer in a subnet served by a DHCP range. dnsmasq " +
+	"does not hand its reservation out until another range serves it."
+
+// NewService returns the DHCP ranges Service. The ranges must not overlap, including the ones kept by hand in the
+// other files of the conf-dir, and the changes are checked against the static hosts they serve. The reloader is
+// triggered after every change written to the ranges file, so dnsmasq picks it up.
+//
+// Every error matches one of the error kinds (errkind.ErrNotFound, errkind.ErrDuplicate, errkind.ErrValidation,
+// errkind.ErrStorageUnavailable or errkind.ErrLockTimeout), except the dnsmasq.ReloadError of a change that was saved.
+func NewService(repository Repository, hosts host.Repository, reloader dnsmasq.Reloader) Service {
+	return &service{
+		repository: repository,
+		hosts:      hosts,
+		reloader:   reloader,
+	}
+}
+
+// Insert adds a new range. It fails with a DuplicatedEntryError if there is already a range with its key, and
+// with an OverlapError if it claims addresses of another range.
+func (s *service) Insert(dhcpRange *model.DhcpRange) error {
+	if err := dhcpRange.Validate(); err != nil {
+		return &errkind.ValidationError{Err: err}
+	}
+
+	err := s.repository.Transaction(func(tx model.DhcpRangeTransaction) error {
+		if _, err := s.check(tx, dhcpRange, nil); err != nil {
+			return err
+		}
+		return tx.Save(dhcpRange)
+	})
+	if err != nil {
+		return errkind.Classify(err)
+	}
+
+	return s.reloader.Reload()
+}
+
+// Update replaces the existing range with the given key, which may change along with the range. It fails with a
+// NotFoundError if there is no such range, and as Insert does if the new range is not valid.
+func (s *service) Update(key model.DhcpRangeKey, dhcpRange *model.DhcpRange) ([]string, error) {
+	if err := dhcpRange.Validate(); err != nil {
+		return nil, &errkind.ValidationError{Err: err}
+	}
+
+	var warnings []string
+	err := s.repository.Transaction(func(tx model.DhcpRangeTransaction) error {
+		existing, err := tx.FindByKey(key)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return &NotFoundError{Key: key}
+		}
+
+		others, err := s.check(tx, dhcpRange, existing)
+		if err != nil {
+			return err
+		}
+		warnings, err = s.orphans(existing, append(others, *dhcpRange))
+		if err != nil {
+			return err
+		}
+		return tx.ReplaceByKey(key, dhcpRange)
+	})
+	if err != nil {
+		return nil, errkind.Classify(err)
+	}
+
+	return warnings, s.reloader.Reload()
+}
+
+// check ensures that no other range than the existing one (nil for a new range) has the key of the given one, nor
+// shares addresses with it. It returns the other ranges, from the transaction and from the conf-dir, without the
+// existing one.
+func (s *service) check(tx model.DhcpRangeTransaction, dhcpRange *model.DhcpRange, existing *model.DhcpRange) ([]model.DhcpRange, error) {
+	sameKeyRange, err := tx.FindByKey(dhcpRange.Key())
+	if err != nil {
+		return nil, err
+	}
+	if sameKeyRange != nil && (existing == nil || sameKeyRange.Key().String() != existing.Key().String()) {
+		return nil, &DuplicatedEntryError{Range: sameKeyRange}
+	}
+
+	others, err := s.otherRanges(tx)
+	if err != nil {
+		return nil, err
+	}
+	// The ranges of the transaction come first, so the existing one is the first with its key
+	i := slices.IndexFunc(others, func(other model.DhcpRange) bool {
+		return existing != nil && other.Key().String() == existing.Key().String()
+	})
+	if i >= 0 {
+		others = slices.Delete(others, i, i+1)
+	}
+	for _, other := range others {
+		if dhcpRange.Overlaps(other) {
+			return nil, &OverlapError{Range: &other}
+		}
+	}
+
+	return others, nil
+}
+
+// otherRanges returns the ranges of the transaction, followed by the ones of the other files of the conf-dir.
+func (s *service) otherRanges(tx model.DhcpRangeTransaction) ([]model.DhcpRange, error) {
+	ranges, err := tx.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	others, err := s.repository.FindOthers()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(*ranges, others...), nil
+}
+
+// orphans warns about the static hosts in the subnet of the removed range that none of the remaining ranges
+// serves. The subnets of the ranges built on an interface, and of the IPv4 ranges without netmask, are unknown, so
+// their removal is not checked.
+func (s *service) orphans(removed *model.DhcpRange, remaining []model.DhcpRange) ([]string, error) {
+	if removed.Interface != "" {
+		return nil, nil
+	}
+	if removed.Subnet() == nil {
+		slog.Info("Skipping the orphaned hosts check of a DHCP range without netmask, whose subnet depends on the interface",
+			slog.String("start", removed.Start.String()),
+		)
+		return nil, nil
+	}
+
+	hosts, err := s.hosts.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	for _, host := range *hosts {
+		for _, ip := range []net.IP{host.IPAddress, host.IPv6Address} {
+			if ip != nil && serves([]model.DhcpRange{*removed}, ip) && !serves(remaining, ip) {
+				warnings = append(warnings, fmt.Sprintf(OrphanedHostWarning, host.HostName, ip))
+			}
+		}
+	}
+
+	return warnings, nil
+}
+
+// serves reports whether one of the ranges serves the subnet of the address. An address may be served by any of the
+// ranges whose subnet is unknown: the IPv6 ranges built on an interface and the IPv4 ranges without netmask.
+func serves(ranges []model.DhcpRange, ip net.IP) bool {
+	for _, dhcpRange := range ranges {
+		if dhcpRange.IsIPv4() != (ip.To4() != nil) {
+			continue
+		}
+		subnet := dhcpRange.Subnet()
+		if dhcpRange.Interface != "" || subnet == nil || subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *service) FetchAll() (*[]model.DhcpRange, error) {
+	ranges, err := s.repository.FindAll()
+	return ranges, errkind.Classify(err)
+}
+
+func (s *service) FetchByKey(key model.DhcpRangeKey) (*model.DhcpRange, error) {
+	dhcpRange, err := s.repository.FindByKey(key)
+	return dhcpRange, errkind.Classify(err)
+}
+
+// RemoveByKey deletes the range with the given key, and reloads dnsmasq when a range was actually removed. The
+// removed range is returned even if the reload fails, since it is already gone from the ranges file.
+func (s *service) RemoveByKey(key model.DhcpRangeKey) (*model.DhcpRange, []string, error) {
+	var removed *model.DhcpRange
+	var warnings []string
+	err := s.repository.Transaction(func(tx model.DhcpRangeTransaction) error {
+		var err error
+		removed, err = tx.DeleteByKey(key)
+		if err != nil || removed == nil {
+			return err
+		}
+
+		remaining, err := s.otherRanges(tx)
+		if err != nil {
+			return err
+		}
+		warnings, err = s.orphans(removed, remaining)
+		return err
+	})
+	if err != nil {
+		return nil, nil, errkind.Classify(err)
+	}
+	if removed == nil {
+		return nil, nil, nil
+	}
+
+	return removed, warnings, s.reloader.Reload()
+}
@@ -0,0 +1,269 @@
+package dhcp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	dhcpmock "github.com/gringolito/dnsmasq-manager/pkg/dhcp/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	dnsmasqmock "github.com/gringolito/dnsmasq-manager/pkg/dnsmasq/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	hostmock "github.com/gringolito/dnsmasq-manager/pkg/host/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+var LanRange = model.DhcpRange{Start: net.ParseIP("192.168.1.100"), End: net.ParseIP("192.168.1.200"), Netmask: net.ParseIP("255.255.255.0")}
+var MovedRange = model.DhcpRange{Start: net.ParseIP("192.168.1.50"), End: net.ParseIP("192.168.1.99"), Netmask: net.ParseIP("255.255.255.0")}
+var OtherSubnetRange = model.DhcpRange{Start: net.ParseIP("192.168.5.100"), End: net.ParseIP("192.168.5.200"), Netmask: net.ParseIP("255.255.255.0")}
+var OverlappingRange = model.DhcpRange{Start: net.ParseIP("192.168.1.150"), End: net.ParseIP("192.168.1.250")}
+var StaticRange = model.DhcpRange{Start: net.ParseIP("192.168.1.0"), Modes: []string{"static"}, Netmask: net.ParseIP("255.255.255.0")}
+var GuestRange = model.DhcpRange{Start: net.ParseIP("::"), Interface: "eth1", Modes: []string{"ra-stateless"}}
+var InvalidRange = model.DhcpRange{Start: net.ParseIP("192.168.1.200"), End: net.ParseIP("192.168.1.100")}
+var NasHost = model.StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("192.168.1.10"), IPv6Address: net.ParseIP("2001:db8::10"), HostName: "nas"}
+var PrinterHost = model.StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:dd:ee:ff"), IPAddress: net.ParseIP("10.0.0.10"), HostName: "printer"}
+
+var NasOrphanedWarning = fmt.Sprintf(OrphanedHostWarning, "nas", "192.168.1.10")
+
+type serviceMocks struct {
+	repository *dhcpmock.RepositoryMock
+	hosts      *hostmock.RepositoryMock
+}
+
+// ranges expects the lookups of the other ranges, in the ranges file and in the conf-dir.
+func (m *serviceMocks) ranges(ranges []model.DhcpRange, others []model.DhcpRange) {
+	m.repository.On("FindAll").Once().Return(&ranges, nil)
+	m.repository.On("FindOthers").Once().Return(others, nil)
+}
+
+func TestRangeServiceChanges(t *testing.T) {
+	Insert := func(service Service) ([]string, error) { return nil, service.Insert(&LanRange) }
+	Update := func(dhcpRange *model.DhcpRange) func(service Service) ([]string, error) {
+		return func(service Service) ([]string, error) { return service.Update(LanRange.Key(), dhcpRange) }
+	}
+	Remove := func(key model.DhcpRangeKey) func(service Service) ([]string, error) {
+		return func(service Service) ([]string, error) {
+			dhcpRange, warnings, err := service.RemoveByKey(key)
+			if err == nil && dhcpRange == nil {
+				return nil, errors.New("nothing removed")
+			}
+			return warnings, err
+		}
+	}
+	tailRange := model.DhcpRange{Start: net.ParseIP("192.168.1.240"), End: net.ParseIP("192.168.1.254")}
+
+	var testCases = []struct {
+		name             string
+		method           func(service Service) ([]string, error)
+		on               func(mocks *serviceMocks)
+		expectedWarnings []string
+		expectedErr      error
+	}{
+		{
+			name:   "InsertSuccess",
+			method: Insert,
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByKey", LanRange.Key()).Once().Return(nil, nil)
+				mocks.ranges([]model.DhcpRange{StaticRange, GuestRange}, []model.DhcpRange{OtherSubnetRange})
+				mocks.repository.On("Save", &LanRange).Once().Return(nil)
+				mocks.repository.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "InsertDuplicatedKey",
+			method: Insert,
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByKey", LanRange.Key()).Once().Return(&LanRange, nil)
+			},
+			expectedErr: &DuplicatedEntryError{Range: &LanRange},
+		},
+		{
+			name:   "InsertOverlap",
+			method: Insert,
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByKey", LanRange.Key()).Once().Return(nil, nil)
+				mocks.ranges([]model.DhcpRange{OverlappingRange}, []model.DhcpRange{})
+			},
+			expectedErr: &OverlapError{Range: &OverlappingRange},
+		},
+		{
+			name:   "InsertOverlapConfDir",
+			method: Insert,
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByKey", LanRange.Key()).Once().Return(nil, nil)
+				mocks.ranges([]model.DhcpRange{}, []model.DhcpRange{OverlappingRange})
+			},
+			expectedErr: &OverlapError{Range: &OverlappingRange},
+		},
+		{
+			name:        "InsertInvalidRange",
+			method:      func(service Service) ([]string, error) { return nil, service.Insert(&InvalidRange) },
+			on:          func(mocks *serviceMocks) {},
+			expectedErr: &errkind.ValidationError{Err: InvalidRange.Validate()},
+		},
+		{
+			name:   "UpdateSuccess",
+			method: Update(&MovedRange),
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByKey", LanRange.Key()).Once().Return(&LanRange, nil)
+				mocks.repository.On("FindByKey", MovedRange.Key()).Once().Return(nil, nil)
+				mocks.ranges([]model.DhcpRange{LanRange}, []model.DhcpRange{})
+				mocks.hosts.On("FindAll").Once().Return(&[]model.StaticDhcpHost{NasHost, PrinterHost}, nil)
+				mocks.repository.On("ReplaceByKey", LanRange.Key(), &MovedRange).Once().Return(nil)
+				mocks.repository.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "UpdateOrphanedHost",
+			method: Update(&OtherSubnetRange),
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByKey", LanRange.Key()).Once().Return(&LanRange, nil)
+				mocks.repository.On("FindByKey", OtherSubnetRange.Key()).Once().Return(nil, nil)
+				mocks.ranges([]model.DhcpRange{LanRange, GuestRange}, []model.DhcpRange{})
+				mocks.hosts.On("FindAll").Once().Return(&[]model.StaticDhcpHost{NasHost, PrinterHost}, nil)
+				mocks.repository.On("ReplaceByKey", LanRange.Key(), &OtherSubnetRange).Once().Return(nil)
+				mocks.repository.On("Transaction").Once().Return(nil)
+			},
+			expectedWarnings: []string{NasOrphanedWarning},
+		},
+		{
+			name:   "UpdateSubnetServedByStaticRange",
+			method: Update(&OtherSubnetRange),
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByKey", LanRange.Key()).Once().Return(&LanRange, nil)
+				mocks.repository.On("FindByKey", OtherSubnetRange.Key()).Once().Return(nil, nil)
+				mocks.ranges([]model.DhcpRange{LanRange}, []model.DhcpRange{StaticRange})
+				mocks.hosts.On("FindAll").Once().Return(&[]model.StaticDhcpHost{NasHost}, nil)
+				mocks.repository.On("ReplaceByKey", LanRange.Key(), &OtherSubnetRange).Once().Return(nil)
+				mocks.repository.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "UpdateNotFound",
+			method: Update(&MovedRange),
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByKey", LanRange.Key()).Once().Return(nil, nil)
+			},
+			expectedErr: &NotFoundError{Key: LanRange.Key()},
+		},
+		{
+			name:   "UpdateOverlap",
+			method: Update(&OverlappingRange),
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByKey", LanRange.Key()).Once().Return(&LanRange, nil)
+				mocks.repository.On("FindByKey", OverlappingRange.Key()).Once().Return(nil, nil)
+				mocks.ranges([]model.DhcpRange{LanRange, MovedRange}, []model.DhcpRange{OtherSubnetRange, tailRange})
+			},
+			expectedErr: &OverlapError{Range: &tailRange},
+		},
+		{
+			name:   "UpdateHostsUnavailable",
+			method: Update(&MovedRange),
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("FindByKey", LanRange.Key()).Once().Return(&LanRange, nil)
+				mocks.repository.On("FindByKey", MovedRange.Key()).Once().Return(nil, nil)
+				mocks.ranges([]model.DhcpRange{LanRange}, []model.DhcpRange{})
+				mocks.hosts.On("FindAll").Once().Return(nil, errors.New("an error"))
+			},
+			expectedErr: &errkind.StorageError{Err: errors.New("an error")},
+		},
+		{
+			name:   "RemoveSuccess",
+			method: Remove(LanRange.Key()),
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("DeleteByKey", LanRange.Key()).Once().Return(&LanRange, nil)
+				mocks.ranges([]model.DhcpRange{GuestRange}, []model.DhcpRange{})
+				mocks.hosts.On("FindAll").Once().Return(&[]model.StaticDhcpHost{NasHost, PrinterHost}, nil)
+				mocks.repository.On("Transaction").Once().Return(nil)
+			},
+			expectedWarnings: []string{NasOrphanedWarning},
+		},
+		{
+			name:   "RemoveRangeWithoutNetmask",
+			method: Remove(OverlappingRange.Key()),
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("DeleteByKey", OverlappingRange.Key()).Once().Return(&OverlappingRange, nil)
+				mocks.ranges([]model.DhcpRange{LanRange}, []model.DhcpRange{})
+				mocks.repository.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "RemoveConstructedRange",
+			method: Remove(GuestRange.Key()),
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("DeleteByKey", GuestRange.Key()).Once().Return(&GuestRange, nil)
+				mocks.ranges([]model.DhcpRange{LanRange}, []model.DhcpRange{})
+				mocks.repository.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "RemoveNothing",
+			method: Remove(LanRange.Key()),
+			on: func(mocks *serviceMocks) {
+				mocks.repository.On("DeleteByKey", LanRange.Key()).Once().Return(nil, nil)
+				mocks.repository.On("Transaction").Once().Return(nil)
+			},
+			expectedErr: errors.New("nothing removed"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := &serviceMocks{repository: &dhcpmock.RepositoryMock{}, hosts: &hostmock.RepositoryMock{}}
+			test.on(mocks)
+
+			service := NewService(mocks.repository, mocks.hosts, dnsmasq.NoReload())
+			warnings, err := test.method(service)
+			assert.Equal(t, test.expectedErr, err, "unexpected error")
+			assert.Equal(t, test.expectedWarnings, warnings, "unexpected warnings")
+			mocks.repository.AssertExpectations(t)
+			mocks.hosts.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRangeServiceFetch(t *testing.T) {
+	repositoryMock := &dhcpmock.RepositoryMock{}
+	repositoryMock.On("FindAll").Once().Return(&[]model.DhcpRange{LanRange, GuestRange}, nil)
+	repositoryMock.On("FindByKey", GuestRange.Key()).Once().Return(&GuestRange, nil)
+	repositoryMock.On("FindByKey", LanRange.Key()).Once().Return(nil, errors.New("an error"))
+	service := NewService(repositoryMock, &hostmock.RepositoryMock{}, dnsmasq.NoReload())
+
+	ranges, err := service.FetchAll()
+	assert.NoError(t, err, "FetchAll() returned an unexpected error")
+	assert.Equal(t, &[]model.DhcpRange{LanRange, GuestRange}, ranges, "FetchAll() returned unexpected ranges")
+
+	dhcpRange, err := service.FetchByKey(GuestRange.Key())
+	assert.NoError(t, err, "FetchByKey() returned an unexpected error")
+	assert.Equal(t, &GuestRange, dhcpRange, "FetchByKey() returned an unexpected range")
+
+	_, err = service.FetchByKey(LanRange.Key())
+	assert.ErrorIs(t, err, errkind.ErrStorageUnavailable, "FetchByKey() returned an unexpected error")
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestRangeServiceReload(t *testing.T) {
+	reloadErr := &dnsmasq.ReloadError{Err: errors.New("exit status 1")}
+	repositoryMock := &dhcpmock.RepositoryMock{}
+	repositoryMock.On("DeleteByKey", GuestRange.Key()).Once().Return(&GuestRange, nil)
+	repositoryMock.On("FindAll").Once().Return(&[]model.DhcpRange{}, nil)
+	repositoryMock.On("FindOthers").Once().Return([]model.DhcpRange{}, nil)
+	repositoryMock.On("Transaction").Once().Return(nil)
+	reloaderMock := &dnsmasqmock.ReloaderMock{}
+	reloaderMock.On("Reload").Once().Return(reloadErr)
+
+	service := NewService(repositoryMock, &hostmock.RepositoryMock{}, reloaderMock)
+	dhcpRange, _, err := service.RemoveByKey(GuestRange.Key())
+	assert.Equal(t, &GuestRange, dhcpRange, "RemoveByKey() did NOT return the removed range")
+	assert.Equal(t, reloadErr, err, "RemoveByKey() returned an unexpected error")
+	repositoryMock.AssertExpectations(t)
+	reloaderMock.AssertExpectations(t)
+}
+
+func TestRangeServiceErrorKinds(t *testing.T) {
+	assert.ErrorIs(t, OverlapError{Range: &LanRange}, errkind.ErrDuplicate, "an OverlapError is NOT an errkind.ErrDuplicate")
+	assert.ErrorIs(t, NotFoundError{Key: LanRange.Key()}, errkind.ErrNotFound, "a NotFoundError is NOT an errkind.ErrNotFound")
+}
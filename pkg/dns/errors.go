@@ -0,0 +1,40 @@
+package dns
+
+import (
+	"fmt"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// DuplicatedEntryError is returned when a name of a record is already answered by another record.
+type DuplicatedEntryError struct {
+	Name string
+	// The record already answering the name
+	Record *model.DnsRecord
+}
+
+const duplicatedEntryErrorMessage = "Duplicated DNS name: %s"
+
+func (e DuplicatedEntryError) Error() string {
+	return fmt.Sprintf(duplicatedEntryErrorMessage, e.Name)
+}
+
+func (e DuplicatedEntryError) Is(target error) bool {
+	return target == errkind.ErrDuplicate
+}
+
+// NotFoundError is returned when the record to be changed does not exist.
+type NotFoundError struct {
+	Name string
+}
+
+const notFoundErrorMessage = "No static DNS record found with name: %s"
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf(notFoundErrorMessage, e.Name)
+}
+
+func (e NotFoundError) Is(target error) bool {
+	return target == errkind.ErrNotFound
+}
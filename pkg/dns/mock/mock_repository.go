@@ -0,0 +1,56 @@
+package dnsmock
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type RepositoryMock struct {
+	mock.Mock
+}
+
+func (m *RepositoryMock) DeleteByName(name string) (*model.DnsRecord, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DnsRecord), args.Error(1)
+}
+
+func (m *RepositoryMock) FindAll() (*[]model.DnsRecord, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*[]model.DnsRecord), args.Error(1)
+}
+
+func (m *RepositoryMock) FindByName(name string) (*model.DnsRecord, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DnsRecord), args.Error(1)
+}
+
+func (m *RepositoryMock) ReplaceByName(name string, record *model.DnsRecord) error {
+	args := m.Called(name, record)
+	return args.Error(0)
+}
+
+func (m *RepositoryMock) Save(record *model.DnsRecord) error {
+	args := m.Called(record)
+	return args.Error(0)
+}
+
+// Transaction runs fn against the mock itself, so the calls made within the transaction are expected as any
+// other. The "Transaction" call stands for the commit, it is only made when fn succeeds.
+func (m *RepositoryMock) Transaction(fn func(tx model.DnsTransaction) error) error {
+	err := fn(m)
+	if err != nil {
+		return err
+	}
+
+	args := m.Called()
+	return args.Error(0)
+}
@@ -0,0 +1,44 @@
+package dnsmock
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type ServiceMock struct {
+	mock.Mock
+}
+
+func (m *ServiceMock) Insert(record *model.DnsRecord) error {
+	args := m.Called(record)
+	return args.Error(0)
+}
+
+func (m *ServiceMock) Update(name string, record *model.DnsRecord) error {
+	args := m.Called(name, record)
+	return args.Error(0)
+}
+
+func (m *ServiceMock) FetchAll() (*[]model.DnsRecord, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*[]model.DnsRecord), args.Error(1)
+}
+
+func (m *ServiceMock) FetchByName(name string) (*model.DnsRecord, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DnsRecord), args.Error(1)
+}
+
+func (m *ServiceMock) RemoveByName(name string) (*model.DnsRecord, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DnsRecord), args.Error(1)
+}
@@ -0,0 +1,131 @@
+package dns
+
+import (
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+)
+
+type Repository interface {
+	DeleteByName(name string) (*model.DnsRecord, error)
+	FindAll() (*[]model.DnsRecord, error)
+	// FindByName looks the record up regardless of the case, as DNS names are
+	FindByName(name string) (*model.DnsRecord, error)
+	Save(record *model.DnsRecord) error
+	// Transaction runs fn as a single unit of work, holding the exclusive lock of the static DNS records file.
+	// The changes made by fn are written at once when it returns nil, and thrown away otherwise.
+	Transaction(fn func(tx model.DnsTransaction) error) error
+}
+
+type repository struct {
+	document *storage.DocumentRepository[model.DnsRecord]
+}
+
+// NewRepository returns the Repository of the static DNS records kept in the given file, which is created by the
+// first change when it does not exist yet.
+func NewRepository(staticRecordsFilePath string, options storage.Options) Repository {
+	return &repository{
+		document: storage.NewDocumentRepository(staticRecordsFilePath, options, storage.EntryHooks[model.DnsRecord]{
+			Name: "static DNS record",
+			Is:   model.IsDnsRecord,
+			Parse: func(line string) (model.DnsRecord, error) {
+				record := model.DnsRecord{}
+				err := record.FromConfig(line)
+				return record, err
+			},
+			Format: (*model.DnsRecord).ToConfig,
+			Keys:   names,
+		}),
+	}
+}
+
+// names indexes the records by their lower case names.
+func names(record *model.DnsRecord) []string {
+	keys := make([]string, 0, len(record.Names))
+	for _, name := range record.Names {
+		keys = append(keys, strings.ToLower(name))
+	}
+	return keys
+}
+
+func (r *repository) FindAll() (*[]model.DnsRecord, error) {
+	entries, err := r.document.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	records := entries.All()
+	return &records, nil
+}
+
+func (r *repository) FindByName(name string) (*model.DnsRecord, error) {
+	entries, err := r.document.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	_, record := entries.Lookup(strings.ToLower(name))
+	return record, nil
+}
+
+func (r *repository) Save(record *model.DnsRecord) error {
+	return r.Transaction(func(tx model.DnsTransaction) error {
+		return tx.Save(record)
+	})
+}
+
+// DeleteByName removes the record answering the given name, returning it even if the change could not be written.
+func (r *repository) DeleteByName(name string) (*model.DnsRecord, error) {
+	var deleted *model.DnsRecord
+	err := r.Transaction(func(tx model.DnsTransaction) error {
+		var err error
+		deleted, err = tx.DeleteByName(name)
+		return err
+	})
+
+	return deleted, err
+}
+
+func (r *repository) Transaction(fn func(tx model.DnsTransaction) error) error {
+	return r.document.Transaction(func(tx *storage.DocumentTransaction[model.DnsRecord]) error {
+		return fn(&fileTransaction{tx})
+	})
+}
+
+// fileTransaction looks the records up by name in a storage.DocumentTransaction.
+type fileTransaction struct {
+	*storage.DocumentTransaction[model.DnsRecord]
+}
+
+func (tx *fileTransaction) FindAll() (*[]model.DnsRecord, error) {
+	records := tx.Entries().All()
+	return &records, nil
+}
+
+func (tx *fileTransaction) FindByName(name string) (*model.DnsRecord, error) {
+	_, record := tx.Entries().Lookup(strings.ToLower(name))
+	return record, nil
+}
+
+func (tx *fileTransaction) Save(record *model.DnsRecord) error {
+	return tx.Append(record)
+}
+
+func (tx *fileTransaction) ReplaceByName(name string, record *model.DnsRecord) error {
+	i, _ := tx.Entries().Lookup(strings.ToLower(name))
+	if i < 0 {
+		return nil
+	}
+
+	return tx.Replace(i, record)
+}
+
+func (tx *fileTransaction) DeleteByName(name string) (*model.DnsRecord, error) {
+	i, record := tx.Entries().Lookup(strings.ToLower(name))
+	if record == nil {
+		return nil, nil
+	}
+
+	return record, tx.Remove(i)
+}
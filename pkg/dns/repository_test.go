@@ -0,0 +1,140 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var AllRecords = []model.DnsRecord{
+	{Type: model.DnsRecordTypeHost, Names: []string{"nas.lan", "nas"}, IPAddress: net.ParseIP("192.168.1.10"), IPv6Address: net.ParseIP("2001:db8::10")},
+	{Type: model.DnsRecordTypeAddress, Names: []string{"ads.example"}, Answer: model.DnsRecordAnswerNull},
+	{Type: model.DnsRecordTypeAddress, Names: []string{"printer.lan"}, IPAddress: net.ParseIP("192.168.1.20")},
+}
+
+var NewRecord = model.DnsRecord{Type: model.DnsRecordTypeHost, Names: []string{"router.lan"}, IPAddress: net.ParseIP("192.168.1.1"), TTL: 300}
+
+const (
+	AllRecordsFileContent = `# Static DNS records
+host-record=nas.lan,nas,192.168.1.10,2001:db8::10
+address=/ads.example/#
+address=/printer.lan/192.168.1.20
+`
+	AddedRecordFileContent = AllRecordsFileContent + `host-record=router.lan,192.168.1.1,300
+`
+	DeletedRecordFileContent = `# Static DNS records
+address=/ads.example/#
+address=/printer.lan/192.168.1.20
+`
+	InvalidRecordFileContent = `host-record=nas.lan,192.168.1
+address=/printer.lan/192.168.1.20
+`
+)
+
+func setUpStaticRecordsFile(t *testing.T, content string) string {
+	fileName := filepath.Join(t.TempDir(), "05-dns-static-records.conf")
+	require.NoError(t, os.WriteFile(fileName, []byte(content), 0644), "Failed to initialize the static DNS records file")
+	return fileName
+}
+
+func assertFileContent(t *testing.T, expectedFileContent string, fileName string) {
+	actualFileData, err := os.ReadFile(fileName)
+	require.NoError(t, err, "Failed to open test file for validation")
+	assert.Equal(t, expectedFileContent, string(actualFileData), "Static DNS records file doesn't match")
+}
+
+func TestDnsRepositoryFind(t *testing.T) {
+	fileName := setUpStaticRecordsFile(t, AllRecordsFileContent)
+	repository := NewRepository(fileName, storage.Options{})
+
+	records, err := repository.FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.Equal(t, AllRecords, *records, "FindAll() returned unexpected records")
+
+	record, err := repository.FindByName("NAS")
+	assert.NoError(t, err, "FindByName() returned an unexpected error")
+	assert.Equal(t, &AllRecords[0], record, "FindByName() returned an unexpected record")
+
+	record, err = repository.FindByName("ads.example")
+	assert.NoError(t, err, "FindByName() returned an unexpected error")
+	assert.Equal(t, &AllRecords[1], record, "FindByName() did NOT find a blocked domain")
+	assertFileContent(t, AllRecordsFileContent, fileName)
+}
+
+func TestDnsRepositoryMissingFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "05-dns-static-records.conf")
+	repository := NewRepository(fileName, storage.Options{})
+
+	records, err := repository.FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.Empty(t, *records, "FindAll() returned unexpected records")
+
+	assert.NoError(t, repository.Save(&NewRecord), "Save() returned an unexpected error")
+	assertFileContent(t, "host-record=router.lan,192.168.1.1,300", fileName)
+}
+
+func TestDnsRepositorySaveDelete(t *testing.T) {
+	fileName := setUpStaticRecordsFile(t, AllRecordsFileContent)
+	repository := NewRepository(fileName, storage.Options{})
+
+	assert.NoError(t, repository.Save(&NewRecord), "Save() returned an unexpected error")
+	assertFileContent(t, AddedRecordFileContent, fileName)
+
+	record, err := repository.FindByName("router.lan")
+	assert.NoError(t, err, "FindByName() returned an unexpected error")
+	assert.Equal(t, &NewRecord, record, "FindByName() returned an unexpected record")
+
+	deleted, err := repository.DeleteByName("router.lan")
+	assert.NoError(t, err, "DeleteByName() returned an unexpected error")
+	assert.Equal(t, &NewRecord, deleted, "DeleteByName() returned an unexpected record")
+	assertFileContent(t, AllRecordsFileContent, fileName)
+
+	deleted, err = repository.DeleteByName("nas")
+	assert.NoError(t, err, "DeleteByName() returned an unexpected error")
+	assert.Equal(t, &AllRecords[0], deleted, "DeleteByName() returned an unexpected record")
+	assertFileContent(t, DeletedRecordFileContent, fileName)
+
+	deleted, err = repository.DeleteByName("nas")
+	assert.NoError(t, err, "DeleteByName() returned an unexpected error")
+	assert.Nil(t, deleted, "DeleteByName() returned an unexpected record")
+	assertFileContent(t, DeletedRecordFileContent, fileName)
+}
+
+func TestDnsRepositoryTransactionRollback(t *testing.T) {
+	fileName := setUpStaticRecordsFile(t, AllRecordsFileContent)
+	repository := NewRepository(fileName, storage.Options{})
+	rollback := errors.New("rollback")
+
+	err := repository.Transaction(func(tx model.DnsTransaction) error {
+		if _, err := tx.DeleteByName("nas"); err != nil {
+			return err
+		}
+		if err := tx.Save(&NewRecord); err != nil {
+			return err
+		}
+		record, err := tx.FindByName("nas")
+		assert.NoError(t, err, "FindByName() returned an unexpected error")
+		assert.Nil(t, record, "FindByName() did NOT see the deletion")
+		return rollback
+	})
+	assert.ErrorIs(t, err, rollback, "Transaction() returned an unexpected error")
+	assertFileContent(t, AllRecordsFileContent, fileName)
+}
+
+func TestDnsRepositoryParseMode(t *testing.T) {
+	fileName := setUpStaticRecordsFile(t, InvalidRecordFileContent)
+
+	_, err := NewRepository(fileName, storage.Options{}).FindAll()
+	assert.Error(t, err, "FindAll() did NOT returned an error in the strict mode")
+
+	records, err := NewRepository(fileName, storage.Options{Lenient: true}).FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error in the lenient mode")
+	assert.Equal(t, AllRecords[2:], *records, "FindAll() returned unexpected records")
+}
@@ -0,0 +1,127 @@
+package dns
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+type Service interface {
+	Insert(record *model.DnsRecord) error
+	Update(name string, record *model.DnsRecord) error
+	FetchAll() (*[]model.DnsRecord, error)
+	FetchByName(name string) (*model.DnsRecord, error)
+	RemoveByName(name string) (*model.DnsRecord, error)
+}
+
+type service struct {
+	repository Repository
+	reloader   dnsmasq.Reloader
+}
+
+// NewService returns the static DNS records Service. The reloader is triggered after every change written to the
+// static DNS records file, so dnsmasq picks it up.
+//
+// Every error matches one of the error kinds (errkind.ErrNotFound, errkind.ErrDuplicate, errkind.ErrValidation,
+// errkind.ErrStorageUnavailable or errkind.ErrLockTimeout), except the dnsmasq.ReloadError of a change that was saved.
+func NewService(repository Repository, reloader dnsmasq.Reloader) Service {
+	return &service{
+		repository: repository,
+		reloader:   reloader,
+	}
+}
+
+// Insert adds a new record. It fails with a DuplicatedEntryError if one of its names is already answered by
+// another record.
+func (s *service) Insert(record *model.DnsRecord) error {
+	if err := record.Validate(); err != nil {
+		return &errkind.ValidationError{Err: err}
+	}
+
+	err := s.repository.Transaction(func(tx model.DnsTransaction) error {
+		if err := s.checkNames(tx, record, nil); err != nil {
+			return err
+		}
+		return tx.Save(record)
+	})
+	if err != nil {
+		return errkind.Classify(err)
+	}
+
+	return s.reloader.Reload()
+}
+
+// Update replaces the existing record answering the given name, which may be renamed. It fails with a
+// NotFoundError if there is no such record, and with a DuplicatedEntryError if one of the new names is answered
+// by another record.
+func (s *service) Update(name string, record *model.DnsRecord) error {
+	if err := record.Validate(); err != nil {
+		return &errkind.ValidationError{Err: err}
+	}
+
+	err := s.repository.Transaction(func(tx model.DnsTransaction) error {
+		existing, err := tx.FindByName(name)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return &NotFoundError{Name: name}
+		}
+		if err := s.checkNames(tx, record, existing); err != nil {
+			return err
+		}
+
+		return tx.ReplaceByName(name, record)
+	})
+	if err != nil {
+		return errkind.Classify(err)
+	}
+
+	return s.reloader.Reload()
+}
+
+// checkNames ensures that no other record than the existing one (nil for a new record) answers the names of the
+// given record.
+func (s *service) checkNames(tx model.DnsTransaction, record *model.DnsRecord, existing *model.DnsRecord) error {
+	for _, name := range record.Names {
+		sameNameRecord, err := tx.FindByName(name)
+		if err != nil {
+			return err
+		}
+		if sameNameRecord != nil && (existing == nil || !sameNameRecord.Equal(*existing)) {
+			return &DuplicatedEntryError{Name: name, Record: sameNameRecord}
+		}
+	}
+
+	return nil
+}
+
+func (s *service) FetchAll() (*[]model.DnsRecord, error) {
+	records, err := s.repository.FindAll()
+	return records, errkind.Classify(err)
+}
+
+func (s *service) FetchByName(name string) (*model.DnsRecord, error) {
+	record, err := s.repository.FindByName(name)
+	return record, errkind.Classify(err)
+}
+
+// RemoveByName deletes the record answering the given name, and reloads dnsmasq when a record was actually
+// removed. The removed record is returned even if the reload fails, since it is already gone from the static DNS
+// records file.
+func (s *service) RemoveByName(name string) (*model.DnsRecord, error) {
+	var removed *model.DnsRecord
+	err := s.repository.Transaction(func(tx model.DnsTransaction) error {
+		var err error
+		removed, err = tx.DeleteByName(name)
+		return err
+	})
+	if err != nil {
+		return nil, errkind.Classify(err)
+	}
+	if removed == nil {
+		return nil, nil
+	}
+
+	return removed, s.reloader.Reload()
+}
@@ -0,0 +1,161 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	dnsmock "github.com/gringolito/dnsmasq-manager/pkg/dns/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	dnsmasqmock "github.com/gringolito/dnsmasq-manager/pkg/dnsmasq/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var ValidRecord = model.DnsRecord{Type: model.DnsRecordTypeHost, Names: []string{"nas.lan", "nas"}, IPAddress: net.ParseIP("192.168.1.10")}
+var OldRecord = model.DnsRecord{Type: model.DnsRecordTypeHost, Names: []string{"nas.lan"}, IPAddress: net.ParseIP("192.168.1.9")}
+var SameNameRecord = model.DnsRecord{Type: model.DnsRecordTypeAddress, Names: []string{"NAS"}, IPAddress: net.ParseIP("192.168.1.11")}
+var InvalidRecord = model.DnsRecord{Type: model.DnsRecordTypeAddress, Names: []string{"nas"}, IPAddress: net.ParseIP("192.168.1.10"), TTL: 60}
+
+func TestDnsServiceChanges(t *testing.T) {
+	Insert := func(service Service) error { return service.Insert(&ValidRecord) }
+	Update := func(service Service) error { return service.Update("nas.lan", &ValidRecord) }
+	Remove := func(service Service) error {
+		record, err := service.RemoveByName("nas.lan")
+		if err == nil && record == nil {
+			return errors.New("nothing removed")
+		}
+		return err
+	}
+
+	var testCases = []struct {
+		name        string
+		method      func(service Service) error
+		on          func(mock *dnsmock.RepositoryMock)
+		expectedErr error
+	}{
+		{
+			name:   "InsertSuccess",
+			method: Insert,
+			on: func(mock *dnsmock.RepositoryMock) {
+				mock.On("FindByName", "nas.lan").Once().Return(nil, nil)
+				mock.On("FindByName", "nas").Once().Return(nil, nil)
+				mock.On("Save", &ValidRecord).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "InsertDuplicatedName",
+			method: Insert,
+			on: func(mock *dnsmock.RepositoryMock) {
+				mock.On("FindByName", "nas.lan").Once().Return(nil, nil)
+				mock.On("FindByName", "nas").Once().Return(&SameNameRecord, nil)
+			},
+			expectedErr: &DuplicatedEntryError{Name: "nas", Record: &SameNameRecord},
+		},
+		{
+			name:        "InsertInvalidRecord",
+			method:      func(service Service) error { return service.Insert(&InvalidRecord) },
+			on:          func(mock *dnsmock.RepositoryMock) {},
+			expectedErr: &errkind.ValidationError{Err: InvalidRecord.Validate()},
+		},
+		{
+			name:   "UpdateSuccess",
+			method: Update,
+			on: func(mock *dnsmock.RepositoryMock) {
+				mock.On("FindByName", "nas.lan").Once().Return(&OldRecord, nil)
+				mock.On("FindByName", "nas.lan").Once().Return(&OldRecord, nil)
+				mock.On("FindByName", "nas").Once().Return(nil, nil)
+				mock.On("ReplaceByName", "nas.lan", &ValidRecord).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "UpdateNotFound",
+			method: Update,
+			on: func(mock *dnsmock.RepositoryMock) {
+				mock.On("FindByName", "nas.lan").Once().Return(nil, nil)
+			},
+			expectedErr: &NotFoundError{Name: "nas.lan"},
+		},
+		{
+			name:   "UpdateDuplicatedName",
+			method: Update,
+			on: func(mock *dnsmock.RepositoryMock) {
+				mock.On("FindByName", "nas.lan").Twice().Return(&OldRecord, nil)
+				mock.On("FindByName", "nas").Once().Return(&SameNameRecord, nil)
+			},
+			expectedErr: &DuplicatedEntryError{Name: "nas", Record: &SameNameRecord},
+		},
+		{
+			name:   "RemoveSuccess",
+			method: Remove,
+			on: func(mock *dnsmock.RepositoryMock) {
+				mock.On("DeleteByName", "nas.lan").Once().Return(&OldRecord, nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "RemoveNothing",
+			method: Remove,
+			on: func(mock *dnsmock.RepositoryMock) {
+				mock.On("DeleteByName", "nas.lan").Once().Return(nil, nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			expectedErr: errors.New("nothing removed"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := &dnsmock.RepositoryMock{}
+			test.on(repositoryMock)
+
+			service := NewService(repositoryMock, dnsmasq.NoReload())
+			err := test.method(service)
+			assert.Equal(t, test.expectedErr, err, "unexpected error")
+			repositoryMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDnsServiceFetch(t *testing.T) {
+	repositoryMock := &dnsmock.RepositoryMock{}
+	repositoryMock.On("FindAll").Once().Return(&[]model.DnsRecord{ValidRecord}, nil)
+	repositoryMock.On("FindByName", "nas").Once().Return(&ValidRecord, nil)
+	repositoryMock.On("FindByName", "foo").Once().Return(nil, errors.New("an error"))
+	service := NewService(repositoryMock, dnsmasq.NoReload())
+
+	records, err := service.FetchAll()
+	assert.NoError(t, err, "FetchAll() returned an unexpected error")
+	assert.Equal(t, &[]model.DnsRecord{ValidRecord}, records, "FetchAll() returned unexpected records")
+
+	record, err := service.FetchByName("nas")
+	assert.NoError(t, err, "FetchByName() returned an unexpected error")
+	assert.Equal(t, &ValidRecord, record, "FetchByName() returned an unexpected record")
+
+	_, err = service.FetchByName("foo")
+	assert.ErrorIs(t, err, errkind.ErrStorageUnavailable, "FetchByName() returned an unexpected error")
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestDnsServiceReload(t *testing.T) {
+	reloadErr := &dnsmasq.ReloadError{Err: errors.New("exit status 1")}
+	repositoryMock := &dnsmock.RepositoryMock{}
+	repositoryMock.On("DeleteByName", "nas.lan").Once().Return(&OldRecord, nil)
+	repositoryMock.On("Transaction").Once().Return(nil)
+	reloaderMock := &dnsmasqmock.ReloaderMock{}
+	reloaderMock.On("Reload").Once().Return(reloadErr)
+
+	service := NewService(repositoryMock, reloaderMock)
+	record, err := service.RemoveByName("nas.lan")
+	assert.Equal(t, &OldRecord, record, "RemoveByName() did NOT return the removed record")
+	assert.Equal(t, reloadErr, err, "RemoveByName() returned an unexpected error")
+	repositoryMock.AssertExpectations(t)
+	reloaderMock.AssertExpectations(t)
+}
+
+func TestDnsServiceErrorKinds(t *testing.T) {
+	assert.ErrorIs(t, NotFoundError{Name: "nas"}, errkind.ErrNotFound, "a NotFoundError is NOT an errkind.ErrNotFound")
+}
@@ -0,0 +1,91 @@
+package dnsmasq
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ReadOptions returns the values of every directive with the given option name found in the configuration
+// files of a dnsmasq conf-dir, in the order dnsmasq reads them. As dnsmasq does, the files whose names start
+// with a dot (e.g. the backups and lock files of the manager) or end with a tilde are skipped. A missing
+// directory has no options.
+func ReadOptions(dir string, name string) ([]string, error) {
+	return ReadOptionsExcept(dir, name, "")
+}
+
+// ReadOptionsExcept is ReadOptions, skipping the given file of the conf-dir (e.g. a file kept by the manager,
+// which is read apart).
+func ReadOptionsExcept(dir string, name string, except string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := []string{}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !entry.Type().IsRegular() || isIgnoredConfFile(entry.Name()) || (except != "" && isSameFile(path, except)) {
+			continue
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		document, err := ParseDocument(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range document.Len() {
+			if option, value, ok := Option(document.Line(i)); ok && option == name {
+				values = append(values, value)
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// Domains returns the local domains set by the `domain=` directives of a dnsmasq conf-dir, without the subnets or
+// the ranges they are limited to. The domain taken from resolv.conf (`#`) is unknown, so it is skipped.
+func Domains(dir string) ([]string, error) {
+	values, err := ReadOptions(dir, "domain")
+	if err != nil {
+		return nil, err
+	}
+
+	domains := []string{}
+	for _, value := range values {
+		domain, _, _ := strings.Cut(value, ",")
+		domain = strings.TrimSpace(domain)
+		if domain == "" || domain == "#" || slices.Contains(domains, domain) {
+			continue
+		}
+		domains = append(domains, domain)
+	}
+
+	return domains, nil
+}
+
+func isSameFile(path string, other string) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	other, err = filepath.Abs(other)
+	return err == nil && path == other
+}
+
+func isIgnoredConfFile(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") ||
+		(len(name) > 1 && strings.HasPrefix(name, "#") && strings.HasSuffix(name, "#")) ||
+		slices.Contains([]string{".dpkg-dist", ".dpkg-old", ".dpkg-new", ".rpmnew", ".rpmsave"}, filepath.Ext(name))
+}
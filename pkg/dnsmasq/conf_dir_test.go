@@ -0,0 +1,58 @@
+package dnsmasq
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOptions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"01-dhcp.conf":                      "# LAN\ndhcp-range=192.168.1.100,192.168.1.200,12h\ndomain=lan\n",
+		"02-dhcp-v6.conf":                   "  dhcp-range = 2001:db8::100,2001:db8::1ff\r\n",
+		"04-dhcp-static-leases.conf":        "dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo",
+		".04-dhcp-static-leases.conf.1.bak": "dhcp-range=10.0.0.1,10.0.0.2",
+		"03-old.conf~":                      "dhcp-range=10.0.0.1,10.0.0.2",
+		"#03-editing.conf#":                 "dhcp-range=10.0.0.1,10.0.0.2",
+		"03-old.conf.dpkg-old":              "dhcp-range=10.0.0.1,10.0.0.2",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644), "Failed to create conf file")
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "05-subdir"), 0755), "Failed to create conf subdirectory")
+
+	values, err := ReadOptions(dir, "dhcp-range")
+	require.NoError(t, err, "ReadOptions() returned an unexpected error")
+	assert.Equal(t, []string{"192.168.1.100,192.168.1.200,12h", "2001:db8::100,2001:db8::1ff"}, values, "ReadOptions() returned unexpected values")
+
+	values, err = ReadOptionsExcept(dir, "dhcp-range", filepath.Join(dir, "01-dhcp.conf"))
+	require.NoError(t, err, "ReadOptionsExcept() returned an unexpected error")
+	assert.Equal(t, []string{"2001:db8::100,2001:db8::1ff"}, values, "ReadOptionsExcept() returned unexpected values")
+
+	values, err = ReadOptions(filepath.Join(dir, "missing"), "dhcp-range")
+	assert.NoError(t, err, "ReadOptions() returned an unexpected error")
+	assert.Empty(t, values, "ReadOptions() returned unexpected values")
+}
+
+func TestDomains(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"01-dhcp.conf":  "domain=lan\ndomain=guest.lan,192.168.5.0/24\n",
+		"02-other.conf": "domain=#\ndomain=lan,192.168.1.100,192.168.1.200,local\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644), "Failed to create conf file")
+	}
+
+	domains, err := Domains(dir)
+	require.NoError(t, err, "Domains() returned an unexpected error")
+	assert.Equal(t, []string{"lan", "guest.lan"}, domains, "Domains() returned unexpected domains")
+
+	domains, err = Domains(filepath.Join(dir, "missing"))
+	assert.NoError(t, err, "Domains() returned an unexpected error")
+	assert.Empty(t, domains, "Domains() returned unexpected domains")
+}
@@ -0,0 +1,107 @@
+package dnsmasq
+
+import (
+	"io"
+	"strings"
+)
+
+// Document is a line-preserving representation of a dnsmasq configuration file.
+//
+// Only the lines explicitly edited are changed, comments, blank lines and any other directive keep
+// their original position and formatting when the document is written back.
+type Document struct {
+	lines           []string
+	trailingNewline bool
+}
+
+func NewDocument() *Document {
+	return &Document{}
+}
+
+func ParseDocument(reader io.Reader) (*Document, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	document := NewDocument()
+	if len(data) == 0 {
+		return document, nil
+	}
+
+	content := string(data)
+	document.trailingNewline = strings.HasSuffix(content, "\n")
+	document.lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+
+	return document, nil
+}
+
+// Len returns the number of lines in the document.
+func (d *Document) Len() int {
+	return len(d.lines)
+}
+
+// Line returns the raw text of the i-th line, without the line terminator.
+func (d *Document) Line(i int) string {
+	return d.lines[i]
+}
+
+// Set replaces the text of the i-th line.
+func (d *Document) Set(i int, text string) {
+	d.lines[i] = text
+}
+
+// Insert adds a new line at the i-th position, shifting the following lines down.
+func (d *Document) Insert(i int, text string) {
+	d.lines = append(d.lines[:i], append([]string{text}, d.lines[i:]...)...)
+}
+
+// Append adds a new line at the end of the document.
+func (d *Document) Append(text string) {
+	d.lines = append(d.lines, text)
+}
+
+// Remove deletes the i-th line, shifting the following lines up.
+func (d *Document) Remove(i int) {
+	d.lines = append(d.lines[:i], d.lines[i+1:]...)
+}
+
+// Clone returns a deep copy of the document, so it can be edited without affecting the original one.
+func (d *Document) Clone() *Document {
+	return &Document{
+		lines:           append([]string(nil), d.lines...),
+		trailingNewline: d.trailingNewline,
+	}
+}
+
+// Bytes renders the document back, keeping the original trailing newline (or the lack of it).
+func (d *Document) Bytes() []byte {
+	if len(d.lines) == 0 {
+		return []byte{}
+	}
+
+	content := strings.Join(d.lines, "\n")
+	if d.trailingNewline {
+		content += "\n"
+	}
+
+	return []byte(content)
+}
+
+// Option returns the option name and value of a directive line (e.g. "dhcp-host" and "aa:bb:cc:dd:ee:ff,host"
+// for "dhcp-host=aa:bb:cc:dd:ee:ff,host"). Comments and blank lines are not directives.
+func Option(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+
+	name, value, _ := strings.Cut(line, "=")
+	return strings.TrimSpace(name), strings.TrimSpace(value), true
+}
+
+// IsOption reports whether the line is a directive for the given option name.
+func IsOption(line string, name string) bool {
+	option, _, ok := Option(line)
+	return ok && option == name
+}
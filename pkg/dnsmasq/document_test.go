@@ -0,0 +1,142 @@
+package dnsmasq
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const DocumentContent = `# Static leases
+dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo
+
+  # Printers
+domain=lan
+dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+`
+
+func TestDocumentRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		length  int
+	}{
+		{name: "EmptyFile", content: "", length: 0},
+		{name: "SingleLine", content: "dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo", length: 1},
+		{name: "TrailingNewline", content: "dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo\n", length: 1},
+		{name: "OnlyNewline", content: "\n", length: 1},
+		{name: "CommentsAndBlankLines", content: DocumentContent, length: 6},
+		{name: "CRLF", content: "# comment\r\ndhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo\r\n", length: 2},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			document, err := ParseDocument(strings.NewReader(test.content))
+			require.NoError(t, err, "ParseDocument() returned an unexpected error")
+			assert.Equal(t, test.length, document.Len(), "ParseDocument() returned an unexpected number of lines")
+			assert.Equal(t, test.content, string(document.Bytes()), "Document.Bytes() did not preserve the original content")
+		})
+	}
+}
+
+func TestDocumentEdit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		edit     func(d *Document)
+		expected string
+	}{
+		{
+			name:     "AppendToEmptyFile",
+			content:  "",
+			edit:     func(d *Document) { d.Append("dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo") },
+			expected: "dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo",
+		},
+		{
+			name:     "Append",
+			content:  DocumentContent,
+			edit:     func(d *Document) { d.Append("dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz") },
+			expected: DocumentContent + "dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz\n",
+		},
+		{
+			name:    "Remove",
+			content: DocumentContent,
+			edit:    func(d *Document) { d.Remove(1) },
+			expected: `# Static leases
+
+  # Printers
+domain=lan
+dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+`,
+		},
+		{
+			name:     "RemoveLastLine",
+			content:  "dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo\n",
+			edit:     func(d *Document) { d.Remove(0) },
+			expected: "",
+		},
+		{
+			name:    "Set",
+			content: DocumentContent,
+			edit:    func(d *Document) { d.Set(5, "dhcp-host=02:04:06:dd:ee:ff,1.1.1.5,Bar") },
+			expected: `# Static leases
+dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo
+
+  # Printers
+domain=lan
+dhcp-host=02:04:06:dd:ee:ff,1.1.1.5,Bar
+`,
+		},
+		{
+			name:    "Insert",
+			content: DocumentContent,
+			edit:    func(d *Document) { d.Insert(2, "dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz") },
+			expected: `# Static leases
+dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo
+dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz
+
+  # Printers
+domain=lan
+dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			document, err := ParseDocument(strings.NewReader(test.content))
+			require.NoError(t, err, "ParseDocument() returned an unexpected error")
+
+			clone := document.Clone()
+			test.edit(document)
+			assert.Equal(t, test.expected, string(document.Bytes()), "Document has an unexpected content")
+			assert.Equal(t, test.content, string(clone.Bytes()), "Document edit has changed a cloned document")
+		})
+	}
+}
+
+func TestOption(t *testing.T) {
+	testCases := []struct {
+		line  string
+		name  string
+		value string
+		ok    bool
+	}{
+		{line: "dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo", name: "dhcp-host", value: "02:04:06:aa:bb:cc,1.1.1.1,Foo", ok: true},
+		{line: "  dhcp-range = 10.0.0.10,10.0.0.99 \r", name: "dhcp-range", value: "10.0.0.10,10.0.0.99", ok: true},
+		{line: "no-resolv", name: "no-resolv", value: "", ok: true},
+		{line: "# dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo", ok: false},
+		{line: "   ", ok: false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.line, func(t *testing.T) {
+			name, value, ok := Option(test.line)
+			assert.Equal(t, test.ok, ok, "Option() returned an unexpected result")
+			assert.Equal(t, test.name, name, "Option() returned an unexpected option name")
+			assert.Equal(t, test.value, value, "Option() returned an unexpected option value")
+			assert.Equal(t, test.ok, IsOption(test.line, test.name), "IsOption() returned an unexpected result")
+		})
+	}
+}
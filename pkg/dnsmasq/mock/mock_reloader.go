@@ -0,0 +1,14 @@
+package dnsmasqmock
+
+import (
+	"github.com/stretchr/testify/mock"
+)
+
+type ReloaderMock struct {
+	mock.Mock
+}
+
+func (m *ReloaderMock) Reload() error {
+	args := m.Called()
+	return args.Error(0)
+}
@@ -0,0 +1,212 @@
+package dnsmasq
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+)
+
+// Reload methods
+const (
+	ReloadNone    = "none"
+	ReloadSignal  = "signal"
+	ReloadCommand = "command"
+)
+
+// Reloader makes the running dnsmasq pick up the changes written to its configuration files.
+type Reloader interface {
+	Reload() error
+}
+
+type ReloadOptions struct {
+	// One of ReloadNone, ReloadSignal or ReloadCommand
+	Method string
+	// dnsmasq pidfile, used by ReloadSignal
+	PidFile string
+	// Command line run by ReloadCommand, split on spaces (it is not interpreted by a shell)
+	Command string
+	// Reload requests received within this interval are coalesced into a single reload
+	Debounce time.Duration
+}
+
+// ReloadError is returned when dnsmasq could not be reloaded. The configuration changes were already
+// written, they will only take effect on the next successful reload.
+type ReloadError struct {
+	Method string
+	Err    error
+	// Combined output of the reload command, if any
+	Output string
+}
+
+const reloadErrorMessage = "dnsmasq reload (%s) failed: %s"
+
+func (e *ReloadError) Error() string {
+	return fmt.Sprintf(reloadErrorMessage, e.Method, e.Err.Error())
+}
+
+func (e *ReloadError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ReloadError) Is(target error) bool {
+	return target == errkind.ErrReloadFailed
+}
+
+var ErrUnknownReloadMethod = errors.New("unknown dnsmasq reload method")
+
+func NewReloader(options ReloadOptions) (Reloader, error) {
+	var reloader Reloader
+	switch options.Method {
+	case ReloadNone, "":
+		return NoReload(), nil
+	case ReloadSignal:
+		if options.PidFile == "" {
+			return nil, errors.New("dnsmasq reload by signal requires a pidfile")
+		}
+		reloader = &signalReloader{pidFile: options.PidFile}
+	case ReloadCommand:
+		command := strings.Fields(options.Command)
+		if len(command) == 0 {
+			return nil, errors.New("dnsmasq reload by command requires a command")
+		}
+		reloader = &commandReloader{command: command}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownReloadMethod, options.Method)
+	}
+
+	if options.Debounce > 0 {
+		reloader = NewDebouncedReloader(reloader, options.Debounce)
+	}
+
+	return reloader, nil
+}
+
+type noReload struct{}
+
+// NoReload returns a Reloader that does nothing, leaving it up to the operator to reload dnsmasq.
+func NoReload() Reloader {
+	return noReload{}
+}
+
+func (noReload) Reload() error {
+	return nil
+}
+
+// signalReloader sends SIGHUP to the dnsmasq process found in the pidfile.
+type signalReloader struct {
+	pidFile string
+}
+
+func (r *signalReloader) Reload() error {
+	data, err := os.ReadFile(r.pidFile)
+	if err != nil {
+		return &ReloadError{Method: ReloadSignal, Err: err}
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return &ReloadError{Method: ReloadSignal, Err: fmt.Errorf("invalid pidfile %s: %q", r.pidFile, strings.TrimSpace(string(data)))}
+	}
+
+	err = syscall.Kill(pid, syscall.SIGHUP)
+	if err != nil {
+		return &ReloadError{Method: ReloadSignal, Err: fmt.Errorf("signaling process %d: %w", pid, err)}
+	}
+
+	slog.Info("dnsmasq reloaded", slog.String("method", ReloadSignal), slog.Int("pid", pid))
+	return nil
+}
+
+// commandReloader runs an arbitrary command, e.g. `systemctl restart dnsmasq`.
+type commandReloader struct {
+	command []string
+}
+
+func (r *commandReloader) Reload() error {
+	var output bytes.Buffer
+	cmd := exec.Command(r.command[0], r.command[1:]...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	if err != nil {
+		return &ReloadError{Method: ReloadCommand, Err: err, Output: strings.TrimSpace(output.String())}
+	}
+
+	slog.Info("dnsmasq reloaded", slog.String("method", ReloadCommand), slog.String("command", strings.Join(r.command, " ")))
+	return nil
+}
+
+// Debounce intervals after the first request of a batch at which the batch is reloaded, even if the requests keep
+// arriving
+const debounceMaxDelays = 10
+
+// DebouncedReloader coalesces bursts of reload requests. A request waits until no other request has
+// arrived for the debounce interval, and then all the waiting requests share the result of a single reload.
+// A steady stream of requests can't postpone the reload forever: a batch is reloaded at most ten debounce
+// intervals after its first request.
+type DebouncedReloader struct {
+	reloader Reloader
+	delay    time.Duration
+	maxWait  time.Duration
+	mutex    sync.Mutex
+	// Reload serialization, a new batch must not reload dnsmasq while the previous one is still at it
+	reloading sync.Mutex
+	pending   *reloadBatch
+}
+
+type reloadBatch struct {
+	timer *time.Timer
+	// Time at which the batch is reloaded at the latest
+	deadline time.Time
+	done     chan struct{}
+	err      error
+}
+
+func NewDebouncedReloader(reloader Reloader, delay time.Duration) *DebouncedReloader {
+	return &DebouncedReloader{
+		reloader: reloader,
+		delay:    delay,
+		maxWait:  debounceMaxDelays * delay,
+	}
+}
+
+func (d *DebouncedReloader) Reload() error {
+	d.mutex.Lock()
+	batch := d.pending
+	// A batch whose timer can't be stopped anymore is already being reloaded, so it is too late to join it
+	if batch != nil && batch.timer.Stop() {
+		batch.timer.Reset(min(d.delay, time.Until(batch.deadline)))
+	} else {
+		batch = &reloadBatch{deadline: time.Now().Add(d.maxWait), done: make(chan struct{})}
+		batch.timer = time.AfterFunc(d.delay, func() { d.fire(batch) })
+		d.pending = batch
+	}
+	d.mutex.Unlock()
+
+	<-batch.done
+	return batch.err
+}
+
+func (d *DebouncedReloader) fire(batch *reloadBatch) {
+	d.mutex.Lock()
+	if d.pending == batch {
+		d.pending = nil
+	}
+	d.mutex.Unlock()
+
+	d.reloading.Lock()
+	defer d.reloading.Unlock()
+	batch.err = d.reloader.Reload()
+	close(batch.done)
+}
@@ -0,0 +1,203 @@
+package dnsmasq
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReloader(t *testing.T) {
+	testCases := []struct {
+		name        string
+		options     ReloadOptions
+		expectError bool
+	}{
+		{name: "Default", options: ReloadOptions{}},
+		{name: "None", options: ReloadOptions{Method: ReloadNone}},
+		{name: "Signal", options: ReloadOptions{Method: ReloadSignal, PidFile: "/run/dnsmasq/dnsmasq.pid"}},
+		{name: "SignalMissingPidFile", options: ReloadOptions{Method: ReloadSignal}, expectError: true},
+		{name: "Command", options: ReloadOptions{Method: ReloadCommand, Command: "systemctl restart dnsmasq"}},
+		{name: "CommandMissingCommand", options: ReloadOptions{Method: ReloadCommand, Command: "  "}, expectError: true},
+		{name: "Debounced", options: ReloadOptions{Method: ReloadCommand, Command: "true", Debounce: time.Second}},
+		{name: "UnknownMethod", options: ReloadOptions{Method: "restart"}, expectError: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			reloader, err := NewReloader(test.options)
+			if test.expectError {
+				assert.Error(t, err, "NewReloader() did NOT returned an error")
+				assert.Nil(t, reloader, "NewReloader() returned an unexpected reloader")
+				return
+			}
+			assert.NoError(t, err, "NewReloader() returned an unexpected error")
+			assert.NotNil(t, reloader, "NewReloader() unexpectedly returned a nil reloader")
+		})
+	}
+}
+
+func TestCommandReloader(t *testing.T) {
+	testCases := []struct {
+		name           string
+		command        string
+		expectError    bool
+		expectedOutput string
+	}{
+		{name: "Success", command: "true"},
+		{name: "Failure", command: "false", expectError: true},
+		{name: "FailureWithOutput", command: "ls /nonexistent-dnsmasq-manager-dir", expectError: true, expectedOutput: "nonexistent-dnsmasq-manager-dir"},
+		{name: "CommandNotFound", command: "/nonexistent/dnsmasq-reload", expectError: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			reloader, err := NewReloader(ReloadOptions{Method: ReloadCommand, Command: test.command})
+			require.NoError(t, err, "NewReloader() returned an unexpected error")
+
+			err = reloader.Reload()
+			if !test.expectError {
+				assert.NoError(t, err, "Reload() returned an unexpected error")
+				return
+			}
+
+			var reloadErr *ReloadError
+			require.ErrorAs(t, err, &reloadErr, "Reload() returned an unexpected error")
+			assert.Equal(t, ReloadCommand, reloadErr.Method, "ReloadError has an unexpected method")
+			assert.Contains(t, reloadErr.Output, test.expectedOutput, "ReloadError has an unexpected output")
+		})
+	}
+}
+
+func TestSignalReloader(t *testing.T) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	testCases := []struct {
+		name        string
+		pidFile     string
+		expectError bool
+	}{
+		{name: "Success", pidFile: strconv.Itoa(os.Getpid()) + "\n"},
+		{name: "InvalidPidFile", pidFile: "dnsmasq", expectError: true},
+		{name: "EmptyPidFile", pidFile: "", expectError: true},
+		{name: "MissingPidFile", expectError: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			pidFile := filepath.Join(t.TempDir(), "dnsmasq.pid")
+			if test.name != "MissingPidFile" {
+				require.NoError(t, os.WriteFile(pidFile, []byte(test.pidFile), 0644), "Failed to create the pidfile")
+			}
+
+			reloader, err := NewReloader(ReloadOptions{Method: ReloadSignal, PidFile: pidFile})
+			require.NoError(t, err, "NewReloader() returned an unexpected error")
+
+			err = reloader.Reload()
+			if test.expectError {
+				var reloadErr *ReloadError
+				assert.ErrorAs(t, err, &reloadErr, "Reload() returned an unexpected error")
+				return
+			}
+
+			require.NoError(t, err, "Reload() returned an unexpected error")
+			select {
+			case sig := <-signals:
+				assert.Equal(t, syscall.SIGHUP, sig, "Reload() sent an unexpected signal")
+			case <-time.After(time.Second):
+				assert.Fail(t, "Reload() has not sent a signal")
+			}
+		})
+	}
+}
+
+type countingReloader struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (r *countingReloader) Reload() error {
+	r.calls.Add(1)
+	return r.err
+}
+
+func TestDebouncedReloader(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+	}{
+		{name: "Success", err: nil},
+		{name: "Failure", err: &ReloadError{Method: ReloadCommand, Err: errors.New("exit status 1")}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			counter := &countingReloader{err: test.err}
+			reloader := NewDebouncedReloader(counter, 50*time.Millisecond)
+
+			// A burst of changes results in a single reload, whose result is seen by every change
+			var wg sync.WaitGroup
+			results := make([]error, 5)
+			for i := range results {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					results[i] = reloader.Reload()
+				}()
+				time.Sleep(5 * time.Millisecond)
+			}
+			wg.Wait()
+
+			assert.Equal(t, int32(1), counter.calls.Load(), "Reload() has not coalesced the burst of reloads")
+			for _, err := range results {
+				assert.Equal(t, test.err, err, "Reload() returned an unexpected result")
+			}
+
+			// Later changes trigger a new reload
+			assert.Equal(t, test.err, reloader.Reload(), "Reload() returned an unexpected result")
+			assert.Equal(t, int32(2), counter.calls.Load(), "Reload() has not reloaded again")
+		})
+	}
+}
+
+func TestDebouncedReloaderMaxWait(t *testing.T) {
+	counter := &countingReloader{}
+	reloader := NewDebouncedReloader(counter, 20*time.Millisecond)
+
+	// Requests keep arriving well within the debounce interval, for longer than the batch may wait
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				reloader.Reload()
+			}()
+			time.Sleep(2 * time.Millisecond)
+		}
+	}()
+
+	assert.Eventually(t, func() bool { return counter.calls.Load() > 0 }, time.Second, 10*time.Millisecond,
+		"Reload() has been postponed past the max wait")
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,71 @@
+package errkind
+
+import (
+	"errors"
+)
+
+// Kinds of the errors returned by the services, checked with errors.Is. The errors of each resource (e.g. a host
+// that was not found) match one of them, and so do the errors of the storage, the version checks and the reloads.
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrDuplicate          = errors.New("duplicated entry")
+	ErrValidation         = errors.New("validation failed")
+	ErrVersionMismatch    = errors.New("version mismatch")
+	ErrStorageUnavailable = errors.New("storage unavailable")
+	// Lock timeouts are also storage errors, they are checked first to tell the client to retry
+	ErrLockTimeout  = errors.New("storage locked")
+	ErrReloadFailed = errors.New("reload failed")
+)
+
+// ValidationError is returned when an entry can't be stored as it is, e.g. it can't be written as a dnsmasq line.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// StorageError is returned when the entries could not be read or written, e.g. the disk is full.
+type StorageError struct {
+	Err error
+}
+
+func (e *StorageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StorageError) Unwrap() error {
+	return e.Err
+}
+
+func (e *StorageError) Is(target error) bool {
+	return target == ErrStorageUnavailable
+}
+
+// Classify gives a kind to the errors of the repositories: the errors which already have a kind (e.g. the changes
+// rejected by the validator, or the lock timeouts) are returned as they are, and any other failure is a storage one.
+func Classify(err error) error {
+	if err == nil || HasKind(err) {
+		return err
+	}
+	return &StorageError{Err: err}
+}
+
+// HasKind reports whether the error matches one of the kinds.
+func HasKind(err error) bool {
+	for _, kind := range []error{ErrNotFound, ErrDuplicate, ErrValidation, ErrVersionMismatch, ErrStorageUnavailable, ErrLockTimeout, ErrReloadFailed} {
+		if errors.Is(err, kind) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package errkind_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	lockErr := &storage.LockTimeoutError{File: "/etc/dnsmasq.d/04-dhcp-static-leases.conf", Timeout: time.Second}
+	validationErr := &storage.ValidationError{Err: errors.New("exit status 1")}
+	pathErr := &fs.PathError{Op: "open", Path: "/etc/dnsmasq.d/04-dhcp-static-leases.conf", Err: fs.ErrPermission}
+	mismatchErr := &model.VersionMismatchError{Current: "v2"}
+	reloadErr := &dnsmasq.ReloadError{Method: "signal", Err: errors.New("no such process")}
+	notFoundErr := fmt.Errorf("no host with MAC 02:04:06:aa:bb:cc: %w", errkind.ErrNotFound)
+	kinds := []error{errkind.ErrNotFound, errkind.ErrDuplicate, errkind.ErrValidation, errkind.ErrVersionMismatch,
+		errkind.ErrStorageUnavailable, errkind.ErrLockTimeout, errkind.ErrReloadFailed}
+
+	testCases := []struct {
+		name          string
+		err           error
+		expectedKinds []error
+		expectedError error
+	}{
+		{name: "Validation", err: &errkind.ValidationError{Err: model.ErrDnsRecordMissingIPAddress}, expectedKinds: []error{errkind.ErrValidation}, expectedError: model.ErrDnsRecordMissingIPAddress},
+		{name: "RejectedByValidator", err: fmt.Errorf("saving: %w", validationErr), expectedKinds: []error{errkind.ErrValidation}, expectedError: validationErr},
+		{name: "Storage", err: pathErr, expectedKinds: []error{errkind.ErrStorageUnavailable}, expectedError: fs.ErrPermission},
+		{name: "LockTimeout", err: lockErr, expectedKinds: []error{errkind.ErrStorageUnavailable, errkind.ErrLockTimeout}, expectedError: lockErr},
+		{name: "WrappedLockTimeout", err: &errkind.StorageError{Err: lockErr}, expectedKinds: []error{errkind.ErrStorageUnavailable, errkind.ErrLockTimeout}, expectedError: lockErr},
+		{name: "NotFound", err: notFoundErr, expectedKinds: []error{errkind.ErrNotFound}, expectedError: notFoundErr},
+		{name: "VersionMismatch", err: mismatchErr, expectedKinds: []error{errkind.ErrVersionMismatch}, expectedError: mismatchErr},
+		{name: "ReloadFailed", err: reloadErr, expectedKinds: []error{errkind.ErrReloadFailed}, expectedError: reloadErr},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			err := errkind.Classify(test.err)
+			for _, kind := range kinds {
+				assert.Equal(t, slices.Contains(test.expectedKinds, kind), errors.Is(err, kind), "unexpected kind: %v", kind)
+			}
+			assert.ErrorIs(t, err, test.expectedError, "Classify() lost the original error")
+		})
+	}
+
+	assert.Nil(t, errkind.Classify(nil), "Classify() returned an unexpected error")
+}
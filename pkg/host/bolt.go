@@ -0,0 +1,731 @@
+package host
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	bolt "go.etcd.io/bbolt"
+	"log/slog"
+)
+
+const (
+	// How long to wait for the database, which can't be opened by two processes at once
+	boltOpenTimeout = time.Second
+	// First line of the rendered static hosts file
+	renderedFileHeader = "# Generated by dnsmasq-manager, any change made by hand is overwritten by the next API change"
+	// Number of revisions kept in the history, the oldest ones are dropped
+	boltHistoryLength = 100
+)
+
+var (
+	// Hosts in insertion order, keyed by a sequence number
+	hostsBucket = []byte("hosts")
+	// Indexes keyed by the normalized address followed by the host sequence number, so an address
+	// shared by several hosts (hand-written entries) can still be indexed
+	macAddressesBucket = []byte("macAddresses")
+	ipAddressesBucket  = []byte("ipAddresses")
+	// Keyed by the lowercase hostname
+	hostNamesBucket = []byte("hostNames")
+	// Hosts after each committed change, keyed by the revision number
+	revisionsBucket = []byte("revisions")
+	// Database information, such as where the hosts were imported from
+	metaBucket      = []byte("meta")
+	importedFromKey = []byte("importedFrom")
+	importedAtKey   = []byte("importedAt")
+)
+
+// boltHost is a host as stored in the database. The `dhcp-host=` line and the metadata are rendered as is,
+// so the file backend can take over the rendered file at any time.
+type boltHost struct {
+	Config   string             `json:"config"`
+	Metadata model.HostMetadata `json:"metadata,omitzero"`
+}
+
+// boltRevision is a revision of the hosts as stored in the database, see model.HostRevision.
+type boltRevision struct {
+	CommittedAt time.Time  `json:"committedAt"`
+	Hosts       []boltHost `json:"hosts"`
+}
+
+// boltRepository is a HistoryRepository backed by a bbolt database, which is the source of truth for the static
+// hosts. The static hosts file is rendered from the database after each committed change, and a change whose file
+// could not be written (e.g. rejected by the validator) is rolled back to the previous revision.
+type boltRepository struct {
+	db *bolt.DB
+	// Serializes the changes, so each one is rendered (or rolled back) before the next one is committed
+	mutex sync.Mutex
+	file  *storage.File
+	// The rendered static hosts file, read by Diagnostics
+	document *storage.DocumentRepository[model.StaticDhcpHost]
+}
+
+// NewBoltRepository opens (or creates) the database at databasePath, rendering the static hosts into
+// staticHostsFilePath. A new database imports the hosts of the existing static hosts file, once: any other
+// line of the file (comments, other dnsmasq options) is dropped by the first change made through the API.
+//
+// Reads are served by the database, which takes the place of the file cache. A change that is rolled back may
+// still be seen by the reads made while its file is rendered. Close must be called to release the database.
+func NewBoltRepository(databasePath string, staticHostsFilePath string, options storage.Options) (CachedRepository, error) {
+	db, err := bolt.Open(databasePath, 0600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		slog.Error("Error opening the static hosts database",
+			slog.String("database", databasePath),
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	r := &boltRepository{
+		db:       db,
+		file:     storage.NewFile(staticHostsFilePath, options),
+		document: newHostsDocument(staticHostsFilePath, options),
+	}
+	err = r.db.Update(func(tx *bolt.Tx) error {
+		return r.initialize(tx, &repository{document: r.document})
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *boltRepository) Close() error {
+	return r.db.Close()
+}
+
+// initialize creates the buckets of a new database, importing the hosts found in the static hosts file as its
+// first revision.
+func (r *boltRepository) initialize(tx *bolt.Tx, source Repository) error {
+	if tx.Bucket(hostsBucket) != nil {
+		return r.initializeHistory(tx)
+	}
+
+	for _, name := range [][]byte{hostsBucket, macAddressesBucket, ipAddressesBucket, hostNamesBucket, metaBucket, revisionsBucket} {
+		_, err := tx.CreateBucket(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	hosts, err := source.FindAll()
+	if errors.Is(err, os.ErrNotExist) {
+		hosts, err = &[]model.StaticDhcpHost{}, nil
+	}
+	if err != nil {
+		slog.Error("Error importing the static hosts file",
+			slog.String("file", r.file.Path()),
+			slog.String("error", err.Error()),
+		)
+		return err
+	}
+
+	for _, host := range *hosts {
+		err := put(tx, &host)
+		if err != nil {
+			return err
+		}
+	}
+
+	meta := tx.Bucket(metaBucket)
+	err = meta.Put(importedFromKey, []byte(r.file.Path()))
+	if err != nil {
+		return err
+	}
+	err = meta.Put(importedAtKey, []byte(time.Now().UTC().Format(time.RFC3339)))
+	if err != nil {
+		return err
+	}
+	_, err = record(tx)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Static hosts imported into the database",
+		slog.String("file", r.file.Path()),
+		slog.String("database", r.db.Path()),
+		slog.Int("hosts", len(*hosts)),
+	)
+	return nil
+}
+
+// initializeHistory starts the history of a database created before it was kept, from its current hosts.
+func (r *boltRepository) initializeHistory(tx *bolt.Tx) error {
+	if tx.Bucket(revisionsBucket) != nil {
+		return nil
+	}
+
+	_, err := tx.CreateBucket(revisionsBucket)
+	if err != nil {
+		return err
+	}
+	_, err = record(tx)
+	return err
+}
+
+func (r *boltRepository) FindAll() (*[]model.StaticDhcpHost, error) {
+	var hosts *[]model.StaticDhcpHost
+	err := r.view(func(tx *boltTransaction) error {
+		var err error
+		hosts, err = tx.FindAll()
+		return err
+	})
+
+	return hosts, err
+}
+
+func (r *boltRepository) Find(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
+	return r.findOne(func(tx *boltTransaction) (*model.StaticDhcpHost, error) {
+		return tx.Find(host)
+	})
+}
+
+func (r *boltRepository) FindByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
+	return r.findOne(func(tx *boltTransaction) (*model.StaticDhcpHost, error) {
+		return tx.FindByMac(macAddress)
+	})
+}
+
+func (r *boltRepository) FindByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
+	return r.findOne(func(tx *boltTransaction) (*model.StaticDhcpHost, error) {
+		return tx.FindByIP(ipAddress)
+	})
+}
+
+func (r *boltRepository) FindByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	return r.findOne(func(tx *boltTransaction) (*model.StaticDhcpHost, error) {
+		return tx.FindByHostName(hostName)
+	})
+}
+
+// Diagnostics reports the problems of the rendered static hosts file, such as lines added by hand, which
+// the next change will overwrite.
+func (r *boltRepository) Diagnostics() ([]model.Diagnostic, error) {
+	return (&repository{document: r.document}).Diagnostics()
+}
+
+func (r *boltRepository) Save(host *model.StaticDhcpHost) error {
+	return r.Transaction(func(tx model.HostTransaction) error {
+		return tx.Save(host)
+	})
+}
+
+func (r *boltRepository) Delete(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
+	return r.deleteOne(func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return tx.Delete(host)
+	})
+}
+
+func (r *boltRepository) DeleteByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
+	return r.deleteOne(func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return tx.DeleteByMac(macAddress)
+	})
+}
+
+func (r *boltRepository) DeleteByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
+	return r.deleteOne(func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return tx.DeleteByIP(ipAddress)
+	})
+}
+
+func (r *boltRepository) DeleteByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	return r.deleteOne(func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return tx.DeleteByHostName(hostName)
+	})
+}
+
+// Revisions returns the kept revisions of the static hosts, oldest first.
+func (r *boltRepository) Revisions() ([]model.HostRevision, error) {
+	revisions := []model.HostRevision{}
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(revisionsBucket).ForEach(func(key, value []byte) error {
+			revision, err := decodeBoltRevision(key, value)
+			if err != nil {
+				return err
+			}
+			revisions = append(revisions, *revision)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// Transaction runs fn in a database transaction, recording the hosts it leaves as a new revision. The static hosts
+// file is rendered once the transaction is committed, and the change is rolled back if the file could not be
+// written (e.g. it was rejected by the validator).
+func (r *boltRepository) Transaction(fn func(tx model.HostTransaction) error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var revision []byte
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		btx := &boltTransaction{tx: tx}
+		err := fn(btx)
+		if err != nil || !btx.changed {
+			return err
+		}
+
+		revision, err = record(tx)
+		return err
+	})
+	if err != nil || revision == nil {
+		return err
+	}
+
+	err = r.render()
+	if err != nil {
+		r.rollback(revision)
+		return err
+	}
+
+	return nil
+}
+
+func (r *boltRepository) view(fn func(tx *boltTransaction) error) error {
+	return r.db.View(func(tx *bolt.Tx) error {
+		return fn(&boltTransaction{tx: tx})
+	})
+}
+
+func (r *boltRepository) findOne(findFn func(tx *boltTransaction) (*model.StaticDhcpHost, error)) (*model.StaticDhcpHost, error) {
+	var found *model.StaticDhcpHost
+	err := r.view(func(tx *boltTransaction) error {
+		var err error
+		found, err = findFn(tx)
+		return err
+	})
+
+	return found, err
+}
+
+// deleteOne runs a single deletion as a transaction, the deleted host is only returned once committed.
+func (r *boltRepository) deleteOne(deleteFn func(tx model.HostTransaction) (*model.StaticDhcpHost, error)) (*model.StaticDhcpHost, error) {
+	var deleted *model.StaticDhcpHost
+	err := r.Transaction(func(tx model.HostTransaction) error {
+		var err error
+		deleted, err = deleteFn(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+// boltTransaction is a HostTransaction over a database transaction, which keeps the changes until it is committed.
+type boltTransaction struct {
+	tx *bolt.Tx
+	// Whether the static hosts file must be rendered again
+	changed bool
+}
+
+func (tx *boltTransaction) FindAll() (*[]model.StaticDhcpHost, error) {
+	hosts := []model.StaticDhcpHost{}
+	err := tx.tx.Bucket(hostsBucket).ForEach(func(_, value []byte) error {
+		host, err := decodeBoltHost(value)
+		if err != nil {
+			return err
+		}
+		hosts = append(hosts, *host)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &hosts, nil
+}
+
+func (tx *boltTransaction) Find(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
+	_, found, err := lookup(tx.tx, macAddressesBucket, host.MacAddress.String(), sameHost(host))
+	return found, err
+}
+
+func (tx *boltTransaction) FindByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
+	_, found, err := lookup(tx.tx, macAddressesBucket, macAddress.String(), sameMacAddress(macAddress))
+	return found, err
+}
+
+func (tx *boltTransaction) FindByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
+	_, found, err := lookup(tx.tx, ipAddressesBucket, ipAddress.String(), sameIPAddress(ipAddress))
+	return found, err
+}
+
+func (tx *boltTransaction) FindByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	_, found, err := lookup(tx.tx, hostNamesBucket, strings.ToLower(hostName), sameHostName(hostName))
+	return found, err
+}
+
+func (tx *boltTransaction) Save(host *model.StaticDhcpHost) error {
+	err := put(tx.tx, host)
+	if err != nil {
+		return err
+	}
+
+	tx.changed = true
+	return nil
+}
+
+// Replace changes the first host equal to the given one into the replacement, keeping its place in the file.
+func (tx *boltTransaction) Replace(host *model.StaticDhcpHost, replacement *model.StaticDhcpHost) error {
+	id, existing, err := lookup(tx.tx, macAddressesBucket, host.MacAddress.String(), sameHost(host))
+	if err != nil || existing == nil {
+		return err
+	}
+
+	err = remove(tx.tx, id, existing)
+	if err != nil {
+		return err
+	}
+	err = store(tx.tx, id, replacement)
+	if err != nil {
+		return err
+	}
+
+	tx.changed = true
+	return nil
+}
+
+func (tx *boltTransaction) Delete(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
+	return tx.delete(macAddressesBucket, host.MacAddress.String(), sameHost(host))
+}
+
+func (tx *boltTransaction) DeleteByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
+	return tx.delete(macAddressesBucket, macAddress.String(), sameMacAddress(macAddress))
+}
+
+func (tx *boltTransaction) DeleteByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
+	return tx.delete(ipAddressesBucket, ipAddress.String(), sameIPAddress(ipAddress))
+}
+
+func (tx *boltTransaction) DeleteByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	return tx.delete(hostNamesBucket, strings.ToLower(hostName), sameHostName(hostName))
+}
+
+func (tx *boltTransaction) delete(index []byte, address string, filter Filter) (*model.StaticDhcpHost, error) {
+	id, host, err := lookup(tx.tx, index, address, filter)
+	if err != nil || host == nil {
+		return nil, err
+	}
+
+	err = remove(tx.tx, id, host)
+	if err != nil {
+		return nil, err
+	}
+
+	tx.changed = true
+	return host, nil
+}
+
+// put stores a new host, after every other one.
+func put(tx *bolt.Tx, host *model.StaticDhcpHost) error {
+	sequence, err := tx.Bucket(hostsBucket).NextSequence()
+	if err != nil {
+		return err
+	}
+
+	return store(tx, binary.BigEndian.AppendUint64(nil, sequence), host)
+}
+
+// store writes the host under the given id, which sets its place among the other hosts.
+func store(tx *bolt.Tx, id []byte, host *model.StaticDhcpHost) error {
+	config, err := host.ToConfig()
+	if err != nil {
+		slog.Debug("Invalid static DHCP host",
+			slog.Any("host", host),
+			slog.String("error", err.Error()),
+		)
+		return err
+	}
+
+	value, err := json.Marshal(boltHost{Config: config, Metadata: host.Metadata})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Bucket(hostsBucket).Put(id, value)
+	if err != nil {
+		return err
+	}
+
+	return forEachIndexKey(id, host, func(index []byte, key []byte) error {
+		return tx.Bucket(index).Put(key, nil)
+	})
+}
+
+func remove(tx *bolt.Tx, id []byte, host *model.StaticDhcpHost) error {
+	err := tx.Bucket(hostsBucket).Delete(id)
+	if err != nil {
+		return err
+	}
+
+	return forEachIndexKey(id, host, func(index []byte, key []byte) error {
+		return tx.Bucket(index).Delete(key)
+	})
+}
+
+// record adds the hosts of the transaction as a new revision, dropping the revisions past the history length. It
+// returns the key of the new revision.
+func record(tx *bolt.Tx) ([]byte, error) {
+	revision := boltRevision{CommittedAt: time.Now().UTC(), Hosts: []boltHost{}}
+	err := tx.Bucket(hostsBucket).ForEach(func(_, value []byte) error {
+		var stored boltHost
+		err := json.Unmarshal(value, &stored)
+		if err != nil {
+			return err
+		}
+		revision.Hosts = append(revision.Hosts, stored)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := json.Marshal(revision)
+	if err != nil {
+		return nil, err
+	}
+	revisions := tx.Bucket(revisionsBucket)
+	sequence, err := revisions.NextSequence()
+	if err != nil {
+		return nil, err
+	}
+	key := binary.BigEndian.AppendUint64(nil, sequence)
+	err = revisions.Put(key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys [][]byte
+	cursor := revisions.Cursor()
+	for key, _ := cursor.First(); key != nil; key, _ = cursor.Next() {
+		keys = append(keys, bytes.Clone(key))
+	}
+	for _, old := range keys[:max(0, len(keys)-boltHistoryLength)] {
+		err := revisions.Delete(old)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+// rollback restores the hosts of the revision before the given one, which is dropped. It is only logged when it
+// fails, as the change being rolled back has already failed.
+func (r *boltRepository) rollback(revision []byte) {
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		revisions := tx.Bucket(revisionsBucket)
+		cursor := revisions.Cursor()
+		cursor.Seek(revision)
+		_, value := cursor.Prev()
+		if value == nil {
+			return errors.New("no previous revision")
+		}
+		var previous boltRevision
+		err := json.Unmarshal(value, &previous)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range [][]byte{hostsBucket, macAddressesBucket, ipAddressesBucket, hostNamesBucket} {
+			err := tx.DeleteBucket(name)
+			if err != nil {
+				return err
+			}
+			_, err = tx.CreateBucket(name)
+			if err != nil {
+				return err
+			}
+		}
+		for _, stored := range previous.Hosts {
+			host, err := stored.host()
+			if err != nil {
+				return err
+			}
+			err = put(tx, host)
+			if err != nil {
+				return err
+			}
+		}
+
+		return revisions.Delete(revision)
+	})
+	if err != nil {
+		slog.Error("Error rolling back a static hosts change, the database no longer matches the static hosts file",
+			slog.String("file", r.file.Path()),
+			slog.String("database", r.db.Path()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// render writes the static hosts file from the hosts committed to the database.
+func (r *boltRepository) render() error {
+	var content bytes.Buffer
+	content.WriteString(renderedFileHeader + "\n")
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(hostsBucket).ForEach(func(_, value []byte) error {
+			var stored boltHost
+			err := json.Unmarshal(value, &stored)
+			if err != nil {
+				return err
+			}
+
+			if !stored.Metadata.IsZero() {
+				comment, err := stored.Metadata.ToComment()
+				if err != nil {
+					return err
+				}
+				content.WriteString(comment + "\n")
+			}
+			content.WriteString(stored.Config + "\n")
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// Other processes coordinating through the file lock must still be honored
+	lock, err := r.file.Lock()
+	if err != nil {
+		slog.Error("Error locking static hosts file",
+			slog.String("file", r.file.Path()),
+			slog.Bool("exclusive", true),
+			slog.String("error", err.Error()),
+		)
+		return err
+	}
+	defer lock.Unlock()
+
+	err = r.file.Write(content.Bytes())
+	if err != nil {
+		slog.Error("Error writing into the static hosts file",
+			slog.String("file", r.file.Path()),
+			slog.String("error", err.Error()),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// lookup returns the first host, in insertion order, indexed under the given address and matching the filter.
+func lookup(tx *bolt.Tx, index []byte, address string, filter Filter) ([]byte, *model.StaticDhcpHost, error) {
+	prefix := indexKeyPrefix(address)
+	hosts := tx.Bucket(hostsBucket)
+	cursor := tx.Bucket(index).Cursor()
+	for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+		// The key memory is owned by the database, the id outlives the cursor
+		id := bytes.Clone(key[len(prefix):])
+		value := hosts.Get(id)
+		if value == nil {
+			continue
+		}
+
+		host, err := decodeBoltHost(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		if filter(*host) {
+			return id, host, nil
+		}
+	}
+
+	return nil, nil, nil
+}
+
+// forEachIndexKey calls fn with the key of every index entry of a host: one for each of its MAC addresses
+// (primary and extra ones) and IP addresses (IPv4 and IPv6 ones), and one for its hostname (if any).
+func forEachIndexKey(id []byte, host *model.StaticDhcpHost, fn func(index []byte, key []byte) error) error {
+	macAddresses := []string{host.MacAddress.String()}
+	for _, extra := range host.ExtraMacAddresses {
+		mac, err := net.ParseMAC(extra)
+		if err == nil {
+			macAddresses = append(macAddresses, mac.String())
+		}
+	}
+	for _, mac := range macAddresses {
+		err := fn(macAddressesBucket, append(indexKeyPrefix(mac), id...))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, ip := range host.IPAddresses() {
+		err := fn(ipAddressesBucket, append(indexKeyPrefix(ip.String()), id...))
+		if err != nil {
+			return err
+		}
+	}
+
+	if host.HostName == "" {
+		return nil
+	}
+	return fn(hostNamesBucket, append(indexKeyPrefix(strings.ToLower(host.HostName)), id...))
+}
+
+// indexKeyPrefix returns the part of the index keys shared by every host having the address. It is
+// terminated by a NUL byte, so an address is never the prefix of a longer one.
+func indexKeyPrefix(address string) []byte {
+	return append([]byte(address), 0)
+}
+
+func decodeBoltHost(value []byte) (*model.StaticDhcpHost, error) {
+	var stored boltHost
+	err := json.Unmarshal(value, &stored)
+	if err != nil {
+		return nil, err
+	}
+
+	return stored.host()
+}
+
+func (stored *boltHost) host() (*model.StaticDhcpHost, error) {
+	host := model.StaticDhcpHost{}
+	err := host.FromConfig(stored.Config)
+	if err != nil {
+		return nil, err
+	}
+	host.Metadata = stored.Metadata
+
+	return &host, nil
+}
+
+func decodeBoltRevision(key []byte, value []byte) (*model.HostRevision, error) {
+	var stored boltRevision
+	err := json.Unmarshal(value, &stored)
+	if err != nil {
+		return nil, err
+	}
+
+	revision := model.HostRevision{
+		Number:      binary.BigEndian.Uint64(key),
+		CommittedAt: stored.CommittedAt,
+		Hosts:       make([]model.StaticDhcpHost, 0, len(stored.Hosts)),
+	}
+	for _, storedHost := range stored.Hosts {
+		host, err := storedHost.host()
+		if err != nil {
+			return nil, err
+		}
+		revision.Hosts = append(revision.Hosts, *host)
+	}
+
+	return &revision, nil
+}
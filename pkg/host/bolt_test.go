@@ -0,0 +1,348 @@
+package host
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"github.com/gringolito/dnsmasq-manager/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	RenderedFileHeader        = "# Generated by dnsmasq-manager, any change made by hand is overwritten by the next API change\n"
+	RenderedAddedHostsContent = RenderedFileHeader + `dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+# dmm: {"description":"Living room NAS","owner":"alice","labels":["storage"],"createdAt":"2024-05-01T10:00:00Z","updatedAt":"2024-05-02T10:00:00Z","createdBy":"bob"}
+dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo
+dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz
+dhcp-host=02:04:06:ab:cd:ef,1.1.1.4,[2001:db8::4],Qux
+`
+	RenderedDeletedHostsContent = RenderedFileHeader + `dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz
+`
+)
+
+func setUpBoltRepository(t *testing.T, fileName string, options storage.Options) (CachedRepository, string) {
+	databasePath := filepath.Join(t.TempDir(), "static-hosts.db")
+	repository, err := NewBoltRepository(databasePath, fileName, options)
+	require.NoError(t, err, "NewBoltRepository() returned an unexpected error")
+	t.Cleanup(func() { repository.Close() })
+
+	return repository, databasePath
+}
+
+func TestBoltRepositoryImport(t *testing.T) {
+	fileName := setUpStaticHostsFile(t, MetadataFileContent)
+	defer tearDownStaticHostsFile(t, fileName)
+
+	expectedHosts, err := NewRepository(fileName, storage.Options{}).FindAll()
+	require.NoError(t, err, "FindAll() returned an unexpected error")
+
+	repository, databasePath := setUpBoltRepository(t, fileName, storage.Options{})
+	hosts, err := repository.FindAll()
+	require.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.Equal(t, expectedHosts, hosts, "FindAll() returned unexpected hosts")
+	// Importing doesn't touch the static hosts file
+	assertFileContent(t, MetadataFileContent, fileName)
+
+	// The import only happens once, the database is the source of truth afterwards
+	require.NoError(t, repository.Close(), "Close() returned an unexpected error")
+	require.NoError(t, os.WriteFile(fileName, []byte(ValidHostFileContent), 0644), "Failed to change DHCP static hosts file")
+	repository, err = NewBoltRepository(databasePath, fileName, storage.Options{})
+	require.NoError(t, err, "NewBoltRepository() returned an unexpected error")
+	defer repository.Close()
+	hosts, err = repository.FindAll()
+	require.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.Equal(t, expectedHosts, hosts, "FindAll() returned unexpected hosts")
+}
+
+func TestBoltRepositoryImportMissingFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "04-dhcp-static-leases.conf")
+	repository, _ := setUpBoltRepository(t, fileName, storage.Options{})
+
+	hosts, err := repository.FindAll()
+	require.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.Empty(t, *hosts, "FindAll() returned unexpected hosts")
+}
+
+func TestBoltRepositoryImportParseMode(t *testing.T) {
+	brokenFileContent := AllHostsFileContent + "\n" + InvalidHostsFileContent
+
+	testCases := []struct {
+		name        string
+		lenient     bool
+		expectError bool
+	}{
+		{name: "Strict", lenient: false, expectError: true},
+		{name: "Lenient", lenient: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := setUpStaticHostsFile(t, brokenFileContent)
+			defer tearDownStaticHostsFile(t, fileName)
+			databasePath := filepath.Join(t.TempDir(), "static-hosts.db")
+
+			repository, err := NewBoltRepository(databasePath, fileName, storage.Options{Lenient: test.lenient})
+			if test.expectError {
+				assert.Error(t, err, "NewBoltRepository() did NOT returned an error")
+				assert.Nil(t, repository, "NewBoltRepository() returned an unexpected repository")
+				return
+			}
+			require.NoError(t, err, "NewBoltRepository() returned an unexpected error")
+			defer repository.Close()
+
+			hosts, err := repository.FindAll()
+			require.NoError(t, err, "FindAll() returned an unexpected error")
+			assert.Len(t, *hosts, len(AllHosts), "FindAll() returned unexpected hosts")
+		})
+	}
+}
+
+func TestBoltRepository(t *testing.T) {
+	fileName := setUpStaticHostsFile(t, MetadataFileContent)
+	defer tearDownStaticHostsFile(t, fileName)
+	repository, _ := setUpBoltRepository(t, fileName, storage.Options{})
+
+	// Changes render the whole static hosts file from the database
+	require.NoError(t, repository.Save(&DualStackHost), "Save() returned an unexpected error")
+	assertFileContent(t, RenderedAddedHostsContent, fileName)
+
+	host, err := repository.FindByIP(DualStackHost.IPv6Address)
+	require.NoError(t, err, "FindByIP() returned an unexpected error")
+	assert.Equal(t, &DualStackHost, host, "FindByIP() returned an unexpected host")
+
+	host, err = repository.FindByMac(ValidHost.MacAddress)
+	require.NoError(t, err, "FindByMac() returned an unexpected error")
+	assert.Equal(t, "alice", host.Metadata.Owner, "FindByMac() returned an unexpected host")
+
+	host, err = repository.FindByHostName("FOO")
+	require.NoError(t, err, "FindByHostName() returned an unexpected error")
+	assert.Equal(t, ValidHost.MacAddress, host.MacAddress, "FindByHostName() returned an unexpected host")
+
+	host, err = repository.Find(&UnknownHost)
+	require.NoError(t, err, "Find() returned an unexpected error")
+	assert.Nil(t, host, "Find() returned an unexpected host")
+
+	host, err = repository.DeleteByIP(DualStackHost.IPAddress)
+	require.NoError(t, err, "DeleteByIP() returned an unexpected error")
+	assert.Equal(t, &DualStackHost, host, "DeleteByIP() returned an unexpected host")
+
+	host, err = repository.Delete(&ValidHost)
+	require.NoError(t, err, "Delete() returned an unexpected error")
+	assert.Equal(t, ValidHost.MacAddress, host.MacAddress, "Delete() returned an unexpected host")
+	assertFileContent(t, RenderedDeletedHostsContent, fileName)
+
+	// Deleted hosts are gone from every index
+	host, err = repository.FindByMac(DualStackHost.MacAddress)
+	require.NoError(t, err, "FindByMac() returned an unexpected error")
+	assert.Nil(t, host, "FindByMac() returned an unexpected host")
+
+	host, err = repository.FindByHostName(ValidHost.HostName)
+	require.NoError(t, err, "FindByHostName() returned an unexpected error")
+	assert.Nil(t, host, "FindByHostName() returned an unexpected host")
+
+	host, err = repository.DeleteByMac(ValidHost.MacAddress)
+	require.NoError(t, err, "DeleteByMac() returned an unexpected error")
+	assert.Nil(t, host, "DeleteByMac() returned an unexpected host")
+	assertFileContent(t, RenderedDeletedHostsContent, fileName)
+
+	diagnostics, err := repository.Diagnostics()
+	require.NoError(t, err, "Diagnostics() returned an unexpected error")
+	assert.Empty(t, diagnostics, "Diagnostics() returned unexpected diagnostics")
+}
+
+func TestBoltRepositoryReplace(t *testing.T) {
+	fileName := setUpStaticHostsFile(t, MetadataFileContent)
+	defer tearDownStaticHostsFile(t, fileName)
+	repository, _ := setUpBoltRepository(t, fileName, storage.Options{})
+
+	movedHost := ValidHost
+	movedHost.IPAddress = net.ParseIP("1.1.1.5")
+	movedHost.Metadata = model.HostMetadata{Owner: "carol"}
+	err := repository.Transaction(func(tx model.HostTransaction) error {
+		return tx.Replace(&ValidHost, &movedHost)
+	})
+	require.NoError(t, err, "Replace() returned an unexpected error")
+
+	// The replaced host keeps its place in the rendered file
+	assertFileContent(t, RenderedFileHeader+`dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+# dmm: {"owner":"carol"}
+dhcp-host=02:04:06:aa:bb:cc,1.1.1.5,Foo
+dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz
+`, fileName)
+
+	host, err := repository.FindByIP(ValidHost.IPAddress)
+	require.NoError(t, err, "FindByIP() returned an unexpected error")
+	assert.Nil(t, host, "FindByIP() has found the replaced address")
+
+	host, err = repository.FindByIP(movedHost.IPAddress)
+	require.NoError(t, err, "FindByIP() returned an unexpected error")
+	assert.Equal(t, &movedHost, host, "FindByIP() returned an unexpected host")
+}
+
+func TestBoltRepositorySharedAddress(t *testing.T) {
+	// Hand-written entries may share an address, the first one in the file wins as with the file backend
+	fileName := setUpStaticHostsFile(t, `dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+dhcp-host=02:04:06:aa:bb:cc,1.1.1.2,Foo`)
+	defer tearDownStaticHostsFile(t, fileName)
+	repository, _ := setUpBoltRepository(t, fileName, storage.Options{})
+
+	host, err := repository.DeleteByIP(net.ParseIP("1.1.1.2"))
+	require.NoError(t, err, "DeleteByIP() returned an unexpected error")
+	assert.Equal(t, "Bar", host.HostName, "DeleteByIP() returned an unexpected host")
+
+	host, err = repository.FindByIP(net.ParseIP("1.1.1.2"))
+	require.NoError(t, err, "FindByIP() returned an unexpected error")
+	assert.Equal(t, &model.StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.2"), HostName: "Foo"}, host, "FindByIP() returned an unexpected host")
+}
+
+func TestBoltRepositoryRejectedChanges(t *testing.T) {
+	testCases := []struct {
+		name string
+		call func(r Repository) error
+	}{
+		{name: "Save", call: func(r Repository) error { return r.Save(&UnknownHost) }},
+		{name: "DeleteByMac", call: func(r Repository) error { _, err := r.DeleteByMac(ValidHost.MacAddress); return err }},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := setUpStaticHostsFile(t, AllHostsFileContent)
+			defer tearDownStaticHostsFile(t, fileName)
+			repository, _ := setUpBoltRepository(t, fileName, storage.Options{Validator: rejectingValidator{}})
+
+			err := test.call(repository)
+			var validationErr *storage.ValidationError
+			assert.ErrorAs(t, err, &validationErr, "Repository returned an unexpected error")
+			assertFileContent(t, AllHostsFileContent, fileName)
+
+			// The transaction was rolled back along with the file
+			hosts, err := repository.FindAll()
+			require.NoError(t, err, "FindAll() returned an unexpected error")
+			assert.ElementsMatch(t, AllHosts, *hosts, "FindAll() returned unexpected hosts")
+		})
+	}
+}
+
+func TestBoltRepositoryTransaction(t *testing.T) {
+	fileName := setUpStaticHostsFile(t, AllHostsFileContent)
+	defer tearDownStaticHostsFile(t, fileName)
+	repository, _ := setUpBoltRepository(t, fileName, storage.Options{})
+
+	// A failed transaction leaves neither the database nor the file changed
+	err := repository.Transaction(func(tx model.HostTransaction) error {
+		_, err := tx.DeleteByMac(ValidHost.MacAddress)
+		require.NoError(t, err, "DeleteByMac() returned an unexpected error")
+		host, err := tx.FindByMac(ValidHost.MacAddress)
+		require.NoError(t, err, "FindByMac() returned an unexpected error")
+		assert.Nil(t, host, "FindByMac() returned a host deleted by the transaction")
+		return errors.New("an error")
+	})
+	assert.Error(t, err, "Transaction() did NOT returned an error")
+	assertFileContent(t, AllHostsFileContent, fileName)
+	hosts, err := repository.FindAll()
+	require.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.ElementsMatch(t, AllHosts, *hosts, "FindAll() returned unexpected hosts")
+
+	err = repository.Transaction(func(tx model.HostTransaction) error {
+		_, err := tx.DeleteByMac(ValidHost.MacAddress)
+		if err != nil {
+			return err
+		}
+		return tx.Save(&UnknownHost)
+	})
+	require.NoError(t, err, "Transaction() returned an unexpected error")
+	assertFileContent(t, RenderedFileHeader+`dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz
+dhcp-host=02:04:06:aa:bb:ff,9.9.9.9,Unknown
+`, fileName)
+}
+
+func TestBoltRepositoryRevisions(t *testing.T) {
+	fileName := setUpStaticHostsFile(t, AllHostsFileContent)
+	defer tearDownStaticHostsFile(t, fileName)
+	repository, databasePath := setUpBoltRepository(t, fileName, storage.Options{})
+
+	// The imported hosts are the first revision
+	require.NoError(t, repository.Save(&UnknownHost), "Save() returned an unexpected error")
+	revisions, err := repository.(HistoryRepository).Revisions()
+	require.NoError(t, err, "Revisions() returned an unexpected error")
+	require.Len(t, revisions, 2, "Revisions() returned unexpected revisions")
+	assert.Equal(t, uint64(1), revisions[0].Number, "Revisions() returned an unexpected revision")
+	assert.ElementsMatch(t, AllHosts, revisions[0].Hosts, "Revisions() returned unexpected hosts")
+	assert.Equal(t, uint64(2), revisions[1].Number, "Revisions() returned an unexpected revision")
+	assert.ElementsMatch(t, append(AllHosts, UnknownHost), revisions[1].Hosts, "Revisions() returned unexpected hosts")
+	assert.False(t, revisions[1].CommittedAt.IsZero(), "Revisions() returned a revision without commit time")
+
+	// A rejected change is rolled back along with its revision
+	require.NoError(t, repository.Close(), "Close() returned an unexpected error")
+	repository, err = NewBoltRepository(databasePath, fileName, storage.Options{Validator: rejectingValidator{}})
+	require.NoError(t, err, "NewBoltRepository() returned an unexpected error")
+	_, err = repository.DeleteByMac(ValidHost.MacAddress)
+	assert.ErrorIs(t, err, ErrValidation, "DeleteByMac() returned an unexpected error")
+	rejected, err := repository.(HistoryRepository).Revisions()
+	require.NoError(t, err, "Revisions() returned an unexpected error")
+	assert.Equal(t, revisions, rejected, "Revisions() returned unexpected revisions")
+
+	// The next revision leaves a gap
+	require.NoError(t, repository.Close(), "Close() returned an unexpected error")
+	repository, err = NewBoltRepository(databasePath, fileName, storage.Options{})
+	require.NoError(t, err, "NewBoltRepository() returned an unexpected error")
+	defer repository.Close()
+	_, err = repository.DeleteByMac(ValidHost.MacAddress)
+	require.NoError(t, err, "DeleteByMac() returned an unexpected error")
+	revisions, err = repository.(HistoryRepository).Revisions()
+	require.NoError(t, err, "Revisions() returned an unexpected error")
+	require.Len(t, revisions, 3, "Revisions() returned unexpected revisions")
+	assert.Equal(t, uint64(4), revisions[2].Number, "Revisions() returned an unexpected revision")
+	assert.Len(t, revisions[2].Hosts, len(AllHosts), "Revisions() returned unexpected hosts")
+}
+
+func TestBoltRepositoryRevisionsLength(t *testing.T) {
+	fileName := setUpStaticHostsFile(t, AllHostsFileContent)
+	defer tearDownStaticHostsFile(t, fileName)
+	repository, _ := setUpBoltRepository(t, fileName, storage.Options{})
+
+	for i := 0; i < boltHistoryLength; i++ {
+		require.NoError(t, repository.Save(&UnknownHost), "Save() returned an unexpected error")
+		_, err := repository.Delete(&UnknownHost)
+		require.NoError(t, err, "Delete() returned an unexpected error")
+	}
+
+	// Only the newest revisions are kept
+	revisions, err := repository.(HistoryRepository).Revisions()
+	require.NoError(t, err, "Revisions() returned an unexpected error")
+	require.Len(t, revisions, boltHistoryLength, "Revisions() returned unexpected revisions")
+	assert.Equal(t, uint64(2*boltHistoryLength+1), revisions[boltHistoryLength-1].Number, "Revisions() returned an unexpected revision")
+}
+
+func TestBoltRepositoryRevisionsOfAnOlderDatabase(t *testing.T) {
+	fileName := setUpStaticHostsFile(t, AllHostsFileContent)
+	defer tearDownStaticHostsFile(t, fileName)
+	repository, databasePath := setUpBoltRepository(t, fileName, storage.Options{})
+	require.NoError(t, repository.Save(&UnknownHost), "Save() returned an unexpected error")
+	require.NoError(t, repository.Close(), "Close() returned an unexpected error")
+
+	// Databases created before the history was kept start it from their current hosts
+	db, err := bolt.Open(databasePath, 0600, nil)
+	require.NoError(t, err, "Failed to open the database")
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		return tx.DeleteBucket(revisionsBucket)
+	}), "Failed to drop the history")
+	require.NoError(t, db.Close(), "Failed to close the database")
+
+	repository, err = NewBoltRepository(databasePath, fileName, storage.Options{})
+	require.NoError(t, err, "NewBoltRepository() returned an unexpected error")
+	defer repository.Close()
+	revisions, err := repository.(HistoryRepository).Revisions()
+	require.NoError(t, err, "Revisions() returned an unexpected error")
+	require.Len(t, revisions, 1, "Revisions() returned unexpected revisions")
+	assert.ElementsMatch(t, append(AllHosts, UnknownHost), revisions[0].Hosts, "Revisions() returned unexpected hosts")
+}
@@ -0,0 +1,101 @@
+package host
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"log/slog"
+)
+
+// cache keeps the last parsed static hosts file in memory, until a change to the file is notified.
+//
+// The parent directory is watched instead of the file itself, because atomic writes (ours included)
+// replace the file with a new one, which would silently drop a watch set on the original file.
+type cache struct {
+	path    string
+	watcher *fsnotify.Watcher
+	mutex   sync.Mutex
+	// Incremented on every invalidation, so a file parsed before a change is never cached after it
+	generation uint64
+	hostsFile  *hostsFile
+}
+
+func newCache(path string) (*cache, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	path = filepath.Clean(path)
+	err = watcher.Add(filepath.Dir(path))
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	c := &cache{
+		path:    path,
+		watcher: watcher,
+	}
+	go c.watch()
+
+	return c, nil
+}
+
+func (c *cache) watch() {
+	for {
+		select {
+		case event, ok := <-c.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != c.path {
+				continue
+			}
+			slog.Debug("Static hosts file changed",
+				slog.String("file", c.path),
+				slog.String("event", event.Op.String()),
+			)
+			c.invalidate()
+		case err, ok := <-c.watcher.Errors:
+			if !ok {
+				return
+			}
+			// Events may have been lost (e.g. queue overflow), so the cached content can't be trusted anymore
+			slog.Warn("Error watching the static hosts file",
+				slog.String("file", c.path),
+				slog.String("error", err.Error()),
+			)
+			c.invalidate()
+		}
+	}
+}
+
+// get returns the cached hosts file (nil if there is none) and the current cache generation, which
+// must be handed back to put.
+func (c *cache) get() (*hostsFile, uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.hostsFile, c.generation
+}
+
+// put caches a hosts file, as long as the file has not changed since the given generation.
+func (c *cache) put(hf *hostsFile, generation uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if generation == c.generation {
+		c.hostsFile = hf
+	}
+}
+
+func (c *cache) invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.generation++
+	c.hostsFile = nil
+}
+
+func (c *cache) close() error {
+	return c.watcher.Close()
+}
@@ -0,0 +1,215 @@
+package host
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"github.com/gringolito/dnsmasq-manager/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cacheNotificationTimeout = 2 * time.Second
+
+func setUpCachedRepository(t *testing.T, content string) (CachedRepository, string) {
+	fileName := filepath.Join(t.TempDir(), "04-dhcp-static-leases.conf")
+	require.NoError(t, os.WriteFile(fileName, []byte(content), 0644), "Failed to create DHCP static hosts file")
+
+	repository, err := NewCachedRepository(fileName, storage.Options{})
+	require.NoError(t, err, "NewCachedRepository() returned an unexpected error")
+	t.Cleanup(func() { repository.Close() })
+
+	return repository, fileName
+}
+
+func TestCachedHostRepositoryExternalChanges(t *testing.T) {
+	testCases := []struct {
+		name   string
+		change func(t *testing.T, fileName string)
+	}{
+		{
+			name: "InPlaceWrite",
+			change: func(t *testing.T, fileName string) {
+				require.NoError(t, os.WriteFile(fileName, []byte(AddedUnknownHostFileContent), 0644))
+			},
+		},
+		{
+			name: "AtomicReplace",
+			change: func(t *testing.T, fileName string) {
+				tmp := filepath.Join(filepath.Dir(fileName), "replacement")
+				require.NoError(t, os.WriteFile(tmp, []byte(AddedUnknownHostFileContent), 0644))
+				require.NoError(t, os.Rename(tmp, fileName))
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			repository, fileName := setUpCachedRepository(t, AllHostsFileContent)
+
+			host, err := repository.FindByMac(UnknownHost.MacAddress)
+			require.NoError(t, err, "FindByMac() returned an unexpected error")
+			require.Nil(t, host, "FindByMac() returned an unexpected host")
+
+			test.change(t, fileName)
+
+			assert.Eventually(t, func() bool {
+				host, err := repository.FindByMac(UnknownHost.MacAddress)
+				return err == nil && host != nil && host.Equal(UnknownHost)
+			}, cacheNotificationTimeout, 10*time.Millisecond, "External change has not been noticed")
+
+			// Further changes must keep being noticed after the file has been replaced
+			require.NoError(t, os.WriteFile(fileName, []byte(ValidHostFileContent), 0644))
+			assert.Eventually(t, func() bool {
+				hosts, err := repository.FindAll()
+				return err == nil && len(*hosts) == 1
+			}, cacheNotificationTimeout, 10*time.Millisecond, "External change has not been noticed")
+		})
+	}
+}
+
+func TestCachedHostRepositoryOwnChanges(t *testing.T) {
+	repository, fileName := setUpCachedRepository(t, AllHostsFileContent)
+
+	err := repository.Save(&UnknownHost)
+	require.NoError(t, err, "Save() returned an unexpected error")
+	host, err := repository.FindByIP(UnknownHost.IPAddress)
+	require.NoError(t, err, "FindByIP() returned an unexpected error")
+	assert.Equal(t, &UnknownHost, host, "FindByIP() does not see a saved host")
+
+	host, err = repository.DeleteByMac(ValidHost.MacAddress)
+	require.NoError(t, err, "DeleteByMac() returned an unexpected error")
+	assert.Equal(t, &ValidHost, host, "DeleteByMac() returned an unexpected host")
+	host, err = repository.Find(&ValidHost)
+	require.NoError(t, err, "Find() returned an unexpected error")
+	assert.Nil(t, host, "Find() still sees a deleted host")
+
+	assertFileContent(t, `dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz
+dhcp-host=02:04:06:aa:bb:ff,9.9.9.9,Unknown`, fileName)
+}
+
+func TestCachedHostRepositoryReturnsCopies(t *testing.T) {
+	repository, _ := setUpCachedRepository(t, AllHostsFileContent)
+
+	hosts, err := repository.FindAll()
+	require.NoError(t, err, "FindAll() returned an unexpected error")
+	(*hosts)[0].HostName = "Changed"
+
+	hosts, err = repository.FindAll()
+	require.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.ElementsMatch(t, AllHosts, *hosts, "FindAll() callers can change the cached hosts")
+}
+
+func TestHostRepositoryIndexes(t *testing.T) {
+	fileContent := `dhcp-host=02:04:06:12:34:56,02:04:06:aa:bb:cc,1.1.1.3,Baz
+dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo
+dhcp-host=id:*,1.1.1.4,NoMac`
+	noMacHost := model.StaticDhcpHost{ClientID: "*", IPAddress: net.ParseIP("1.1.1.4"), HostName: "NoMac"}
+
+	repository, _ := setUpCachedRepository(t, fileContent)
+
+	// Like a linear scan, the first host in the file having the address as an extra one wins
+	host, err := repository.FindByMac(tests.ParseMAC("02:04:06:aa:bb:cc"))
+	require.NoError(t, err, "FindByMac() returned an unexpected error")
+	assert.Equal(t, "Baz", host.HostName, "FindByMac() returned an unexpected host")
+
+	host, err = repository.Find(&ValidHost)
+	require.NoError(t, err, "Find() returned an unexpected error")
+	assert.Equal(t, &ValidHost, host, "Find() returned an unexpected host")
+
+	host, err = repository.Find(&noMacHost)
+	require.NoError(t, err, "Find() returned an unexpected error")
+	assert.Equal(t, &noMacHost, host, "Find() returned an unexpected host")
+
+	host, err = repository.FindByIP(net.ParseIP("::ffff:1.1.1.1"))
+	require.NoError(t, err, "FindByIP() returned an unexpected error")
+	assert.Equal(t, &ValidHost, host, "FindByIP() returned an unexpected host")
+}
+
+func generateStaticHostsFileContent(size int) string {
+	lines := make([]string, 0, size)
+	for i := 0; i < size; i++ {
+		lines = append(lines, fmt.Sprintf("dhcp-host=02:04:06:%02x:%02x:%02x,10.%d.%d.%d,host-%d",
+			(i>>16)&0xff, (i>>8)&0xff, i&0xff, (i>>16)&0xff, (i>>8)&0xff, i&0xff, i))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func BenchmarkHostRepositoryFindByMac(b *testing.B) {
+	for _, size := range []int{100, 1000, 5000} {
+		fileName := filepath.Join(b.TempDir(), "04-dhcp-static-leases.conf")
+		require.NoError(b, os.WriteFile(fileName, []byte(generateStaticHostsFileContent(size)), 0644))
+		// Worst case for a linear scan
+		last := size - 1
+		macAddress := tests.ParseMAC(fmt.Sprintf("02:04:06:%02x:%02x:%02x", (last>>16)&0xff, (last>>8)&0xff, last&0xff))
+
+		b.Run(fmt.Sprintf("Uncached/%d", size), func(b *testing.B) {
+			repository := NewRepository(fileName, storage.Options{})
+			for i := 0; i < b.N; i++ {
+				host, err := repository.FindByMac(macAddress)
+				if err != nil || host == nil {
+					b.Fatalf("FindByMac() failed: %v", err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("Cached/%d", size), func(b *testing.B) {
+			repository, err := NewCachedRepository(fileName, storage.Options{})
+			require.NoError(b, err)
+			defer repository.Close()
+			// The file is parsed only once, by the first lookup
+			_, err = repository.FindByMac(macAddress)
+			require.NoError(b, err)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				host, err := repository.FindByMac(macAddress)
+				if err != nil || host == nil {
+					b.Fatalf("FindByMac() failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkHostRepositoryFindByIP(b *testing.B) {
+	for _, size := range []int{100, 1000, 5000} {
+		fileName := filepath.Join(b.TempDir(), "04-dhcp-static-leases.conf")
+		require.NoError(b, os.WriteFile(fileName, []byte(generateStaticHostsFileContent(size)), 0644))
+		last := size - 1
+		ipAddress := net.ParseIP(fmt.Sprintf("10.%d.%d.%d", (last>>16)&0xff, (last>>8)&0xff, last&0xff))
+
+		b.Run(fmt.Sprintf("Uncached/%d", size), func(b *testing.B) {
+			repository := NewRepository(fileName, storage.Options{})
+			for i := 0; i < b.N; i++ {
+				host, err := repository.FindByIP(ipAddress)
+				if err != nil || host == nil {
+					b.Fatalf("FindByIP() failed: %v", err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("Cached/%d", size), func(b *testing.B) {
+			repository, err := NewCachedRepository(fileName, storage.Options{})
+			require.NoError(b, err)
+			defer repository.Close()
+			// The file is parsed only once, by the first lookup
+			_, err = repository.FindByIP(ipAddress)
+			require.NoError(b, err)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				host, err := repository.FindByIP(ipAddress)
+				if err != nil || host == nil {
+					b.Fatalf("FindByIP() failed: %v", err)
+				}
+			}
+		})
+	}
+}
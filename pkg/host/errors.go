@@ -0,0 +1,68 @@
+package host
+
+import (
+	"fmt"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// Kinds of the errors returned by the Service, checked with errors.Is. They are the kinds shared by every service
+// (see package errkind), so that a single mapper turns any of them into an HTTP response.
+var (
+	ErrNotFound           = errkind.ErrNotFound
+	ErrDuplicate          = errkind.ErrDuplicate
+	ErrValidation         = errkind.ErrValidation
+	ErrVersionMismatch    = errkind.ErrVersionMismatch
+	ErrStorageUnavailable = errkind.ErrStorageUnavailable
+	// Lock timeouts are also storage errors, they are checked first to tell the client to retry
+	ErrLockTimeout  = errkind.ErrLockTimeout
+	ErrReloadFailed = errkind.ErrReloadFailed
+)
+
+// ErrNoHistory is returned when the storage backend keeps no history of the static hosts, only the bolt one does.
+// It is a not found error.
+var ErrNoHistory = fmt.Errorf("no history of the static hosts: %w", ErrNotFound)
+
+// ValidationError is returned when a host can't be stored as it is, because it would be left without any IP
+// address or because the dnsmasq configuration validator rejected it (storage.ValidationError). Addresses
+// outside the served subnets are reported by a SubnetError, which is a validation error as well.
+type ValidationError = errkind.ValidationError
+
+// StorageError is returned when the hosts could not be read or written, e.g. the disk is full.
+type StorageError = errkind.StorageError
+
+// DuplicatedEntryError is returned when the MAC address, an IP address or the hostname of a host is already
+// used by another host.
+type DuplicatedEntryError struct {
+	Field string
+	Value string
+	// The host already using the value
+	Host *model.StaticDhcpHost
+}
+
+const duplicatedEntryErrorMessage = "Duplicated %s: %s"
+
+func (e DuplicatedEntryError) Error() string {
+	return fmt.Sprintf(duplicatedEntryErrorMessage, e.Field, e.Value)
+}
+
+func (e DuplicatedEntryError) Is(target error) bool {
+	return target == ErrDuplicate
+}
+
+// NotFoundError is returned when the host to be changed does not exist.
+type NotFoundError struct {
+	Field string
+	Value string
+}
+
+const notFoundErrorMessage = "No static host found with %s: %s"
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf(notFoundErrorMessage, e.Field, e.Value)
+}
+
+func (e NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
@@ -0,0 +1,53 @@
+package host
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostServiceErrors(t *testing.T) {
+
+	var testCases = []struct {
+		name            string
+		field           string
+		value           string
+		expectedMessage string
+	}{
+		{
+			name:  "DuplicatedIP",
+			field: "IP",
+			value: "1.1.1.1",
+		},
+		{
+			name:  "DuplicatedMAC",
+			field: "MAC",
+			value: "aa:bb:cc:dd:ee:ff",
+		},
+		{
+			name:  "DuplicatedHostName",
+			field: "hostname",
+			value: "Foo",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			err := &DuplicatedEntryError{Field: test.field, Value: test.value}
+			expectedMessage := fmt.Sprintf(duplicatedEntryErrorMessage, test.field, test.value)
+			assert.EqualError(t, err, expectedMessage)
+		})
+	}
+}
+
+func TestHostServiceNotFoundError(t *testing.T) {
+	err := &NotFoundError{Field: "MAC", Value: ValidMACAddress}
+	assert.EqualError(t, err, fmt.Sprintf(notFoundErrorMessage, "MAC", ValidMACAddress))
+}
+
+func TestErrorKinds(t *testing.T) {
+	assert.ErrorIs(t, &NotFoundError{Field: "MAC", Value: ValidMACAddress}, ErrNotFound)
+	assert.ErrorIs(t, &DuplicatedEntryError{Field: "IP", Value: ValidIPAddress}, ErrDuplicate)
+	assert.ErrorIs(t, &SubnetError{}, ErrValidation)
+}
@@ -35,6 +35,14 @@ func (m *RepositoryMock) DeleteByIP(ipAddress net.IP) (*model.StaticDhcpHost, er
 	return args.Get(0).(*model.StaticDhcpHost), args.Error(1)
 }
 
+func (m *RepositoryMock) DeleteByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	args := m.Called(hostName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.StaticDhcpHost), args.Error(1)
+}
+
 func (m *RepositoryMock) Find(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
 	args := m.Called(host)
 	if args.Get(0) == nil {
@@ -67,7 +75,53 @@ func (m *RepositoryMock) FindByIP(ipAddress net.IP) (*model.StaticDhcpHost, erro
 	return args.Get(0).(*model.StaticDhcpHost), args.Error(1)
 }
 
+func (m *RepositoryMock) FindByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	args := m.Called(hostName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.StaticDhcpHost), args.Error(1)
+}
+
+func (m *RepositoryMock) Replace(host *model.StaticDhcpHost, replacement *model.StaticDhcpHost) error {
+	args := m.Called(host, replacement)
+	return args.Error(0)
+}
+
 func (m *RepositoryMock) Save(host *model.StaticDhcpHost) error {
 	args := m.Called(host)
 	return args.Error(0)
 }
+
+func (m *RepositoryMock) Diagnostics() ([]model.Diagnostic, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Diagnostic), args.Error(1)
+}
+
+// Transaction runs fn against the mock itself, so the calls made within the transaction are expected as any
+// other. The "Transaction" call stands for the commit, it is only made when fn succeeds.
+func (m *RepositoryMock) Transaction(fn func(tx model.HostTransaction) error) error {
+	err := fn(m)
+	if err != nil {
+		return err
+	}
+
+	args := m.Called()
+	return args.Error(0)
+}
+
+// HistoryRepositoryMock is a RepositoryMock keeping the revisions of the static hosts.
+type HistoryRepositoryMock struct {
+	RepositoryMock
+}
+
+func (m *HistoryRepositoryMock) Revisions() ([]model.HostRevision, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.HostRevision), args.Error(1)
+}
@@ -11,13 +11,23 @@ type ServiceMock struct {
 	mock.Mock
 }
 
-func (m *ServiceMock) Insert(host *model.StaticDhcpHost) error {
-	args := m.Called(host)
+func (m *ServiceMock) Insert(host *model.StaticDhcpHost, collection model.Versions) error {
+	args := m.Called(host, collection)
 	return args.Error(0)
 }
 
-func (m *ServiceMock) Update(host *model.StaticDhcpHost) error {
-	args := m.Called(host)
+func (m *ServiceMock) Update(host *model.StaticDhcpHost, versions model.Versions) error {
+	args := m.Called(host, versions)
+	return args.Error(0)
+}
+
+func (m *ServiceMock) UpdateByMac(macAddress net.HardwareAddr, host *model.StaticDhcpHost, versions model.Versions) error {
+	args := m.Called(macAddress, host, versions)
+	return args.Error(0)
+}
+
+func (m *ServiceMock) UpdateByHostName(hostName string, host *model.StaticDhcpHost, versions model.Versions) error {
+	args := m.Called(hostName, host, versions)
 	return args.Error(0)
 }
 
@@ -45,16 +55,64 @@ func (m *ServiceMock) FetchByMac(macAddress net.HardwareAddr) (*model.StaticDhcp
 	return args.Get(0).(*model.StaticDhcpHost), args.Error(1)
 }
 
-func (m *ServiceMock) RemoveByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
-	args := m.Called(ipAddress)
+func (m *ServiceMock) FetchByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	args := m.Called(hostName)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.StaticDhcpHost), args.Error(1)
 }
 
-func (m *ServiceMock) RemoveByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
-	args := m.Called(macAddress)
+func (m *ServiceMock) RemoveByIP(ipAddress net.IP, versions model.Versions) (*model.StaticDhcpHost, error) {
+	args := m.Called(ipAddress, versions)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.StaticDhcpHost), args.Error(1)
+}
+
+func (m *ServiceMock) RemoveByMac(macAddress net.HardwareAddr, versions model.Versions) (*model.StaticDhcpHost, error) {
+	args := m.Called(macAddress, versions)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.StaticDhcpHost), args.Error(1)
+}
+
+func (m *ServiceMock) RemoveByHostName(hostName string, versions model.Versions) (*model.StaticDhcpHost, error) {
+	args := m.Called(hostName, versions)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.StaticDhcpHost), args.Error(1)
+}
+
+func (m *ServiceMock) Diagnostics() ([]model.Diagnostic, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Diagnostic), args.Error(1)
+}
+
+func (m *ServiceMock) History() ([]model.HostRevision, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.HostRevision), args.Error(1)
+}
+
+func (m *ServiceMock) Patch(macAddress net.HardwareAddr, patch *model.StaticDhcpHostPatch, versions model.Versions) (*model.StaticDhcpHost, error) {
+	args := m.Called(macAddress, patch, versions)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.StaticDhcpHost), args.Error(1)
+}
+
+func (m *ServiceMock) PatchByHostName(hostName string, patch *model.StaticDhcpHostPatch, versions model.Versions) (*model.StaticDhcpHost, error) {
+	args := m.Called(hostName, patch, versions)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -0,0 +1,147 @@
+package host
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/oui"
+)
+
+// Query selects, orders and pages the hosts of a listing.
+type Query struct {
+	Filter Filter
+	// Nil keeps the static hosts file order
+	Order Order
+	// Number of matching hosts skipped before the page
+	Offset int
+	// Maximum number of hosts in the page, 0 for no limit
+	Limit int
+}
+
+// Apply returns the page of the hosts matching the query, along with the total number of matching hosts.
+// The given slice is reused.
+func (q *Query) Apply(hosts []model.StaticDhcpHost) ([]model.StaticDhcpHost, int) {
+	if q.Filter != nil {
+		hosts = slices.DeleteFunc(hosts, func(h model.StaticDhcpHost) bool { return !q.Filter(h) })
+	}
+	if q.Order != nil {
+		slices.SortStableFunc(hosts, q.Order)
+	}
+
+	total := len(hosts)
+	start := min(q.Offset, total)
+	end := total
+	if q.Limit > 0 {
+		end = min(start+q.Limit, total)
+	}
+
+	return hosts[start:end], total
+}
+
+// Order compares two hosts, as in slices.SortFunc.
+type Order func(a, b model.StaticDhcpHost) int
+
+// Sort keys of a listing
+const (
+	SortByIP       = "ip"
+	SortByHostName = "hostname"
+	SortByMac      = "mac"
+)
+
+var ErrUnknownSortKey = errors.New("unknown sort key")
+
+// OrderBy returns the order of the given sort key. Hosts are sorted by IP address numerically, the IPv4 ones
+// first (dual-stack hosts by their IPv4 address), by hostname regardless of the case, or by MAC address.
+func OrderBy(key string, descending bool) (Order, error) {
+	var order Order
+	switch key {
+	case SortByIP:
+		order = compareIPAddresses
+	case SortByHostName:
+		order = func(a, b model.StaticDhcpHost) int {
+			return strings.Compare(strings.ToLower(a.HostName), strings.ToLower(b.HostName))
+		}
+	case SortByMac:
+		order = func(a, b model.StaticDhcpHost) int {
+			return bytes.Compare(a.MacAddress, b.MacAddress)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSortKey, key)
+	}
+
+	if descending {
+		return func(a, b model.StaticDhcpHost) int { return order(b, a) }, nil
+	}
+	return order, nil
+}
+
+func compareIPAddresses(a, b model.StaticDhcpHost) int {
+	familyA, ipA := sortAddress(a)
+	familyB, ipB := sortAddress(b)
+	return cmp.Or(cmp.Compare(familyA, familyB), bytes.Compare(ipA, ipB), bytes.Compare(a.MacAddress, b.MacAddress))
+}
+
+// sortAddress returns the address a host is sorted by, along with its rank: IPv4 addresses come first, then
+// the IPv6 ones and finally the hosts without any address (which dnsmasq ignores).
+func sortAddress(host model.StaticDhcpHost) (int, net.IP) {
+	switch {
+	case host.IPAddress != nil:
+		return 0, host.IPAddress.To16()
+	case host.IPv6Address != nil:
+		return 1, host.IPv6Address.To16()
+	default:
+		return 2, nil
+	}
+}
+
+// InSubnet matches the hosts having an address, of either family, in the given subnet.
+func InSubnet(subnet *net.IPNet) Filter {
+	return func(other model.StaticDhcpHost) bool {
+		return slices.ContainsFunc(other.IPAddresses(), subnet.Contains)
+	}
+}
+
+// HostNameMatches matches the hosts whose hostname matches the glob pattern (see path.Match), regardless of
+// the case. A prefix is matched with a trailing `*` (e.g. `printer-*`).
+func HostNameMatches(pattern string) (Filter, error) {
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	return func(other model.StaticDhcpHost) bool {
+		matched, _ := path.Match(pattern, strings.ToLower(other.HostName))
+		return matched
+	}, nil
+}
+
+// MacPrefix matches the hosts whose MAC address starts with the given bytes, such as the OUI of a vendor.
+func MacPrefix(prefix []byte) Filter {
+	return func(other model.StaticDhcpHost) bool {
+		return bytes.HasPrefix(other.MacAddress, prefix)
+	}
+}
+
+// FromVendor matches the hosts whose MAC address was assigned to a vendor whose name contains the given one,
+// regardless of the case (e.g. `espressif`).
+func FromVendor(vendor string) Filter {
+	vendor = strings.ToLower(vendor)
+	return func(other model.StaticDhcpHost) bool {
+		name := oui.Vendor(other.MacAddress)
+		return name != "" && strings.Contains(strings.ToLower(name), vendor)
+	}
+}
+
+// Tagged matches the hosts setting, or requiring, the given dnsmasq tag.
+func Tagged(tag string) Filter {
+	return func(other model.StaticDhcpHost) bool {
+		return slices.Contains(other.SetTags, tag) || slices.Contains(other.MatchTags, tag)
+	}
+}
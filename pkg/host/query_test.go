@@ -0,0 +1,119 @@
+package host
+
+import (
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var QueryHosts = []model.StaticDhcpHost{
+	{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("192.168.1.10"), HostName: "printer-lab"},
+	{MacAddress: tests.ParseMAC("00:11:22:33:44:55"), IPv6Address: net.ParseIP("2001:db8::5"), HostName: "Nas", SetTags: []string{"lan"}},
+	{MacAddress: tests.ParseMAC("02:04:06:dd:ee:ff"), IPAddress: net.ParseIP("192.168.1.9"), IPv6Address: net.ParseIP("2001:db8::1"), HostName: "printer-office"},
+	{MacAddress: tests.ParseMAC("00:11:22:aa:bb:cc"), HostName: "blocked", Ignore: true, MatchTags: []string{"lan"}},
+	{MacAddress: tests.ParseMAC("0a:00:00:00:00:01"), IPAddress: net.ParseIP("10.0.0.1"), HostName: "router"},
+	{MacAddress: tests.ParseMAC("24:0a:c4:12:34:56"), IPAddress: net.ParseIP("10.0.0.2"), HostName: "sensor"},
+}
+
+func hostNames(hosts []model.StaticDhcpHost) []string {
+	names := []string{}
+	for _, host := range hosts {
+		names = append(names, host.HostName)
+	}
+	return names
+}
+
+func TestQueryOrder(t *testing.T) {
+	testCases := []struct {
+		key           string
+		descending    bool
+		expectedNames []string
+	}{
+		{key: SortByIP, expectedNames: []string{"router", "sensor", "printer-office", "printer-lab", "Nas", "blocked"}},
+		{key: SortByIP, descending: true, expectedNames: []string{"blocked", "Nas", "printer-lab", "printer-office", "sensor", "router"}},
+		{key: SortByHostName, expectedNames: []string{"blocked", "Nas", "printer-lab", "printer-office", "router", "sensor"}},
+		{key: SortByMac, expectedNames: []string{"Nas", "blocked", "printer-lab", "printer-office", "router", "sensor"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.key, func(t *testing.T) {
+			order, err := OrderBy(test.key, test.descending)
+			require.NoError(t, err, "OrderBy() returned an unexpected error")
+
+			query := Query{Order: order}
+			hosts, total := query.Apply(slices.Clone(QueryHosts))
+			assert.Equal(t, len(QueryHosts), total, "Apply() returned an unexpected total")
+			assert.Equal(t, test.expectedNames, hostNames(hosts), "Apply() returned the hosts in an unexpected order")
+		})
+	}
+
+	_, err := OrderBy("owner", false)
+	assert.ErrorIs(t, err, ErrUnknownSortKey, "OrderBy() returned an unexpected error")
+}
+
+func TestQueryFilters(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("192.168.1.0/24")
+	_, ipv6Subnet, _ := net.ParseCIDR("2001:db8::/64")
+	printers, err := HostNameMatches("PRINTER-*")
+	require.NoError(t, err, "HostNameMatches() returned an unexpected error")
+	nas, err := HostNameMatches("nas")
+	require.NoError(t, err, "HostNameMatches() returned an unexpected error")
+
+	testCases := []struct {
+		name          string
+		filter        Filter
+		expectedNames []string
+	}{
+		{name: "InSubnet", filter: InSubnet(subnet), expectedNames: []string{"printer-lab", "printer-office"}},
+		{name: "InIPv6Subnet", filter: InSubnet(ipv6Subnet), expectedNames: []string{"Nas", "printer-office"}},
+		{name: "HostNamePrefix", filter: printers, expectedNames: []string{"printer-lab", "printer-office"}},
+		{name: "HostName", filter: nas, expectedNames: []string{"Nas"}},
+		{name: "MacPrefix", filter: MacPrefix([]byte{0x00, 0x11, 0x22}), expectedNames: []string{"Nas", "blocked"}},
+		{name: "FromVendor", filter: FromVendor("ESPRESSIF"), expectedNames: []string{"sensor"}},
+		{name: "Tagged", filter: Tagged("lan"), expectedNames: []string{"Nas", "blocked"}},
+		{name: "All", filter: All(InSubnet(ipv6Subnet), Tagged("lan")), expectedNames: []string{"Nas"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			query := Query{Filter: test.filter}
+			hosts, total := query.Apply(slices.Clone(QueryHosts))
+			assert.Equal(t, len(test.expectedNames), total, "Apply() returned an unexpected total")
+			assert.Equal(t, test.expectedNames, hostNames(hosts), "Apply() returned unexpected hosts")
+		})
+	}
+
+	_, err = HostNameMatches("printer-[")
+	assert.Error(t, err, "HostNameMatches() did NOT returned an error")
+}
+
+func TestQueryPagination(t *testing.T) {
+	order, err := OrderBy(SortByHostName, false)
+	require.NoError(t, err, "OrderBy() returned an unexpected error")
+
+	testCases := []struct {
+		name          string
+		offset        int
+		limit         int
+		expectedNames []string
+	}{
+		{name: "FirstPage", offset: 0, limit: 2, expectedNames: []string{"blocked", "Nas"}},
+		{name: "LastPage", offset: 4, limit: 2, expectedNames: []string{"router", "sensor"}},
+		{name: "PastTheEnd", offset: 9, limit: 2, expectedNames: []string{}},
+		{name: "NoLimit", offset: 3, expectedNames: []string{"printer-office", "router", "sensor"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			query := Query{Order: order, Offset: test.offset, Limit: test.limit}
+			hosts, total := query.Apply(slices.Clone(QueryHosts))
+			assert.Equal(t, len(QueryHosts), total, "Apply() returned an unexpected total")
+			assert.Equal(t, test.expectedNames, hostNames(hosts), "Apply() returned an unexpected page")
+		})
+	}
+}
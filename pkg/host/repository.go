@@ -1,194 +1,371 @@
 package host
 
 import (
-	"bufio"
-	"bytes"
 	"net"
-	"os"
 	"strings"
 	"sync"
 
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
 	"github.com/gringolito/dnsmasq-manager/pkg/model"
-	"log/slog"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
 )
 
 type Repository interface {
 	Delete(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error)
 	DeleteByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error)
 	DeleteByIP(ipAddress net.IP) (*model.StaticDhcpHost, error)
+	DeleteByHostName(hostName string) (*model.StaticDhcpHost, error)
 	Find(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error)
 	FindAll() (*[]model.StaticDhcpHost, error)
 	FindByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error)
 	FindByIP(ipAddress net.IP) (*model.StaticDhcpHost, error)
+	// FindByHostName looks the host up regardless of the case, as DNS names are
+	FindByHostName(hostName string) (*model.StaticDhcpHost, error)
 	Save(host *model.StaticDhcpHost) error
+	// Transaction runs fn as a single unit of work, holding the exclusive lock of the static hosts file.
+	// The changes made by fn are written at once when it returns nil, and thrown away otherwise.
+	Transaction(fn func(tx model.HostTransaction) error) error
+	// Diagnostics parses the static hosts file leniently, reporting every line that could not be parsed
+	Diagnostics() ([]model.Diagnostic, error)
+}
+
+// CachedRepository is a Repository that keeps the parsed static hosts file in memory, reloading it
+// only when the file changes on disk. Close must be called to stop watching the file.
+type CachedRepository interface {
+	Repository
+	Close() error
+}
+
+// HistoryRepository is a Repository keeping the revisions of the static hosts, one for each committed change.
+type HistoryRepository interface {
+	Repository
+	// Revisions returns the kept revisions, oldest first
+	Revisions() ([]model.HostRevision, error)
 }
 
 type repository struct {
-	staticHostsFilePath string
-	mutex               sync.RWMutex
+	document *storage.DocumentRepository[model.StaticDhcpHost]
+	// Keeps the cache consistent with the writes, which invalidate it
+	mutex sync.RWMutex
+	// Nil when the file must be parsed on every call
+	cache *cache
+}
+
+// hostsFile is a parsed static hosts file. It may be shared by concurrent readers through the cache, which is
+// fine as the transactions never modify the entries they started from.
+type hostsFile = storage.Entries[model.StaticDhcpHost]
+
+func NewRepository(staticHostsFilePath string, options storage.Options) Repository {
+	return &repository{
+		document: newHostsDocument(staticHostsFilePath, options),
+	}
 }
 
-func NewRepository(staticHostsFilePath string) Repository {
+func NewCachedRepository(staticHostsFilePath string, options storage.Options) (CachedRepository, error) {
+	cache, err := newCache(staticHostsFilePath)
+	if err != nil {
+		return nil, err
+	}
+
 	return &repository{
-		staticHostsFilePath: staticHostsFilePath,
+		document: newHostsDocument(staticHostsFilePath, options),
+		cache:    cache,
+	}, nil
+}
+
+// newHostsDocument returns the storage.DocumentRepository of the static hosts kept in the given file, which must
+// exist. The metadata of a host is kept in a comment on the line before it, see model.HostMetadata.
+func newHostsDocument(staticHostsFilePath string, options storage.Options) *storage.DocumentRepository[model.StaticDhcpHost] {
+	return storage.NewDocumentRepository(staticHostsFilePath, options, storage.EntryHooks[model.StaticDhcpHost]{
+		Name: "static DHCP host",
+		Is: func(line string) bool {
+			return dnsmasq.IsOption(line, "dhcp-host")
+		},
+		Parse: func(line string) (model.StaticDhcpHost, error) {
+			host := model.StaticDhcpHost{}
+			err := host.FromConfig(line)
+			return host, err
+		},
+		Format:    (*model.StaticDhcpHost).ToConfig,
+		Keys:      keys,
+		IsComment: model.IsMetadataComment,
+		ParseComment: func(host *model.StaticDhcpHost, comment string) error {
+			return host.Metadata.FromComment(comment)
+		},
+		FormatComment: func(host *model.StaticDhcpHost) (string, error) {
+			if host.Metadata.IsZero() {
+				return "", nil
+			}
+			return host.Metadata.ToComment()
+		},
+		Required: true,
+	})
+}
+
+// keys indexes the hosts by their normalized MAC addresses (primary and extra ones), IP addresses (IPv4 and IPv6
+// ones) and lower case hostname, see macAddressKey, ipAddressKey and hostNameKey.
+func keys(host *model.StaticDhcpHost) []string {
+	keys := []string{macAddressKey(host.MacAddress)}
+	for _, extra := range host.ExtraMacAddresses {
+		mac, err := net.ParseMAC(extra)
+		if err == nil {
+			keys = append(keys, macAddressKey(mac))
+		}
+	}
+	for _, ip := range host.IPAddresses() {
+		keys = append(keys, ipAddressKey(ip))
+	}
+	if host.HostName != "" {
+		keys = append(keys, hostNameKey(host.HostName))
 	}
+	return keys
+}
+
+func (r *repository) Close() error {
+	if r.cache == nil {
+		return nil
+	}
+	return r.cache.close()
 }
 
 func (r *repository) FindAll() (*[]model.StaticDhcpHost, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
-	return r.load()
+	hf, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// The cached hosts must not be changed by the caller
+	hosts := hf.All()
+	return &hosts, nil
 }
 
 func (r *repository) Find(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-	return r.find(sameHost(host))
+	return r.find(macAddressKey(host.MacAddress), sameHost(host))
 }
 
 func (r *repository) FindByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-	return r.find(sameMacAddress(macAddress))
+	return r.find(macAddressKey(macAddress), sameMacAddress(macAddress))
 }
 
 func (r *repository) FindByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-	return r.find(sameIPAddress(ipAddress))
+	return r.find(ipAddressKey(ipAddress), sameIPAddress(ipAddress))
 }
 
-func (r *repository) Save(host *model.StaticDhcpHost) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-	hosts, err := r.load()
+func (r *repository) FindByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	return r.find(hostNameKey(hostName), sameHostName(hostName))
+}
+
+func (r *repository) Diagnostics() ([]model.Diagnostic, error) {
+	// Always lenient, in strict mode this is the only way to find out every broken line at once
+	hf, err := r.document.Diagnose()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	*hosts = append(*hosts, *host)
-	return r.save(hosts)
+	return hf.Diagnostics(), nil
+}
+
+func (r *repository) Save(host *model.StaticDhcpHost) error {
+	return r.Transaction(func(tx model.HostTransaction) error {
+		return tx.Save(host)
+	})
 }
 
 func (r *repository) Delete(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-	return r.delete(sameHost(host))
+	return r.deleteOne(func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return tx.Delete(host)
+	})
 }
 
 func (r *repository) DeleteByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-	return r.delete(sameMacAddress(macAddress))
+	return r.deleteOne(func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return tx.DeleteByMac(macAddress)
+	})
 }
 
 func (r *repository) DeleteByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
+	return r.deleteOne(func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return tx.DeleteByIP(ipAddress)
+	})
+}
+
+func (r *repository) DeleteByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	return r.deleteOne(func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return tx.DeleteByHostName(hostName)
+	})
+}
+
+// Transaction reads the file while holding its exclusive lock instead of relying on the cache, which may not have
+// been notified yet about a change made by another process.
+func (r *repository) Transaction(fn func(tx model.HostTransaction) error) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	return r.delete(sameIPAddress(ipAddress))
+	changed := false
+	err := r.document.Transaction(func(tx *storage.DocumentTransaction[model.StaticDhcpHost]) error {
+		ftx := &fileTransaction{tx: tx}
+		err := fn(ftx)
+		changed = ftx.changed
+		return err
+	})
+	// Don't wait for the file change notification, the next call must already see the new content
+	if err == nil && changed && r.cache != nil {
+		r.cache.invalidate()
+	}
+
+	return err
+}
+
+// deleteOne runs a single deletion as a transaction, returning the deleted host even if it could not be written.
+func (r *repository) deleteOne(deleteFn func(tx model.HostTransaction) (*model.StaticDhcpHost, error)) (*model.StaticDhcpHost, error) {
+	var deleted *model.StaticDhcpHost
+	err := r.Transaction(func(tx model.HostTransaction) error {
+		var err error
+		deleted, err = deleteFn(tx)
+		return err
+	})
+
+	return deleted, err
 }
 
-func (r *repository) load() (*[]model.StaticDhcpHost, error) {
-	file, err := os.Open(r.staticHostsFilePath)
+func (r *repository) load() (*hostsFile, error) {
+	var generation uint64
+	if r.cache != nil {
+		var hf *hostsFile
+		hf, generation = r.cache.get()
+		if hf != nil {
+			return hf, nil
+		}
+	}
+
+	hf, err := r.document.Load()
 	if err != nil {
-		slog.Error("Error reading static hosts file",
-			slog.String("file", r.staticHostsFilePath),
-			slog.String("error", err.Error()),
-		)
 		return nil, err
 	}
-	defer file.Close()
 
-	return r.parse(file)
+	if r.cache != nil {
+		r.cache.put(hf, generation)
+	}
+
+	return hf, nil
 }
 
-func (r *repository) parse(file *os.File) (*[]model.StaticDhcpHost, error) {
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanLines)
+func (r *repository) find(key string, filter Filter) (*model.StaticDhcpHost, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	hf, err := r.load()
+	if err != nil {
+		return nil, err
+	}
 
-	hosts := []model.StaticDhcpHost{}
-	for scanner.Scan() {
-		if !strings.HasPrefix(scanner.Text(), "dhcp-host=") {
-			slog.Debug("Skipping line", slog.String("line", scanner.Text()))
-			continue
-		}
-		slog.Debug("Parsing line", slog.String("line", scanner.Text()))
-
-		host := model.StaticDhcpHost{}
-		err := host.FromConfig(scanner.Text())
-		if err != nil {
-			slog.Error("Failed to parse static DHCP host entry",
-				slog.String("entry", scanner.Text()),
-				slog.String("error", err.Error()),
-			)
-			return nil, err
-		}
+	_, host := hf.LookupFunc(key, filter)
+	return host, nil
+}
 
-		hosts = append(hosts, host)
-	}
+// fileTransaction looks the hosts up by address or hostname in a storage.DocumentTransaction.
+type fileTransaction struct {
+	tx *storage.DocumentTransaction[model.StaticDhcpHost]
+	// Whether the file is written back, so the cache must be invalidated
+	changed bool
+}
+
+func (tx *fileTransaction) Find(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
+	_, found := tx.tx.Entries().LookupFunc(macAddressKey(host.MacAddress), sameHost(host))
+	return found, nil
+}
 
+func (tx *fileTransaction) FindAll() (*[]model.StaticDhcpHost, error) {
+	hosts := tx.tx.Entries().All()
 	return &hosts, nil
 }
 
-func (r *repository) save(hosts *[]model.StaticDhcpHost) error {
-	config := make([]string, 0, len(*hosts))
-	for _, host := range *hosts {
-		hostConfig, err := host.ToConfig()
-		if err != nil {
-			slog.Debug("Invalid static DHCP host",
-				slog.Any("host", host),
-				slog.String("error", err.Error()),
-			)
-			return err
-		}
-		config = append(config, hostConfig)
-	}
+func (tx *fileTransaction) FindByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
+	_, host := tx.tx.Entries().LookupFunc(macAddressKey(macAddress), sameMacAddress(macAddress))
+	return host, nil
+}
+
+func (tx *fileTransaction) FindByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
+	_, host := tx.tx.Entries().LookupFunc(ipAddressKey(ipAddress), sameIPAddress(ipAddress))
+	return host, nil
+}
+
+func (tx *fileTransaction) FindByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	_, host := tx.tx.Entries().LookupFunc(hostNameKey(hostName), sameHostName(hostName))
+	return host, nil
+}
 
-	err := os.WriteFile(r.staticHostsFilePath, []byte(strings.Join(config, "\n")), os.FileMode(0644))
+func (tx *fileTransaction) Save(host *model.StaticDhcpHost) error {
+	err := tx.tx.Append(host)
 	if err != nil {
-		slog.Error("Error writing into the static hosts file",
-			slog.String("file", r.staticHostsFilePath),
-			slog.String("error", err.Error()),
-		)
 		return err
 	}
 
+	tx.changed = true
 	return nil
 }
 
-func (r *repository) delete(filter Filter) (*model.StaticDhcpHost, error) {
-	hosts, err := r.load()
+// Replace changes the first host equal to the given one into the replacement, keeping its place in the file. Its
+// metadata comment is updated along with it.
+func (tx *fileTransaction) Replace(host *model.StaticDhcpHost, replacement *model.StaticDhcpHost) error {
+	i, _ := tx.tx.Entries().LookupFunc(macAddressKey(host.MacAddress), sameHost(host))
+	if i < 0 {
+		return nil
+	}
+
+	err := tx.tx.Replace(i, replacement)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	h := *hosts
-	for i, host := range h {
-		if !filter(host) {
-			continue
-		}
+	tx.changed = true
+	return nil
+}
 
-		*hosts = append(h[:i], h[i+1:]...)
-		err := r.save(hosts)
-		return &host, err
-	}
+func (tx *fileTransaction) Delete(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
+	return tx.delete(macAddressKey(host.MacAddress), sameHost(host))
+}
 
-	return nil, nil
+func (tx *fileTransaction) DeleteByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
+	return tx.delete(macAddressKey(macAddress), sameMacAddress(macAddress))
 }
 
-func (r *repository) find(filter Filter) (*model.StaticDhcpHost, error) {
-	hosts, err := r.load()
-	if err != nil {
-		return nil, err
+func (tx *fileTransaction) DeleteByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
+	return tx.delete(ipAddressKey(ipAddress), sameIPAddress(ipAddress))
+}
+
+func (tx *fileTransaction) DeleteByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	return tx.delete(hostNameKey(hostName), sameHostName(hostName))
+}
+
+// delete removes the first host indexed under the key and matching the filter, along with its metadata comment.
+func (tx *fileTransaction) delete(key string, filter Filter) (*model.StaticDhcpHost, error) {
+	i, host := tx.tx.Entries().LookupFunc(key, filter)
+	if host == nil {
+		return nil, nil
 	}
 
-	for _, host := range *hosts {
-		if filter(host) {
-			return &host, nil
-		}
+	err := tx.tx.Remove(i)
+	if err != nil {
+		return host, err
 	}
 
-	return nil, nil
+	tx.changed = true
+	return host, nil
+}
+
+// macAddressKey returns the index key of a MAC address. The keys are prefixed by their kind, so an address is
+// never mistaken for a hostname.
+func macAddressKey(macAddress net.HardwareAddr) string {
+	return "mac:" + macAddress.String()
+}
+
+func ipAddressKey(ipAddress net.IP) string {
+	return "ip:" + ipAddress.String()
+}
+
+func hostNameKey(hostName string) string {
+	return "name:" + strings.ToLower(hostName)
 }
 
 type Filter func(model.StaticDhcpHost) bool
@@ -200,12 +377,53 @@ func sameHost(host *model.StaticDhcpHost) Filter {
 }
 func sameMacAddress(macAddress net.HardwareAddr) Filter {
 	return func(other model.StaticDhcpHost) bool {
-		return bytes.Equal(macAddress, other.MacAddress)
+		return other.HasMacAddress(macAddress)
 	}
 }
 
 func sameIPAddress(ipAddress net.IP) Filter {
 	return func(other model.StaticDhcpHost) bool {
-		return ipAddress.Equal(other.IPAddress)
+		return other.HasIPAddress(ipAddress)
+	}
+}
+
+// sameHostName matches the hosts with the given hostname, regardless of the case. Hosts without a hostname
+// (e.g. ignored ones) never match.
+func sameHostName(hostName string) Filter {
+	return func(other model.StaticDhcpHost) bool {
+		return hostName != "" && strings.EqualFold(other.HostName, hostName)
+	}
+}
+
+// OwnedBy matches the hosts whose metadata owner is the given one.
+func OwnedBy(owner string) Filter {
+	return func(other model.StaticDhcpHost) bool {
+		return other.Metadata.Owner == owner
+	}
+}
+
+// CreatedBy matches the hosts created by the given user.
+func CreatedBy(user string) Filter {
+	return func(other model.StaticDhcpHost) bool {
+		return other.Metadata.CreatedBy == user
+	}
+}
+
+// Labeled matches the hosts having the given label.
+func Labeled(label string) Filter {
+	return func(other model.StaticDhcpHost) bool {
+		return other.Metadata.HasLabel(label)
+	}
+}
+
+// All matches the hosts matched by every one of the given filters.
+func All(filters ...Filter) Filter {
+	return func(other model.StaticDhcpHost) bool {
+		for _, filter := range filters {
+			if !filter(other) {
+				return false
+			}
+		}
+		return true
 	}
 }
@@ -5,8 +5,10 @@ import (
 	"net"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
 	"github.com/gringolito/dnsmasq-manager/tests"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,6 +20,10 @@ var AllHosts = []model.StaticDhcpHost{
 	{MacAddress: tests.ParseMAC("02:04:06:12:34:56"), IPAddress: net.ParseIP("1.1.1.3"), HostName: "Baz"},
 }
 
+var DualStackHost = model.StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:ab:cd:ef"), IPAddress: net.ParseIP("1.1.1.4"), IPv6Address: net.ParseIP("2001:db8::4"), HostName: "Qux"}
+
+var IPv6OnlyHost = model.StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:fe:dc:ba"), IPv6Address: net.ParseIP("2001:db8::5"), HostName: "Quux"}
+
 var UnknownHost = model.StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:ff"), IPAddress: net.ParseIP("9.9.9.9"), HostName: "Unknown"}
 
 const (
@@ -30,6 +36,44 @@ dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz`
 dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo
 dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz
 dhcp-host=02:04:06:aa:bb:ff,9.9.9.9,Unknown`
+	ExtendedSyntaxFileContent = `dhcp-host=02:04:06:dd:ee:ff,11:22:33:*:*:*,set:red,1.1.1.2,Bar,12h
+dhcp-host=02:04:06:12:34:56,id:*,ignore`
+	AddedToExtendedSyntaxFileContent = `dhcp-host=02:04:06:dd:ee:ff,11:22:33:*:*:*,set:red,1.1.1.2,Bar,12h
+dhcp-host=02:04:06:12:34:56,id:*,ignore
+dhcp-host=02:04:06:aa:bb:ff,9.9.9.9,Unknown`
+	CommentedFileContent = `# Static DHCP leases
+dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+
+# Servers
+dhcp-option=option:router,1.1.1.254
+dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo
+dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz
+`
+	DeletedFromCommentedFileContent = `# Static DHCP leases
+dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+
+# Servers
+dhcp-option=option:router,1.1.1.254
+dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz
+`
+	AddedToCommentedFileContent = CommentedFileContent + `dhcp-host=02:04:06:aa:bb:ff,9.9.9.9,Unknown
+`
+	DualStackFileContent = `dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+dhcp-host=02:04:06:ab:cd:ef,1.1.1.4,[2001:db8::4],Qux
+dhcp-host=02:04:06:fe:dc:ba,[2001:db8::5],Quux`
+	DeletedDualStackHostFileContent = `dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+dhcp-host=02:04:06:fe:dc:ba,[2001:db8::5],Quux`
+	MetadataFileContent = `dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+# dmm: {"description":"Living room NAS","owner":"alice","labels":["storage"],"createdAt":"2024-05-01T10:00:00Z","updatedAt":"2024-05-02T10:00:00Z","createdBy":"bob"}
+dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo
+# dmm: {"owner":
+dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz`
+	AddedWithMetadataFileContent = MetadataFileContent + `
+# dmm: {"description":"Unknown host","labels":["guest"],"createdBy":"carol"}
+dhcp-host=02:04:06:aa:bb:ff,9.9.9.9,Unknown`
+	DeletedWithMetadataFileContent = `dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+# dmm: {"owner":
+dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz`
 	ValidHostFileContent    = `dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo`
 	InvalidHostsFileContent = `dhcp-host=ab:cd:ef:gh:ij:kl,1.1.1.1,Jung`
 )
@@ -51,6 +95,7 @@ func tearDownStaticHostsFile(t *testing.T, fileName string) {
 	if !errors.Is(err, os.ErrNotExist) {
 		os.Remove(fileName)
 	}
+	os.Remove(storage.NewFile(fileName, storage.Options{}).LockPath())
 }
 
 func assertFileContent(t *testing.T, expectedFileContent string, fileName string) {
@@ -84,6 +129,17 @@ func TestHostRepositoryFindAll(t *testing.T) {
 				assertFileContent(t, tc.expectedFileContent, tc.fileName)
 			},
 		},
+		{
+			name:                "CommentedFile",
+			setupFileContent:    CommentedFileContent,
+			expectedFileContent: CommentedFileContent,
+			setup:               voidSetup,
+			assert: func(t *testing.T, hosts *[]model.StaticDhcpHost, err error, tc *testcase) {
+				assert.NoError(t, err, "FindAll() returned an unexpected error")
+				assert.ElementsMatch(t, AllHosts, *hosts, "FindAll() returned unexpected hosts")
+				assertFileContent(t, tc.expectedFileContent, tc.fileName)
+			},
+		},
 		{
 			name:                "EmptyFile",
 			setupFileContent:    "",
@@ -124,7 +180,7 @@ func TestHostRepositoryFindAll(t *testing.T) {
 		test.fileName = setUpStaticHostsFile(t, test.setupFileContent)
 		t.Run(test.name, func(t *testing.T) {
 			test.setup(&test)
-			repository := NewRepository(test.fileName)
+			repository := NewRepository(test.fileName, storage.Options{})
 			hosts, err := repository.FindAll()
 			test.assert(t, hosts, err, &test)
 		})
@@ -203,7 +259,7 @@ func TestHostRepositoryFind(t *testing.T) {
 		test.fileName = setUpStaticHostsFile(t, test.setupFileContent)
 		t.Run(test.name, func(t *testing.T) {
 			test.setup(&test)
-			repository := NewRepository(test.fileName)
+			repository := NewRepository(test.fileName, storage.Options{})
 			host, err := repository.Find(test.argument)
 			test.assert(t, host, err, &test)
 		})
@@ -238,6 +294,45 @@ func TestHostRepositoryFindByIP(t *testing.T) {
 				assertFileContent(t, tc.expectedFileContent, tc.fileName)
 			},
 		},
+		{
+			name:                "DualStackByIPv4",
+			setupFileContent:    DualStackFileContent,
+			expectedFileContent: DualStackFileContent,
+			argument:            DualStackHost.IPAddress,
+			expectedHost:        &DualStackHost,
+			setup:               voidSetup,
+			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, tc *testcase) {
+				assert.NoError(t, err, "FindByIP() returned an expected error")
+				assert.Equal(t, tc.expectedHost, host, "FindByIP() returned an unexpected host")
+				assertFileContent(t, tc.expectedFileContent, tc.fileName)
+			},
+		},
+		{
+			name:                "DualStackByIPv6",
+			setupFileContent:    DualStackFileContent,
+			expectedFileContent: DualStackFileContent,
+			argument:            DualStackHost.IPv6Address,
+			expectedHost:        &DualStackHost,
+			setup:               voidSetup,
+			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, tc *testcase) {
+				assert.NoError(t, err, "FindByIP() returned an expected error")
+				assert.Equal(t, tc.expectedHost, host, "FindByIP() returned an unexpected host")
+				assertFileContent(t, tc.expectedFileContent, tc.fileName)
+			},
+		},
+		{
+			name:                "IPv6Only",
+			setupFileContent:    DualStackFileContent,
+			expectedFileContent: DualStackFileContent,
+			argument:            net.ParseIP("2001:0db8::0005"),
+			expectedHost:        &IPv6OnlyHost,
+			setup:               voidSetup,
+			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, tc *testcase) {
+				assert.NoError(t, err, "FindByIP() returned an expected error")
+				assert.Equal(t, tc.expectedHost, host, "FindByIP() returned an unexpected host")
+				assertFileContent(t, tc.expectedFileContent, tc.fileName)
+			},
+		},
 		{
 			name:                "HostNotFound",
 			setupFileContent:    AllHostsFileContent,
@@ -282,7 +377,7 @@ func TestHostRepositoryFindByIP(t *testing.T) {
 		test.fileName = setUpStaticHostsFile(t, test.setupFileContent)
 		t.Run(test.name, func(t *testing.T) {
 			test.setup(&test)
-			repository := NewRepository(test.fileName)
+			repository := NewRepository(test.fileName, storage.Options{})
 			host, err := repository.FindByIP(test.argument)
 			test.assert(t, host, err, &test)
 		})
@@ -361,7 +456,7 @@ func TestHostRepositoryFindByMac(t *testing.T) {
 		test.fileName = setUpStaticHostsFile(t, test.setupFileContent)
 		t.Run(test.name, func(t *testing.T) {
 			test.setup(&test)
-			repository := NewRepository(test.fileName)
+			repository := NewRepository(test.fileName, storage.Options{})
 			host, err := repository.FindByMac(test.argument)
 			test.assert(t, host, err, &test)
 		})
@@ -369,6 +464,32 @@ func TestHostRepositoryFindByMac(t *testing.T) {
 	}
 }
 
+func TestHostRepositoryFindByHostName(t *testing.T) {
+	var testCases = []struct {
+		name             string
+		setupFileContent string
+		argument         string
+		expectedHost     *model.StaticDhcpHost
+	}{
+		{name: "Success", setupFileContent: AllHostsFileContent, argument: ValidHost.HostName, expectedHost: &ValidHost},
+		{name: "IgnoresCase", setupFileContent: AllHostsFileContent, argument: "fOO", expectedHost: &ValidHost},
+		{name: "HostNotFound", setupFileContent: AllHostsFileContent, argument: UnknownHost.HostName},
+		{name: "HostWithoutHostName", setupFileContent: ExtendedSyntaxFileContent, argument: ""},
+	}
+
+	for _, test := range testCases {
+		fileName := setUpStaticHostsFile(t, test.setupFileContent)
+		t.Run(test.name, func(t *testing.T) {
+			repository := NewRepository(fileName, storage.Options{})
+			host, err := repository.FindByHostName(test.argument)
+			assert.NoError(t, err, "FindByHostName() returned an expected error")
+			assert.Equal(t, test.expectedHost, host, "FindByHostName() returned an unexpected host")
+			assertFileContent(t, test.setupFileContent, fileName)
+		})
+		tearDownStaticHostsFile(t, fileName)
+	}
+}
+
 func TestHostRepositoryDelete(t *testing.T) {
 	type testcase struct {
 		name                string
@@ -396,6 +517,19 @@ func TestHostRepositoryDelete(t *testing.T) {
 				assertFileContent(t, tc.expectedFileContent, tc.fileName)
 			},
 		},
+		{
+			name:                "CommentedFile",
+			setupFileContent:    CommentedFileContent,
+			expectedFileContent: DeletedFromCommentedFileContent,
+			argument:            &ValidHost,
+			expectedHost:        &ValidHost,
+			setup:               voidSetup,
+			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, tc *testcase) {
+				assert.NoError(t, err, "Delete() returned an expected error")
+				assert.Equal(t, tc.expectedHost, host, "Delete() returned an unexpected host")
+				assertFileContent(t, tc.expectedFileContent, tc.fileName)
+			},
+		},
 		{
 			name:                "LastHost",
 			setupFileContent:    ValidHostFileContent,
@@ -467,7 +601,7 @@ func TestHostRepositoryDelete(t *testing.T) {
 		test.fileName = setUpStaticHostsFile(t, test.setupFileContent)
 		t.Run(test.name, func(t *testing.T) {
 			test.setup(&test)
-			repository := NewRepository(test.fileName)
+			repository := NewRepository(test.fileName, storage.Options{})
 			host, err := repository.Delete(test.argument)
 			test.assert(t, host, err, &test)
 		})
@@ -515,6 +649,46 @@ func TestHostRepositoryDeleteByIP(t *testing.T) {
 				assertFileContent(t, tc.expectedFileContent, tc.fileName)
 			},
 		},
+		{
+			name:                "DualStackByIPv4",
+			setupFileContent:    DualStackFileContent,
+			expectedFileContent: DeletedDualStackHostFileContent,
+			argument:            DualStackHost.IPAddress,
+			expectedHost:        &DualStackHost,
+			setup:               voidSetup,
+			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, tc *testcase) {
+				assert.NoError(t, err, "DeleteByIP() returned an expected error")
+				assert.Equal(t, tc.expectedHost, host, "DeleteByIP() returned an unexpected host")
+				assertFileContent(t, tc.expectedFileContent, tc.fileName)
+			},
+		},
+		{
+			name:                "DualStackByIPv6",
+			setupFileContent:    DualStackFileContent,
+			expectedFileContent: DeletedDualStackHostFileContent,
+			argument:            DualStackHost.IPv6Address,
+			expectedHost:        &DualStackHost,
+			setup:               voidSetup,
+			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, tc *testcase) {
+				assert.NoError(t, err, "DeleteByIP() returned an expected error")
+				assert.Equal(t, tc.expectedHost, host, "DeleteByIP() returned an unexpected host")
+				assertFileContent(t, tc.expectedFileContent, tc.fileName)
+			},
+		},
+		{
+			name:             "IPv6Only",
+			setupFileContent: DualStackFileContent,
+			expectedFileContent: `dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+dhcp-host=02:04:06:ab:cd:ef,1.1.1.4,[2001:db8::4],Qux`,
+			argument:     net.ParseIP("2001:0db8::0005"),
+			expectedHost: &IPv6OnlyHost,
+			setup:        voidSetup,
+			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, tc *testcase) {
+				assert.NoError(t, err, "DeleteByIP() returned an expected error")
+				assert.Equal(t, tc.expectedHost, host, "DeleteByIP() returned an unexpected host")
+				assertFileContent(t, tc.expectedFileContent, tc.fileName)
+			},
+		},
 		{
 			name:                "HostNotFound",
 			setupFileContent:    AllHostsFileContent,
@@ -573,7 +747,7 @@ func TestHostRepositoryDeleteByIP(t *testing.T) {
 		test.fileName = setUpStaticHostsFile(t, test.setupFileContent)
 		t.Run(test.name, func(t *testing.T) {
 			test.setup(&test)
-			repository := NewRepository(test.fileName)
+			repository := NewRepository(test.fileName, storage.Options{})
 			host, err := repository.DeleteByIP(test.argument)
 			test.assert(t, host, err, &test)
 		})
@@ -679,7 +853,7 @@ func TestHostRepositoryDeleteByMac(t *testing.T) {
 		test.fileName = setUpStaticHostsFile(t, test.setupFileContent)
 		t.Run(test.name, func(t *testing.T) {
 			test.setup(&test)
-			repository := NewRepository(test.fileName)
+			repository := NewRepository(test.fileName, storage.Options{})
 			host, err := repository.DeleteByMac(test.argument)
 			test.assert(t, host, err, &test)
 		})
@@ -687,6 +861,42 @@ func TestHostRepositoryDeleteByMac(t *testing.T) {
 	}
 }
 
+func TestHostRepositoryDeleteByHostName(t *testing.T) {
+	var testCases = []struct {
+		name                string
+		setupFileContent    string
+		expectedFileContent string
+		argument            string
+		expectedHost        *model.StaticDhcpHost
+	}{
+		{
+			name:                "Success",
+			setupFileContent:    AllHostsFileContent,
+			expectedFileContent: DeletedValidHostFileContent,
+			argument:            "FOO",
+			expectedHost:        &ValidHost,
+		},
+		{
+			name:                "HostNotFound",
+			setupFileContent:    AllHostsFileContent,
+			expectedFileContent: AllHostsFileContent,
+			argument:            UnknownHost.HostName,
+		},
+	}
+
+	for _, test := range testCases {
+		fileName := setUpStaticHostsFile(t, test.setupFileContent)
+		t.Run(test.name, func(t *testing.T) {
+			repository := NewRepository(fileName, storage.Options{})
+			host, err := repository.DeleteByHostName(test.argument)
+			assert.NoError(t, err, "DeleteByHostName() returned an expected error")
+			assert.Equal(t, test.expectedHost, host, "DeleteByHostName() returned an unexpected host")
+			assertFileContent(t, test.expectedFileContent, fileName)
+		})
+		tearDownStaticHostsFile(t, fileName)
+	}
+}
+
 func TestHostRepositorySave(t *testing.T) {
 	type testcase struct {
 		name                string
@@ -711,6 +921,28 @@ func TestHostRepositorySave(t *testing.T) {
 				assertFileContent(t, tc.expectedFileContent, tc.fileName)
 			},
 		},
+		{
+			name:                "CommentedFile",
+			setupFileContent:    CommentedFileContent,
+			expectedFileContent: AddedToCommentedFileContent,
+			host:                &UnknownHost,
+			setup:               voidSetup,
+			assert: func(t *testing.T, err error, tc *testcase) {
+				assert.NoError(t, err, "Save() returned an expected error")
+				assertFileContent(t, tc.expectedFileContent, tc.fileName)
+			},
+		},
+		{
+			name:                "ExtendedSyntax",
+			setupFileContent:    ExtendedSyntaxFileContent,
+			expectedFileContent: AddedToExtendedSyntaxFileContent,
+			host:                &UnknownHost,
+			setup:               voidSetup,
+			assert: func(t *testing.T, err error, tc *testcase) {
+				assert.NoError(t, err, "Save() returned an expected error")
+				assertFileContent(t, tc.expectedFileContent, tc.fileName)
+			},
+		},
 		{
 			name:                "EmptyFile",
 			setupFileContent:    "",
@@ -768,7 +1000,7 @@ func TestHostRepositorySave(t *testing.T) {
 			setup:            voidSetup,
 			assert: func(t *testing.T, err error, tc *testcase) {
 				assert.Error(t, err, "Save() did NOT returned an error")
-				assert.ErrorIs(t, err, model.ErrDHCPHostMissingMACAddress, "Save() returned an unexpected error")
+				assert.ErrorIs(t, err, model.ErrDHCPHostMissingIdentifier, "Save() returned an unexpected error")
 				// Verify that the file content hasn't changed
 				assertFileContent(t, tc.setupFileContent, tc.fileName)
 			},
@@ -779,10 +1011,343 @@ func TestHostRepositorySave(t *testing.T) {
 		test.fileName = setUpStaticHostsFile(t, test.setupFileContent)
 		t.Run(test.name, func(t *testing.T) {
 			test.setup(&test)
-			repository := NewRepository(test.fileName)
+			repository := NewRepository(test.fileName, storage.Options{})
 			err := repository.Save(test.host)
 			test.assert(t, err, &test)
 		})
 		tearDownStaticHostsFile(t, test.fileName)
 	}
 }
+
+func TestHostRepositoryMetadata(t *testing.T) {
+	fileName := setUpStaticHostsFile(t, MetadataFileContent)
+	defer tearDownStaticHostsFile(t, fileName)
+	repository := NewRepository(fileName, storage.Options{})
+
+	host, err := repository.FindByMac(ValidHost.MacAddress)
+	require.NoError(t, err, "FindByMac() returned an unexpected error")
+	assert.Equal(t, model.HostMetadata{
+		Description: "Living room NAS",
+		Owner:       "alice",
+		Labels:      []string{"storage"},
+		CreatedAt:   time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC),
+		UpdatedAt:   time.Date(2024, time.May, 2, 10, 0, 0, 0, time.UTC),
+		CreatedBy:   "bob",
+	}, host.Metadata, "FindByMac() returned unexpected metadata")
+
+	// A broken metadata comment leaves its host without metadata, instead of breaking the whole file
+	host, err = repository.FindByIP(net.ParseIP("1.1.1.3"))
+	require.NoError(t, err, "FindByIP() returned an unexpected error")
+	assert.True(t, host.Metadata.IsZero(), "FindByIP() returned unexpected metadata")
+
+	// Hosts without metadata don't take the comment of the previous lines
+	host, err = repository.FindByIP(net.ParseIP("1.1.1.2"))
+	require.NoError(t, err, "FindByIP() returned an unexpected error")
+	assert.True(t, host.Metadata.IsZero(), "FindByIP() returned unexpected metadata")
+
+	unknownHost := UnknownHost
+	unknownHost.Metadata = model.HostMetadata{Description: "Unknown host", Labels: []string{"guest"}, CreatedBy: "carol"}
+	require.NoError(t, repository.Save(&unknownHost), "Save() returned an unexpected error")
+	assertFileContent(t, AddedWithMetadataFileContent, fileName)
+
+	host, err = repository.FindByMac(UnknownHost.MacAddress)
+	require.NoError(t, err, "FindByMac() returned an unexpected error")
+	assert.Equal(t, &unknownHost, host, "FindByMac() returned an unexpected host")
+
+	// The metadata comment is removed along with its host
+	_, err = repository.DeleteByMac(UnknownHost.MacAddress)
+	require.NoError(t, err, "DeleteByMac() returned an unexpected error")
+	host, err = repository.DeleteByMac(ValidHost.MacAddress)
+	require.NoError(t, err, "DeleteByMac() returned an unexpected error")
+	assert.Equal(t, "alice", host.Metadata.Owner, "DeleteByMac() returned an unexpected host")
+	assertFileContent(t, DeletedWithMetadataFileContent, fileName)
+}
+
+func TestHostRepositoryReplace(t *testing.T) {
+	barHost := model.StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:dd:ee:ff"), IPAddress: net.ParseIP("1.1.1.2"), HostName: "Bar"}
+	movedHost := ValidHost
+	movedHost.IPAddress = net.ParseIP("1.1.1.5")
+	ownedHost := barHost
+	ownedHost.Metadata = model.HostMetadata{Owner: "carol"}
+	metadataHost := movedHost
+	metadataHost.Metadata = model.HostMetadata{Owner: "carol"}
+
+	testCases := []struct {
+		name            string
+		host            *model.StaticDhcpHost
+		replacement     *model.StaticDhcpHost
+		expectedContent string
+	}{
+		{
+			name:        "KeepsItsPlace",
+			host:        &ValidHost,
+			replacement: &metadataHost,
+			expectedContent: `dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+# dmm: {"owner":"carol"}
+dhcp-host=02:04:06:aa:bb:cc,1.1.1.5,Foo
+# dmm: {"owner":
+dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz`,
+		},
+		{
+			name:        "AddsTheMetadataComment",
+			host:        &barHost,
+			replacement: &ownedHost,
+			expectedContent: `# dmm: {"owner":"carol"}
+` + MetadataFileContent,
+		},
+		{
+			name:        "RemovesTheMetadataComment",
+			host:        &ValidHost,
+			replacement: &movedHost,
+			expectedContent: `dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+dhcp-host=02:04:06:aa:bb:cc,1.1.1.5,Foo
+# dmm: {"owner":
+dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz`,
+		},
+		{
+			name:            "MissingHost",
+			host:            &UnknownHost,
+			replacement:     &movedHost,
+			expectedContent: MetadataFileContent,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := setUpStaticHostsFile(t, MetadataFileContent)
+			defer tearDownStaticHostsFile(t, fileName)
+
+			err := NewRepository(fileName, storage.Options{}).Transaction(func(tx model.HostTransaction) error {
+				return tx.Replace(test.host, test.replacement)
+			})
+			assert.NoError(t, err, "Replace() returned an unexpected error")
+			assertFileContent(t, test.expectedContent, fileName)
+		})
+	}
+}
+
+func TestHostRepositoryParseMode(t *testing.T) {
+	const brokenFileContent = `# Static DHCP leases
+dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+dhcp-host=ab:cd:ef:gh:ij:kl,1.1.1.1,Jung
+# dmm: {"owner":
+dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz`
+
+	testCases := []struct {
+		name        string
+		lenient     bool
+		expectError bool
+	}{
+		{name: "Strict", lenient: false, expectError: true},
+		{name: "Lenient", lenient: true, expectError: false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := setUpStaticHostsFile(t, brokenFileContent)
+			defer tearDownStaticHostsFile(t, fileName)
+			repository := NewRepository(fileName, storage.Options{Lenient: test.lenient})
+
+			hosts, err := repository.FindAll()
+			if test.expectError {
+				assert.Error(t, err, "FindAll() did NOT returned an error")
+				assert.Error(t, repository.Save(&UnknownHost), "Save() did NOT returned an error")
+				assertFileContent(t, brokenFileContent, fileName)
+			} else {
+				require.NoError(t, err, "FindAll() returned an unexpected error")
+				assert.Len(t, *hosts, 2, "FindAll() returned unexpected hosts")
+
+				// The broken lines are kept verbatim
+				require.NoError(t, repository.Save(&UnknownHost), "Save() returned an unexpected error")
+				_, err = repository.DeleteByIP(net.ParseIP("1.1.1.2"))
+				require.NoError(t, err, "DeleteByIP() returned an unexpected error")
+				assertFileContent(t, `# Static DHCP leases
+dhcp-host=ab:cd:ef:gh:ij:kl,1.1.1.1,Jung
+# dmm: {"owner":
+dhcp-host=02:04:06:12:34:56,1.1.1.3,Baz
+dhcp-host=02:04:06:aa:bb:ff,9.9.9.9,Unknown`, fileName)
+				require.NoError(t, os.WriteFile(fileName, []byte(brokenFileContent), 0644), "Failed to restore the hosts file")
+			}
+
+			// The diagnostics are always available, whatever the parse mode is
+			diagnostics, err := repository.Diagnostics()
+			require.NoError(t, err, "Diagnostics() returned an unexpected error")
+			require.Len(t, diagnostics, 2, "Diagnostics() returned unexpected diagnostics")
+			assert.Equal(t, fileName, diagnostics[0].File, "Diagnostics() returned an unexpected file")
+			assert.Equal(t, 3, diagnostics[0].Line, "Diagnostics() returned an unexpected line number")
+			assert.Equal(t, "dhcp-host=ab:cd:ef:gh:ij:kl,1.1.1.1,Jung", diagnostics[0].Text, "Diagnostics() returned an unexpected line")
+			assert.EqualError(t, diagnostics[0].Err, "address ab:cd:ef:gh:ij:kl: invalid MAC address", "Diagnostics() returned an unexpected error")
+			assert.Equal(t, 4, diagnostics[1].Line, "Diagnostics() returned an unexpected line number")
+			assert.Equal(t, `# dmm: {"owner":`, diagnostics[1].Text, "Diagnostics() returned an unexpected line")
+		})
+	}
+
+	fileName := setUpStaticHostsFile(t, AllHostsFileContent)
+	defer tearDownStaticHostsFile(t, fileName)
+	diagnostics, err := NewRepository(fileName, storage.Options{}).Diagnostics()
+	assert.NoError(t, err, "Diagnostics() returned an unexpected error")
+	assert.Empty(t, diagnostics, "Diagnostics() returned unexpected diagnostics")
+}
+
+func TestHostRepositoryLockedFile(t *testing.T) {
+	testCases := []struct {
+		name      string
+		exclusive bool
+		call      func(r Repository) error
+		expectErr bool
+	}{
+		{name: "FindAllSharedLock", exclusive: false, call: func(r Repository) error { _, err := r.FindAll(); return err }, expectErr: false},
+		{name: "FindAllExclusiveLock", exclusive: true, call: func(r Repository) error { _, err := r.FindAll(); return err }, expectErr: true},
+		{name: "SaveSharedLock", exclusive: false, call: func(r Repository) error { return r.Save(&UnknownHost) }, expectErr: true},
+		{name: "DeleteByMacSharedLock", exclusive: false, call: func(r Repository) error { _, err := r.DeleteByMac(ValidHost.MacAddress); return err }, expectErr: true},
+	}
+
+	for _, test := range testCases {
+		fileName := setUpStaticHostsFile(t, AllHostsFileContent)
+		t.Run(test.name, func(t *testing.T) {
+			// Another process holding the lock
+			file := storage.NewFile(fileName, storage.Options{})
+			takeLock := file.RLock
+			if test.exclusive {
+				takeLock = file.Lock
+			}
+			lock, err := takeLock()
+			require.NoError(t, err, "Failed to lock DHCP static hosts file")
+			defer lock.Unlock()
+
+			repository := NewRepository(fileName, storage.Options{LockTimeout: 10 * time.Millisecond})
+			err = test.call(repository)
+			if !test.expectErr {
+				assert.NoError(t, err, "Repository returned an unexpected error")
+				return
+			}
+
+			var lockErr *storage.LockTimeoutError
+			assert.ErrorAs(t, err, &lockErr, "Repository returned an unexpected error")
+			// Verify that the file content hasn't changed
+			assertFileContent(t, AllHostsFileContent, fileName)
+		})
+		tearDownStaticHostsFile(t, fileName)
+	}
+}
+
+type rejectingValidator struct{}
+
+func (rejectingValidator) Validate(candidate string) error {
+	return &storage.ValidationError{Err: errors.New("exit status 1"), Output: "dnsmasq: bad dhcp-host"}
+}
+
+func TestHostRepositoryRejectedChanges(t *testing.T) {
+	testCases := []struct {
+		name string
+		call func(r Repository) error
+	}{
+		{name: "Save", call: func(r Repository) error { return r.Save(&UnknownHost) }},
+		{name: "DeleteByMac", call: func(r Repository) error { _, err := r.DeleteByMac(ValidHost.MacAddress); return err }},
+	}
+
+	for _, test := range testCases {
+		fileName := setUpStaticHostsFile(t, AllHostsFileContent)
+		t.Run(test.name, func(t *testing.T) {
+			repository := NewRepository(fileName, storage.Options{Validator: rejectingValidator{}})
+			err := test.call(repository)
+
+			var validationErr *storage.ValidationError
+			assert.ErrorAs(t, err, &validationErr, "Repository returned an unexpected error")
+			assert.Equal(t, fileName, validationErr.File, "ValidationError has an unexpected file")
+			// Verify that the file content hasn't changed
+			assertFileContent(t, AllHostsFileContent, fileName)
+		})
+		tearDownStaticHostsFile(t, fileName)
+	}
+}
+
+func TestHostRepositoryTransaction(t *testing.T) {
+	replacedHost := model.StaticDhcpHost{MacAddress: ValidHost.MacAddress, IPAddress: net.ParseIP("1.1.1.3"), HostName: "Foo"}
+	replaceFn := func(tx model.HostTransaction) error {
+		_, err := tx.DeleteByMac(replacedHost.MacAddress)
+		if err != nil {
+			return err
+		}
+		// Changes are seen by the following calls of the same transaction
+		host, err := tx.FindByMac(replacedHost.MacAddress)
+		if err != nil || host != nil {
+			return errors.New("deleted host still found")
+		}
+		_, err = tx.DeleteByIP(replacedHost.IPAddress)
+		if err != nil {
+			return err
+		}
+		return tx.Save(&replacedHost)
+	}
+
+	testCases := []struct {
+		name            string
+		options         storage.Options
+		fn              func(tx model.HostTransaction) error
+		expectError     bool
+		expectedContent string
+		expectedBackups int
+	}{
+		{
+			name:    "Commit",
+			options: storage.Options{Backups: 5},
+			fn:      replaceFn,
+			expectedContent: `dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar
+dhcp-host=02:04:06:aa:bb:cc,1.1.1.3,Foo`,
+			expectedBackups: 1,
+		},
+		{
+			name:    "FailureRollsBack",
+			options: storage.Options{Backups: 5},
+			fn: func(tx model.HostTransaction) error {
+				err := replaceFn(tx)
+				if err != nil {
+					return err
+				}
+				return errors.New("an error")
+			},
+			expectError:     true,
+			expectedContent: AllHostsFileContent,
+		},
+		{
+			name:            "RejectedRollsBack",
+			options:         storage.Options{Backups: 5, Validator: rejectingValidator{}},
+			fn:              replaceFn,
+			expectError:     true,
+			expectedContent: AllHostsFileContent,
+		},
+		{
+			name:    "ReadOnly",
+			options: storage.Options{Backups: 5},
+			fn: func(tx model.HostTransaction) error {
+				_, err := tx.FindAll()
+				return err
+			},
+			expectedContent: AllHostsFileContent,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := setUpStaticHostsFile(t, AllHostsFileContent)
+			defer tearDownStaticHostsFile(t, fileName)
+
+			err := NewRepository(fileName, test.options).Transaction(test.fn)
+			if test.expectError {
+				assert.Error(t, err, "Transaction() did NOT returned an error")
+			} else {
+				assert.NoError(t, err, "Transaction() returned an unexpected error")
+			}
+			assertFileContent(t, test.expectedContent, fileName)
+
+			// The whole transaction is written at once
+			backups, err := storage.NewFile(fileName, test.options).Backups()
+			require.NoError(t, err, "Backups() returned an unexpected error")
+			for _, backup := range backups {
+				defer os.Remove(backup)
+			}
+			assert.Len(t, backups, test.expectedBackups, "Transaction() has written an unexpected number of times")
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package host
+
+import (
+	"cmp"
+	"encoding/hex"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// SearchResult is a host matching a free-text search.
+type SearchResult struct {
+	Host model.StaticDhcpHost
+	// How well the host matches, see the match scores
+	Score int
+	// Host field matching best: MacAddress, IPAddress, IPv6Address or HostName
+	Field string
+}
+
+// Match scores, exact matches rank first, then the prefixes and finally the substrings. Hostnames rank above
+// addresses as they are what people usually remember.
+const (
+	ExactMatchScore            = 100
+	HostNamePrefixMatchScore   = 80
+	AddressPrefixMatchScore    = 60
+	HostNameContainsMatchScore = 50
+	AddressContainsMatchScore  = 30
+)
+
+// Length of a bare MAC address, EUI-64 and longer ones must have separators
+const macAddressLength = 6
+
+// Minimum number of hex digits of a partial MAC address, shorter ones would match almost every host
+const macSearchMinDigits = 2
+
+var macSeparators = strings.NewReplacer(":", "", "-", "", ".", "")
+
+// Search returns the hosts matching the free-text query, best matches first (the hosts with the same score are
+// sorted by hostname). The query is matched, regardless of the case, against:
+//   - the MAC address, complete or partial, in any notation (colon, dash, dot or bare hex digits)
+//   - the IP addresses, complete or partial, in their canonical notation
+//   - the hostname, or any part of it
+func Search(hosts []model.StaticDhcpHost, query string) []SearchResult {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return []SearchResult{}
+	}
+
+	// Complete addresses are looked up as GetStaticHost does
+	sameMac, sameIP := noMatch, noMatch
+	if mac := parseSearchMac(query); mac != nil {
+		sameMac = sameMacAddress(mac)
+	}
+	ip := net.ParseIP(query)
+	if ip != nil {
+		sameIP = sameIPAddress(ip)
+	}
+
+	results := []SearchResult{}
+	for _, host := range hosts {
+		result := SearchResult{Host: host}
+		if sameMac(host) {
+			result.match(ExactMatchScore, "MacAddress")
+		}
+		if sameIP(host) {
+			result.match(ExactMatchScore, ipAddressField(host, ip))
+		}
+		result.matchHostName(query)
+		result.matchMacAddress(query)
+		result.matchIPAddress("IPAddress", host.IPAddress, query)
+		result.matchIPAddress("IPv6Address", host.IPv6Address, query)
+
+		if result.Score > 0 {
+			results = append(results, result)
+		}
+	}
+
+	slices.SortStableFunc(results, func(a, b SearchResult) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score),
+			strings.Compare(strings.ToLower(a.Host.HostName), strings.ToLower(b.Host.HostName)))
+	})
+	return results
+}
+
+func noMatch(model.StaticDhcpHost) bool {
+	return false
+}
+
+// ipAddressField returns the field of the host address equal to the given one.
+func ipAddressField(host model.StaticDhcpHost, ip net.IP) string {
+	if ip.Equal(host.IPAddress) {
+		return "IPAddress"
+	}
+	return "IPv6Address"
+}
+
+// parseSearchMac parses a complete MAC address in any notation, including bare hex digits.
+func parseSearchMac(query string) net.HardwareAddr {
+	if mac, err := net.ParseMAC(query); err == nil {
+		return mac
+	}
+
+	mac, err := hex.DecodeString(query)
+	if err != nil || len(mac) != macAddressLength {
+		return nil
+	}
+	return mac
+}
+
+func (r *SearchResult) match(score int, field string) {
+	if score > r.Score {
+		r.Score = score
+		r.Field = field
+	}
+}
+
+func (r *SearchResult) matchHostName(query string) {
+	hostName := strings.ToLower(r.Host.HostName)
+	switch {
+	case hostName == query:
+		r.match(ExactMatchScore, "HostName")
+	case strings.HasPrefix(hostName, query):
+		r.match(HostNamePrefixMatchScore, "HostName")
+	case strings.Contains(hostName, query):
+		r.match(HostNameContainsMatchScore, "HostName")
+	}
+}
+
+// matchMacAddress matches the hex digits of the query, whatever the separators are, against the MAC address.
+// Partial IPv4 addresses (e.g. 10.1) are not taken as dot-separated MAC addresses.
+func (r *SearchResult) matchMacAddress(query string) {
+	if strings.Contains(query, ".") && strings.Trim(query, "0123456789.") == "" {
+		return
+	}
+
+	digits := macSeparators.Replace(query)
+	if len(digits) < macSearchMinDigits || strings.Trim(digits, "0123456789abcdef") != "" {
+		return
+	}
+
+	mac := hex.EncodeToString(r.Host.MacAddress)
+	switch {
+	case strings.HasPrefix(mac, digits):
+		r.match(AddressPrefixMatchScore, "MacAddress")
+	case strings.Contains(mac, digits):
+		r.match(AddressContainsMatchScore, "MacAddress")
+	}
+}
+
+func (r *SearchResult) matchIPAddress(field string, ip net.IP, query string) {
+	if ip == nil {
+		return
+	}
+
+	address := ip.String()
+	switch {
+	case strings.HasPrefix(address, query):
+		r.match(AddressPrefixMatchScore, field)
+	case strings.Contains(address, query):
+		r.match(AddressContainsMatchScore, field)
+	}
+}
@@ -0,0 +1,115 @@
+package host
+
+import (
+	"net"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+var SearchHosts = []model.StaticDhcpHost{
+	{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("192.168.1.10"), HostName: "printer-lab"},
+	{MacAddress: tests.ParseMAC("00:11:22:33:44:55"), IPv6Address: net.ParseIP("2001:db8::5"), HostName: "nas"},
+	{MacAddress: tests.ParseMAC("02:04:06:dd:ee:ff"), IPAddress: net.ParseIP("192.168.1.101"), IPv6Address: net.ParseIP("2001:db8::1"), HostName: "office-printer"},
+	{MacAddress: tests.ParseMAC("0a:00:00:00:01:01"), IPAddress: net.ParseIP("10.0.0.1"), HostName: "router", ExtraMacAddresses: []string{"0a:00:00:00:01:02"}},
+}
+
+type searchMatch struct {
+	HostName string
+	Score    int
+	Field    string
+}
+
+func TestSearch(t *testing.T) {
+	testCases := []struct {
+		name            string
+		query           string
+		expectedMatches []searchMatch
+	}{
+		{
+			name:  "HostName",
+			query: "PRINTER",
+			expectedMatches: []searchMatch{
+				{HostName: "printer-lab", Score: HostNamePrefixMatchScore, Field: "HostName"},
+				{HostName: "office-printer", Score: HostNameContainsMatchScore, Field: "HostName"},
+			},
+		},
+		{
+			name:            "ExactHostName",
+			query:           "nas",
+			expectedMatches: []searchMatch{{HostName: "nas", Score: ExactMatchScore, Field: "HostName"}},
+		},
+		{
+			name:            "MacAddress",
+			query:           "02-04-06-DD-EE-FF",
+			expectedMatches: []searchMatch{{HostName: "office-printer", Score: ExactMatchScore, Field: "MacAddress"}},
+		},
+		{
+			name:            "BareMacAddress",
+			query:           "020406ddeeff",
+			expectedMatches: []searchMatch{{HostName: "office-printer", Score: ExactMatchScore, Field: "MacAddress"}},
+		},
+		{
+			name:            "ExtraMacAddress",
+			query:           "0a00.0000.0102",
+			expectedMatches: []searchMatch{{HostName: "router", Score: ExactMatchScore, Field: "MacAddress"}},
+		},
+		{
+			name:  "PartialMacAddress",
+			query: "02:04:06",
+			expectedMatches: []searchMatch{
+				{HostName: "office-printer", Score: AddressPrefixMatchScore, Field: "MacAddress"},
+				{HostName: "printer-lab", Score: AddressPrefixMatchScore, Field: "MacAddress"},
+			},
+		},
+		{
+			name:  "MacAddressSubstring",
+			query: "bb-cc",
+			expectedMatches: []searchMatch{
+				{HostName: "printer-lab", Score: AddressContainsMatchScore, Field: "MacAddress"},
+			},
+		},
+		{
+			name:  "PartialIPAddress",
+			query: "192.168.1.10",
+			expectedMatches: []searchMatch{
+				{HostName: "printer-lab", Score: ExactMatchScore, Field: "IPAddress"},
+				{HostName: "office-printer", Score: AddressPrefixMatchScore, Field: "IPAddress"},
+			},
+		},
+		{
+			name:  "PartialIPv6Address",
+			query: "db8::",
+			expectedMatches: []searchMatch{
+				{HostName: "nas", Score: AddressContainsMatchScore, Field: "IPv6Address"},
+				{HostName: "office-printer", Score: AddressContainsMatchScore, Field: "IPv6Address"},
+			},
+		},
+		{
+			name:            "IPv6Address",
+			query:           "2001:DB8::1",
+			expectedMatches: []searchMatch{{HostName: "office-printer", Score: ExactMatchScore, Field: "IPv6Address"}},
+		},
+		{
+			name:  "IPv4FragmentIsNotAMacAddress",
+			query: "10.0",
+			expectedMatches: []searchMatch{
+				{HostName: "router", Score: AddressPrefixMatchScore, Field: "IPAddress"},
+			},
+		},
+		{name: "NoMatch", query: "camera", expectedMatches: []searchMatch{}},
+		{name: "Empty", query: "  ", expectedMatches: []searchMatch{}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			matches := []searchMatch{}
+			for _, result := range Search(SearchHosts, test.query) {
+				matches = append(matches, searchMatch{HostName: result.Host.HostName, Score: result.Score, Field: result.Field})
+			}
+			assert.Equal(t, test.expectedMatches, matches, "Search() returned unexpected results")
+		})
+	}
+}
@@ -1,93 +1,364 @@
 package host
 
 import (
-	"fmt"
+	"bytes"
 	"net"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
 	"github.com/gringolito/dnsmasq-manager/pkg/model"
 )
 
 type Service interface {
-	Insert(host *model.StaticDhcpHost) error
-	Update(host *model.StaticDhcpHost) error
+	Insert(host *model.StaticDhcpHost, collection model.Versions) error
+	Update(host *model.StaticDhcpHost, versions model.Versions) error
+	UpdateByMac(macAddress net.HardwareAddr, host *model.StaticDhcpHost, versions model.Versions) error
+	UpdateByHostName(hostName string, host *model.StaticDhcpHost, versions model.Versions) error
+	Patch(macAddress net.HardwareAddr, patch *model.StaticDhcpHostPatch, versions model.Versions) (*model.StaticDhcpHost, error)
+	PatchByHostName(hostName string, patch *model.StaticDhcpHostPatch, versions model.Versions) (*model.StaticDhcpHost, error)
 	FetchAll() (*[]model.StaticDhcpHost, error)
 	FetchByIP(ipAddress net.IP) (*model.StaticDhcpHost, error)
 	FetchByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error)
-	RemoveByIP(ipAddress net.IP) (*model.StaticDhcpHost, error)
-	RemoveByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error)
+	FetchByHostName(hostName string) (*model.StaticDhcpHost, error)
+	RemoveByIP(ipAddress net.IP, versions model.Versions) (*model.StaticDhcpHost, error)
+	RemoveByMac(macAddress net.HardwareAddr, versions model.Versions) (*model.StaticDhcpHost, error)
+	RemoveByHostName(hostName string, versions model.Versions) (*model.StaticDhcpHost, error)
+	Diagnostics() ([]model.Diagnostic, error)
+	// History returns the revisions of the static hosts, oldest first. It fails with ErrNoHistory when the
+	// repository is not a HistoryRepository.
+	History() ([]model.HostRevision, error)
 }
 
 type service struct {
 	repository Repository
+	reloader   dnsmasq.Reloader
+	subnets    SubnetChecker
+	// Clock used for the host metadata timestamps
+	now func() time.Time
 }
 
-func NewService(repository Repository) Service {
+// NewService returns the static hosts Service. The reloader is triggered after every change written to
+// the static hosts file, so dnsmasq picks it up, and the addresses of every added or changed host must pass
+// the subnet checker.
+//
+// Changes are only made if the host, or the whole collection when adding a new host, is still at one of the
+// given versions. Otherwise they fail with a model.VersionMismatchError.
+//
+// Every other error matches one of the error kinds (ErrNotFound, ErrDuplicate, ErrValidation,
+// ErrStorageUnavailable or ErrLockTimeout), except the dnsmasq.ReloadError of a change that was saved.
+func NewService(repository Repository, reloader dnsmasq.Reloader, subnets SubnetChecker) Service {
 	return &service{
 		repository: repository,
+		reloader:   reloader,
+		subnets:    subnets,
+		now:        time.Now,
 	}
 }
 
-func (s *service) Insert(host *model.StaticDhcpHost) error {
-	sameMacHost, err := s.repository.FindByMac(host.MacAddress)
+// Insert adds a new host, setting its metadata timestamps. The metadata CreatedBy is left up to the caller.
+// It fails with a DuplicatedEntryError if the MAC address, one of the IP addresses or the hostname is already
+// used by another host, as dnsmasq can't resolve a hostname shared by two hosts.
+func (s *service) Insert(host *model.StaticDhcpHost, collection model.Versions) error {
+	if err := s.subnets.Check(host); err != nil {
+		return errkind.Classify(err)
+	}
+
+	err := s.repository.Transaction(func(tx model.HostTransaction) error {
+		if !collection.IsEmpty() {
+			hosts, err := tx.FindAll()
+			if err != nil {
+				return err
+			}
+			if err := collection.CheckAll(*hosts); err != nil {
+				return err
+			}
+		}
+
+		sameMacHost, err := tx.FindByMac(host.MacAddress)
+		if err != nil {
+			return err
+		}
+		if sameMacHost != nil {
+			return &DuplicatedEntryError{Field: "MAC", Value: host.MacAddress.String(), Host: sameMacHost}
+		}
+
+		// Both addresses of a dual-stack host must be free
+		for _, ipAddress := range host.IPAddresses() {
+			sameIPHost, err := tx.FindByIP(ipAddress)
+			if err != nil {
+				return err
+			}
+			if sameIPHost != nil {
+				return &DuplicatedEntryError{Field: "IP", Value: ipAddress.String(), Host: sameIPHost}
+			}
+		}
+
+		if err := s.checkHostName(tx, host); err != nil {
+			return err
+		}
+
+		host.Metadata.CreatedAt = s.timestamp()
+		host.Metadata.UpdatedAt = host.Metadata.CreatedAt
+		return tx.Save(host)
+	})
 	if err != nil {
-		return err
+		return errkind.Classify(err)
 	}
-	if sameMacHost != nil {
-		return &DuplicatedEntryError{Field: "MAC", Value: host.MacAddress.String()}
+
+	return s.reloader.Reload()
+}
+
+// Update replaces the existing host with the same MAC address, keeping its creation metadata and the dnsmasq
+// options the API does not manage (see model.StaticDhcpHost.KeepOptions). It fails with a
+// NotFoundError if there is no such host, and with a DuplicatedEntryError if one of the new IP addresses, or the
+// new hostname, belongs to another host.
+func (s *service) Update(host *model.StaticDhcpHost, versions model.Versions) error {
+	return s.UpdateByMac(host.MacAddress, host, versions)
+}
+
+// UpdateByMac replaces the existing host with the given MAC address, as Update does. The new host may have another
+// MAC address, as long as it does not belong to another host.
+func (s *service) UpdateByMac(macAddress net.HardwareAddr, host *model.StaticDhcpHost, versions model.Versions) error {
+	return s.update(host, versions, func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return s.existing(tx, macAddress)
+	})
+}
+
+// UpdateByHostName replaces the existing host with the given hostname, as UpdateByMac does.
+func (s *service) UpdateByHostName(hostName string, host *model.StaticDhcpHost, versions model.Versions) error {
+	return s.update(host, versions, func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return s.existingByHostName(tx, hostName)
+	})
+}
+
+func (s *service) update(host *model.StaticDhcpHost, versions model.Versions, find func(tx model.HostTransaction) (*model.StaticDhcpHost, error)) error {
+	err := s.repository.Transaction(func(tx model.HostTransaction) error {
+		existing, err := find(tx)
+		if err != nil {
+			return err
+		}
+		if err := versions.Check(existing); err != nil {
+			return err
+		}
+
+		host.Metadata.CreatedAt = existing.Metadata.CreatedAt
+		host.Metadata.CreatedBy = existing.Metadata.CreatedBy
+		host.KeepOptions(existing)
+		return s.replace(tx, existing, host)
+	})
+	if err != nil {
+		return errkind.Classify(err)
 	}
 
-	sameIPHost, err := s.repository.FindByIP(host.IPAddress)
+	return s.reloader.Reload()
+}
+
+// Patch changes some fields of the existing host with the given MAC address, returning the changed host. It
+// fails as Update does.
+func (s *service) Patch(macAddress net.HardwareAddr, patch *model.StaticDhcpHostPatch, versions model.Versions) (*model.StaticDhcpHost, error) {
+	return s.patch(patch, versions, func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return s.existing(tx, macAddress)
+	})
+}
+
+// PatchByHostName changes some fields of the existing host with the given hostname, as Patch does.
+func (s *service) PatchByHostName(hostName string, patch *model.StaticDhcpHostPatch, versions model.Versions) (*model.StaticDhcpHost, error) {
+	return s.patch(patch, versions, func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return s.existingByHostName(tx, hostName)
+	})
+}
+
+func (s *service) patch(patch *model.StaticDhcpHostPatch, versions model.Versions, find func(tx model.HostTransaction) (*model.StaticDhcpHost, error)) (*model.StaticDhcpHost, error) {
+	var patched model.StaticDhcpHost
+	err := s.repository.Transaction(func(tx model.HostTransaction) error {
+		existing, err := find(tx)
+		if err != nil {
+			return err
+		}
+		if err := versions.Check(existing); err != nil {
+			return err
+		}
+
+		patched = *existing
+		patched.Metadata.Labels = slices.Clone(existing.Metadata.Labels)
+		patch.Apply(&patched)
+		// The patch may remove the only address of the host, or its hostname, which dnsmasq allows but the API does not
+		if patched.IPAddress == nil && patched.IPv6Address == nil {
+			return &ValidationError{Err: model.ErrDHCPHostMissingIPAddress}
+		}
+		if patched.HostName == "" {
+			return &ValidationError{Err: model.ErrDHCPHostMissingHostName}
+		}
+		return s.replace(tx, existing, &patched)
+	})
 	if err != nil {
-		return err
+		return nil, errkind.Classify(err)
+	}
+
+	return &patched, s.reloader.Reload()
+}
+
+// existing returns the host to be changed, identified by its MAC address.
+func (s *service) existing(tx model.HostTransaction, macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
+	existing, err := tx.FindByMac(macAddress)
+	if err != nil {
+		return nil, err
 	}
-	if sameIPHost != nil {
-		return &DuplicatedEntryError{Field: "IP", Value: host.IPAddress.String()}
+	if existing == nil {
+		return nil, &NotFoundError{Field: "MAC", Value: macAddress.String()}
 	}
 
-	return s.repository.Save(host)
+	return existing, nil
 }
 
-func (s *service) Update(host *model.StaticDhcpHost) error {
-	_, err := s.repository.DeleteByMac(host.MacAddress)
+// existingByHostName returns the host to be changed, identified by its hostname.
+func (s *service) existingByHostName(tx model.HostTransaction, hostName string) (*model.StaticDhcpHost, error) {
+	existing, err := tx.FindByHostName(hostName)
 	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, &NotFoundError{Field: "hostname", Value: hostName}
+	}
+
+	return existing, nil
+}
+
+// replace swaps the existing host by its new version, as long as its addresses fit in the served subnets and
+// are not used by another host, nor its new hostname.
+func (s *service) replace(tx model.HostTransaction, existing *model.StaticDhcpHost, host *model.StaticDhcpHost) error {
+	if err := s.subnets.Check(host); err != nil {
 		return err
 	}
 
-	_, err = s.repository.DeleteByIP(host.IPAddress)
+	if !bytes.Equal(host.MacAddress, existing.MacAddress) {
+		sameMacHost, err := tx.FindByMac(host.MacAddress)
+		if err != nil {
+			return err
+		}
+		if sameMacHost != nil && !sameMacHost.Equal(*existing) {
+			return &DuplicatedEntryError{Field: "MAC", Value: host.MacAddress.String(), Host: sameMacHost}
+		}
+	}
+
+	for _, ipAddress := range host.IPAddresses() {
+		sameIPHost, err := tx.FindByIP(ipAddress)
+		if err != nil {
+			return err
+		}
+		if sameIPHost != nil && !sameIPHost.Equal(*existing) {
+			return &DuplicatedEntryError{Field: "IP", Value: ipAddress.String(), Host: sameIPHost}
+		}
+	}
+
+	// Hand-written files may already have hosts sharing a hostname, they can still be changed if they keep it
+	if !strings.EqualFold(host.HostName, existing.HostName) {
+		if err := s.checkHostName(tx, host); err != nil {
+			return err
+		}
+	}
+
+	host.Metadata.UpdatedAt = s.timestamp()
+	return tx.Replace(existing, host)
+}
+
+// checkHostName ensures that no other host uses the hostname of the given one.
+func (s *service) checkHostName(tx model.HostTransaction, host *model.StaticDhcpHost) error {
+	if host.HostName == "" {
+		return nil
+	}
+
+	sameNameHost, err := tx.FindByHostName(host.HostName)
 	if err != nil {
 		return err
 	}
+	if sameNameHost != nil {
+		return &DuplicatedEntryError{Field: "hostname", Value: host.HostName, Host: sameNameHost}
+	}
+
+	return nil
+}
 
-	return s.repository.Save(host)
+// timestamp returns the current time in the precision stored in the host metadata.
+func (s *service) timestamp() time.Time {
+	return s.now().UTC().Truncate(time.Second)
 }
 
 func (s *service) FetchAll() (*[]model.StaticDhcpHost, error) {
-	return s.repository.FindAll()
+	hosts, err := s.repository.FindAll()
+	return hosts, errkind.Classify(err)
 }
 
 func (s *service) FetchByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
-	return s.repository.FindByMac(macAddress)
+	host, err := s.repository.FindByMac(macAddress)
+	return host, errkind.Classify(err)
 }
 
 func (s *service) FetchByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
-	return s.repository.FindByIP(ipAddress)
+	host, err := s.repository.FindByIP(ipAddress)
+	return host, errkind.Classify(err)
+}
+
+func (s *service) FetchByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	host, err := s.repository.FindByHostName(hostName)
+	return host, errkind.Classify(err)
+}
+
+func (s *service) RemoveByMac(macAddress net.HardwareAddr, versions model.Versions) (*model.StaticDhcpHost, error) {
+	return s.remove(versions, func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return tx.DeleteByMac(macAddress)
+	})
 }
 
-func (s *service) RemoveByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
-	return s.repository.DeleteByMac(macAddress)
+func (s *service) RemoveByIP(ipAddress net.IP, versions model.Versions) (*model.StaticDhcpHost, error) {
+	return s.remove(versions, func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return tx.DeleteByIP(ipAddress)
+	})
 }
 
-func (s *service) RemoveByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
-	return s.repository.DeleteByIP(ipAddress)
+func (s *service) RemoveByHostName(hostName string, versions model.Versions) (*model.StaticDhcpHost, error) {
+	return s.remove(versions, func(tx model.HostTransaction) (*model.StaticDhcpHost, error) {
+		return tx.DeleteByHostName(hostName)
+	})
 }
 
-type DuplicatedEntryError struct {
-	Field string
-	Value string
+func (s *service) Diagnostics() ([]model.Diagnostic, error) {
+	diagnostics, err := s.repository.Diagnostics()
+	return diagnostics, errkind.Classify(err)
 }
 
-const duplicatedEntryErrorMessage = "Duplicated %s address: %s"
+func (s *service) History() ([]model.HostRevision, error) {
+	history, ok := s.repository.(HistoryRepository)
+	if !ok {
+		return nil, ErrNoHistory
+	}
+
+	revisions, err := history.Revisions()
+	return revisions, errkind.Classify(err)
+}
+
+// remove deletes a host, as long as it is at one of the versions, and reloads dnsmasq when a host was actually
+// removed. The removed host is returned even if the reload fails, since it is already gone from the static hosts
+// file.
+func (s *service) remove(versions model.Versions, delete func(tx model.HostTransaction) (*model.StaticDhcpHost, error)) (*model.StaticDhcpHost, error) {
+	var removed *model.StaticDhcpHost
+	err := s.repository.Transaction(func(tx model.HostTransaction) error {
+		var err error
+		removed, err = delete(tx)
+		if err != nil {
+			return err
+		}
+		return versions.Check(removed)
+	})
+	if err != nil {
+		return nil, errkind.Classify(err)
+	}
+	if removed == nil {
+		return nil, nil
+	}
 
-func (e DuplicatedEntryError) Error() string {
-	return fmt.Sprintf(duplicatedEntryErrorMessage, e.Field, e.Value)
+	return removed, s.reloader.Reload()
 }
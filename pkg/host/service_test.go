@@ -2,14 +2,17 @@ package host
 
 import (
 	"errors"
-	"fmt"
 	"net"
 	"testing"
+	"time"
 
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	dnsmasqmock "github.com/gringolito/dnsmasq-manager/pkg/dnsmasq/mock"
 	hostmock "github.com/gringolito/dnsmasq-manager/pkg/host/mock"
 	"github.com/gringolito/dnsmasq-manager/pkg/model"
 	"github.com/gringolito/dnsmasq-manager/tests"
 	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
 )
 
 const (
@@ -18,10 +21,17 @@ const (
 )
 
 var ValidHost = model.StaticDhcpHost{MacAddress: tests.ParseMAC(ValidMACAddress), IPAddress: net.ParseIP(ValidIPAddress), HostName: "Foo"}
+var OldHost = model.StaticDhcpHost{MacAddress: tests.ParseMAC(ValidMACAddress), IPAddress: net.ParseIP("1.1.1.9"), HostName: "Foo"}
+var SameIPHost = model.StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:ff"), IPAddress: net.ParseIP(ValidIPAddress), HostName: "Bar"}
+var ValidDualStackHost = model.StaticDhcpHost{MacAddress: tests.ParseMAC(ValidMACAddress), IPAddress: net.ParseIP(ValidIPAddress), IPv6Address: net.ParseIP("2001:db8::1"), HostName: "Foo"}
+var SameHostNameHost = model.StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:ee"), IPAddress: net.ParseIP("1.1.1.8"), HostName: "foo"}
+var SameIPv6Host = model.StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:dd:ee:ff"), IPv6Address: net.ParseIP("2001:db8::1"), HostName: "Baz"}
 
 func TestHostServiceInsertUpdate(t *testing.T) {
-	Insert := func(service Service) error { return service.Insert(&ValidHost) }
-	Update := func(service Service) error { return service.Update(&ValidHost) }
+	Insert := func(service Service) error { return service.Insert(&ValidHost, nil) }
+	Update := func(service Service) error { return service.Update(&ValidHost, nil) }
+	InsertDualStack := func(service Service) error { return service.Insert(&ValidDualStackHost, nil) }
+	UpdateDualStack := func(service Service) error { return service.Update(&ValidDualStackHost, nil) }
 
 	var testCases = []struct {
 		name   string
@@ -35,7 +45,9 @@ func TestHostServiceInsertUpdate(t *testing.T) {
 			on: func(mock *hostmock.RepositoryMock) {
 				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(nil, nil)
 				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("FindByHostName", ValidHost.HostName).Once().Return(nil, nil)
 				mock.On("Save", &ValidHost).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
 			},
 			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
 				assert.NoError(t, err, "unexpected error")
@@ -50,7 +62,7 @@ func TestHostServiceInsertUpdate(t *testing.T) {
 			},
 			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
 				assert.Error(t, err, "expected error not found")
-				assert.Equal(t, &DuplicatedEntryError{Field: "MAC", Value: ValidHost.MacAddress.String()}, err, "error mismatch")
+				assert.Equal(t, &DuplicatedEntryError{Field: "MAC", Value: ValidHost.MacAddress.String(), Host: &ValidHost}, err, "error mismatch")
 				mock.AssertExpectations(t)
 			},
 		},
@@ -63,7 +75,21 @@ func TestHostServiceInsertUpdate(t *testing.T) {
 			},
 			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
 				assert.Error(t, err, "expected error not found")
-				assert.Equal(t, &DuplicatedEntryError{Field: "IP", Value: ValidHost.IPAddress.String()}, err, "error mismatch")
+				assert.Equal(t, &DuplicatedEntryError{Field: "IP", Value: ValidHost.IPAddress.String(), Host: &ValidHost}, err, "error mismatch")
+				mock.AssertExpectations(t)
+			},
+		},
+		{
+			name:   "InsertDuplicatedHostName",
+			method: Insert,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(nil, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("FindByHostName", ValidHost.HostName).Once().Return(&SameHostNameHost, nil)
+			},
+			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
+				assert.Error(t, err, "expected error not found")
+				assert.Equal(t, &DuplicatedEntryError{Field: "hostname", Value: ValidHost.HostName, Host: &SameHostNameHost}, err, "error mismatch")
 				mock.AssertExpectations(t)
 			},
 		},
@@ -73,6 +99,7 @@ func TestHostServiceInsertUpdate(t *testing.T) {
 			on: func(mock *hostmock.RepositoryMock) {
 				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(nil, nil)
 				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("FindByHostName", ValidHost.HostName).Once().Return(nil, nil)
 				mock.On("Save", &ValidHost).Once().Return(errors.New("an error"))
 			},
 			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
@@ -104,12 +131,15 @@ func TestHostServiceInsertUpdate(t *testing.T) {
 			},
 		},
 		{
-			name:   "UpdateNewHost",
-			method: Update,
+			name:   "InsertDualStackSuccess",
+			method: InsertDualStack,
 			on: func(mock *hostmock.RepositoryMock) {
-				mock.On("DeleteByMac", ValidHost.MacAddress).Once().Return(nil, nil)
-				mock.On("DeleteByIP", ValidHost.IPAddress).Once().Return(nil, nil)
-				mock.On("Save", &ValidHost).Once().Return(nil)
+				mock.On("FindByMac", ValidDualStackHost.MacAddress).Once().Return(nil, nil)
+				mock.On("FindByIP", ValidDualStackHost.IPAddress).Once().Return(nil, nil)
+				mock.On("FindByIP", ValidDualStackHost.IPv6Address).Once().Return(nil, nil)
+				mock.On("FindByHostName", ValidDualStackHost.HostName).Once().Return(nil, nil)
+				mock.On("Save", &ValidDualStackHost).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
 			},
 			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
 				assert.NoError(t, err, "unexpected error")
@@ -117,12 +147,27 @@ func TestHostServiceInsertUpdate(t *testing.T) {
 			},
 		},
 		{
-			name:   "UpdateSameMac",
+			name:   "InsertDualStackDuplicatedIPv6",
+			method: InsertDualStack,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidDualStackHost.MacAddress).Once().Return(nil, nil)
+				mock.On("FindByIP", ValidDualStackHost.IPAddress).Once().Return(nil, nil)
+				mock.On("FindByIP", ValidDualStackHost.IPv6Address).Once().Return(&SameIPv6Host, nil)
+			},
+			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
+				assert.Error(t, err, "expected error not found")
+				assert.Equal(t, &DuplicatedEntryError{Field: "IP", Value: "2001:db8::1", Host: &SameIPv6Host}, err, "error mismatch")
+				mock.AssertExpectations(t)
+			},
+		},
+		{
+			name:   "UpdateSuccess",
 			method: Update,
 			on: func(mock *hostmock.RepositoryMock) {
-				mock.On("DeleteByMac", ValidHost.MacAddress).Once().Return(&ValidHost, nil)
-				mock.On("DeleteByIP", ValidHost.IPAddress).Once().Return(nil, nil)
-				mock.On("Save", &ValidHost).Once().Return(nil)
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(&OldHost, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("Replace", &OldHost, &ValidHost).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
 			},
 			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
 				assert.NoError(t, err, "unexpected error")
@@ -133,9 +178,10 @@ func TestHostServiceInsertUpdate(t *testing.T) {
 			name:   "UpdateSameIP",
 			method: Update,
 			on: func(mock *hostmock.RepositoryMock) {
-				mock.On("DeleteByMac", ValidHost.MacAddress).Once().Return(nil, nil)
-				mock.On("DeleteByIP", ValidHost.IPAddress).Once().Return(&ValidHost, nil)
-				mock.On("Save", &ValidHost).Once().Return(nil)
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(&ValidHost, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(&ValidHost, nil)
+				mock.On("Replace", &ValidHost, &ValidHost).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
 			},
 			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
 				assert.NoError(t, err, "unexpected error")
@@ -143,12 +189,13 @@ func TestHostServiceInsertUpdate(t *testing.T) {
 			},
 		},
 		{
-			name:   "UpdateSameHost",
-			method: Update,
+			name:   "UpdateByHostNameSuccess",
+			method: func(service Service) error { return service.UpdateByHostName("foo", &ValidHost, nil) },
 			on: func(mock *hostmock.RepositoryMock) {
-				mock.On("DeleteByMac", ValidHost.MacAddress).Once().Return(&ValidHost, nil)
-				mock.On("DeleteByIP", ValidHost.IPAddress).Once().Return(&ValidHost, nil)
-				mock.On("Save", &ValidHost).Once().Return(nil)
+				mock.On("FindByHostName", "foo").Once().Return(&OldHost, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("Replace", &OldHost, &ValidHost).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
 			},
 			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
 				assert.NoError(t, err, "unexpected error")
@@ -156,35 +203,141 @@ func TestHostServiceInsertUpdate(t *testing.T) {
 			},
 		},
 		{
-			name:   "UpdateSaveError",
+			name:   "UpdateByHostNameNotFound",
+			method: func(service Service) error { return service.UpdateByHostName("foo", &ValidHost, nil) },
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByHostName", "foo").Once().Return(nil, nil)
+			},
+			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
+				assert.Equal(t, &NotFoundError{Field: "hostname", Value: "foo"}, err, "error mismatch")
+				mock.AssertExpectations(t)
+			},
+		},
+		{
+			name:   "UpdateByMacNewMacInUse",
+			method: func(service Service) error { return service.UpdateByMac(SameIPHost.MacAddress, &ValidHost, nil) },
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", SameIPHost.MacAddress).Once().Return(&SameIPHost, nil)
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(&OldHost, nil)
+			},
+			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
+				assert.Equal(t, &DuplicatedEntryError{Field: "MAC", Value: ValidHost.MacAddress.String(), Host: &OldHost}, err, "error mismatch")
+				mock.AssertExpectations(t)
+			},
+		},
+		{
+			name:   "UpdateNotFound",
 			method: Update,
 			on: func(mock *hostmock.RepositoryMock) {
-				mock.On("DeleteByMac", ValidHost.MacAddress).Once().Return(nil, nil)
-				mock.On("DeleteByIP", ValidHost.IPAddress).Once().Return(nil, nil)
-				mock.On("Save", &ValidHost).Once().Return(errors.New("an error"))
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(nil, nil)
 			},
 			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
 				assert.Error(t, err, "expected error not found")
+				assert.Equal(t, &NotFoundError{Field: "MAC", Value: ValidHost.MacAddress.String()}, err, "error mismatch")
 				mock.AssertExpectations(t)
 			},
 		},
 		{
-			name:   "UpdateDeleteByIPError",
+			name:   "UpdateIPInUse",
 			method: Update,
 			on: func(mock *hostmock.RepositoryMock) {
-				mock.On("DeleteByMac", ValidHost.MacAddress).Once().Return(nil, nil)
-				mock.On("DeleteByIP", ValidHost.IPAddress).Once().Return(nil, errors.New("an error"))
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(&OldHost, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(&SameIPHost, nil)
 			},
 			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
 				assert.Error(t, err, "expected error not found")
+				assert.Equal(t, &DuplicatedEntryError{Field: "IP", Value: ValidHost.IPAddress.String(), Host: &SameIPHost}, err, "error mismatch")
 				mock.AssertExpectations(t)
 			},
 		},
 		{
-			name:   "UpdateDeleteByMacError",
+			name:   "UpdateReplaceError",
 			method: Update,
 			on: func(mock *hostmock.RepositoryMock) {
-				mock.On("DeleteByMac", ValidHost.MacAddress).Once().Return(nil, errors.New("an error"))
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(&OldHost, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("Replace", &OldHost, &ValidHost).Once().Return(errors.New("an error"))
+			},
+			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
+				assert.Error(t, err, "expected error not found")
+				mock.AssertExpectations(t)
+			},
+		},
+		{
+			name:   "UpdateFindByIPError",
+			method: Update,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(&OldHost, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, errors.New("an error"))
+			},
+			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
+				assert.Error(t, err, "expected error not found")
+				mock.AssertExpectations(t)
+			},
+		},
+		{
+			name:   "UpdateFindByMacError",
+			method: Update,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(nil, errors.New("an error"))
+			},
+			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
+				assert.Error(t, err, "expected error not found")
+				mock.AssertExpectations(t)
+			},
+		},
+		{
+			name:   "UpdateDualStack",
+			method: UpdateDualStack,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidDualStackHost.MacAddress).Once().Return(&OldHost, nil)
+				mock.On("FindByIP", ValidDualStackHost.IPAddress).Once().Return(nil, nil)
+				mock.On("FindByIP", ValidDualStackHost.IPv6Address).Once().Return(nil, nil)
+				mock.On("Replace", &OldHost, &ValidDualStackHost).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
+				assert.NoError(t, err, "unexpected error")
+				mock.AssertExpectations(t)
+			},
+		},
+		{
+			name:   "UpdateDualStackIPv6InUse",
+			method: UpdateDualStack,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidDualStackHost.MacAddress).Once().Return(&OldHost, nil)
+				mock.On("FindByIP", ValidDualStackHost.IPAddress).Once().Return(nil, nil)
+				mock.On("FindByIP", ValidDualStackHost.IPv6Address).Once().Return(&SameIPv6Host, nil)
+			},
+			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
+				assert.Error(t, err, "expected error not found")
+				assert.Equal(t, &DuplicatedEntryError{Field: "IP", Value: "2001:db8::1", Host: &SameIPv6Host}, err, "error mismatch")
+				mock.AssertExpectations(t)
+			},
+		},
+		{
+			name:   "InsertCommitError",
+			method: Insert,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(nil, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("FindByHostName", ValidHost.HostName).Once().Return(nil, nil)
+				mock.On("Save", &ValidHost).Once().Return(nil)
+				mock.On("Transaction").Once().Return(errors.New("an error"))
+			},
+			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
+				assert.Error(t, err, "expected error not found")
+				mock.AssertExpectations(t)
+			},
+		},
+		{
+			name:   "UpdateCommitError",
+			method: Update,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(&OldHost, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("Replace", &OldHost, &ValidHost).Once().Return(nil)
+				mock.On("Transaction").Once().Return(errors.New("an error"))
 			},
 			assert: func(t *testing.T, err error, mock *hostmock.RepositoryMock) {
 				assert.Error(t, err, "expected error not found")
@@ -198,13 +351,28 @@ func TestHostServiceInsertUpdate(t *testing.T) {
 			repositoryMock := &hostmock.RepositoryMock{}
 			test.on(repositoryMock)
 
-			service := NewService(repositoryMock)
+			service := NewService(repositoryMock, dnsmasq.NoReload(), NoSubnetCheck())
 			err := test.method(service)
 			test.assert(t, err, repositoryMock)
 		})
 	}
 }
 
+func TestHostServiceSubnetCheck(t *testing.T) {
+	subnets, err := NewSubnetChecker(SubnetOptions{Ranges: []string{"10.0.0.100,10.0.0.200,255.255.255.0"}, PoolPolicy: PoolPolicyReject})
+	assert.NoError(t, err, "NewSubnetChecker() returned an unexpected error")
+
+	// Rejected hosts never reach the repository
+	repositoryMock := &hostmock.RepositoryMock{}
+	service := NewService(repositoryMock, dnsmasq.NoReload(), subnets)
+	var subnetErr *SubnetError
+	assert.ErrorAs(t, service.Insert(&ValidHost, nil), &subnetErr, "Insert() returned an unexpected error")
+
+	repositoryMock.On("FindByMac", ValidHost.MacAddress).Once().Return(&OldHost, nil)
+	assert.ErrorAs(t, service.Update(&ValidHost, nil), &subnetErr, "Update() returned an unexpected error")
+	repositoryMock.AssertExpectations(t)
+}
+
 func TestHostServiceFetchAll(t *testing.T) {
 	allHosts := []model.StaticDhcpHost{
 		{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "Foo"},
@@ -257,18 +425,51 @@ func TestHostServiceFetchAll(t *testing.T) {
 			repositoryMock := &hostmock.RepositoryMock{}
 			test.on(repositoryMock)
 
-			service := NewService(repositoryMock)
+			service := NewService(repositoryMock, dnsmasq.NoReload(), NoSubnetCheck())
 			hosts, err := service.FetchAll()
 			test.assert(t, hosts, err, repositoryMock)
 		})
 	}
 }
 
+func TestHostServiceHistory(t *testing.T) {
+	revisions := []model.HostRevision{{
+		Number:      1,
+		CommittedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Hosts:       []model.StaticDhcpHost{{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "Foo"}},
+	}}
+
+	historyMock := &hostmock.HistoryRepositoryMock{}
+	historyMock.On("Revisions").Once().Return(revisions, nil)
+	history, err := NewService(historyMock, dnsmasq.NoReload(), NoSubnetCheck()).History()
+	assert.NoError(t, err, "History() returned an unexpected error")
+	assert.Equal(t, revisions, history, "History() returned unexpected revisions")
+
+	historyMock = &hostmock.HistoryRepositoryMock{}
+	historyMock.On("Revisions").Once().Return(nil, errors.New("an error"))
+	_, err = NewService(historyMock, dnsmasq.NoReload(), NoSubnetCheck()).History()
+	assert.ErrorIs(t, err, ErrStorageUnavailable, "History() returned an unexpected error")
+	historyMock.AssertExpectations(t)
+
+	// The file backend keeps no history
+	_, err = NewService(&hostmock.RepositoryMock{}, dnsmasq.NoReload(), NoSubnetCheck()).History()
+	assert.ErrorIs(t, err, ErrNoHistory, "History() returned an unexpected error")
+	assert.ErrorIs(t, err, ErrNotFound, "History() returned an unexpected error")
+}
+
 func TestHostServiceFetchRemove(t *testing.T) {
 	FetchByMac := func(service Service) (*model.StaticDhcpHost, error) { return service.FetchByMac(ValidHost.MacAddress) }
 	FetchByIP := func(service Service) (*model.StaticDhcpHost, error) { return service.FetchByIP(ValidHost.IPAddress) }
-	RemoveByMac := func(service Service) (*model.StaticDhcpHost, error) { return service.RemoveByMac(ValidHost.MacAddress) }
-	RemoveByIP := func(service Service) (*model.StaticDhcpHost, error) { return service.RemoveByIP(ValidHost.IPAddress) }
+	RemoveByMac := func(service Service) (*model.StaticDhcpHost, error) {
+		return service.RemoveByMac(ValidHost.MacAddress, nil)
+	}
+	RemoveByIP := func(service Service) (*model.StaticDhcpHost, error) {
+		return service.RemoveByIP(ValidHost.IPAddress, nil)
+	}
+	FetchByHostName := func(service Service) (*model.StaticDhcpHost, error) { return service.FetchByHostName("foo") }
+	RemoveByHostName := func(service Service) (*model.StaticDhcpHost, error) {
+		return service.RemoveByHostName("foo", nil)
+	}
 
 	var testCases = []struct {
 		name   string
@@ -353,6 +554,7 @@ func TestHostServiceFetchRemove(t *testing.T) {
 			method: RemoveByMac,
 			on: func(mock *hostmock.RepositoryMock) {
 				mock.On("DeleteByMac", ValidHost.MacAddress).Once().Return(&ValidHost, nil)
+				mock.On("Transaction").Once().Return(nil)
 			},
 			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, mock *hostmock.RepositoryMock) {
 				assert.NoError(t, err, "RemoveByMac() returned an unexpected error")
@@ -366,6 +568,7 @@ func TestHostServiceFetchRemove(t *testing.T) {
 			method: RemoveByMac,
 			on: func(mock *hostmock.RepositoryMock) {
 				mock.On("DeleteByMac", ValidHost.MacAddress).Once().Return(nil, nil)
+				mock.On("Transaction").Once().Return(nil)
 			},
 			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, mock *hostmock.RepositoryMock) {
 				assert.NoError(t, err, "RemoveByMac() returned an unexpected error")
@@ -389,6 +592,7 @@ func TestHostServiceFetchRemove(t *testing.T) {
 			method: RemoveByIP,
 			on: func(mock *hostmock.RepositoryMock) {
 				mock.On("DeleteByIP", ValidHost.IPAddress).Once().Return(&ValidHost, nil)
+				mock.On("Transaction").Once().Return(nil)
 			},
 			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, mock *hostmock.RepositoryMock) {
 				assert.NoError(t, err, "RemoveByIP() returned an unexpected error")
@@ -402,6 +606,7 @@ func TestHostServiceFetchRemove(t *testing.T) {
 			method: RemoveByIP,
 			on: func(mock *hostmock.RepositoryMock) {
 				mock.On("DeleteByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("Transaction").Once().Return(nil)
 			},
 			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, mock *hostmock.RepositoryMock) {
 				assert.NoError(t, err, "RemoveByIP() returned an unexpected error")
@@ -420,6 +625,54 @@ func TestHostServiceFetchRemove(t *testing.T) {
 				mock.AssertExpectations(t)
 			},
 		},
+		{
+			name:   "FetchByHostNameFound",
+			method: FetchByHostName,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByHostName", "foo").Once().Return(&ValidHost, nil)
+			},
+			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, mock *hostmock.RepositoryMock) {
+				assert.NoError(t, err, "FetchByHostName() returned an unexpected error")
+				assert.Equal(t, &ValidHost, host, "FetchByHostName() returned an unexpected host")
+				mock.AssertExpectations(t)
+			},
+		},
+		{
+			name:   "FetchByHostNameNotFound",
+			method: FetchByHostName,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByHostName", "foo").Once().Return(nil, nil)
+			},
+			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, mock *hostmock.RepositoryMock) {
+				assert.NoError(t, err, "FetchByHostName() returned an unexpected error")
+				assert.Nil(t, host, "FetchByHostName() returned an unexpected host")
+				mock.AssertExpectations(t)
+			},
+		},
+		{
+			name:   "RemoveByHostNameFound",
+			method: RemoveByHostName,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("DeleteByHostName", "foo").Once().Return(&ValidHost, nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, mock *hostmock.RepositoryMock) {
+				assert.NoError(t, err, "RemoveByHostName() returned an unexpected error")
+				assert.Equal(t, &ValidHost, host, "RemoveByHostName() returned an unexpected host")
+				mock.AssertExpectations(t)
+			},
+		},
+		{
+			name:   "RemoveByHostNameError",
+			method: RemoveByHostName,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("DeleteByHostName", "foo").Once().Return(nil, errors.New("an error"))
+			},
+			assert: func(t *testing.T, host *model.StaticDhcpHost, err error, mock *hostmock.RepositoryMock) {
+				assert.Error(t, err, "RemoveByHostName() did NOT returned an expected error")
+				mock.AssertExpectations(t)
+			},
+		},
 	}
 
 	for _, test := range testCases {
@@ -427,38 +680,523 @@ func TestHostServiceFetchRemove(t *testing.T) {
 			repositoryMock := &hostmock.RepositoryMock{}
 			test.on(repositoryMock)
 
-			service := NewService(repositoryMock)
+			service := NewService(repositoryMock, dnsmasq.NoReload(), NoSubnetCheck())
 			host, err := test.method(service)
 			test.assert(t, host, err, repositoryMock)
 		})
 	}
 }
 
-func TestHostServiceErrors(t *testing.T) {
+func TestHostServiceReload(t *testing.T) {
+	reloadError := &dnsmasq.ReloadError{Method: dnsmasq.ReloadCommand, Err: errors.New("exit status 1")}
 
 	var testCases = []struct {
+		name          string
+		method        func(service Service) (*model.StaticDhcpHost, error)
+		on            func(mock *hostmock.RepositoryMock)
+		reloadResult  error
+		expectReload  bool
+		expectedHost  *model.StaticDhcpHost
+		expectedError error
+	}{
+		{
+			name:   "InsertReloads",
+			method: func(service Service) (*model.StaticDhcpHost, error) { return nil, service.Insert(&ValidHost, nil) },
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(nil, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("FindByHostName", ValidHost.HostName).Once().Return(nil, nil)
+				mock.On("Save", &ValidHost).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			expectReload: true,
+		},
+		{
+			name:   "InsertReloadError",
+			method: func(service Service) (*model.StaticDhcpHost, error) { return nil, service.Insert(&ValidHost, nil) },
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(nil, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("FindByHostName", ValidHost.HostName).Once().Return(nil, nil)
+				mock.On("Save", &ValidHost).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			reloadResult:  reloadError,
+			expectReload:  true,
+			expectedError: reloadError,
+		},
+		{
+			name:   "InsertDuplicatedDoesNotReload",
+			method: func(service Service) (*model.StaticDhcpHost, error) { return nil, service.Insert(&ValidHost, nil) },
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(&ValidHost, nil)
+			},
+			expectedError: &DuplicatedEntryError{Field: "MAC", Value: ValidHost.MacAddress.String(), Host: &ValidHost},
+		},
+		{
+			name:   "UpdateReloadError",
+			method: func(service Service) (*model.StaticDhcpHost, error) { return nil, service.Update(&ValidHost, nil) },
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(&OldHost, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("Replace", &OldHost, &ValidHost).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			reloadResult:  reloadError,
+			expectReload:  true,
+			expectedError: reloadError,
+		},
+		{
+			name: "RemoveByMacReloadError",
+			method: func(service Service) (*model.StaticDhcpHost, error) {
+				return service.RemoveByMac(ValidHost.MacAddress, nil)
+			},
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("DeleteByMac", ValidHost.MacAddress).Once().Return(&ValidHost, nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			reloadResult:  reloadError,
+			expectReload:  true,
+			expectedHost:  &ValidHost,
+			expectedError: reloadError,
+		},
+		{
+			name: "RemoveByIPNotFoundDoesNotReload",
+			method: func(service Service) (*model.StaticDhcpHost, error) {
+				return service.RemoveByIP(ValidHost.IPAddress, nil)
+			},
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("DeleteByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name: "RemoveByIPErrorDoesNotReload",
+			method: func(service Service) (*model.StaticDhcpHost, error) {
+				return service.RemoveByIP(ValidHost.IPAddress, nil)
+			},
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("DeleteByIP", ValidHost.IPAddress).Once().Return(nil, errors.New("an error"))
+			},
+			expectedError: &StorageError{Err: errors.New("an error")},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := &hostmock.RepositoryMock{}
+			test.on(repositoryMock)
+			reloaderMock := &dnsmasqmock.ReloaderMock{}
+			if test.expectReload {
+				reloaderMock.On("Reload").Once().Return(test.reloadResult)
+			}
+
+			service := NewService(repositoryMock, reloaderMock, NoSubnetCheck())
+			host, err := test.method(service)
+			assert.Equal(t, test.expectedHost, host, "unexpected host")
+			assert.Equal(t, test.expectedError, err, "error mismatch")
+			repositoryMock.AssertExpectations(t)
+			reloaderMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHostServiceVersions(t *testing.T) {
+	hosts := []model.StaticDhcpHost{OldHost, SameIPv6Host}
+	stale := model.Versions{"0123456789abcdef0123456789abcdef"}
+
+	testCases := []struct {
 		name            string
-		field           string
-		value           string
-		expectedMessage string
+		method          func(service Service) (*model.StaticDhcpHost, error)
+		on              func(mock *hostmock.RepositoryMock)
+		expectReload    bool
+		expectedCurrent string
+	}{
+		{
+			name: "InsertCurrentCollection",
+			method: func(service Service) (*model.StaticDhcpHost, error) {
+				host := ValidHost
+				return nil, service.Insert(&host, model.Versions{model.HostsVersion(hosts)})
+			},
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindAll").Once().Return(&hosts, nil)
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(nil, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("FindByHostName", ValidHost.HostName).Once().Return(nil, nil)
+				mock.On("Save", testifymock.Anything).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			expectReload: true,
+		},
+		{
+			name: "InsertStaleCollection",
+			method: func(service Service) (*model.StaticDhcpHost, error) {
+				host := ValidHost
+				return nil, service.Insert(&host, stale)
+			},
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindAll").Once().Return(&hosts, nil)
+			},
+			expectedCurrent: model.HostsVersion(hosts),
+		},
+		{
+			name: "InsertHostVersion",
+			method: func(service Service) (*model.StaticDhcpHost, error) {
+				host := ValidHost
+				return nil, service.Insert(&host, model.Versions{OldHost.Version()})
+			},
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindAll").Once().Return(&hosts, nil)
+			},
+			expectedCurrent: model.HostsVersion(hosts),
+		},
+		{
+			name: "UpdateAnyVersion",
+			method: func(service Service) (*model.StaticDhcpHost, error) {
+				host := ValidHost
+				return nil, service.Update(&host, model.Versions{model.AnyVersion})
+			},
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(&OldHost, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("Replace", &OldHost, testifymock.Anything).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			expectReload: true,
+		},
+		{
+			name: "UpdateStaleVersion",
+			method: func(service Service) (*model.StaticDhcpHost, error) {
+				host := ValidHost
+				return nil, service.Update(&host, stale)
+			},
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(&OldHost, nil)
+			},
+			expectedCurrent: OldHost.Version(),
+		},
+		{
+			name: "PatchStaleVersion",
+			method: func(service Service) (*model.StaticDhcpHost, error) {
+				return service.Patch(ValidHost.MacAddress, &model.StaticDhcpHostPatch{}, stale)
+			},
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(&OldHost, nil)
+			},
+			expectedCurrent: OldHost.Version(),
+		},
+		{
+			name: "RemoveByMacCurrentVersion",
+			method: func(service Service) (*model.StaticDhcpHost, error) {
+				return service.RemoveByMac(ValidHost.MacAddress, model.Versions{ValidHost.Version()})
+			},
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("DeleteByMac", ValidHost.MacAddress).Once().Return(&ValidHost, nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			expectReload: true,
+		},
+		{
+			name: "RemoveByMacStaleVersion",
+			method: func(service Service) (*model.StaticDhcpHost, error) {
+				return service.RemoveByMac(ValidHost.MacAddress, stale)
+			},
+			on: func(mock *hostmock.RepositoryMock) {
+				// Returning an error rolls the deletion back
+				mock.On("DeleteByMac", ValidHost.MacAddress).Once().Return(&ValidHost, nil)
+			},
+			expectedCurrent: ValidHost.Version(),
+		},
+		{
+			name: "RemoveByIPMissingHost",
+			method: func(service Service) (*model.StaticDhcpHost, error) {
+				return service.RemoveByIP(ValidHost.IPAddress, model.Versions{model.AnyVersion})
+			},
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("DeleteByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := &hostmock.RepositoryMock{}
+			test.on(repositoryMock)
+			reloaderMock := &dnsmasqmock.ReloaderMock{}
+			if test.expectReload {
+				reloaderMock.On("Reload").Once().Return(nil)
+			}
+
+			service := NewService(repositoryMock, reloaderMock, NoSubnetCheck())
+			_, err := test.method(service)
+			if test.expectReload {
+				assert.NoError(t, err, "unexpected error")
+			} else {
+				var mismatchErr *model.VersionMismatchError
+				if assert.ErrorAs(t, err, &mismatchErr, "unexpected error") {
+					assert.Equal(t, test.expectedCurrent, mismatchErr.Current, "unexpected current version")
+				}
+			}
+			repositoryMock.AssertExpectations(t)
+			reloaderMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHostServiceMetadata(t *testing.T) {
+	created := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	now := time.Date(2024, time.June, 1, 12, 30, 15, 999, time.Local)
+	expectedNow := now.UTC().Truncate(time.Second)
+	oldHost := OldHost
+	oldHost.Metadata = model.HostMetadata{Owner: "bob", CreatedBy: "bob", CreatedAt: created, UpdatedAt: created}
+
+	testCases := []struct {
+		name   string
+		method func(service Service, host *model.StaticDhcpHost, versions model.Versions) error
+		on     func(mock *hostmock.RepositoryMock)
+		// Host replaced by the updates, nil for the inserts
+		existing         *model.StaticDhcpHost
+		expectedMetadata model.HostMetadata
+	}{
+		{
+			name:   "Insert",
+			method: Service.Insert,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(nil, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+				mock.On("FindByHostName", ValidHost.HostName).Once().Return(nil, nil)
+			},
+			expectedMetadata: model.HostMetadata{Owner: "alice", CreatedBy: "carol", CreatedAt: expectedNow, UpdatedAt: expectedNow},
+		},
+		{
+			name:   "UpdateExistingHost",
+			method: Service.Update,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(&oldHost, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+			},
+			existing:         &oldHost,
+			expectedMetadata: model.HostMetadata{Owner: "alice", CreatedBy: "bob", CreatedAt: created, UpdatedAt: expectedNow},
+		},
+		{
+			name:   "UpdateHostWithoutMetadata",
+			method: Service.Update,
+			on: func(mock *hostmock.RepositoryMock) {
+				mock.On("FindByMac", ValidHost.MacAddress).Once().Return(&OldHost, nil)
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+			},
+			existing:         &OldHost,
+			expectedMetadata: model.HostMetadata{Owner: "alice", UpdatedAt: expectedNow},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			host := ValidHost
+			host.Metadata = model.HostMetadata{Owner: "alice", CreatedBy: "carol"}
+			expectedHost := ValidHost
+			expectedHost.Metadata = test.expectedMetadata
+
+			repositoryMock := &hostmock.RepositoryMock{}
+			test.on(repositoryMock)
+			if test.existing == nil {
+				repositoryMock.On("Save", &expectedHost).Once().Return(nil)
+			} else {
+				repositoryMock.On("Replace", test.existing, &expectedHost).Once().Return(nil)
+			}
+			repositoryMock.On("Transaction").Once().Return(nil)
+
+			s := NewService(repositoryMock, dnsmasq.NoReload(), NoSubnetCheck())
+			s.(*service).now = func() time.Time { return now }
+			assert.NoError(t, test.method(s, &host, nil), "unexpected error")
+			assert.Equal(t, test.expectedMetadata, host.Metadata, "unexpected metadata")
+			repositoryMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHostServiceUpdateKeepsOptions(t *testing.T) {
+	existing := OldHost
+	existing.SetTags = []string{"red"}
+	existing.ClientID = "*"
+	existing.LeaseTime = "12h"
+	host := ValidHost
+
+	repositoryMock := &hostmock.RepositoryMock{}
+	repositoryMock.On("FindByMac", ValidHost.MacAddress).Once().Return(&existing, nil)
+	repositoryMock.On("FindByIP", ValidHost.IPAddress).Once().Return(nil, nil)
+	repositoryMock.On("Replace", &existing, testifymock.Anything).Once().Return(nil)
+	repositoryMock.On("Transaction").Once().Return(nil)
+
+	s := NewService(repositoryMock, dnsmasq.NoReload(), NoSubnetCheck())
+	assert.NoError(t, s.Update(&host, nil), "unexpected error")
+	assert.Equal(t, existing.SetTags, host.SetTags, "the tags of the replaced host must be kept")
+	assert.Equal(t, existing.ClientID, host.ClientID, "the client ID of the replaced host must be kept")
+	assert.Equal(t, existing.LeaseTime, host.LeaseTime, "the lease time of the replaced host must be kept")
+	assert.Equal(t, ValidHost.IPAddress, host.IPAddress, "the new address must be used")
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestHostServicePatch(t *testing.T) {
+	newHostName := "Bar"
+	sameHostName := "FOO"
+	newIPAddress := net.ParseIP("1.1.1.2")
+	var noIPAddress net.IP
+	noHostName := ""
+	existing := model.StaticDhcpHost{
+		MacAddress: ValidHost.MacAddress,
+		IPAddress:  ValidHost.IPAddress,
+		HostName:   "Foo",
+		Metadata:   model.HostMetadata{Owner: "alice", Labels: []string{"lan"}},
+	}
+	now := time.Date(2024, time.June, 1, 12, 30, 15, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		patch         model.StaticDhcpHostPatch
+		on            func(mock *hostmock.RepositoryMock, patched *model.StaticDhcpHost)
+		expectedHost  func() model.StaticDhcpHost
+		expectedError error
 	}{
 		{
-			name:  "DuplicatedIP",
-			field: "IP",
-			value: "1.1.1.1",
+			name:  "HostName",
+			patch: model.StaticDhcpHostPatch{HostName: &newHostName},
+			on: func(mock *hostmock.RepositoryMock, patched *model.StaticDhcpHost) {
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(&existing, nil)
+				mock.On("FindByHostName", newHostName).Once().Return(nil, nil)
+				mock.On("Replace", &existing, patched).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			expectedHost: func() model.StaticDhcpHost {
+				host := existing
+				host.HostName = newHostName
+				host.Metadata.UpdatedAt = now
+				return host
+			},
 		},
 		{
-			name:  "DuplicatedMAC",
-			field: "MAC",
-			value: "aa:bb:cc:dd:ee:ff",
+			name:  "IPAddress",
+			patch: model.StaticDhcpHostPatch{IPAddress: &newIPAddress},
+			on: func(mock *hostmock.RepositoryMock, patched *model.StaticDhcpHost) {
+				mock.On("FindByIP", newIPAddress).Once().Return(nil, nil)
+				mock.On("Replace", &existing, patched).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			expectedHost: func() model.StaticDhcpHost {
+				host := existing
+				host.IPAddress = newIPAddress
+				host.Metadata.UpdatedAt = now
+				return host
+			},
+		},
+		{
+			name:  "IPAddressInUse",
+			patch: model.StaticDhcpHostPatch{IPAddress: &newIPAddress},
+			on: func(mock *hostmock.RepositoryMock, patched *model.StaticDhcpHost) {
+				mock.On("FindByIP", newIPAddress).Once().Return(&SameIPHost, nil)
+			},
+			expectedError: &DuplicatedEntryError{Field: "IP", Value: newIPAddress.String(), Host: &SameIPHost},
+		},
+		{
+			name:  "HostNameInUse",
+			patch: model.StaticDhcpHostPatch{HostName: &newHostName},
+			on: func(mock *hostmock.RepositoryMock, patched *model.StaticDhcpHost) {
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(&existing, nil)
+				mock.On("FindByHostName", newHostName).Once().Return(&SameIPHost, nil)
+			},
+			expectedError: &DuplicatedEntryError{Field: "hostname", Value: newHostName, Host: &SameIPHost},
+		},
+		{
+			name:  "SameHostNameOtherCase",
+			patch: model.StaticDhcpHostPatch{HostName: &sameHostName},
+			on: func(mock *hostmock.RepositoryMock, patched *model.StaticDhcpHost) {
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(&existing, nil)
+				mock.On("Replace", &existing, patched).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			expectedHost: func() model.StaticDhcpHost {
+				host := existing
+				host.HostName = sameHostName
+				host.Metadata.UpdatedAt = now
+				return host
+			},
+		},
+		{
+			name:          "RemovesOnlyAddress",
+			patch:         model.StaticDhcpHostPatch{IPAddress: &noIPAddress},
+			on:            func(mock *hostmock.RepositoryMock, patched *model.StaticDhcpHost) {},
+			expectedError: &ValidationError{Err: model.ErrDHCPHostMissingIPAddress},
+		},
+		{
+			name:          "RemovesHostName",
+			patch:         model.StaticDhcpHostPatch{HostName: &noHostName},
+			on:            func(mock *hostmock.RepositoryMock, patched *model.StaticDhcpHost) {},
+			expectedError: &ValidationError{Err: model.ErrDHCPHostMissingHostName},
+		},
+		{
+			name:  "SaveError",
+			patch: model.StaticDhcpHostPatch{HostName: &newHostName},
+			on: func(mock *hostmock.RepositoryMock, patched *model.StaticDhcpHost) {
+				mock.On("FindByIP", ValidHost.IPAddress).Once().Return(&existing, nil)
+				mock.On("FindByHostName", newHostName).Once().Return(nil, nil)
+				mock.On("Replace", &existing, testifymock.Anything).Once().Return(errors.New("an error"))
+			},
+			expectedError: &StorageError{Err: errors.New("an error")},
 		},
 	}
 
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
-			err := &DuplicatedEntryError{Field: test.field, Value: test.value}
-			expectedMessage := fmt.Sprintf(duplicatedEntryErrorMessage, test.field, test.value)
-			assert.EqualError(t, err, expectedMessage)
+			var expectedHost *model.StaticDhcpHost
+			if test.expectedHost != nil {
+				host := test.expectedHost()
+				expectedHost = &host
+			}
+
+			repositoryMock := &hostmock.RepositoryMock{}
+			repositoryMock.On("FindByMac", ValidHost.MacAddress).Once().Return(&existing, nil)
+			test.on(repositoryMock, expectedHost)
+
+			s := NewService(repositoryMock, dnsmasq.NoReload(), NoSubnetCheck())
+			s.(*service).now = func() time.Time { return now }
+			host, err := s.Patch(ValidHost.MacAddress, &test.patch, nil)
+			assert.Equal(t, test.expectedError, err, "error mismatch")
+			assert.Equal(t, expectedHost, host, "unexpected host")
+			assert.Equal(t, "Foo", existing.HostName, "Patch() changed the existing host")
+			repositoryMock.AssertExpectations(t)
 		})
 	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		repositoryMock := &hostmock.RepositoryMock{}
+		repositoryMock.On("FindByMac", ValidHost.MacAddress).Once().Return(nil, nil)
+
+		host, err := NewService(repositoryMock, dnsmasq.NoReload(), NoSubnetCheck()).Patch(ValidHost.MacAddress, &model.StaticDhcpHostPatch{HostName: &newHostName}, nil)
+		assert.Nil(t, host, "unexpected host")
+		assert.Equal(t, &NotFoundError{Field: "MAC", Value: ValidHost.MacAddress.String()}, err, "error mismatch")
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("ByHostName", func(t *testing.T) {
+		repositoryMock := &hostmock.RepositoryMock{}
+		repositoryMock.On("FindByHostName", "foo").Once().Return(&existing, nil)
+		repositoryMock.On("FindByIP", newIPAddress).Once().Return(nil, nil)
+		repositoryMock.On("Replace", &existing, testifymock.Anything).Once().Return(nil)
+		repositoryMock.On("Transaction").Once().Return(nil)
+
+		host, err := NewService(repositoryMock, dnsmasq.NoReload(), NoSubnetCheck()).PatchByHostName("foo", &model.StaticDhcpHostPatch{IPAddress: &newIPAddress}, nil)
+		assert.NoError(t, err, "unexpected error")
+		assert.Equal(t, newIPAddress, host.IPAddress, "unexpected host")
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("ByHostNameNotFound", func(t *testing.T) {
+		repositoryMock := &hostmock.RepositoryMock{}
+		repositoryMock.On("FindByHostName", "Qux").Once().Return(nil, nil)
+
+		host, err := NewService(repositoryMock, dnsmasq.NoReload(), NoSubnetCheck()).PatchByHostName("Qux", &model.StaticDhcpHostPatch{HostName: &newHostName}, nil)
+		assert.Nil(t, host, "unexpected host")
+		assert.Equal(t, &NotFoundError{Field: "hostname", Value: "Qux"}, err, "error mismatch")
+		repositoryMock.AssertExpectations(t)
+	})
 }
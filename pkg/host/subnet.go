@@ -0,0 +1,246 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"log/slog"
+)
+
+// Pool policies, telling what to do with a host whose address is inside the dynamic pool of a range
+const (
+	PoolPolicyAllow  = "allow"
+	PoolPolicyWarn   = "warn"
+	PoolPolicyReject = "reject"
+)
+
+type SubnetOptions struct {
+	// Values of the `dhcp-range=` directives served by dnsmasq, they are read from ConfDir when empty
+	Ranges []string
+	// dnsmasq conf-dir, read on every check so that changes made to the ranges are picked up
+	ConfDir string
+	// One of PoolPolicyAllow, PoolPolicyWarn or PoolPolicyReject
+	PoolPolicy string
+}
+
+// SubnetChecker ensures that dnsmasq can serve the addresses of a host.
+type SubnetChecker interface {
+	Check(host *model.StaticDhcpHost) error
+}
+
+// SubnetViolation is an address of a host that dnsmasq can't (or shouldn't) serve.
+type SubnetViolation struct {
+	// Host field of the address (IPAddress or IPv6Address)
+	Field  string
+	Value  string
+	Reason string
+}
+
+// SubnetError is returned when some address of a host does not fit in the subnets served by dnsmasq.
+type SubnetError struct {
+	Violations []SubnetViolation
+}
+
+const subnetErrorMessage = "invalid address for the served subnets: %s"
+
+func (e *SubnetError) Error() string {
+	reasons := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		reasons = append(reasons, violation.Reason)
+	}
+	return fmt.Sprintf(subnetErrorMessage, strings.Join(reasons, " "))
+}
+
+func (e *SubnetError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Reasons of the subnet violations
+const (
+	OutsideSubnetsReason   = "The %s %s is outside every subnet served by dnsmasq (%s)."
+	NetworkAddressReason   = "The %s %s is the network address of the subnet %s."
+	BroadcastAddressReason = "The %s %s is the broadcast address of the subnet %s."
+	InsidePoolReason       = "The %s %s is inside the dynamic pool %s-%s of the subnet %s."
+	// The subnet of an IPv4 range without netmask depends on the interface
+	InsidePoolWithoutNetmaskReason = "The %s %s is inside the dynamic pool %s-%s."
+)
+
+var ErrUnknownPoolPolicy = errors.New("unknown DHCP pool policy")
+
+type noSubnetCheck struct{}
+
+// NoSubnetCheck returns a SubnetChecker that accepts any address.
+func NoSubnetCheck() SubnetChecker {
+	return noSubnetCheck{}
+}
+
+func (noSubnetCheck) Check(host *model.StaticDhcpHost) error {
+	return nil
+}
+
+type subnetChecker struct {
+	ranges     []model.DhcpRange
+	confDir    string
+	poolPolicy string
+}
+
+func NewSubnetChecker(options SubnetOptions) (SubnetChecker, error) {
+	switch options.PoolPolicy {
+	case PoolPolicyAllow, PoolPolicyWarn, PoolPolicyReject:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPoolPolicy, options.PoolPolicy)
+	}
+
+	checker := &subnetChecker{confDir: options.ConfDir, poolPolicy: options.PoolPolicy}
+	for _, value := range options.Ranges {
+		dhcpRange := model.DhcpRange{}
+		if err := dhcpRange.FromConfig("dhcp-range=" + value); err != nil {
+			return nil, err
+		}
+		checker.ranges = append(checker.ranges, dhcpRange)
+	}
+
+	return checker, nil
+}
+
+// Check reports the addresses of the host outside every served subnet, the network and broadcast addresses and,
+// depending on the pool policy, the addresses dnsmasq may assign dynamically. An address family without any
+// range (e.g. IPv6 when dnsmasq only serves DHCPv4) is not checked. Only the dynamic pools of the IPv4 ranges
+// without netmask are checked, as their subnets depend on the interface.
+func (c *subnetChecker) Check(host *model.StaticDhcpHost) error {
+	ranges, constructed, err := c.servedRanges()
+	if err != nil {
+		return err
+	}
+
+	violations := []SubnetViolation{}
+	if violation := c.check("IPAddress", host.IPAddress, ranges); violation != nil {
+		violations = append(violations, *violation)
+	}
+	// The subnets of the constructed ranges depend on the interface addresses, so IPv6 can't be checked
+	if violation := c.check("IPv6Address", host.IPv6Address, ranges); violation != nil && !constructed {
+		violations = append(violations, *violation)
+	}
+	if len(violations) > 0 {
+		return &SubnetError{Violations: violations}
+	}
+
+	return nil
+}
+
+// servedRanges returns the configured ranges, or the ones found in the conf-dir. It also reports whether there
+// are IPv6 ranges built from the interface addresses (constructor:), whose subnets are unknown.
+func (c *subnetChecker) servedRanges() ([]model.DhcpRange, bool, error) {
+	if len(c.ranges) > 0 {
+		return c.ranges, false, nil
+	}
+
+	values, err := dnsmasq.ReadOptions(c.confDir, "dhcp-range")
+	if err != nil {
+		return nil, false, err
+	}
+
+	ranges := []model.DhcpRange{}
+	constructed := false
+	for _, value := range values {
+		dhcpRange := model.DhcpRange{}
+		err := dhcpRange.FromConfig("dhcp-range=" + value)
+		if err == nil && dhcpRange.Interface != "" {
+			constructed = true
+			continue
+		}
+		if err != nil {
+			slog.Warn("Ignoring a DHCP range that could not be parsed",
+				slog.String("confDir", c.confDir),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		ranges = append(ranges, dhcpRange)
+	}
+
+	return ranges, constructed, nil
+}
+
+func (c *subnetChecker) check(field string, ip net.IP, ranges []model.DhcpRange) *SubnetViolation {
+	if ip == nil {
+		return nil
+	}
+
+	subnets := []string{}
+	// Whether the address is inside a served subnet, or may be inside a subnet that is unknown
+	served := false
+	for _, dhcpRange := range ranges {
+		if dhcpRange.IsIPv4() != (ip.To4() != nil) {
+			continue
+		}
+
+		subnet := dhcpRange.Subnet()
+		if subnet == nil {
+			// Only the dynamic pool of the range is known, its subnet depends on the interface
+			slog.Info("Skipping the subnet checks of a DHCP range without netmask, whose subnet depends on the interface",
+				slog.String("address", ip.String()),
+				slog.String("start", dhcpRange.Start.String()),
+			)
+			served = true
+			if violation := c.checkPool(field, ip, dhcpRange, InsidePoolWithoutNetmaskReason, dhcpRange.Start, dhcpRange.End); violation != nil {
+				return violation
+			}
+			continue
+		}
+		if !slices.Contains(subnets, subnet.String()) {
+			subnets = append(subnets, subnet.String())
+		}
+		if !subnet.Contains(ip) {
+			continue
+		}
+		served = true
+
+		switch {
+		case dhcpRange.IsNetworkAddress(ip):
+			return c.violation(field, ip, NetworkAddressReason, subnet)
+		case dhcpRange.IsBroadcastAddress(ip):
+			return c.violation(field, ip, BroadcastAddressReason, subnet)
+		}
+		if violation := c.checkPool(field, ip, dhcpRange, InsidePoolReason, dhcpRange.Start, dhcpRange.End, subnet); violation != nil {
+			return violation
+		}
+	}
+
+	if served || len(subnets) == 0 {
+		return nil
+	}
+	return c.violation(field, ip, OutsideSubnetsReason, strings.Join(subnets, ", "))
+}
+
+// checkPool applies the pool policy to an address inside the dynamic pool of the range.
+func (c *subnetChecker) checkPool(field string, ip net.IP, dhcpRange model.DhcpRange, reason string, args ...any) *SubnetViolation {
+	if !dhcpRange.InPool(ip) {
+		return nil
+	}
+
+	switch c.poolPolicy {
+	case PoolPolicyReject:
+		return c.violation(field, ip, reason, args...)
+	case PoolPolicyWarn:
+		slog.Warn("The static host address is inside the dynamic pool of a DHCP range",
+			slog.String("address", ip.String()),
+			slog.String("start", dhcpRange.Start.String()),
+			slog.String("end", dhcpRange.End.String()),
+		)
+	}
+	return nil
+}
+
+func (c *subnetChecker) violation(field string, ip net.IP, reason string, args ...any) *SubnetViolation {
+	return &SubnetViolation{
+		Field:  field,
+		Value:  ip.String(),
+		Reason: fmt.Sprintf(reason, append([]any{field, ip.String()}, args...)...),
+	}
+}
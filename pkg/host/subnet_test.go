@@ -0,0 +1,179 @@
+package host
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ServedRanges = []string{
+	"192.168.1.100,192.168.1.200,12h",
+	"10.0.0.0,static,255.255.0.0",
+	"2001:db8::100,2001:db8::1ff,64",
+}
+
+var ServedRangesWithNetmask = []string{
+	"192.168.1.100,192.168.1.200,255.255.255.0,12h",
+	"10.0.0.0,static,255.255.0.0",
+	"2001:db8::100,2001:db8::1ff,64",
+}
+
+func TestSubnetChecker(t *testing.T) {
+	testCases := []struct {
+		name               string
+		ranges             []string
+		poolPolicy         string
+		ipAddress          string
+		ipv6Address        string
+		expectedViolations []SubnetViolation
+	}{
+		{name: "InsideSubnet", poolPolicy: PoolPolicyReject, ipAddress: "192.168.1.10"},
+		{name: "StaticSubnet", poolPolicy: PoolPolicyReject, ipAddress: "10.0.200.1"},
+		{name: "DualStack", poolPolicy: PoolPolicyReject, ipAddress: "192.168.1.10", ipv6Address: "2001:db8::10"},
+		{name: "InsidePoolAllowed", poolPolicy: PoolPolicyAllow, ipAddress: "192.168.1.150"},
+		{name: "InsidePoolWarned", poolPolicy: PoolPolicyWarn, ipAddress: "192.168.1.150"},
+		{
+			name:       "InsidePoolRejected",
+			poolPolicy: PoolPolicyReject,
+			ipAddress:  "192.168.1.150",
+			expectedViolations: []SubnetViolation{{
+				Field:  "IPAddress",
+				Value:  "192.168.1.150",
+				Reason: fmt.Sprintf(InsidePoolWithoutNetmaskReason, "IPAddress", "192.168.1.150", "192.168.1.100", "192.168.1.200"),
+			}},
+		},
+		{
+			name:       "InsidePoolRejectedWithNetmask",
+			ranges:     ServedRangesWithNetmask,
+			poolPolicy: PoolPolicyReject,
+			ipAddress:  "192.168.1.150",
+			expectedViolations: []SubnetViolation{{
+				Field:  "IPAddress",
+				Value:  "192.168.1.150",
+				Reason: fmt.Sprintf(InsidePoolReason, "IPAddress", "192.168.1.150", "192.168.1.100", "192.168.1.200", "192.168.1.0/24"),
+			}},
+		},
+		{
+			name:       "OutsideSubnets",
+			ranges:     ServedRangesWithNetmask,
+			poolPolicy: PoolPolicyAllow,
+			ipAddress:  "172.16.0.1",
+			expectedViolations: []SubnetViolation{{
+				Field:  "IPAddress",
+				Value:  "172.16.0.1",
+				Reason: fmt.Sprintf(OutsideSubnetsReason, "IPAddress", "172.16.0.1", "192.168.1.0/24, 10.0.0.0/16"),
+			}},
+		},
+		{
+			name:       "NetworkAddress",
+			poolPolicy: PoolPolicyAllow,
+			ipAddress:  "10.0.0.0",
+			expectedViolations: []SubnetViolation{{
+				Field:  "IPAddress",
+				Value:  "10.0.0.0",
+				Reason: fmt.Sprintf(NetworkAddressReason, "IPAddress", "10.0.0.0", "10.0.0.0/16"),
+			}},
+		},
+		{
+			name:        "BroadcastAndIPv6Outside",
+			ranges:      ServedRangesWithNetmask,
+			poolPolicy:  PoolPolicyAllow,
+			ipAddress:   "192.168.1.255",
+			ipv6Address: "2001:db8:1::1",
+			expectedViolations: []SubnetViolation{
+				{
+					Field:  "IPAddress",
+					Value:  "192.168.1.255",
+					Reason: fmt.Sprintf(BroadcastAddressReason, "IPAddress", "192.168.1.255", "192.168.1.0/24"),
+				},
+				{
+					Field:  "IPv6Address",
+					Value:  "2001:db8:1::1",
+					Reason: fmt.Sprintf(OutsideSubnetsReason, "IPv6Address", "2001:db8:1::1", "2001:db8::/64"),
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ranges := test.ranges
+			if ranges == nil {
+				ranges = ServedRanges
+			}
+			checker, err := NewSubnetChecker(SubnetOptions{Ranges: ranges, PoolPolicy: test.poolPolicy})
+			require.NoError(t, err, "NewSubnetChecker() returned an unexpected error")
+
+			host := &model.StaticDhcpHost{MacAddress: tests.ParseMAC(ValidMACAddress), HostName: "Foo"}
+			if test.ipAddress != "" {
+				host.IPAddress = net.ParseIP(test.ipAddress)
+			}
+			if test.ipv6Address != "" {
+				host.IPv6Address = net.ParseIP(test.ipv6Address)
+			}
+
+			err = checker.Check(host)
+			if test.expectedViolations == nil {
+				assert.NoError(t, err, "Check() returned an unexpected error")
+				return
+			}
+			assert.Equal(t, &SubnetError{Violations: test.expectedViolations}, err, "Check() returned unexpected violations")
+		})
+	}
+}
+
+func TestSubnetCheckerConfDir(t *testing.T) {
+	confDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(confDir, "01-dhcp.conf"), []byte(`dhcp-range=192.168.1.100,192.168.1.200,12h
+dhcp-range=::,constructor:eth0,ra-stateless
+dhcp-range=not-a-range
+`), 0644), "Failed to create conf file")
+
+	checker, err := NewSubnetChecker(SubnetOptions{ConfDir: confDir, PoolPolicy: PoolPolicyReject})
+	require.NoError(t, err, "NewSubnetChecker() returned an unexpected error")
+
+	// The IPv6 subnets built from the interface addresses are unknown, so IPv6 addresses are not checked
+	host := &model.StaticDhcpHost{MacAddress: tests.ParseMAC(ValidMACAddress), IPAddress: net.ParseIP("192.168.1.150"), IPv6Address: net.ParseIP("2001:db8::1"), HostName: "Foo"}
+	err = checker.Check(host)
+	var subnetErr *SubnetError
+	require.ErrorAs(t, err, &subnetErr, "Check() returned an unexpected error")
+	assert.Len(t, subnetErr.Violations, 1, "Check() returned unexpected violations")
+	assert.Equal(t, "IPAddress", subnetErr.Violations[0].Field, "Check() returned unexpected violations")
+
+	// Without any range nothing is checked
+	checker, err = NewSubnetChecker(SubnetOptions{ConfDir: filepath.Join(confDir, "missing"), PoolPolicy: PoolPolicyReject})
+	require.NoError(t, err, "NewSubnetChecker() returned an unexpected error")
+	assert.NoError(t, checker.Check(&ValidDualStackHost), "Check() returned an unexpected error")
+}
+
+func TestSubnetCheckerWithoutNetmask(t *testing.T) {
+	checker, err := NewSubnetChecker(SubnetOptions{Ranges: ServedRanges, PoolPolicy: PoolPolicyReject})
+	require.NoError(t, err, "NewSubnetChecker() returned an unexpected error")
+
+	// The subnet of the range without netmask depends on the interface, so the addresses it may serve are accepted
+	for _, ip := range []string{"172.16.0.1", "192.168.1.0", "192.168.1.255"} {
+		host := &model.StaticDhcpHost{MacAddress: tests.ParseMAC(ValidMACAddress), HostName: "Foo", IPAddress: net.ParseIP(ip)}
+		assert.NoError(t, checker.Check(host), "Check() returned an unexpected error for %s", ip)
+	}
+
+	// The dynamic pool and the known subnets are still checked
+	for _, ip := range []string{"192.168.1.150", "10.0.0.0"} {
+		host := &model.StaticDhcpHost{MacAddress: tests.ParseMAC(ValidMACAddress), HostName: "Foo", IPAddress: net.ParseIP(ip)}
+		assert.Error(t, checker.Check(host), "Check() did NOT returned an error for %s", ip)
+	}
+}
+
+func TestNewSubnetCheckerErrors(t *testing.T) {
+	_, err := NewSubnetChecker(SubnetOptions{PoolPolicy: "maybe"})
+	assert.ErrorIs(t, err, ErrUnknownPoolPolicy, "NewSubnetChecker() returned an unexpected error")
+
+	_, err = NewSubnetChecker(SubnetOptions{Ranges: []string{"192.168.1.200,192.168.1.100"}, PoolPolicy: PoolPolicyWarn})
+	assert.Error(t, err, "NewSubnetChecker() did NOT returned an error")
+}
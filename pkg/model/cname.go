@@ -0,0 +1,113 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+)
+
+// Cname represents a dnsmasq `cname=` line, answering the queries of its aliases with a CNAME to the target. dnsmasq
+// only answers them when it knows the target itself (e.g. a static host, a host record or another CNAME).
+type Cname struct {
+	// Names answered with the CNAME, matched regardless of the case
+	Aliases []string
+	Target  string
+	// Time to live of the answers in seconds, zero for the dnsmasq default (local-ttl)
+	TTL int
+}
+
+const (
+	cnamePrefix         = "cname="
+	errInvalidCnameConf = "invalid CNAME config: %s"
+)
+
+var ErrCnameMissingAlias = errors.New("invalid CNAME: missing alias")
+var ErrCnameMissingTarget = errors.New("invalid CNAME: missing target")
+var ErrCnameInvalidName = errors.New("invalid CNAME: names can't contain commas, slashes or spaces")
+var ErrCnameInvalidTTL = errors.New("invalid CNAME: negative TTL")
+var ErrCnameSelfTarget = errors.New("invalid CNAME: an alias can't be its own target")
+
+// IsCname reports whether the line is a `cname=` entry.
+func IsCname(line string) bool {
+	return dnsmasq.IsOption(line, "cname")
+}
+
+// FromConfig parses a `cname=<alias>[,<alias>...],<target>[,<ttl>]` line. As dnsmasq does, a numeric last token
+// is the TTL.
+func (c *Cname) FromConfig(config string) error {
+	*c = Cname{}
+	if !strings.HasPrefix(config, cnamePrefix) {
+		return fmt.Errorf(errInvalidCnameConf, config)
+	}
+
+	tokens := strings.Split(strings.TrimPrefix(config, cnamePrefix), ",")
+	if len(tokens) > 1 {
+		if ttl, err := strconv.Atoi(tokens[len(tokens)-1]); err == nil {
+			c.TTL = ttl
+			tokens = tokens[:len(tokens)-1]
+		}
+	}
+	if len(tokens) < 2 {
+		return fmt.Errorf(errInvalidCnameConf, config)
+	}
+	c.Aliases = tokens[:len(tokens)-1]
+	c.Target = tokens[len(tokens)-1]
+
+	if c.Validate() != nil {
+		return fmt.Errorf(errInvalidCnameConf, config)
+	}
+
+	return nil
+}
+
+// Validate ensures that the CNAME can be written as a dnsmasq line: it must have at least one alias and a target,
+// which can't be one of the aliases.
+func (c *Cname) Validate() error {
+	var err error = nil
+	if len(c.Aliases) == 0 {
+		err = errors.Join(err, ErrCnameMissingAlias)
+	}
+	if c.Target == "" {
+		err = errors.Join(err, ErrCnameMissingTarget)
+	}
+	invalidName := func(name string) bool { return name == "" || strings.ContainsAny(name, ",/ \t#") }
+	if slices.ContainsFunc(c.Aliases, invalidName) || (c.Target != "" && invalidName(c.Target)) {
+		err = errors.Join(err, ErrCnameInvalidName)
+	}
+	if c.TTL < 0 {
+		err = errors.Join(err, ErrCnameInvalidTTL)
+	}
+	if c.Target != "" && c.HasAlias(c.Target) {
+		err = errors.Join(err, ErrCnameSelfTarget)
+	}
+	return err
+}
+
+// ToConfig renders the CNAME as a `cname=` line.
+func (c *Cname) ToConfig() (string, error) {
+	err := c.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	tokens := append(slices.Clone(c.Aliases), c.Target)
+	if c.TTL != 0 {
+		tokens = append(tokens, strconv.Itoa(c.TTL))
+	}
+
+	return cnamePrefix + strings.Join(tokens, ","), nil
+}
+
+// Equal reports whether both CNAMEs result in the same dnsmasq entry.
+func (c *Cname) Equal(other Cname) bool {
+	return slices.Equal(c.Aliases, other.Aliases) && c.Target == other.Target && c.TTL == other.TTL
+}
+
+// HasAlias reports whether the CNAME answers the given name, regardless of the case.
+func (c *Cname) HasAlias(name string) bool {
+	return slices.ContainsFunc(c.Aliases, func(alias string) bool { return strings.EqualFold(alias, name) })
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCnameFromConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        string
+		expectError   bool
+		expectedCname Cname
+	}{
+		{
+			name:          "SingleAlias",
+			config:        `cname=files.lan,nas.lan`,
+			expectedCname: Cname{Aliases: []string{"files.lan"}, Target: "nas.lan"},
+		},
+		{
+			name:          "ManyAliasesWithTTL",
+			config:        `cname=files.lan,backup.lan,nas.lan,300`,
+			expectedCname: Cname{Aliases: []string{"files.lan", "backup.lan"}, Target: "nas.lan", TTL: 300},
+		},
+		{name: "NotACname", config: `host-record=nas.lan,192.168.1.10`, expectError: true},
+		{name: "MissingTarget", config: `cname=files.lan`, expectError: true},
+		{name: "MissingTargetWithTTL", config: `cname=files.lan,300`, expectError: true},
+		{name: "EmptyAlias", config: `cname=,nas.lan`, expectError: true},
+		{name: "SelfTarget", config: `cname=nas.lan,NAS.lan`, expectError: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			cname := Cname{}
+			err := cname.FromConfig(test.config)
+			if test.expectError {
+				assert.Error(t, err, "Cname.FromConfig() did NOT returned an error")
+				return
+			}
+			assert.NoError(t, err, "Cname.FromConfig() returned an unexpected error")
+			assert.Equal(t, test.expectedCname, cname, "Cname.FromConfig() has generated an unexpected CNAME")
+
+			config, err := cname.ToConfig()
+			assert.NoError(t, err, "Cname.ToConfig() returned an unexpected error")
+			assert.Equal(t, test.config, config, "Cname did not survive the round trip")
+		})
+	}
+}
+
+func TestCnameToConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		cname         Cname
+		expectedError error
+	}{
+		{name: "MissingAlias", cname: Cname{Target: "nas.lan"}, expectedError: ErrCnameMissingAlias},
+		{name: "MissingTarget", cname: Cname{Aliases: []string{"files.lan"}}, expectedError: ErrCnameMissingTarget},
+		{name: "InvalidAlias", cname: Cname{Aliases: []string{"files lan"}, Target: "nas.lan"}, expectedError: ErrCnameInvalidName},
+		{name: "InvalidTarget", cname: Cname{Aliases: []string{"files.lan"}, Target: "nas,lan"}, expectedError: ErrCnameInvalidName},
+		{name: "NegativeTTL", cname: Cname{Aliases: []string{"files.lan"}, Target: "nas.lan", TTL: -1}, expectedError: ErrCnameInvalidTTL},
+		{name: "SelfTarget", cname: Cname{Aliases: []string{"files.lan"}, Target: "Files.lan"}, expectedError: ErrCnameSelfTarget},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := test.cname.ToConfig()
+			assert.ErrorIs(t, err, test.expectedError, "Cname.ToConfig() returned an unexpected error")
+			assert.Empty(t, config, "Cname.ToConfig() returned an unexpected config")
+		})
+	}
+}
+
+func TestIsCname(t *testing.T) {
+	assert.True(t, IsCname("cname=files.lan,nas.lan"), "IsCname() did NOT recognize a CNAME")
+	assert.False(t, IsCname("# cname=files.lan,nas.lan"), "IsCname() recognized a comment")
+	assert.False(t, IsCname("host-record=nas.lan,192.168.1.10"), "IsCname() recognized a host record")
+}
@@ -0,0 +1,12 @@
+package model
+
+// CnameTransaction is a unit of work on the CNAMEs, applied in memory as a HostTransaction is.
+type CnameTransaction interface {
+	FindAll() (*[]Cname, error)
+	// FindByAlias returns the CNAME answering the given name, regardless of the case
+	FindByAlias(alias string) (*Cname, error)
+	Save(cname *Cname) error
+	// ReplaceByAlias changes the CNAME answering the given alias into the new one, keeping its place in the file
+	ReplaceByAlias(alias string, cname *Cname) error
+	DeleteByAlias(alias string) (*Cname, error)
+}
@@ -0,0 +1,373 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+)
+
+// DhcpRange represents a dnsmasq `dhcp-range=` entry: a subnet served by dnsmasq and, unless it is a static
+// or proxy range, the pool of addresses it assigns dynamically.
+type DhcpRange struct {
+	// Tag set by this range (`set:<tag>`)
+	SetTag string
+	// Tags required for this range to be used (`tag:<tag>`)
+	MatchTags []string
+	// First address of the pool, or the subnet address of static and proxy ranges
+	Start net.IP
+	// Last address of the pool, nil for static and proxy ranges
+	End net.IP
+	// Interface an IPv6 range is built on (`constructor:<interface>`): Start and End only hold the host part of
+	// the addresses, dnsmasq takes the prefix from the addresses of the interface
+	Interface string
+	// Modes of the range (e.g. static, proxy, ra-only, slaac)
+	Modes []string
+	// IPv4 netmask, dnsmasq takes it from the interface when missing
+	Netmask net.IP
+	// IPv4 broadcast address, derived from the netmask when missing
+	Broadcast net.IP
+	// IPv6 prefix length, dnsmasq defaults to 64 when missing
+	PrefixLength int
+	// Lease time, in dnsmasq notation (e.g. 3600, 45m, 12h, infinite)
+	LeaseTime string
+}
+
+// DhcpRangeKey identifies a range by its start address, along with its interface when it is built on one (see
+// DhcpRange.Interface), since the start address of those ranges is only the host part.
+type DhcpRangeKey struct {
+	Start     net.IP
+	Interface string
+}
+
+const (
+	dhcpRangeOption           = "dhcp-range"
+	dhcpRangePrefix           = dhcpRangeOption + "="
+	errInvalidDHCPRangeConfig = "invalid DHCP range config: %s"
+	constructorPrefix         = "constructor:"
+	defaultIPv6PrefixLength   = 64
+)
+
+// Modes of the ranges without a pool of dynamically assigned addresses
+var modesWithoutPool = []string{"static", "proxy", "ra-only", "ra-stateless"}
+
+var dhcpRangeModes = append([]string{"ra-names", "slaac", "ra-advrouter", "off-link"}, modesWithoutPool...)
+
+var ErrDHCPRangeMissingStart = errors.New("invalid DHCP range: missing start address")
+
+// IsDhcpRange reports whether the line is a `dhcp-range=` entry.
+func IsDhcpRange(line string) bool {
+	return dnsmasq.IsOption(line, dhcpRangeOption)
+}
+
+// FromConfig parses a `dhcp-range=` line, accepting the tokens documented by dnsmasq:
+// `[tag:<tag>[,tag:<tag>],][set:<tag>,]<start-addr>[,<end-addr>|<mode>][,<netmask>[,<broadcast>]][,<lease time>]`
+// for IPv4 and
+// `[tag:<tag>,][set:<tag>,]<start-IPv6addr>[,<end-IPv6addr>][,constructor:<interface>][,<mode>][,<prefix-len>][,<lease time>]`
+// for IPv6.
+func (r *DhcpRange) FromConfig(config string) error {
+	*r = DhcpRange{}
+	option, value, ok := dnsmasq.Option(config)
+	if !ok || option != dhcpRangeOption {
+		return fmt.Errorf(errInvalidDHCPRangeConfig, config)
+	}
+
+	tokens := strings.Split(value, ",")
+	for i := range tokens {
+		tokens[i] = strings.TrimSpace(tokens[i])
+	}
+	for len(tokens) > 0 && r.Start == nil {
+		token := tokens[0]
+		tokens = tokens[1:]
+		switch {
+		case strings.HasPrefix(token, matchTagPrefix):
+			r.MatchTags = append(r.MatchTags, strings.TrimPrefix(token, matchTagPrefix))
+		case strings.HasPrefix(token, setTagPrefix) && r.SetTag == "":
+			r.SetTag = strings.TrimPrefix(token, setTagPrefix)
+		default:
+			r.Start = net.ParseIP(token)
+			if r.Start == nil {
+				return fmt.Errorf(errInvalidDHCPRangeConfig, config)
+			}
+		}
+	}
+	if r.Start == nil {
+		return fmt.Errorf(errInvalidDHCPRangeConfig, config)
+	}
+
+	if len(tokens) > 0 && r.isSameFamily(net.ParseIP(tokens[0])) {
+		r.End = net.ParseIP(tokens[0])
+		tokens = tokens[1:]
+	}
+	for _, token := range tokens {
+		if !r.parseToken(token) {
+			return fmt.Errorf(errInvalidDHCPRangeConfig, config)
+		}
+	}
+
+	if r.check() != nil {
+		return fmt.Errorf(errInvalidDHCPRangeConfig, config)
+	}
+
+	return nil
+}
+
+// parseToken stores one of the tokens following the addresses of the range. It returns false when the
+// token does not fit in the dhcp-range grammar.
+func (r *DhcpRange) parseToken(token string) bool {
+	switch {
+	case slices.Contains(dhcpRangeModes, token):
+		r.Modes = append(r.Modes, token)
+		return true
+	case strings.HasPrefix(token, constructorPrefix) && !r.IsIPv4() && r.Interface == "" && len(r.Modes) == 0:
+		r.Interface = strings.TrimPrefix(token, constructorPrefix)
+		return r.Interface != ""
+	case r.IsIPv4() && net.ParseIP(token).To4() != nil:
+		if r.Netmask == nil {
+			r.Netmask = net.ParseIP(token)
+		} else if r.Broadcast == nil {
+			r.Broadcast = net.ParseIP(token)
+		} else {
+			return false
+		}
+		return true
+	case !r.IsIPv4() && r.PrefixLength == 0 && r.LeaseTime == "" && isPrefixLength(token):
+		r.PrefixLength, _ = strconv.Atoi(token)
+		return true
+	case leaseTimeRegexp.MatchString(token) && r.LeaseTime == "":
+		r.LeaseTime = token
+		return true
+	default:
+		return false
+	}
+}
+
+func isPrefixLength(token string) bool {
+	length, err := strconv.Atoi(token)
+	return err == nil && length > 0 && length <= 128
+}
+
+// Validate ensures that the range can be written as a dnsmasq line: the tokens must belong to the family of the
+// start address, and the pool boundaries and the netmask must be consistent.
+func (r *DhcpRange) Validate() error {
+	if r.Start == nil {
+		return ErrDHCPRangeMissingStart
+	}
+	if r.End != nil && !r.isSameFamily(r.End) {
+		return fmt.Errorf("invalid DHCP range: %s and %s are not of the same family", r.Start, r.End)
+	}
+	if r.IsIPv4() && (r.Interface != "" || r.PrefixLength != 0) {
+		return fmt.Errorf("invalid DHCP range: only IPv6 ranges have an interface or a prefix length")
+	}
+	if !r.IsIPv4() && (r.Netmask != nil || r.Broadcast != nil) {
+		return fmt.Errorf("invalid DHCP range: only IPv4 ranges have a netmask or a broadcast address")
+	}
+	if (r.Netmask != nil && r.Netmask.To4() == nil) || (r.Broadcast != nil && r.Broadcast.To4() == nil) {
+		return fmt.Errorf("invalid DHCP range: the netmask and the broadcast address must be IPv4 addresses")
+	}
+	if r.PrefixLength != 0 && !isPrefixLength(strconv.Itoa(r.PrefixLength)) {
+		return fmt.Errorf("invalid DHCP range: %d is not a prefix length", r.PrefixLength)
+	}
+	for _, mode := range r.Modes {
+		if !slices.Contains(dhcpRangeModes, mode) {
+			return fmt.Errorf("invalid DHCP range: unknown mode %s", mode)
+		}
+	}
+	if r.LeaseTime != "" && !leaseTimeRegexp.MatchString(r.LeaseTime) {
+		return fmt.Errorf("invalid DHCP range: %s is not a lease time", r.LeaseTime)
+	}
+	for _, name := range append([]string{r.SetTag, r.Interface}, r.MatchTags...) {
+		if strings.ContainsAny(name, ", \t\"") {
+			return fmt.Errorf("invalid DHCP range: tags and interfaces can't contain commas, quotes or spaces")
+		}
+	}
+	if slices.Contains(r.MatchTags, "") {
+		return fmt.Errorf("invalid DHCP range: tags can't be empty")
+	}
+
+	return r.check()
+}
+
+// check ensures that the pool boundaries and the netmask are consistent.
+func (r *DhcpRange) check() error {
+	if r.End != nil && bytes.Compare(r.Start.To16(), r.End.To16()) > 0 {
+		return fmt.Errorf("invalid DHCP range: %s is after %s", r.Start, r.End)
+	}
+	if r.Netmask != nil && !isContiguousMask(net.IPMask(r.Netmask.To4())) {
+		return fmt.Errorf("invalid DHCP range: %s is not a netmask", r.Netmask)
+	}
+	if subnet := r.Subnet(); r.End != nil && subnet != nil && !subnet.Contains(r.End) {
+		return fmt.Errorf("invalid DHCP range: %s and %s are in different subnets", r.Start, r.End)
+	}
+	return nil
+}
+
+func isContiguousMask(mask net.IPMask) bool {
+	_, bits := mask.Size()
+	return bits != 0
+}
+
+// ToConfig renders the range as a `dhcp-range=` line.
+func (r *DhcpRange) ToConfig() (string, error) {
+	if err := r.Validate(); err != nil {
+		return "", err
+	}
+
+	tokens := []string{}
+	for _, tag := range r.MatchTags {
+		tokens = append(tokens, matchTagPrefix+tag)
+	}
+	if r.SetTag != "" {
+		tokens = append(tokens, setTagPrefix+r.SetTag)
+	}
+	tokens = append(tokens, r.Start.String())
+	if r.End != nil {
+		tokens = append(tokens, r.End.String())
+	}
+	if r.Interface != "" {
+		tokens = append(tokens, constructorPrefix+r.Interface)
+	}
+	tokens = append(tokens, r.Modes...)
+	if r.Netmask != nil {
+		tokens = append(tokens, r.Netmask.String())
+	}
+	if r.Broadcast != nil {
+		tokens = append(tokens, r.Broadcast.String())
+	}
+	if r.PrefixLength != 0 {
+		tokens = append(tokens, strconv.Itoa(r.PrefixLength))
+	}
+	if r.LeaseTime != "" {
+		tokens = append(tokens, r.LeaseTime)
+	}
+
+	return dhcpRangePrefix + strings.Join(tokens, ","), nil
+}
+
+// IsIPv4 reports whether the range serves an IPv4 subnet.
+func (r *DhcpRange) IsIPv4() bool {
+	return r.Start.To4() != nil
+}
+
+func (r *DhcpRange) isSameFamily(ip net.IP) bool {
+	return ip != nil && (ip.To4() != nil) == r.IsIPv4()
+}
+
+// Key returns the key identifying the range.
+func (r *DhcpRange) Key() DhcpRangeKey {
+	return DhcpRangeKey{Start: r.Start, Interface: r.Interface}
+}
+
+// String renders the key, comparing the start addresses by their value (e.g. 2001:db8::1 and 2001:DB8:0::1 are
+// the same address).
+func (k DhcpRangeKey) String() string {
+	if k.Interface == "" {
+		return k.Start.String()
+	}
+	return k.Start.String() + "," + constructorPrefix + k.Interface
+}
+
+// Subnet returns the subnet served by the range, or nil when it is unknown: dnsmasq takes the netmask of IPv4
+// ranges without one from the interface they are served on, which is unknown here. The subnets of the ranges built
+// on an interface are unknown as well, their result is meaningless.
+func (r *DhcpRange) Subnet() *net.IPNet {
+	if r.IsIPv4() {
+		if r.Netmask == nil {
+			return nil
+		}
+		mask := net.IPMask(r.Netmask.To4())
+		return &net.IPNet{IP: r.Start.To4().Mask(mask), Mask: mask}
+	}
+
+	prefixLength := r.PrefixLength
+	if prefixLength == 0 {
+		prefixLength = defaultIPv6PrefixLength
+	}
+	mask := net.CIDRMask(prefixLength, 8*net.IPv6len)
+	return &net.IPNet{IP: r.Start.Mask(mask), Mask: mask}
+}
+
+// HasPool reports whether dnsmasq dynamically assigns the addresses between Start and End.
+func (r *DhcpRange) HasPool() bool {
+	if r.End == nil {
+		return false
+	}
+	for _, mode := range r.Modes {
+		if slices.Contains(modesWithoutPool, mode) {
+			return false
+		}
+	}
+	return true
+}
+
+// Overlaps reports whether both ranges claim the same addresses: their pools share addresses, or neither has a
+// pool and they serve the same subnet (the same start, when a subnet is unknown). Ranges of different families, or
+// built on different interfaces, never overlap.
+func (r *DhcpRange) Overlaps(other DhcpRange) bool {
+	if r.IsIPv4() != other.IsIPv4() || r.Interface != other.Interface {
+		return false
+	}
+
+	switch {
+	case r.HasPool() && other.HasPool():
+		return bytes.Compare(r.Start.To16(), other.End.To16()) <= 0 && bytes.Compare(other.Start.To16(), r.End.To16()) <= 0
+	case !r.HasPool() && !other.HasPool():
+		subnet, otherSubnet := r.Subnet(), other.Subnet()
+		if subnet == nil || otherSubnet == nil {
+			return r.Start.Equal(other.Start)
+		}
+		return subnet.String() == otherSubnet.String()
+	default:
+		return false
+	}
+}
+
+// InPool reports whether the address may be dynamically assigned by the range.
+func (r *DhcpRange) InPool(ip net.IP) bool {
+	if !r.HasPool() || !r.isSameFamily(ip) {
+		return false
+	}
+	ip = ip.To16()
+	return bytes.Compare(r.Start.To16(), ip) <= 0 && bytes.Compare(ip, r.End.To16()) <= 0
+}
+
+// IsNetworkAddress reports whether the address identifies the subnet itself, so it can't be assigned to a host.
+// Point-to-point subnets (/31 and /32 for IPv4, /127 and /128 for IPv6) and unknown subnets have no such address.
+func (r *DhcpRange) IsNetworkAddress(ip net.IP) bool {
+	subnet := r.Subnet()
+	if subnet == nil {
+		return false
+	}
+	ones, bits := subnet.Mask.Size()
+	return bits-ones > 1 && subnet.IP.Equal(ip)
+}
+
+// IsBroadcastAddress reports whether the address is the broadcast address of an IPv4 subnet. It is never the case
+// for an unknown subnet without an explicit broadcast address.
+func (r *DhcpRange) IsBroadcastAddress(ip net.IP) bool {
+	if !r.IsIPv4() {
+		return false
+	}
+	if r.Broadcast != nil {
+		return r.Broadcast.Equal(ip)
+	}
+
+	subnet := r.Subnet()
+	if subnet == nil {
+		return false
+	}
+	ones, bits := subnet.Mask.Size()
+	if bits-ones <= 1 {
+		return false
+	}
+	broadcast := make(net.IP, net.IPv4len)
+	for i := range broadcast {
+		broadcast[i] = subnet.IP[i] | ^subnet.Mask[i]
+	}
+	return broadcast.Equal(ip)
+}
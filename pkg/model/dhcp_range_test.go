@@ -0,0 +1,196 @@
+package model
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDhcpRangeFromConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        string
+		expectError   bool
+		expectedRange DhcpRange
+	}{
+		{
+			name:          "Pool",
+			config:        `dhcp-range=192.168.1.100,192.168.1.200,12h`,
+			expectedRange: DhcpRange{Start: net.ParseIP("192.168.1.100"), End: net.ParseIP("192.168.1.200"), LeaseTime: "12h"},
+		},
+		{
+			// The subnet depends on the interface, which may serve both addresses
+			name:          "PoolWithoutNetmask",
+			config:        `dhcp-range=192.168.1.100,192.168.2.100`,
+			expectedRange: DhcpRange{Start: net.ParseIP("192.168.1.100"), End: net.ParseIP("192.168.2.100")},
+		},
+		{
+			name:   "Netmask",
+			config: `dhcp-range=set:lan,10.0.0.10,10.0.0.50,255.255.255.0,10.0.0.255,infinite`,
+			expectedRange: DhcpRange{SetTag: "lan", Start: net.ParseIP("10.0.0.10"), End: net.ParseIP("10.0.0.50"),
+				Netmask: net.ParseIP("255.255.255.0"), Broadcast: net.ParseIP("10.0.0.255"), LeaseTime: "infinite"},
+		},
+		{
+			name:          "Static",
+			config:        `dhcp-range=tag:red,tag:blue,192.168.2.0,static, 255.255.255.0`,
+			expectedRange: DhcpRange{MatchTags: []string{"red", "blue"}, Start: net.ParseIP("192.168.2.0"), Modes: []string{"static"}, Netmask: net.ParseIP("255.255.255.0")},
+		},
+		{
+			name:          "IPv6",
+			config:        `dhcp-range=2001:db8::100,2001:db8::1ff,ra-names,slaac,64,1h`,
+			expectedRange: DhcpRange{Start: net.ParseIP("2001:db8::100"), End: net.ParseIP("2001:db8::1ff"), Modes: []string{"ra-names", "slaac"}, PrefixLength: 64, LeaseTime: "1h"},
+		},
+		{
+			name:          "Constructor",
+			config:        `dhcp-range=::1,::400,constructor:eth0,12h`,
+			expectedRange: DhcpRange{Start: net.ParseIP("::1"), End: net.ParseIP("::400"), Interface: "eth0", LeaseTime: "12h"},
+		},
+		{
+			name:          "ConstructorStateless",
+			config:        `dhcp-range=tag:lan,::,constructor:eth0,ra-stateless,ra-names`,
+			expectedRange: DhcpRange{MatchTags: []string{"lan"}, Start: net.ParseIP("::"), Interface: "eth0", Modes: []string{"ra-stateless", "ra-names"}},
+		},
+		{name: "IPv4Constructor", config: `dhcp-range=192.168.1.100,192.168.1.200,constructor:eth0`, expectError: true},
+		{name: "EmptyConstructor", config: `dhcp-range=::1,::400,constructor:`, expectError: true},
+		{name: "NotARange", config: `dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo`, expectError: true},
+		{name: "MissingStart", config: `dhcp-range=set:lan`, expectError: true},
+		{name: "InvalidStart", config: `dhcp-range=192.168.1,192.168.1.200`, expectError: true},
+		{name: "ReversedPool", config: `dhcp-range=192.168.1.200,192.168.1.100`, expectError: true},
+		{name: "PoolAcrossSubnets", config: `dhcp-range=192.168.1.100,192.168.2.100,255.255.255.0`, expectError: true},
+		{name: "InvalidNetmask", config: `dhcp-range=192.168.1.100,192.168.1.200,255.0.255.0`, expectError: true},
+		{name: "UnknownToken", config: `dhcp-range=192.168.1.100,192.168.1.200,foo`, expectError: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			dhcpRange := DhcpRange{}
+			err := dhcpRange.FromConfig(test.config)
+			if test.expectError {
+				assert.Error(t, err, "DhcpRange.FromConfig() did NOT returned an error")
+				return
+			}
+			assert.NoError(t, err, "DhcpRange.FromConfig() returned an unexpected error")
+			assert.Equal(t, test.expectedRange, dhcpRange, "DhcpRange.FromConfig() has generated an unexpected range")
+
+			config, err := dhcpRange.ToConfig()
+			assert.NoError(t, err, "DhcpRange.ToConfig() returned an unexpected error")
+			reparsed := DhcpRange{}
+			assert.NoError(t, reparsed.FromConfig(config), "DhcpRange.FromConfig() returned an unexpected error")
+			assert.Equal(t, dhcpRange, reparsed, "DhcpRange did not survive the round trip")
+		})
+	}
+}
+
+func TestDhcpRangeAddresses(t *testing.T) {
+	parse := func(config string) *DhcpRange {
+		dhcpRange := &DhcpRange{}
+		assert.NoError(t, dhcpRange.FromConfig(config), "DhcpRange.FromConfig() returned an unexpected error")
+		return dhcpRange
+	}
+
+	pool := parse(`dhcp-range=192.168.1.100,192.168.1.200,255.255.255.0,12h`)
+	assert.Equal(t, "192.168.1.0/24", pool.Subnet().String())
+	assert.True(t, pool.InPool(net.ParseIP("192.168.1.100")))
+	assert.True(t, pool.InPool(net.ParseIP("192.168.1.200")))
+	assert.False(t, pool.InPool(net.ParseIP("192.168.1.201")))
+	assert.False(t, pool.InPool(net.ParseIP("2001:db8::1")))
+	assert.True(t, pool.IsNetworkAddress(net.ParseIP("192.168.1.0")))
+	assert.True(t, pool.IsBroadcastAddress(net.ParseIP("192.168.1.255")))
+	assert.False(t, pool.IsBroadcastAddress(net.ParseIP("192.168.1.254")))
+
+	// dnsmasq takes the netmask from the interface, so the subnet is unknown
+	unknown := parse(`dhcp-range=192.168.1.100,192.168.1.200,12h`)
+	assert.Nil(t, unknown.Subnet())
+	assert.True(t, unknown.InPool(net.ParseIP("192.168.1.150")))
+	assert.False(t, unknown.IsNetworkAddress(net.ParseIP("192.168.1.0")))
+	assert.False(t, unknown.IsBroadcastAddress(net.ParseIP("192.168.1.255")))
+
+	static := parse(`dhcp-range=10.1.0.0,static,255.255.254.0`)
+	assert.Equal(t, "10.1.0.0/23", static.Subnet().String())
+	assert.False(t, static.HasPool())
+	assert.False(t, static.InPool(net.ParseIP("10.1.0.0")))
+	assert.True(t, static.IsBroadcastAddress(net.ParseIP("10.1.1.255")))
+
+	pointToPoint := parse(`dhcp-range=10.2.0.0,10.2.0.1,255.255.255.254`)
+	assert.False(t, pointToPoint.IsNetworkAddress(net.ParseIP("10.2.0.0")))
+	assert.False(t, pointToPoint.IsBroadcastAddress(net.ParseIP("10.2.0.1")))
+
+	ipv6 := parse(`dhcp-range=2001:db8::100,2001:db8::1ff`)
+	assert.Equal(t, "2001:db8::/64", ipv6.Subnet().String())
+	assert.True(t, ipv6.InPool(net.ParseIP("2001:db8::1aa")))
+	assert.True(t, ipv6.IsNetworkAddress(net.ParseIP("2001:db8::")))
+	assert.False(t, ipv6.IsBroadcastAddress(net.ParseIP("2001:db8::ffff:ffff:ffff:ffff")))
+}
+
+func TestDhcpRangeValidate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		dhcpRange DhcpRange
+	}{
+		{name: "MissingStart", dhcpRange: DhcpRange{End: net.ParseIP("192.168.1.200")}},
+		{name: "MixedFamilies", dhcpRange: DhcpRange{Start: net.ParseIP("192.168.1.100"), End: net.ParseIP("2001:db8::1ff")}},
+		{name: "IPv4Interface", dhcpRange: DhcpRange{Start: net.ParseIP("192.168.1.100"), Interface: "eth0"}},
+		{name: "IPv4PrefixLength", dhcpRange: DhcpRange{Start: net.ParseIP("192.168.1.100"), PrefixLength: 24}},
+		{name: "IPv6Netmask", dhcpRange: DhcpRange{Start: net.ParseIP("2001:db8::100"), Netmask: net.ParseIP("255.255.255.0")}},
+		{name: "InvalidPrefixLength", dhcpRange: DhcpRange{Start: net.ParseIP("2001:db8::100"), PrefixLength: 129}},
+		{name: "UnknownMode", dhcpRange: DhcpRange{Start: net.ParseIP("192.168.1.0"), Modes: []string{"foo"}}},
+		{name: "InvalidLeaseTime", dhcpRange: DhcpRange{Start: net.ParseIP("192.168.1.100"), LeaseTime: "12 hours"}},
+		{name: "InvalidTag", dhcpRange: DhcpRange{Start: net.ParseIP("192.168.1.100"), SetTag: "lan,wan"}},
+		{name: "EmptyTag", dhcpRange: DhcpRange{Start: net.ParseIP("192.168.1.100"), MatchTags: []string{""}}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Error(t, test.dhcpRange.Validate(), "DhcpRange.Validate() did NOT returned an error")
+			config, err := test.dhcpRange.ToConfig()
+			assert.Error(t, err, "DhcpRange.ToConfig() did NOT returned an error")
+			assert.Empty(t, config, "DhcpRange.ToConfig() returned an unexpected config")
+		})
+	}
+}
+
+func TestDhcpRangeOverlaps(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   string
+		other    string
+		overlaps bool
+	}{
+		{name: "SharedAddresses", config: `dhcp-range=192.168.1.100,192.168.1.200`, other: `dhcp-range=192.168.1.200,192.168.1.250`, overlaps: true},
+		{name: "Enclosed", config: `dhcp-range=192.168.1.100,192.168.1.200`, other: `dhcp-range=192.168.1.150,192.168.1.160`, overlaps: true},
+		{name: "Adjacent", config: `dhcp-range=192.168.1.100,192.168.1.200`, other: `dhcp-range=192.168.1.201,192.168.1.250`},
+		{name: "SameSubnet", config: `dhcp-range=192.168.2.0,static`, other: `dhcp-range=192.168.2.0,proxy`, overlaps: true},
+		{name: "UnknownSubnet", config: `dhcp-range=192.168.2.0,static`, other: `dhcp-range=192.168.2.128,static,255.255.255.128`},
+		{name: "StaticAndPool", config: `dhcp-range=192.168.1.0,static`, other: `dhcp-range=192.168.1.100,192.168.1.200`},
+		{name: "IPv6", config: `dhcp-range=2001:db8::100,2001:db8::1ff`, other: `dhcp-range=2001:db8::1f0,2001:db8::2ff`, overlaps: true},
+		{name: "IPv6RaOnly", config: `dhcp-range=2001:db8::,ra-only`, other: `dhcp-range=2001:db8::,ra-stateless`, overlaps: true},
+		{name: "SameInterface", config: `dhcp-range=::1,::400,constructor:eth0`, other: `dhcp-range=::100,::200,constructor:eth0`, overlaps: true},
+		{name: "OtherInterface", config: `dhcp-range=::1,::400,constructor:eth0`, other: `dhcp-range=::1,::400,constructor:eth1`},
+		{name: "OtherFamily", config: `dhcp-range=0.0.0.1,0.0.0.200`, other: `dhcp-range=::1,::200`},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			dhcpRange, other := DhcpRange{}, DhcpRange{}
+			assert.NoError(t, dhcpRange.FromConfig(test.config), "DhcpRange.FromConfig() returned an unexpected error")
+			assert.NoError(t, other.FromConfig(test.other), "DhcpRange.FromConfig() returned an unexpected error")
+			assert.Equal(t, test.overlaps, dhcpRange.Overlaps(other), "DhcpRange.Overlaps() returned an unexpected result")
+			assert.Equal(t, test.overlaps, other.Overlaps(dhcpRange), "DhcpRange.Overlaps() must be symmetric")
+		})
+	}
+}
+
+func TestDhcpRangeKey(t *testing.T) {
+	dhcpRange := DhcpRange{Start: net.ParseIP("2001:DB8:0::100"), End: net.ParseIP("2001:db8::1ff")}
+	constructed := DhcpRange{Start: net.ParseIP("::1"), End: net.ParseIP("::400"), Interface: "eth0"}
+
+	assert.Equal(t, "2001:db8::100", dhcpRange.Key().String(), "unexpected key")
+	assert.Equal(t, "::1,constructor:eth0", constructed.Key().String(), "unexpected key of a range built on an interface")
+}
+
+func TestIsDhcpRange(t *testing.T) {
+	assert.True(t, IsDhcpRange("dhcp-range=192.168.1.100,192.168.1.200"), "IsDhcpRange() did NOT recognize a range")
+	assert.False(t, IsDhcpRange("# dhcp-range=192.168.1.100,192.168.1.200"), "IsDhcpRange() recognized a comment")
+	assert.False(t, IsDhcpRange("dhcp-host=02:04:06:aa:bb:cc,1.1.1.1"), "IsDhcpRange() recognized a host")
+}
@@ -0,0 +1,11 @@
+package model
+
+// DhcpRangeTransaction is a unit of work on the DHCP ranges, applied in memory as a HostTransaction is.
+type DhcpRangeTransaction interface {
+	FindAll() (*[]DhcpRange, error)
+	FindByKey(key DhcpRangeKey) (*DhcpRange, error)
+	Save(dhcpRange *DhcpRange) error
+	// ReplaceByKey changes the range with the given key into the new one, keeping its place in the file
+	ReplaceByKey(key DhcpRangeKey, dhcpRange *DhcpRange) error
+	DeleteByKey(key DhcpRangeKey) (*DhcpRange, error)
+}
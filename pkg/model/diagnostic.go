@@ -0,0 +1,12 @@
+package model
+
+// Diagnostic is a problem found while parsing a managed file, e.g. a malformed `dhcp-host=` line
+// kept verbatim by the lenient parse mode.
+type Diagnostic struct {
+	File string
+	// Line number, starting from 1
+	Line int
+	// Raw text of the line
+	Text string
+	Err  error
+}
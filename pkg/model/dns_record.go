@@ -0,0 +1,263 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+)
+
+// DnsRecord represents a static DNS entry, written either as a dnsmasq `host-record=` line, which answers the
+// A/AAAA (and the matching PTR) queries of its names, or as an `address=` line, which answers the queries of its
+// domains and of every subdomain of them.
+type DnsRecord struct {
+	// One of DnsRecordTypeHost or DnsRecordTypeAddress
+	Type string
+	// Names answered by the record (domains, for an address record), matched regardless of the case
+	Names []string
+	// IPv4 address, a record must have an IPv4 address, an IPv6 address or, for a host record, both
+	IPAddress   net.IP
+	IPv6Address net.IP
+	// Time to live of the answers in seconds, zero for the dnsmasq default (local-ttl). Host records only
+	TTL int
+	// What an address record without any IP address answers, one of DnsRecordAnswerNxDomain or DnsRecordAnswerNull
+	Answer string
+}
+
+// Record types
+const (
+	DnsRecordTypeHost    = "host"
+	DnsRecordTypeAddress = "address"
+)
+
+// Answers of the address records without any IP address
+const (
+	// `address=/<domain>/`, the domains are local: the names unknown to dnsmasq fail with NXDOMAIN
+	DnsRecordAnswerNxDomain = "nxdomain"
+	// `address=/<domain>/#`, the names are answered with the null addresses (0.0.0.0 and ::), e.g. to block them
+	DnsRecordAnswerNull = "null"
+)
+
+const (
+	hostRecordPrefix        = "host-record="
+	addressPrefix           = "address="
+	errInvalidDnsRecordConf = "invalid DNS record config: %s"
+)
+
+var ErrDnsRecordInvalidType = errors.New("invalid DNS record: unknown record type")
+var ErrDnsRecordMissingName = errors.New("invalid DNS record: missing name")
+var ErrDnsRecordInvalidName = errors.New("invalid DNS record: names can't contain commas, slashes or spaces")
+var ErrDnsRecordMissingIPAddress = errors.New("invalid DNS record: missing IP address")
+var ErrDnsRecordInvalidIPv4Address = errors.New("invalid DNS record: IPAddress is not an IPv4 address")
+var ErrDnsRecordInvalidIPv6Address = errors.New("invalid DNS record: IPv6Address is not an IPv6 address")
+var ErrDnsRecordInvalidTTL = errors.New("invalid DNS record: negative TTL")
+var ErrDnsRecordAddressDualStack = errors.New("invalid DNS record: an address record has a single IP address")
+var ErrDnsRecordAddressTTL = errors.New("invalid DNS record: an address record has no TTL")
+var ErrDnsRecordInvalidAnswer = errors.New("invalid DNS record: only address records answer nxdomain or null")
+var ErrDnsRecordAnswerWithAddress = errors.New("invalid DNS record: a record answering nxdomain or null has no IP address")
+
+// IsDnsRecord reports whether the line is a static DNS entry, including the `address=` lines without an address
+// (local-only domains) or with `#` (blocked domains).
+func IsDnsRecord(line string) bool {
+	option, _, ok := dnsmasq.Option(line)
+	return ok && (option == "host-record" || option == "address")
+}
+
+// FromConfig parses a `host-record=<name>[,<name>...],[<ipv4>],[<ipv6>][,<ttl>]` or an
+// `address=/<domain>[/<domain>...]/[<ipaddr>|#]` line.
+func (r *DnsRecord) FromConfig(config string) error {
+	*r = DnsRecord{}
+	var err error
+	switch {
+	case strings.HasPrefix(config, hostRecordPrefix):
+		err = r.parseHostRecord(strings.TrimPrefix(config, hostRecordPrefix))
+	case strings.HasPrefix(config, addressPrefix):
+		err = r.parseAddress(strings.TrimPrefix(config, addressPrefix))
+	default:
+		return fmt.Errorf(errInvalidDnsRecordConf, config)
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.Validate() != nil {
+		return fmt.Errorf(errInvalidDnsRecordConf, config)
+	}
+
+	return nil
+}
+
+// parseHostRecord reads the names up to the first address, then the addresses and the TTL, which must be the
+// last token.
+func (r *DnsRecord) parseHostRecord(value string) error {
+	r.Type = DnsRecordTypeHost
+	tokens := strings.Split(value, ",")
+	for i, token := range tokens {
+		ip := net.ParseIP(token)
+		switch {
+		case token == "":
+			// Both addresses are optional, but their positions are kept (e.g. `name,,2001:db8::1`)
+			if len(r.Names) == 0 {
+				return fmt.Errorf(errInvalidDnsRecordConf, hostRecordPrefix+value)
+			}
+		case ip != nil && ip.To4() != nil && r.IPAddress == nil && r.IPv6Address == nil:
+			r.IPAddress = ip
+		case ip != nil && ip.To4() == nil && r.IPv6Address == nil:
+			r.IPv6Address = ip
+		case ip != nil:
+			return fmt.Errorf(errInvalidDnsRecordConf, hostRecordPrefix+value)
+		case r.IPAddress == nil && r.IPv6Address == nil:
+			r.Names = append(r.Names, token)
+		case i == len(tokens)-1:
+			ttl, err := strconv.Atoi(token)
+			if err != nil {
+				return fmt.Errorf(errInvalidDnsRecordConf, hostRecordPrefix+value)
+			}
+			r.TTL = ttl
+		default:
+			return fmt.Errorf(errInvalidDnsRecordConf, hostRecordPrefix+value)
+		}
+	}
+
+	return nil
+}
+
+func (r *DnsRecord) parseAddress(value string) error {
+	r.Type = DnsRecordTypeAddress
+	tokens := strings.Split(value, "/")
+	if len(tokens) < 3 || tokens[0] != "" {
+		return fmt.Errorf(errInvalidDnsRecordConf, addressPrefix+value)
+	}
+
+	r.Names = tokens[1 : len(tokens)-1]
+	address := tokens[len(tokens)-1]
+	switch address {
+	case "":
+		r.Answer = DnsRecordAnswerNxDomain
+		return nil
+	case "#":
+		r.Answer = DnsRecordAnswerNull
+		return nil
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return &net.AddrError{Err: "invalid IP address", Addr: address}
+	}
+	if ip.To4() != nil {
+		r.IPAddress = ip
+	} else {
+		r.IPv6Address = ip
+	}
+
+	return nil
+}
+
+// Validate ensures that the record can be written as a dnsmasq line: it must have at least one name, and an
+// address of the family of its field. An address record has a single address, or an answer instead, and no TTL,
+// since dnsmasq doesn't support them on `address=` lines.
+func (r *DnsRecord) Validate() error {
+	var err error = nil
+	if r.Type != DnsRecordTypeHost && r.Type != DnsRecordTypeAddress {
+		err = errors.Join(err, ErrDnsRecordInvalidType)
+	}
+	if len(r.Names) == 0 {
+		err = errors.Join(err, ErrDnsRecordMissingName)
+	}
+	if slices.ContainsFunc(r.Names, func(name string) bool { return name == "" || strings.ContainsAny(name, ",/ \t#") }) {
+		err = errors.Join(err, ErrDnsRecordInvalidName)
+	}
+	if r.IPAddress == nil && r.IPv6Address == nil && r.Answer == "" {
+		err = errors.Join(err, ErrDnsRecordMissingIPAddress)
+	}
+	if r.IPAddress != nil && r.IPAddress.To4() == nil {
+		err = errors.Join(err, ErrDnsRecordInvalidIPv4Address)
+	}
+	if r.IPv6Address != nil && r.IPv6Address.To4() != nil {
+		err = errors.Join(err, ErrDnsRecordInvalidIPv6Address)
+	}
+	if r.TTL < 0 {
+		err = errors.Join(err, ErrDnsRecordInvalidTTL)
+	}
+	if r.Type == DnsRecordTypeAddress && r.IPAddress != nil && r.IPv6Address != nil {
+		err = errors.Join(err, ErrDnsRecordAddressDualStack)
+	}
+	if r.Type == DnsRecordTypeAddress && r.TTL != 0 {
+		err = errors.Join(err, ErrDnsRecordAddressTTL)
+	}
+	if r.Answer != "" && (r.Type != DnsRecordTypeAddress || (r.Answer != DnsRecordAnswerNxDomain && r.Answer != DnsRecordAnswerNull)) {
+		err = errors.Join(err, ErrDnsRecordInvalidAnswer)
+	}
+	if r.Answer != "" && (r.IPAddress != nil || r.IPv6Address != nil) {
+		err = errors.Join(err, ErrDnsRecordAnswerWithAddress)
+	}
+	return err
+}
+
+// ToConfig renders the record as a `host-record=` or an `address=` line, depending on its type.
+func (r *DnsRecord) ToConfig() (string, error) {
+	err := r.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	if r.Type == DnsRecordTypeAddress {
+		return addressPrefix + "/" + strings.Join(r.Names, "/") + "/" + r.answer(), nil
+	}
+
+	tokens := slices.Clone(r.Names)
+	if r.IPAddress != nil {
+		tokens = append(tokens, r.IPAddress.String())
+	} else if r.TTL != 0 {
+		// Keeps the position of the IPv4 address, so the TTL is not taken for it
+		tokens = append(tokens, "")
+	}
+	if r.IPv6Address != nil {
+		tokens = append(tokens, r.IPv6Address.String())
+	}
+	if r.TTL != 0 {
+		tokens = append(tokens, strconv.Itoa(r.TTL))
+	}
+
+	return hostRecordPrefix + strings.Join(tokens, ","), nil
+}
+
+// answer returns the value of an `address=` line after its domains.
+func (r *DnsRecord) answer() string {
+	switch r.Answer {
+	case DnsRecordAnswerNxDomain:
+		return ""
+	case DnsRecordAnswerNull:
+		return "#"
+	default:
+		return r.IPAddresses()[0].String()
+	}
+}
+
+// IPAddresses returns the addresses answered by the record, the IPv4 one first.
+func (r *DnsRecord) IPAddresses() []net.IP {
+	addresses := []net.IP{}
+	if r.IPAddress != nil {
+		addresses = append(addresses, r.IPAddress)
+	}
+	if r.IPv6Address != nil {
+		addresses = append(addresses, r.IPv6Address)
+	}
+
+	return addresses
+}
+
+// HasName reports whether the record answers the given name, regardless of the case.
+func (r *DnsRecord) HasName(name string) bool {
+	return slices.ContainsFunc(r.Names, func(other string) bool { return strings.EqualFold(other, name) })
+}
+
+// Equal reports whether both records result in the same dnsmasq entry.
+func (r *DnsRecord) Equal(other DnsRecord) bool {
+	return r.Type == other.Type && slices.Equal(r.Names, other.Names) && r.IPAddress.Equal(other.IPAddress) &&
+		r.IPv6Address.Equal(other.IPv6Address) && r.TTL == other.TTL && r.Answer == other.Answer
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDnsRecordFromConfig(t *testing.T) {
+	testCases := []struct {
+		name           string
+		config         string
+		expectError    bool
+		expectedRecord DnsRecord
+	}{
+		{
+			name:           "HostRecord",
+			config:         `host-record=nas.lan,nas,192.168.1.10`,
+			expectedRecord: DnsRecord{Type: DnsRecordTypeHost, Names: []string{"nas.lan", "nas"}, IPAddress: net.ParseIP("192.168.1.10")},
+		},
+		{
+			name:   "DualStackHostRecord",
+			config: `host-record=nas.lan,192.168.1.10,2001:db8::10,300`,
+			expectedRecord: DnsRecord{Type: DnsRecordTypeHost, Names: []string{"nas.lan"}, IPAddress: net.ParseIP("192.168.1.10"),
+				IPv6Address: net.ParseIP("2001:db8::10"), TTL: 300},
+		},
+		{
+			name:           "IPv6OnlyHostRecord",
+			config:         `host-record=nas.lan,,2001:db8::10,60`,
+			expectedRecord: DnsRecord{Type: DnsRecordTypeHost, Names: []string{"nas.lan"}, IPv6Address: net.ParseIP("2001:db8::10"), TTL: 60},
+		},
+		{
+			name:           "Address",
+			config:         `address=/printer.lan/192.168.1.20`,
+			expectedRecord: DnsRecord{Type: DnsRecordTypeAddress, Names: []string{"printer.lan"}, IPAddress: net.ParseIP("192.168.1.20")},
+		},
+		{
+			name:           "IPv6AddressOfManyDomains",
+			config:         `address=/printer.lan/scanner.lan/2001:db8::20`,
+			expectedRecord: DnsRecord{Type: DnsRecordTypeAddress, Names: []string{"printer.lan", "scanner.lan"}, IPv6Address: net.ParseIP("2001:db8::20")},
+		},
+		{
+			name:           "LocalDomain",
+			config:         `address=/corp.example/`,
+			expectedRecord: DnsRecord{Type: DnsRecordTypeAddress, Names: []string{"corp.example"}, Answer: DnsRecordAnswerNxDomain},
+		},
+		{
+			name:           "BlockedDomains",
+			config:         `address=/ads.example/tracker.example/#`,
+			expectedRecord: DnsRecord{Type: DnsRecordTypeAddress, Names: []string{"ads.example", "tracker.example"}, Answer: DnsRecordAnswerNull},
+		},
+		{name: "NotARecord", config: `dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo`, expectError: true},
+		{name: "MissingName", config: `host-record=192.168.1.10`, expectError: true},
+		{name: "MissingAddress", config: `host-record=nas.lan`, expectError: true},
+		{name: "InvalidAddress", config: `host-record=nas.lan,192.168.1.10,nas`, expectError: true},
+		{name: "TwoIPv4Addresses", config: `host-record=nas.lan,192.168.1.10,192.168.1.11`, expectError: true},
+		{name: "InvalidTTL", config: `host-record=nas.lan,192.168.1.10,5m`, expectError: true},
+		{name: "AddressWithoutDomain", config: `address=//192.168.1.20`, expectError: true},
+		{name: "InvalidAddressIP", config: `address=/printer.lan/192.168.1`, expectError: true},
+		{name: "BlockedWithoutDomain", config: `address=/#`, expectError: true},
+		{name: "MissingLeadingSlash", config: `address=printer.lan/192.168.1.20`, expectError: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			record := DnsRecord{}
+			err := record.FromConfig(test.config)
+			if test.expectError {
+				assert.Error(t, err, "DnsRecord.FromConfig() did NOT returned an error")
+				return
+			}
+			assert.NoError(t, err, "DnsRecord.FromConfig() returned an unexpected error")
+			assert.Equal(t, test.expectedRecord, record, "DnsRecord.FromConfig() has generated an unexpected record")
+
+			config, err := record.ToConfig()
+			assert.NoError(t, err, "DnsRecord.ToConfig() returned an unexpected error")
+			assert.Equal(t, test.config, config, "DnsRecord did not survive the round trip")
+		})
+	}
+}
+
+func TestDnsRecordToConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		record        DnsRecord
+		expectedError error
+	}{
+		{name: "UnknownType", record: DnsRecord{Type: "cname", Names: []string{"nas"}, IPAddress: net.ParseIP("1.1.1.1")}, expectedError: ErrDnsRecordInvalidType},
+		{name: "MissingName", record: DnsRecord{Type: DnsRecordTypeHost, IPAddress: net.ParseIP("1.1.1.1")}, expectedError: ErrDnsRecordMissingName},
+		{name: "InvalidName", record: DnsRecord{Type: DnsRecordTypeHost, Names: []string{"nas,lan"}, IPAddress: net.ParseIP("1.1.1.1")}, expectedError: ErrDnsRecordInvalidName},
+		{name: "MissingAddress", record: DnsRecord{Type: DnsRecordTypeHost, Names: []string{"nas"}}, expectedError: ErrDnsRecordMissingIPAddress},
+		{name: "IPv6AsIPv4", record: DnsRecord{Type: DnsRecordTypeHost, Names: []string{"nas"}, IPAddress: net.ParseIP("2001:db8::1")}, expectedError: ErrDnsRecordInvalidIPv4Address},
+		{name: "IPv4AsIPv6", record: DnsRecord{Type: DnsRecordTypeHost, Names: []string{"nas"}, IPv6Address: net.ParseIP("1.1.1.1")}, expectedError: ErrDnsRecordInvalidIPv6Address},
+		{name: "NegativeTTL", record: DnsRecord{Type: DnsRecordTypeHost, Names: []string{"nas"}, IPAddress: net.ParseIP("1.1.1.1"), TTL: -1}, expectedError: ErrDnsRecordInvalidTTL},
+		{
+			name:          "DualStackAddress",
+			record:        DnsRecord{Type: DnsRecordTypeAddress, Names: []string{"nas"}, IPAddress: net.ParseIP("1.1.1.1"), IPv6Address: net.ParseIP("2001:db8::1")},
+			expectedError: ErrDnsRecordAddressDualStack,
+		},
+		{name: "AddressTTL", record: DnsRecord{Type: DnsRecordTypeAddress, Names: []string{"nas"}, IPAddress: net.ParseIP("1.1.1.1"), TTL: 60}, expectedError: ErrDnsRecordAddressTTL},
+		{name: "HostAnswer", record: DnsRecord{Type: DnsRecordTypeHost, Names: []string{"nas"}, Answer: DnsRecordAnswerNull}, expectedError: ErrDnsRecordInvalidAnswer},
+		{name: "UnknownAnswer", record: DnsRecord{Type: DnsRecordTypeAddress, Names: []string{"nas"}, Answer: "refused"}, expectedError: ErrDnsRecordInvalidAnswer},
+		{
+			name:          "AnswerWithAddress",
+			record:        DnsRecord{Type: DnsRecordTypeAddress, Names: []string{"nas"}, IPAddress: net.ParseIP("1.1.1.1"), Answer: DnsRecordAnswerNxDomain},
+			expectedError: ErrDnsRecordAnswerWithAddress,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := test.record.ToConfig()
+			assert.ErrorIs(t, err, test.expectedError, "DnsRecord.ToConfig() returned an unexpected error")
+			assert.Empty(t, config, "DnsRecord.ToConfig() returned an unexpected config")
+		})
+	}
+}
+
+func TestIsDnsRecord(t *testing.T) {
+	assert.True(t, IsDnsRecord("host-record=nas.lan,192.168.1.10"), "IsDnsRecord() did NOT recognize a host record")
+	assert.True(t, IsDnsRecord(" address=/printer.lan/192.168.1.20"), "IsDnsRecord() did NOT recognize an address")
+	assert.True(t, IsDnsRecord("address=/ads.example/#"), "IsDnsRecord() did NOT recognize a blocked domain")
+	assert.True(t, IsDnsRecord("address=/corp.example/"), "IsDnsRecord() did NOT recognize a local-only domain")
+	assert.False(t, IsDnsRecord("# host-record=nas.lan,192.168.1.10"), "IsDnsRecord() recognized a comment")
+	assert.False(t, IsDnsRecord("dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo"), "IsDnsRecord() recognized a DHCP host")
+}
+
+func TestDnsRecordHasName(t *testing.T) {
+	record := DnsRecord{Type: DnsRecordTypeHost, Names: []string{"nas.lan", "nas"}, IPAddress: net.ParseIP("1.1.1.1")}
+	assert.True(t, record.HasName("NAS"), "DnsRecord.HasName() did NOT match an alternative name")
+	assert.True(t, record.HasName("nas.lan"), "DnsRecord.HasName() did NOT match the first name")
+	assert.False(t, record.HasName("nas.lan.example"), "DnsRecord.HasName() matched an unknown name")
+}
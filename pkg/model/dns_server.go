@@ -0,0 +1,239 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+)
+
+// DnsServer represents a dnsmasq `server=` line: an upstream resolver used for every query, or a server the
+// queries of some domains are forwarded to (conditional forwarding).
+type DnsServer struct {
+	// Domains forwarded to the server, along with their subdomains, matched regardless of the case. None for an
+	// upstream resolver
+	Domains []string
+	// IPv4 or IPv6 address of the server. DnsServerAddressDefault forwards the domains to the upstream resolvers
+	// instead, and an empty address keeps them local: they are only answered from /etc/hosts, DHCP and the static
+	// records, and never forwarded
+	Address string
+	// Port of the server, zero for the DNS port (53)
+	Port int
+	// Interface or source address the queries are sent from (e.g. eth0 or 192.168.1.1#1053), empty for any
+	Source string
+}
+
+// Domain scopes of a server, see DnsServer.Scope
+const (
+	DnsServerScopeUpstream = "upstream"
+	DnsServerScopeDomain   = "domain"
+	DnsServerScopeReverse  = "reverse"
+)
+
+const (
+	// Address forwarding the domains to the upstream resolvers, overriding a server of their parent domain
+	DnsServerAddressDefault = "#"
+	serverOption            = "server"
+	maxServerPort           = 65535
+	errInvalidDnsServerConf = "invalid DNS server config: %s"
+)
+
+var ErrDnsServerInvalidDomain = errors.New("invalid DNS server: domains can't be empty, nor contain commas, slashes, hashes or spaces")
+var ErrDnsServerInvalidReverseZone = errors.New("invalid DNS server: reverse zones must be made of the labels of an IP prefix (e.g. 1.168.192.in-addr.arpa)")
+var ErrDnsServerMissingAddress = errors.New("invalid DNS server: upstream servers must have an address")
+var ErrDnsServerInvalidAddress = errors.New("invalid DNS server: address is not an IP address, # or empty")
+var ErrDnsServerInvalidPort = errors.New("invalid DNS server: port out of range (1-65535)")
+var ErrDnsServerUnexpectedPort = errors.New("invalid DNS server: only servers with an IP address have a port or a source")
+var ErrDnsServerInvalidSource = errors.New("invalid DNS server: sources can't contain commas, slashes or spaces")
+
+// IsDnsServer reports whether the line is a `server=` entry.
+func IsDnsServer(line string) bool {
+	return dnsmasq.IsOption(line, serverOption)
+}
+
+// FromConfig parses a `server=[/<domain>[/<domain>...]/][<ipaddr>[#<port>][@<source>]]` line, where the address
+// may also be `#`, or empty when there are domains.
+func (s *DnsServer) FromConfig(config string) error {
+	*s = DnsServer{}
+	option, value, ok := dnsmasq.Option(config)
+	if !ok || option != serverOption {
+		return fmt.Errorf(errInvalidDnsServerConf, config)
+	}
+
+	if strings.HasPrefix(value, "/") {
+		tokens := strings.Split(value, "/")
+		if len(tokens) < 3 {
+			return fmt.Errorf(errInvalidDnsServerConf, config)
+		}
+		s.Domains = tokens[1 : len(tokens)-1]
+		value = tokens[len(tokens)-1]
+	}
+
+	if value != DnsServerAddressDefault {
+		value, s.Source, _ = strings.Cut(value, "@")
+		address, port, hasPort := strings.Cut(value, "#")
+		value = address
+		if hasPort {
+			var err error
+			s.Port, err = strconv.Atoi(port)
+			if err != nil {
+				return fmt.Errorf(errInvalidDnsServerConf, config)
+			}
+		}
+	}
+	s.Address = value
+
+	if s.Validate() != nil {
+		return fmt.Errorf(errInvalidDnsServerConf, config)
+	}
+
+	return nil
+}
+
+// Validate ensures that the server can be written as a dnsmasq line. An upstream resolver must have an IP
+// address, the reverse zones must match an IP prefix, and only the servers with an IP address have a port or a
+// source.
+func (s *DnsServer) Validate() error {
+	var err error = nil
+	for _, domain := range s.Domains {
+		if domain == "" || strings.ContainsAny(domain, ",/# \t\"\\@") {
+			err = errors.Join(err, ErrDnsServerInvalidDomain)
+			break
+		}
+	}
+	if slices.ContainsFunc(s.Domains, func(domain string) bool { return isReverseZone(domain) && !isIPPrefixZone(domain) }) {
+		err = errors.Join(err, ErrDnsServerInvalidReverseZone)
+	}
+
+	isIP := net.ParseIP(s.Address) != nil
+	switch {
+	case (s.Address == "" || s.Address == DnsServerAddressDefault) && len(s.Domains) == 0:
+		err = errors.Join(err, ErrDnsServerMissingAddress)
+	case s.Address != "" && s.Address != DnsServerAddressDefault && !isIP:
+		err = errors.Join(err, ErrDnsServerInvalidAddress)
+	}
+	if s.Port < 0 || s.Port > maxServerPort {
+		err = errors.Join(err, ErrDnsServerInvalidPort)
+	}
+	if !isIP && (s.Port != 0 || s.Source != "") {
+		err = errors.Join(err, ErrDnsServerUnexpectedPort)
+	}
+	if strings.ContainsAny(s.Source, ",/ \t\"\\@") {
+		err = errors.Join(err, ErrDnsServerInvalidSource)
+	}
+	return err
+}
+
+// ToConfig renders the server as a `server=` line.
+func (s *DnsServer) ToConfig() (string, error) {
+	err := s.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	config := serverOption + "="
+	if len(s.Domains) > 0 {
+		config += "/" + strings.Join(s.Domains, "/") + "/"
+	}
+	config += s.Address
+	if s.Port != 0 {
+		config += "#" + strconv.Itoa(s.Port)
+	}
+	if s.Source != "" {
+		config += "@" + s.Source
+	}
+
+	return config, nil
+}
+
+// Scope returns the domain scope of the server: DnsServerScopeUpstream for an upstream resolver,
+// DnsServerScopeReverse when it only has reverse zones (in-addr.arpa or ip6.arpa), and DnsServerScopeDomain
+// otherwise.
+func (s *DnsServer) Scope() string {
+	switch {
+	case len(s.Domains) == 0:
+		return DnsServerScopeUpstream
+	case !slices.ContainsFunc(s.Domains, func(domain string) bool { return !isReverseZone(domain) }):
+		return DnsServerScopeReverse
+	default:
+		return DnsServerScopeDomain
+	}
+}
+
+// IsLocal reports whether the domains of the server are kept local instead of being forwarded.
+func (s *DnsServer) IsLocal() bool {
+	return len(s.Domains) > 0 && s.Address == ""
+}
+
+// HasDomain reports whether the server is used for the given domain, regardless of the case.
+func (s *DnsServer) HasDomain(domain string) bool {
+	return slices.ContainsFunc(s.Domains, func(other string) bool { return strings.EqualFold(other, domain) })
+}
+
+// HasAddress reports whether the server has the given address, comparing the IP addresses by their value (e.g.
+// 2001:db8::1 and 2001:DB8:0::1 are the same address).
+func (s *DnsServer) HasAddress(address string) bool {
+	ip := net.ParseIP(address)
+	if ip != nil {
+		return ip.Equal(net.ParseIP(s.Address))
+	}
+	return s.Address == address
+}
+
+// WithoutDomain returns a copy of the server which no longer forwards the given domain.
+func (s *DnsServer) WithoutDomain(domain string) DnsServer {
+	server := *s
+	server.Domains = slices.DeleteFunc(slices.Clone(s.Domains), func(other string) bool {
+		return strings.EqualFold(other, domain)
+	})
+	return server
+}
+
+// Equal reports whether both servers result in the same dnsmasq entry, regardless of the case and the order of
+// their domains.
+func (s *DnsServer) Equal(other DnsServer) bool {
+	return slices.Equal(normalizeDomains(s.Domains), normalizeDomains(other.Domains)) && s.HasAddress(other.Address) &&
+		s.Port == other.Port && s.Source == other.Source
+}
+
+func normalizeDomains(domains []string) []string {
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		normalized = append(normalized, strings.ToLower(domain))
+	}
+	slices.Sort(normalized)
+	return normalized
+}
+
+func isReverseZone(domain string) bool {
+	domain = strings.ToLower(domain)
+	return strings.HasSuffix(domain, ".in-addr.arpa") || strings.HasSuffix(domain, ".ip6.arpa")
+}
+
+// isIPPrefixZone reports whether the reverse zone is made of the labels of an IP prefix: up to 4 numbers from 0
+// to 255 for an in-addr.arpa zone, and up to 32 hexadecimal nibbles for an ip6.arpa one.
+func isIPPrefixZone(domain string) bool {
+	domain = strings.ToLower(domain)
+	var labels []string
+	var maxLabels int
+	var isLabel func(label string) bool
+	if zone, ok := strings.CutSuffix(domain, ".in-addr.arpa"); ok {
+		labels, maxLabels = strings.Split(zone, "."), 4
+		isLabel = func(label string) bool {
+			number, err := strconv.Atoi(label)
+			return err == nil && number >= 0 && number <= 255 && strconv.Itoa(number) == label
+		}
+	} else {
+		zone, _ := strings.CutSuffix(domain, ".ip6.arpa")
+		labels, maxLabels = strings.Split(zone, "."), 32
+		isLabel = func(label string) bool {
+			return len(label) == 1 && strings.Contains("0123456789abcdef", label)
+		}
+	}
+
+	return len(labels) <= maxLabels && !slices.ContainsFunc(labels, func(label string) bool { return !isLabel(label) })
+}
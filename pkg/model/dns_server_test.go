@@ -0,0 +1,146 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDnsServerFromConfig(t *testing.T) {
+	testCases := []struct {
+		name           string
+		config         string
+		expectError    bool
+		expectedServer DnsServer
+		expectedScope  string
+	}{
+		{
+			name:           "Upstream",
+			config:         `server=1.1.1.1`,
+			expectedServer: DnsServer{Address: "1.1.1.1"},
+			expectedScope:  DnsServerScopeUpstream,
+		},
+		{
+			name:           "UpstreamWithPortAndSource",
+			config:         `server=2001:db8::53#5353@eth0`,
+			expectedServer: DnsServer{Address: "2001:db8::53", Port: 5353, Source: "eth0"},
+			expectedScope:  DnsServerScopeUpstream,
+		},
+		{
+			name:           "UpstreamWithSourceAddressAndPort",
+			config:         `server=10.0.0.53@192.168.1.1#1053`,
+			expectedServer: DnsServer{Address: "10.0.0.53", Source: "192.168.1.1#1053"},
+			expectedScope:  DnsServerScopeUpstream,
+		},
+		{
+			name:           "Domain",
+			config:         `server=/corp.example/10.0.0.53`,
+			expectedServer: DnsServer{Domains: []string{"corp.example"}, Address: "10.0.0.53"},
+			expectedScope:  DnsServerScopeDomain,
+		},
+		{
+			name:           "Domains",
+			config:         `server=/corp.example/3.168.192.in-addr.arpa/10.0.0.53#5353`,
+			expectedServer: DnsServer{Domains: []string{"corp.example", "3.168.192.in-addr.arpa"}, Address: "10.0.0.53", Port: 5353},
+			expectedScope:  DnsServerScopeDomain,
+		},
+		{
+			name:           "ReverseZone",
+			config:         `server=/3.168.192.in-addr.arpa/10.0.0.53`,
+			expectedServer: DnsServer{Domains: []string{"3.168.192.in-addr.arpa"}, Address: "10.0.0.53"},
+			expectedScope:  DnsServerScopeReverse,
+		},
+		{
+			name:           "IPv6ReverseZone",
+			config:         `server=/8.b.d.0.1.0.0.2.ip6.arpa/2001:db8::53`,
+			expectedServer: DnsServer{Domains: []string{"8.b.d.0.1.0.0.2.ip6.arpa"}, Address: "2001:db8::53"},
+			expectedScope:  DnsServerScopeReverse,
+		},
+		{
+			name:           "LocalOnly",
+			config:         `server=/lan/`,
+			expectedServer: DnsServer{Domains: []string{"lan"}},
+			expectedScope:  DnsServerScopeDomain,
+		},
+		{
+			name:           "DefaultServers",
+			config:         `server=/www.corp.example/#`,
+			expectedServer: DnsServer{Domains: []string{"www.corp.example"}, Address: "#"},
+			expectedScope:  DnsServerScopeDomain,
+		},
+		{name: "NotAServer", config: `address=/corp.example/10.0.0.53`, expectError: true},
+		{name: "UpstreamWithoutAddress", config: `server=`, expectError: true},
+		{name: "UpstreamDefaultServers", config: `server=#`, expectError: true},
+		{name: "InvalidAddress", config: `server=/corp.example/dns.corp.example`, expectError: true},
+		{name: "InvalidPort", config: `server=10.0.0.53#dns`, expectError: true},
+		{name: "PortOutOfRange", config: `server=10.0.0.53#65536`, expectError: true},
+		{name: "LocalOnlyWithPort", config: `server=/lan/#53`, expectError: true},
+		{name: "EmptyDomain", config: `server=//10.0.0.53`, expectError: true},
+		{name: "InvalidReverseZone", config: `server=/300.168.192.in-addr.arpa/10.0.0.53`, expectError: true},
+		{name: "InvalidIPv6ReverseZone", config: `server=/db8.ip6.arpa/2001:db8::53`, expectError: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			server := DnsServer{}
+			err := server.FromConfig(test.config)
+			if test.expectError {
+				assert.Error(t, err, "DnsServer.FromConfig() did NOT returned an error")
+				return
+			}
+			assert.NoError(t, err, "DnsServer.FromConfig() returned an unexpected error")
+			assert.Equal(t, test.expectedServer, server, "DnsServer.FromConfig() has generated an unexpected server")
+			assert.Equal(t, test.expectedScope, server.Scope(), "DnsServer.Scope() returned an unexpected scope")
+
+			config, err := server.ToConfig()
+			assert.NoError(t, err, "DnsServer.ToConfig() returned an unexpected error")
+			assert.Equal(t, test.config, config, "DnsServer did not survive the round trip")
+		})
+	}
+}
+
+func TestDnsServerToConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		server        DnsServer
+		expectedError error
+	}{
+		{name: "InvalidDomain", server: DnsServer{Domains: []string{"corp example"}, Address: "10.0.0.53"}, expectedError: ErrDnsServerInvalidDomain},
+		{name: "InvalidReverseZone", server: DnsServer{Domains: []string{"1.2.3.4.5.in-addr.arpa"}, Address: "10.0.0.53"}, expectedError: ErrDnsServerInvalidReverseZone},
+		{name: "MissingAddress", server: DnsServer{}, expectedError: ErrDnsServerMissingAddress},
+		{name: "InvalidAddress", server: DnsServer{Address: "dns.example"}, expectedError: ErrDnsServerInvalidAddress},
+		{name: "NegativePort", server: DnsServer{Address: "10.0.0.53", Port: -1}, expectedError: ErrDnsServerInvalidPort},
+		{name: "DefaultServersWithPort", server: DnsServer{Domains: []string{"corp.example"}, Address: "#", Port: 53}, expectedError: ErrDnsServerUnexpectedPort},
+		{name: "LocalOnlyWithSource", server: DnsServer{Domains: []string{"lan"}, Source: "eth0"}, expectedError: ErrDnsServerUnexpectedPort},
+		{name: "InvalidSource", server: DnsServer{Address: "10.0.0.53", Source: "eth0,eth1"}, expectedError: ErrDnsServerInvalidSource},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := test.server.ToConfig()
+			assert.ErrorIs(t, err, test.expectedError, "DnsServer.ToConfig() returned an unexpected error")
+			assert.Empty(t, config, "DnsServer.ToConfig() returned an unexpected config")
+		})
+	}
+}
+
+func TestDnsServerEqual(t *testing.T) {
+	server := DnsServer{Domains: []string{"corp.example", "3.168.192.in-addr.arpa"}, Address: "2001:db8::53"}
+
+	assert.True(t, server.Equal(DnsServer{Domains: []string{"3.168.192.in-addr.arpa", "Corp.Example"}, Address: "2001:DB8:0::53"}), "the domain order and case, and the address notation must be ignored")
+	assert.False(t, server.Equal(DnsServer{Domains: []string{"corp.example"}, Address: "2001:db8::53"}), "servers with other domains must differ")
+	assert.False(t, server.Equal(DnsServer{Domains: server.Domains, Address: "2001:db8::53", Port: 5353}), "servers with other ports must differ")
+}
+
+func TestDnsServerWithoutDomain(t *testing.T) {
+	server := DnsServer{Domains: []string{"corp.example", "3.168.192.in-addr.arpa"}, Address: "10.0.0.53"}
+
+	assert.Equal(t, DnsServer{Domains: []string{"3.168.192.in-addr.arpa"}, Address: "10.0.0.53"}, server.WithoutDomain("CORP.example"), "unexpected server left")
+	assert.Equal(t, []string{"corp.example", "3.168.192.in-addr.arpa"}, server.Domains, "the original server must not change")
+}
+
+func TestIsDnsServer(t *testing.T) {
+	assert.True(t, IsDnsServer("server=/corp.example/10.0.0.53"), "IsDnsServer() did NOT recognize a server")
+	assert.False(t, IsDnsServer("# server=1.1.1.1"), "IsDnsServer() recognized a comment")
+	assert.False(t, IsDnsServer("rev-server=192.168.3.0/24,10.0.0.53"), "IsDnsServer() recognized a rev-server")
+}
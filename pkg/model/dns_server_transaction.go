@@ -0,0 +1,11 @@
+package model
+
+// DnsServerTransaction is a unit of work on the DNS servers, applied in memory as a HostTransaction is.
+type DnsServerTransaction interface {
+	FindAll() (*[]DnsServer, error)
+	Save(server *DnsServer) error
+	// Replace changes the first entry equal to the given server into the replacement, keeping its place in the file
+	Replace(server *DnsServer, replacement *DnsServer) error
+	// Delete removes the first entry equal to the given server, see DnsServer.Equal
+	Delete(server *DnsServer) error
+}
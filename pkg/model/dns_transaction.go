@@ -0,0 +1,12 @@
+package model
+
+// DnsTransaction is a unit of work on the static DNS records, applied in memory as a HostTransaction is.
+type DnsTransaction interface {
+	FindAll() (*[]DnsRecord, error)
+	// FindByName returns the record answering the given name, regardless of the case
+	FindByName(name string) (*DnsRecord, error)
+	Save(record *DnsRecord) error
+	// ReplaceByName changes the record answering the given name into the new one, keeping its place in the file
+	ReplaceByName(name string, record *DnsRecord) error
+	DeleteByName(name string) (*DnsRecord, error)
+}
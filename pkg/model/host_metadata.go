@@ -0,0 +1,73 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// HostMetadata is the information kept about a static host that is not part of its dnsmasq configuration.
+//
+// It is stored as a structured comment, which dnsmasq ignores, right above the `dhcp-host=` line of the host:
+//
+//	# dmm: {"description":"Living room NAS","owner":"alice","labels":["storage"]}
+//	dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,nas
+type HostMetadata struct {
+	Description string   `json:"description,omitempty"`
+	Owner       string   `json:"owner,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	// Set by the manager, zero for hosts written by hand or before the metadata was introduced
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+	UpdatedAt time.Time `json:"updatedAt,omitzero"`
+	// Name of the user that created the host, taken from the JWT `name` claim
+	CreatedBy string `json:"createdBy,omitempty"`
+}
+
+const (
+	metadataCommentPrefix         = "# dmm:"
+	errInvalidHostMetadataComment = "invalid host metadata comment: %s"
+)
+
+// IsMetadataComment reports whether the line is a host metadata comment.
+func IsMetadataComment(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), metadataCommentPrefix)
+}
+
+// FromComment parses a host metadata comment.
+func (m *HostMetadata) FromComment(comment string) error {
+	comment = strings.TrimSpace(comment)
+	if !strings.HasPrefix(comment, metadataCommentPrefix) {
+		return fmt.Errorf(errInvalidHostMetadataComment, comment)
+	}
+
+	*m = HostMetadata{}
+	err := json.Unmarshal([]byte(strings.TrimPrefix(comment, metadataCommentPrefix)), m)
+	if err != nil {
+		return fmt.Errorf(errInvalidHostMetadataComment+": %w", comment, err)
+	}
+
+	return nil
+}
+
+// ToComment renders the metadata as a single comment line, the JSON encoding escapes any line break.
+func (m *HostMetadata) ToComment() (string, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+
+	return metadataCommentPrefix + " " + string(data), nil
+}
+
+// IsZero reports whether there is no metadata at all, so no comment needs to be written.
+func (m *HostMetadata) IsZero() bool {
+	return m.Description == "" && m.Owner == "" && len(m.Labels) == 0 && m.CreatedAt.IsZero() &&
+		m.UpdatedAt.IsZero() && m.CreatedBy == ""
+}
+
+// HasLabel reports whether the given label is one of the host labels.
+func (m *HostMetadata) HasLabel(label string) bool {
+	return slices.Contains(m.Labels, label)
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	FullMetadataComment    = `# dmm: {"description":"Living room NAS","owner":"alice","labels":["storage","lan"],"createdAt":"2024-05-01T10:00:00Z","updatedAt":"2024-05-02T10:00:00Z","createdBy":"bob"}`
+	InvalidMetadataComment = `# dmm: {"description":`
+)
+
+var FullMetadata = HostMetadata{
+	Description: "Living room NAS",
+	Owner:       "alice",
+	Labels:      []string{"storage", "lan"},
+	CreatedAt:   time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC),
+	UpdatedAt:   time.Date(2024, time.May, 2, 10, 0, 0, 0, time.UTC),
+	CreatedBy:   "bob",
+}
+
+func TestHostMetadataFromComment(t *testing.T) {
+	testCases := []struct {
+		name             string
+		comment          string
+		expectError      bool
+		expectedMetadata HostMetadata
+	}{
+		{name: "Success", comment: FullMetadataComment, expectedMetadata: FullMetadata},
+		{name: "Partial", comment: `# dmm: {"owner":"alice"}`, expectedMetadata: HostMetadata{Owner: "alice"}},
+		{name: "Indented", comment: `  # dmm: {"owner":"alice"}  `, expectedMetadata: HostMetadata{Owner: "alice"}},
+		{name: "UnknownFields", comment: `# dmm: {"owner":"alice","color":"red"}`, expectedMetadata: HostMetadata{Owner: "alice"}},
+		{name: "InvalidJSON", comment: InvalidMetadataComment, expectError: true},
+		{name: "NotAMetadataComment", comment: `# Static DHCP leases`, expectError: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			metadata := HostMetadata{Owner: "previous"}
+			err := metadata.FromComment(test.comment)
+			if test.expectError {
+				assert.Error(t, err, "HostMetadata.FromComment() did NOT returned an error")
+				return
+			}
+			assert.NoError(t, err, "HostMetadata.FromComment() returned an unexpected error")
+			assert.Equal(t, test.expectedMetadata, metadata, "HostMetadata.FromComment() has generated unexpected metadata")
+		})
+	}
+}
+
+func TestHostMetadataToComment(t *testing.T) {
+	comment, err := FullMetadata.ToComment()
+	assert.NoError(t, err, "HostMetadata.ToComment() returned an unexpected error")
+	assert.Equal(t, FullMetadataComment, comment, "HostMetadata.ToComment() returned an unexpected comment")
+
+	// Line breaks must never leak into the config file
+	metadata := HostMetadata{Description: "first line\nsecond line"}
+	comment, err = metadata.ToComment()
+	assert.NoError(t, err, "HostMetadata.ToComment() returned an unexpected error")
+	assert.Equal(t, `# dmm: {"description":"first line\nsecond line"}`, comment, "HostMetadata.ToComment() returned an unexpected comment")
+
+	parsed := HostMetadata{}
+	assert.NoError(t, parsed.FromComment(comment), "HostMetadata.FromComment() returned an unexpected error")
+	assert.Equal(t, metadata, parsed, "HostMetadata did not survive the round trip")
+}
+
+func TestIsMetadataComment(t *testing.T) {
+	assert.True(t, IsMetadataComment(FullMetadataComment))
+	assert.True(t, IsMetadataComment(InvalidMetadataComment))
+	assert.False(t, IsMetadataComment(`# Static DHCP leases`))
+	assert.False(t, IsMetadataComment(`dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo`))
+	assert.False(t, IsMetadataComment(``))
+}
+
+func TestHostMetadataIsZero(t *testing.T) {
+	assert.True(t, (&HostMetadata{}).IsZero())
+	assert.False(t, FullMetadata.IsZero())
+	assert.False(t, (&HostMetadata{Labels: []string{"lan"}}).IsZero())
+	assert.False(t, (&HostMetadata{UpdatedAt: time.Now()}).IsZero())
+}
+
+func TestHostMetadataHasLabel(t *testing.T) {
+	assert.True(t, FullMetadata.HasLabel("lan"))
+	assert.False(t, FullMetadata.HasLabel("Lan"))
+	assert.False(t, (&HostMetadata{}).HasLabel("lan"))
+}
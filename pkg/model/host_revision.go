@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// HostRevision is the state of the static hosts after a committed change, as kept by the storage backends with
+// a history.
+type HostRevision struct {
+	// Increasing number of the revision, a change that was rolled back leaves a gap
+	Number      uint64
+	CommittedAt time.Time
+	Hosts       []StaticDhcpHost
+}
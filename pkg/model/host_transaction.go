@@ -0,0 +1,20 @@
+package model
+
+import "net"
+
+// HostTransaction is a unit of work on the static hosts. Its changes are applied in memory, so each call sees
+// the changes made by the previous ones, and they are only written once the whole unit succeeded.
+type HostTransaction interface {
+	Find(host *StaticDhcpHost) (*StaticDhcpHost, error)
+	FindAll() (*[]StaticDhcpHost, error)
+	FindByMac(macAddress net.HardwareAddr) (*StaticDhcpHost, error)
+	FindByIP(ipAddress net.IP) (*StaticDhcpHost, error)
+	FindByHostName(hostName string) (*StaticDhcpHost, error)
+	Save(host *StaticDhcpHost) error
+	// Replace changes the first host equal to the given one into the replacement, keeping its place in the file
+	Replace(host *StaticDhcpHost, replacement *StaticDhcpHost) error
+	Delete(host *StaticDhcpHost) (*StaticDhcpHost, error)
+	DeleteByMac(macAddress net.HardwareAddr) (*StaticDhcpHost, error)
+	DeleteByIP(ipAddress net.IP) (*StaticDhcpHost, error)
+	DeleteByHostName(hostName string) (*StaticDhcpHost, error)
+}
@@ -0,0 +1,352 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+)
+
+// ResourceRecord represents a dnsmasq `srv-host=`, `mx-host=`, `txt-record=` or `ptr-record=` line. Only the fields
+// of its type are used, the other ones are ignored.
+type ResourceRecord struct {
+	// One of ResourceRecordTypeSrv, ResourceRecordTypeMx, ResourceRecordTypeTxt or ResourceRecordTypePtr
+	Type string
+	// Name answered by the record, matched regardless of the case (e.g. _ldap._tcp.lan for an SRV record)
+	Name string
+	// Host name of the SRV, MX or PTR answer. PTR targets may hold spaces (e.g. DNS-SD instance names). SRV records
+	// without target tell that the service is not available, and MX records without target are answered by dnsmasq
+	// with its mx-target (or its own host name)
+	Target string
+	// SRV port, from 1 to 65535 (0 without target)
+	Port int
+	// SRV priority or MX preference, from 0 to 65535 (0 for the SRV records and DefaultMxPreference for the MX
+	// records without target)
+	Priority int
+	// SRV weight, from 0 to 65535 (0 without target)
+	Weight int
+	// TXT strings, of up to 255 bytes each
+	Text []string
+}
+
+// ResourceRecordKey identifies a record among the records of its type: the SRV records by their name, target and
+// port, the MX records by their name and target, and the TXT and PTR records by their name.
+type ResourceRecordKey struct {
+	Type   string
+	Name   string
+	Target string
+	Port   int
+}
+
+// Record types
+const (
+	ResourceRecordTypeSrv = "srv"
+	ResourceRecordTypeMx  = "mx"
+	ResourceRecordTypeTxt = "txt"
+	ResourceRecordTypePtr = "ptr"
+)
+
+// dnsmasq options of each record type
+var resourceRecordOptions = map[string]string{
+	ResourceRecordTypeSrv: "srv-host",
+	ResourceRecordTypeMx:  "mx-host",
+	ResourceRecordTypeTxt: "txt-record",
+	ResourceRecordTypePtr: "ptr-record",
+}
+
+// dnsmasq answers the MX records without a preference with this one
+const DefaultMxPreference = 1
+
+const (
+	maxResourceRecordValue       = 65535
+	maxTxtStringLength           = 255
+	errInvalidResourceRecordConf = "invalid resource record config: %s"
+)
+
+var ErrResourceRecordInvalidType = errors.New("invalid resource record: unknown record type")
+var ErrResourceRecordMissingName = errors.New("invalid resource record: missing name")
+var ErrResourceRecordInvalidName = errors.New("invalid resource record: names can't contain commas, slashes, quotes or spaces")
+var ErrResourceRecordInvalidSrvName = errors.New("invalid resource record: SRV names must start with _service._protocol")
+var ErrResourceRecordMissingTarget = errors.New("invalid resource record: missing target")
+var ErrResourceRecordInvalidTarget = errors.New("invalid resource record: targets can't contain commas, slashes, quotes or spaces")
+var ErrResourceRecordInvalidPort = errors.New("invalid resource record: port out of range (1-65535)")
+var ErrResourceRecordInvalidPriority = errors.New("invalid resource record: priority out of range (0-65535)")
+var ErrResourceRecordInvalidWeight = errors.New("invalid resource record: weight out of range (0-65535)")
+var ErrResourceRecordUnavailableSrv = errors.New("invalid resource record: SRV records without target can't have a port, priority or weight")
+var ErrResourceRecordDefaultMx = errors.New("invalid resource record: MX records without target have the default preference (1)")
+var ErrResourceRecordMissingText = errors.New("invalid resource record: missing text")
+var ErrResourceRecordTextTooLong = errors.New("invalid resource record: TXT strings can't be longer than 255 bytes")
+var ErrResourceRecordInvalidText = errors.New("invalid resource record: TXT strings and PTR targets can't contain control characters")
+
+// IsResourceRecord reports whether the line is a `srv-host=`, `mx-host=`, `txt-record=` or `ptr-record=` entry.
+func IsResourceRecord(line string) bool {
+	option, _, ok := dnsmasq.Option(line)
+	if !ok {
+		return false
+	}
+
+	for _, recordOption := range resourceRecordOptions {
+		if option == recordOption {
+			return true
+		}
+	}
+	return false
+}
+
+// FromConfig parses a `srv-host=<name>[,<target>,<port>[,<priority>[,<weight>]]]`, a
+// `mx-host=<name>[,<target>[,<preference>]]`, a `txt-record=<name>,<text>[,<text>...]` or a
+// `ptr-record=<name>,<target>` line. Values holding commas are quoted, as dnsmasq does.
+func (r *ResourceRecord) FromConfig(config string) error {
+	*r = ResourceRecord{}
+	option, value, ok := dnsmasq.Option(config)
+	if !ok {
+		return fmt.Errorf(errInvalidResourceRecordConf, config)
+	}
+	for recordType, recordOption := range resourceRecordOptions {
+		if option == recordOption {
+			r.Type = recordType
+		}
+	}
+
+	tokens, err := splitQuoted(value)
+	if err != nil || r.Type == "" {
+		return fmt.Errorf(errInvalidResourceRecordConf, config)
+	}
+	r.Name = tokens[0]
+	tokens = tokens[1:]
+
+	switch r.Type {
+	case ResourceRecordTypeSrv:
+		err = r.parseSrv(tokens)
+	case ResourceRecordTypeMx:
+		err = r.parseMx(tokens)
+	case ResourceRecordTypeTxt:
+		r.Text = tokens
+	case ResourceRecordTypePtr:
+		if len(tokens) != 1 {
+			err = fmt.Errorf(errInvalidResourceRecordConf, config)
+		} else {
+			r.Target = tokens[0]
+		}
+	}
+	if err != nil || r.Validate() != nil {
+		return fmt.Errorf(errInvalidResourceRecordConf, config)
+	}
+
+	return nil
+}
+
+func (r *ResourceRecord) parseSrv(tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	if len(tokens) < 2 || len(tokens) > 4 {
+		return errors.New("wrong number of SRV fields")
+	}
+
+	r.Target = tokens[0]
+	numbers := []*int{&r.Port, &r.Priority, &r.Weight}
+	for i, token := range tokens[1:] {
+		number, err := strconv.Atoi(token)
+		if err != nil {
+			return err
+		}
+		*numbers[i] = number
+	}
+
+	return nil
+}
+
+func (r *ResourceRecord) parseMx(tokens []string) error {
+	r.Priority = DefaultMxPreference
+	if len(tokens) == 0 {
+		return nil
+	}
+	if len(tokens) > 2 {
+		return errors.New("wrong number of MX fields")
+	}
+
+	r.Target = tokens[0]
+	if len(tokens) == 2 {
+		preference, err := strconv.Atoi(tokens[1])
+		if err != nil {
+			return err
+		}
+		r.Priority = preference
+	}
+
+	return nil
+}
+
+// Validate ensures that the record can be written as a dnsmasq line: it must have a name, and the fields of its
+// type within the ranges of the DNS wire format.
+func (r *ResourceRecord) Validate() error {
+	var err error = nil
+	if _, ok := resourceRecordOptions[r.Type]; !ok {
+		err = errors.Join(err, ErrResourceRecordInvalidType)
+	}
+	if r.Name == "" {
+		err = errors.Join(err, ErrResourceRecordMissingName)
+	}
+	if r.Name != "" && !isRecordName(r.Name) {
+		err = errors.Join(err, ErrResourceRecordInvalidName)
+	}
+
+	switch r.Type {
+	case ResourceRecordTypeSrv:
+		labels := strings.Split(r.Name, ".")
+		if len(labels) < 2 || !strings.HasPrefix(labels[0], "_") || !strings.HasPrefix(labels[1], "_") {
+			err = errors.Join(err, ErrResourceRecordInvalidSrvName)
+		}
+		if r.Target == "" {
+			if r.Port != 0 || r.Priority != 0 || r.Weight != 0 {
+				err = errors.Join(err, ErrResourceRecordUnavailableSrv)
+			}
+			break
+		}
+		err = errors.Join(err, r.validateTarget())
+		if r.Port < 1 || r.Port > maxResourceRecordValue {
+			err = errors.Join(err, ErrResourceRecordInvalidPort)
+		}
+		if r.Priority < 0 || r.Priority > maxResourceRecordValue {
+			err = errors.Join(err, ErrResourceRecordInvalidPriority)
+		}
+		if r.Weight < 0 || r.Weight > maxResourceRecordValue {
+			err = errors.Join(err, ErrResourceRecordInvalidWeight)
+		}
+	case ResourceRecordTypeMx:
+		if r.Target == "" {
+			if r.Priority != DefaultMxPreference {
+				err = errors.Join(err, ErrResourceRecordDefaultMx)
+			}
+			break
+		}
+		err = errors.Join(err, r.validateTarget())
+		if r.Priority < 0 || r.Priority > maxResourceRecordValue {
+			err = errors.Join(err, ErrResourceRecordInvalidPriority)
+		}
+	case ResourceRecordTypeTxt:
+		if len(r.Text) == 0 {
+			err = errors.Join(err, ErrResourceRecordMissingText)
+		}
+		if slices.ContainsFunc(r.Text, func(text string) bool { return len(text) > maxTxtStringLength }) {
+			err = errors.Join(err, ErrResourceRecordTextTooLong)
+		}
+		if slices.ContainsFunc(r.Text, hasControlCharacters) {
+			err = errors.Join(err, ErrResourceRecordInvalidText)
+		}
+	case ResourceRecordTypePtr:
+		if r.Target == "" {
+			err = errors.Join(err, ErrResourceRecordMissingTarget)
+		}
+		if hasControlCharacters(r.Target) {
+			err = errors.Join(err, ErrResourceRecordInvalidText)
+		}
+	}
+	return err
+}
+
+func (r *ResourceRecord) validateTarget() error {
+	if !isRecordName(r.Target) {
+		return ErrResourceRecordInvalidTarget
+	}
+	return nil
+}
+
+// ToConfig renders the record as a line of the option of its type. The TXT strings are always quoted, and the
+// PTR targets when they hold spaces or commas. The SRV and MX records without target are written with their name only.
+func (r *ResourceRecord) ToConfig() (string, error) {
+	err := r.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	tokens := []string{r.Name}
+	switch r.Type {
+	case ResourceRecordTypeSrv:
+		if r.Target != "" {
+			tokens = append(tokens, r.Target, strconv.Itoa(r.Port), strconv.Itoa(r.Priority), strconv.Itoa(r.Weight))
+		}
+	case ResourceRecordTypeMx:
+		if r.Target != "" {
+			tokens = append(tokens, r.Target, strconv.Itoa(r.Priority))
+		}
+	case ResourceRecordTypeTxt:
+		for _, text := range r.Text {
+			tokens = append(tokens, quote(text))
+		}
+	case ResourceRecordTypePtr:
+		target := r.Target
+		if strings.ContainsAny(target, `, "\`) {
+			target = quote(target)
+		}
+		tokens = append(tokens, target)
+	}
+
+	return resourceRecordOptions[r.Type] + "=" + strings.Join(tokens, ","), nil
+}
+
+// Key returns the key identifying the record among the records of its type.
+func (r *ResourceRecord) Key() ResourceRecordKey {
+	key := ResourceRecordKey{Type: r.Type, Name: r.Name}
+	switch r.Type {
+	case ResourceRecordTypeSrv:
+		key.Target = r.Target
+		key.Port = r.Port
+	case ResourceRecordTypeMx:
+		key.Target = r.Target
+	}
+
+	return key
+}
+
+// String returns the key of the record regardless of the case, as DNS names are.
+func (k ResourceRecordKey) String() string {
+	return strings.ToLower(fmt.Sprintf("%s/%s/%s/%d", k.Type, k.Name, k.Target, k.Port))
+}
+
+// isRecordName reports whether the name can be written unquoted. Underscores are allowed, as the service labels
+// (e.g. _http._tcp) and the TXT names (e.g. _dmarc) use them.
+func isRecordName(name string) bool {
+	return !strings.ContainsAny(name, ",/ \t#\"\\")
+}
+
+func hasControlCharacters(text string) bool {
+	return strings.IndexFunc(text, unicode.IsControl) >= 0
+}
+
+// quote renders a value as a dnsmasq quoted string, escaping the quotes and backslashes.
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// splitQuoted splits an option value at the commas that are not within quotes, unquoting the values as dnsmasq
+// does (e.g. `name,"a,b",c` gives name, a,b and c).
+func splitQuoted(value string) ([]string, error) {
+	tokens := []string{}
+	var token strings.Builder
+	quoted := false
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && quoted && i+1 < len(value):
+			i++
+			token.WriteByte(value[i])
+		case c == ',' && !quoted:
+			tokens = append(tokens, token.String())
+			token.Reset()
+		default:
+			token.WriteByte(c)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf(errInvalidResourceRecordConf, value)
+	}
+
+	return append(tokens, token.String()), nil
+}
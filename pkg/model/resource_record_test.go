@@ -0,0 +1,163 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceRecordFromConfig(t *testing.T) {
+	testCases := []struct {
+		name           string
+		config         string
+		expectError    bool
+		expectedRecord ResourceRecord
+		// Config rendered back, when it is not the parsed one
+		expectedConfig string
+	}{
+		{
+			name:           "Srv",
+			config:         `srv-host=_ldap._tcp.lan,ldap.lan,389,10,50`,
+			expectedRecord: ResourceRecord{Type: ResourceRecordTypeSrv, Name: "_ldap._tcp.lan", Target: "ldap.lan", Port: 389, Priority: 10, Weight: 50},
+		},
+		{
+			name:           "SrvWithoutPriorityAndWeight",
+			config:         `srv-host=_ldap._tcp.lan,ldap.lan,389`,
+			expectedRecord: ResourceRecord{Type: ResourceRecordTypeSrv, Name: "_ldap._tcp.lan", Target: "ldap.lan", Port: 389},
+			expectedConfig: `srv-host=_ldap._tcp.lan,ldap.lan,389,0,0`,
+		},
+		{
+			name:           "SrvWithoutTarget",
+			config:         `srv-host=_ldap._tcp.example.com`,
+			expectedRecord: ResourceRecord{Type: ResourceRecordTypeSrv, Name: "_ldap._tcp.example.com"},
+		},
+		{
+			name:           "Mx",
+			config:         `mx-host=example.lan,mail.example.lan,10`,
+			expectedRecord: ResourceRecord{Type: ResourceRecordTypeMx, Name: "example.lan", Target: "mail.example.lan", Priority: 10},
+		},
+		{
+			name:           "MxWithoutPreference",
+			config:         `mx-host=example.lan,mail.example.lan`,
+			expectedRecord: ResourceRecord{Type: ResourceRecordTypeMx, Name: "example.lan", Target: "mail.example.lan", Priority: 1},
+			expectedConfig: `mx-host=example.lan,mail.example.lan,1`,
+		},
+		{
+			name:           "MxWithoutTarget",
+			config:         `mx-host=example.com`,
+			expectedRecord: ResourceRecord{Type: ResourceRecordTypeMx, Name: "example.com", Priority: 1},
+		},
+		{
+			name:           "Txt",
+			config:         `txt-record=example.lan,"v=spf1 a -all","hello, world"`,
+			expectedRecord: ResourceRecord{Type: ResourceRecordTypeTxt, Name: "example.lan", Text: []string{"v=spf1 a -all", "hello, world"}},
+		},
+		{
+			name:           "TxtUnquoted",
+			config:         `txt-record=_dmarc.example.lan,v=DMARC1`,
+			expectedRecord: ResourceRecord{Type: ResourceRecordTypeTxt, Name: "_dmarc.example.lan", Text: []string{"v=DMARC1"}},
+			expectedConfig: `txt-record=_dmarc.example.lan,"v=DMARC1"`,
+		},
+		{
+			name:           "TxtEscapedQuotes",
+			config:         `txt-record=example.lan,"say \"hi\" \\o/"`,
+			expectedRecord: ResourceRecord{Type: ResourceRecordTypeTxt, Name: "example.lan", Text: []string{`say "hi" \o/`}},
+		},
+		{
+			name:           "Ptr",
+			config:         `ptr-record=10.1.168.192.in-addr.arpa,nas.lan`,
+			expectedRecord: ResourceRecord{Type: ResourceRecordTypePtr, Name: "10.1.168.192.in-addr.arpa", Target: "nas.lan"},
+		},
+		{
+			name:           "PtrServiceInstance",
+			config:         `ptr-record=_http._tcp.dns-sd-services,"New Fast Server._http._tcp.dns-sd-services"`,
+			expectedRecord: ResourceRecord{Type: ResourceRecordTypePtr, Name: "_http._tcp.dns-sd-services", Target: "New Fast Server._http._tcp.dns-sd-services"},
+		},
+		{name: "NotARecord", config: `cname=files.lan,nas.lan`, expectError: true},
+		{name: "SrvWithoutService", config: `srv-host=ldap.lan,ldap.lan,389`, expectError: true},
+		{name: "SrvWithoutPort", config: `srv-host=_ldap._tcp.lan,ldap.lan`, expectError: true},
+		{name: "SrvInvalidPort", config: `srv-host=_ldap._tcp.lan,ldap.lan,70000`, expectError: true},
+		{name: "SrvInvalidWeight", config: `srv-host=_ldap._tcp.lan,ldap.lan,389,0,heavy`, expectError: true},
+		{name: "MxTooManyFields", config: `mx-host=example.lan,mail.example.lan,10,20`, expectError: true},
+		{name: "TxtWithoutText", config: `txt-record=example.lan`, expectError: true},
+		{name: "TxtUnterminatedQuote", config: `txt-record=example.lan,"v=spf1`, expectError: true},
+		{name: "PtrWithoutTarget", config: `ptr-record=10.1.168.192.in-addr.arpa`, expectError: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			record := ResourceRecord{}
+			err := record.FromConfig(test.config)
+			if test.expectError {
+				assert.Error(t, err, "ResourceRecord.FromConfig() did NOT returned an error")
+				return
+			}
+			assert.NoError(t, err, "ResourceRecord.FromConfig() returned an unexpected error")
+			assert.Equal(t, test.expectedRecord, record, "ResourceRecord.FromConfig() has generated an unexpected record")
+
+			expectedConfig := test.expectedConfig
+			if expectedConfig == "" {
+				expectedConfig = test.config
+			}
+			config, err := record.ToConfig()
+			assert.NoError(t, err, "ResourceRecord.ToConfig() returned an unexpected error")
+			assert.Equal(t, expectedConfig, config, "ResourceRecord did not survive the round trip")
+		})
+	}
+}
+
+func TestResourceRecordToConfig(t *testing.T) {
+	srv := func(port int, priority int, weight int) ResourceRecord {
+		return ResourceRecord{Type: ResourceRecordTypeSrv, Name: "_ldap._tcp.lan", Target: "ldap.lan", Port: port, Priority: priority, Weight: weight}
+	}
+
+	testCases := []struct {
+		name          string
+		record        ResourceRecord
+		expectedError error
+	}{
+		{name: "InvalidType", record: ResourceRecord{Type: "a", Name: "nas.lan"}, expectedError: ErrResourceRecordInvalidType},
+		{name: "MissingName", record: ResourceRecord{Type: ResourceRecordTypePtr, Target: "nas.lan"}, expectedError: ErrResourceRecordMissingName},
+		{name: "InvalidName", record: ResourceRecord{Type: ResourceRecordTypePtr, Name: "nas lan", Target: "nas.lan"}, expectedError: ErrResourceRecordInvalidName},
+		{name: "SrvInvalidName", record: ResourceRecord{Type: ResourceRecordTypeSrv, Name: "_ldap.lan", Target: "ldap.lan", Port: 389}, expectedError: ErrResourceRecordInvalidSrvName},
+		{name: "SrvUnavailableWithPort", record: ResourceRecord{Type: ResourceRecordTypeSrv, Name: "_ldap._tcp.lan", Port: 389}, expectedError: ErrResourceRecordUnavailableSrv},
+		{name: "SrvZeroPort", record: srv(0, 0, 0), expectedError: ErrResourceRecordInvalidPort},
+		{name: "SrvPortTooBig", record: srv(65536, 0, 0), expectedError: ErrResourceRecordInvalidPort},
+		{name: "SrvNegativePriority", record: srv(389, -1, 0), expectedError: ErrResourceRecordInvalidPriority},
+		{name: "SrvWeightTooBig", record: srv(389, 0, 65536), expectedError: ErrResourceRecordInvalidWeight},
+		{name: "MxInvalidTarget", record: ResourceRecord{Type: ResourceRecordTypeMx, Name: "example.lan", Target: "mail,example.lan"}, expectedError: ErrResourceRecordInvalidTarget},
+		{name: "MxDefaultWithPreference", record: ResourceRecord{Type: ResourceRecordTypeMx, Name: "example.lan", Priority: 10}, expectedError: ErrResourceRecordDefaultMx},
+		{name: "MxPreferenceTooBig", record: ResourceRecord{Type: ResourceRecordTypeMx, Name: "example.lan", Target: "mail.example.lan", Priority: 65536}, expectedError: ErrResourceRecordInvalidPriority},
+		{name: "TxtMissingText", record: ResourceRecord{Type: ResourceRecordTypeTxt, Name: "example.lan"}, expectedError: ErrResourceRecordMissingText},
+		{name: "TxtTooLong", record: ResourceRecord{Type: ResourceRecordTypeTxt, Name: "example.lan", Text: []string{strings.Repeat("a", 256)}}, expectedError: ErrResourceRecordTextTooLong},
+		{name: "TxtControlCharacters", record: ResourceRecord{Type: ResourceRecordTypeTxt, Name: "example.lan", Text: []string{"a\nb"}}, expectedError: ErrResourceRecordInvalidText},
+		{name: "PtrMissingTarget", record: ResourceRecord{Type: ResourceRecordTypePtr, Name: "10.1.168.192.in-addr.arpa"}, expectedError: ErrResourceRecordMissingTarget},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := test.record.ToConfig()
+			assert.ErrorIs(t, err, test.expectedError, "ResourceRecord.ToConfig() returned an unexpected error")
+			assert.Empty(t, config, "ResourceRecord.ToConfig() returned an unexpected config")
+		})
+	}
+}
+
+func TestResourceRecordKey(t *testing.T) {
+	srv := ResourceRecord{Type: ResourceRecordTypeSrv, Name: "_ldap._tcp.lan", Target: "LDAP.lan", Port: 389, Priority: 10}
+	mx := ResourceRecord{Type: ResourceRecordTypeMx, Name: "example.lan", Target: "mail.example.lan", Priority: 10}
+	txt := ResourceRecord{Type: ResourceRecordTypeTxt, Name: "Example.lan", Text: []string{"v=spf1 a -all"}}
+
+	assert.Equal(t, ResourceRecordKey{Type: ResourceRecordTypeSrv, Name: "_ldap._tcp.lan", Target: "LDAP.lan", Port: 389}, srv.Key(), "unexpected SRV key")
+	assert.Equal(t, ResourceRecordKey{Type: ResourceRecordTypeMx, Name: "example.lan", Target: "mail.example.lan"}, mx.Key(), "unexpected MX key")
+	assert.Equal(t, ResourceRecordKey{Type: ResourceRecordTypeTxt, Name: "Example.lan"}, txt.Key(), "unexpected TXT key")
+	assert.Equal(t, "txt/example.lan//0", txt.Key().String(), "the key strings must ignore the case")
+}
+
+func TestIsResourceRecord(t *testing.T) {
+	assert.True(t, IsResourceRecord("srv-host=_ldap._tcp.lan,ldap.lan,389"), "IsResourceRecord() did NOT recognize an SRV record")
+	assert.True(t, IsResourceRecord(`txt-record=example.lan,"v=spf1 a -all"`), "IsResourceRecord() did NOT recognize a TXT record")
+	assert.False(t, IsResourceRecord("# mx-host=example.lan,mail.example.lan"), "IsResourceRecord() recognized a comment")
+	assert.False(t, IsResourceRecord("cname=files.lan,nas.lan"), "IsResourceRecord() recognized a CNAME")
+}
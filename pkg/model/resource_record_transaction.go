@@ -0,0 +1,13 @@
+package model
+
+// ResourceRecordTransaction is a unit of work on the SRV, MX, TXT and PTR records, applied in memory as a
+// HostTransaction is.
+type ResourceRecordTransaction interface {
+	FindAll() (*[]ResourceRecord, error)
+	// FindByKey returns the record with the given key, regardless of the case
+	FindByKey(key ResourceRecordKey) (*ResourceRecord, error)
+	Save(record *ResourceRecord) error
+	// ReplaceByKey changes the record with the given key into the new one, keeping its place in the file
+	ReplaceByKey(key ResourceRecordKey, record *ResourceRecord) error
+	DeleteByKey(key ResourceRecordKey) (*ResourceRecord, error)
+}
@@ -5,68 +5,316 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"regexp"
+	"slices"
 	"strings"
 )
 
+// StaticDhcpHost represents a dnsmasq `dhcp-host=` entry.
+//
+// MacAddress, IPAddress, IPv6Address and HostName are the fields managed through the API, the remaining
+// ones are optional and only exist so that hand-written entries survive being parsed and written back,
+// see KeepOptions.
 type StaticDhcpHost struct {
 	MacAddress net.HardwareAddr
-	IPAddress  net.IP
-	HostName   string
+	// IPv4 address, a host must have an IPv4 address, an IPv6 address or both (dual-stack)
+	IPAddress net.IP
+	HostName  string
+	// Additional hardware addresses of the same host, wildcards (e.g. 11:22:33:*:*:*) are allowed
+	ExtraMacAddresses []string
+	// IPv6 address, written between brackets on the config line
+	IPv6Address net.IP
+	// Client identifier, without the `id:` prefix. A `*` means "ignore the client ID"
+	ClientID string
+	// Tags set by this host (`set:<tag>`)
+	SetTags []string
+	// Tags required for this host to match (`tag:<tag>`)
+	MatchTags []string
+	// Lease time, in dnsmasq notation (e.g. 3600, 45m, 12h, infinite)
+	LeaseTime string
+	// Whether dnsmasq should ignore any DHCP request from this host
+	Ignore bool
+	// Not part of the dhcp-host line, see HostMetadata
+	Metadata HostMetadata
+	// Line the host was parsed from, only kept when ToConfig would write it differently (e.g. tags after the
+	// hostname, or dashed MAC addresses)
+	config string
 }
 
-const errInvalidDHCPHostConfig = "invalid DHCP host config: %s"
+const (
+	dhcpHostPrefix           = "dhcp-host="
+	clientIDPrefix           = "id:"
+	setTagPrefix             = "set:"
+	matchTagPrefix           = "tag:"
+	ignoreKeyword            = "ignore"
+	errInvalidDHCPHostConfig = "invalid DHCP host config: %s"
+)
 
-var ErrDHCPHostMissingMACAddress = errors.New("invalid DHCP host: missing MAC address")
+var ErrDHCPHostMissingIdentifier = errors.New("invalid DHCP host: missing MAC address, client ID or hostname")
 var ErrDHCPHostMissingIPAddress = errors.New("invalid DHCP host: missing IP address")
 var ErrDHCPHostMissingHostName = errors.New("invalid DHCP host: missing hostname")
+var ErrDHCPHostInvalidIPv4Address = errors.New("invalid DHCP host: IPAddress is not an IPv4 address")
+var ErrDHCPHostInvalidIPv6Address = errors.New("invalid DHCP host: IPv6Address is not an IPv6 address")
+
+var (
+	wildcardMacRegexp = regexp.MustCompile(`^([0-9a-fA-F]{1,2}|\*)([:-]([0-9a-fA-F]{1,2}|\*)){5}$`)
+	leaseTimeRegexp   = regexp.MustCompile(`^([0-9]+[smhdw]?|infinite|deprecated)$`)
+	ipv4LikeRegexp    = regexp.MustCompile(`^[0-9.]+$`)
+	dashedMacRegexp   = regexp.MustCompile(`^([0-9a-fA-F]{1,2}|\*)(-([0-9a-fA-F]{1,2}|\*)){5}$`)
+	numberRegexp      = regexp.MustCompile(`^[0-9]+$`)
+)
 
+// FromConfig parses a `dhcp-host=` line, accepting the tokens documented by dnsmasq:
+// `[<hwaddr>...][,id:<client_id>|*][,set:<tag>][,tag:<tag>][,<ipaddr>][,[<ipv6addr>]][,<hostname>][,<lease_time>][,ignore]`
 func (h *StaticDhcpHost) FromConfig(config string) error {
-	tokens := strings.Split(config, ",")
-	if len(tokens) != 3 {
+	if !strings.HasPrefix(config, dhcpHostPrefix) {
 		return fmt.Errorf(errInvalidDHCPHostConfig, config)
 	}
 
-	var mac string
-	_, err := fmt.Sscanf(tokens[0], "dhcp-host=%s", &mac)
+	*h = StaticDhcpHost{}
+	tokens := strings.Split(strings.TrimPrefix(config, dhcpHostPrefix), ",")
+	last := len(tokens) - 1
+	if last > 0 && tokens[last] == ignoreKeyword {
+		last--
+	}
+
+	var err error
+	for i, token := range tokens {
+		if token == "" {
+			return fmt.Errorf(errInvalidDHCPHostConfig, config)
+		}
+
+		ok, tokenErr := h.parseToken(token, i == last)
+		if !ok {
+			return fmt.Errorf(errInvalidDHCPHostConfig, config)
+		}
+		err = errors.Join(err, tokenErr)
+	}
+
 	if err != nil {
-		return errors.Join(fmt.Errorf(errInvalidDHCPHostConfig, config), err)
+		return err
 	}
 
-	h.MacAddress, err = net.ParseMAC(mac)
-	h.IPAddress = net.ParseIP(tokens[1])
-	if h.IPAddress == nil {
-		err = errors.Join(err, &net.AddrError{Err: "invalid IP address", Addr: tokens[1]})
+	if h.check() != nil {
+		return fmt.Errorf(errInvalidDHCPHostConfig, config)
 	}
 
-	h.HostName = tokens[2]
+	if canonical, _ := h.ToConfig(); canonical != config {
+		h.config = config
+	}
+	return nil
+}
 
-	return err
+// parseToken stores a single config token into the host. It returns false when the token does not
+// fit in the dhcp-host grammar (e.g. duplicated hostname), and an error when the token was
+// recognized but its value is invalid. A bare number is a hostname, unless it is the last token
+// (an ignore keyword aside) and follows a hostname or an address, where it is the lease time.
+func (h *StaticDhcpHost) parseToken(token string, last bool) (bool, error) {
+	switch {
+	case strings.HasPrefix(token, clientIDPrefix):
+		if h.ClientID != "" {
+			return false, nil
+		}
+		h.ClientID = strings.TrimPrefix(token, clientIDPrefix)
+		return h.ClientID != "", nil
+	case strings.HasPrefix(token, setTagPrefix):
+		h.SetTags = append(h.SetTags, strings.TrimPrefix(token, setTagPrefix))
+		return true, nil
+	case strings.HasPrefix(token, matchTagPrefix):
+		h.MatchTags = append(h.MatchTags, strings.TrimPrefix(token, matchTagPrefix))
+		return true, nil
+	case strings.HasPrefix(token, "[") && strings.HasSuffix(token, "]"):
+		if h.IPv6Address != nil {
+			return false, nil
+		}
+		address := strings.TrimSuffix(strings.TrimPrefix(token, "["), "]")
+		h.IPv6Address = net.ParseIP(address)
+		if h.IPv6Address == nil || h.IPv6Address.To4() != nil {
+			h.IPv6Address = nil
+			return true, &net.AddrError{Err: "invalid IPv6 address", Addr: address}
+		}
+		return true, nil
+	case token == ignoreKeyword:
+		h.Ignore = true
+		return true, nil
+	case leaseTimeRegexp.MatchString(token) && (!numberRegexp.MatchString(token) || last && h.hasName()):
+		if h.LeaseTime != "" {
+			return false, nil
+		}
+		h.LeaseTime = token
+		return true, nil
+	case strings.Contains(token, "."):
+		// Hostnames may also contain dots, but anything made only of digits and dots is meant to be an IPv4 address
+		if !ipv4LikeRegexp.MatchString(token) && net.ParseIP(token) == nil {
+			return h.parseHostName(token), nil
+		}
+		if h.IPAddress != nil {
+			return false, nil
+		}
+		h.IPAddress = net.ParseIP(token)
+		if h.IPAddress == nil {
+			return true, &net.AddrError{Err: "invalid IP address", Addr: token}
+		}
+		return true, nil
+	case strings.Contains(token, ":") || dashedMacRegexp.MatchString(token):
+		return true, h.parseMacAddress(token)
+	default:
+		return h.parseHostName(token), nil
+	}
 }
 
+// hasName reports whether the host already has a hostname or an address, which a lease time may follow.
+func (h *StaticDhcpHost) hasName() bool {
+	return h.HostName != "" || h.IPAddress != nil || h.IPv6Address != nil
+}
+
+func (h *StaticDhcpHost) parseHostName(token string) bool {
+	if h.HostName != "" {
+		return false
+	}
+	h.HostName = token
+	return true
+}
+
+func (h *StaticDhcpHost) parseMacAddress(token string) error {
+	if strings.Contains(token, "*") {
+		if !wildcardMacRegexp.MatchString(token) {
+			return &net.AddrError{Err: "invalid MAC address", Addr: token}
+		}
+		h.ExtraMacAddresses = append(h.ExtraMacAddresses, token)
+		return nil
+	}
+
+	mac, err := net.ParseMAC(token)
+	if err != nil {
+		return err
+	}
+
+	// The first hardware address is the one that identifies the host, as long as it is not a wildcard
+	if h.MacAddress == nil && len(h.ExtraMacAddresses) == 0 {
+		h.MacAddress = mac
+	} else {
+		h.ExtraMacAddresses = append(h.ExtraMacAddresses, mac.String())
+	}
+	return nil
+}
+
+// check ensures that the host can be identified by dnsmasq (MAC address, client ID or hostname), as
+// `dhcp-host=<mac>,<ip>` and `dhcp-host=<hostname>,<ip>` are valid entries. Each address must belong to
+// the family of its field, since they are written differently on the config line. The API requires
+// more than that (e.g. a hostname and an address), which is up to its own validation.
 func (h *StaticDhcpHost) check() error {
 	var err error = nil
-	if h.MacAddress.String() == "" {
-		err = errors.Join(err, ErrDHCPHostMissingMACAddress)
+	if h.MacAddress.String() == "" && len(h.ExtraMacAddresses) == 0 && h.ClientID == "" && h.HostName == "" {
+		err = errors.Join(err, ErrDHCPHostMissingIdentifier)
 	}
-	if h.IPAddress.String() == "<nil>" {
-		err = errors.Join(err, ErrDHCPHostMissingIPAddress)
+	if h.IPAddress != nil && h.IPAddress.To4() == nil {
+		err = errors.Join(err, ErrDHCPHostInvalidIPv4Address)
 	}
-	if h.HostName == "" {
-		err = errors.Join(err, ErrDHCPHostMissingHostName)
+	if h.IPv6Address != nil && h.IPv6Address.To4() != nil {
+		err = errors.Join(err, ErrDHCPHostInvalidIPv6Address)
 	}
 	return err
 }
 
+// ToConfig renders the host as a `dhcp-host=` line. A parsed host that was not changed since is written back
+// as it was read, otherwise the tokens are written in the same order used by the dnsmasq documentation.
 func (h *StaticDhcpHost) ToConfig() (string, error) {
 	err := h.check()
 	if err != nil {
 		return "", err
 	}
 
-	config := fmt.Sprintf("dhcp-host=%s,%s,%s", h.MacAddress.String(), h.IPAddress.String(), h.HostName)
-	return config, nil
+	if h.config != "" {
+		original := StaticDhcpHost{}
+		if original.FromConfig(h.config) == nil && original.Equal(*h) {
+			return h.config, nil
+		}
+	}
+
+	tokens := []string{}
+	if h.MacAddress != nil {
+		tokens = append(tokens, h.MacAddress.String())
+	}
+	tokens = append(tokens, h.ExtraMacAddresses...)
+	if h.ClientID != "" {
+		tokens = append(tokens, clientIDPrefix+h.ClientID)
+	}
+	for _, tag := range h.SetTags {
+		tokens = append(tokens, setTagPrefix+tag)
+	}
+	for _, tag := range h.MatchTags {
+		tokens = append(tokens, matchTagPrefix+tag)
+	}
+	if h.IPAddress != nil {
+		tokens = append(tokens, h.IPAddress.String())
+	}
+	if h.IPv6Address != nil {
+		tokens = append(tokens, "["+h.IPv6Address.String()+"]")
+	}
+	if h.HostName != "" {
+		tokens = append(tokens, h.HostName)
+	}
+	if h.LeaseTime != "" {
+		tokens = append(tokens, h.LeaseTime)
+	}
+	if h.Ignore {
+		tokens = append(tokens, ignoreKeyword)
+	}
+
+	return dhcpHostPrefix + strings.Join(tokens, ","), nil
+}
+
+// KeepOptions copies the fields that are not managed through the API (extra MAC addresses, client ID, tags,
+// lease time and ignore) from the host being replaced, so that replacing a hand-written entry does not drop them.
+func (h *StaticDhcpHost) KeepOptions(replaced *StaticDhcpHost) {
+	h.ExtraMacAddresses = replaced.ExtraMacAddresses
+	h.ClientID = replaced.ClientID
+	h.SetTags = replaced.SetTags
+	h.MatchTags = replaced.MatchTags
+	h.LeaseTime = replaced.LeaseTime
+	h.Ignore = replaced.Ignore
+	h.config = replaced.config
+}
+
+// HasMacAddress reports whether the given MAC address is one of the (non-wildcard) hardware addresses of the host.
+func (h *StaticDhcpHost) HasMacAddress(macAddress net.HardwareAddr) bool {
+	if bytes.Equal(h.MacAddress, macAddress) {
+		return true
+	}
+
+	for _, extra := range h.ExtraMacAddresses {
+		mac, err := net.ParseMAC(extra)
+		if err == nil && bytes.Equal(mac, macAddress) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IPAddresses returns the addresses assigned to the host, the IPv4 one first.
+func (h *StaticDhcpHost) IPAddresses() []net.IP {
+	addresses := []net.IP{}
+	if h.IPAddress != nil {
+		addresses = append(addresses, h.IPAddress)
+	}
+	if h.IPv6Address != nil {
+		addresses = append(addresses, h.IPv6Address)
+	}
+
+	return addresses
+}
+
+// HasIPAddress reports whether the given IP address, of either family, is assigned to the host.
+func (h *StaticDhcpHost) HasIPAddress(ipAddress net.IP) bool {
+	return ipAddress != nil && (ipAddress.Equal(h.IPAddress) || ipAddress.Equal(h.IPv6Address))
 }
 
+// Equal reports whether both hosts result in the same dnsmasq entry, their metadata is not compared.
 func (h *StaticDhcpHost) Equal(other StaticDhcpHost) bool {
-	return bytes.Equal(h.MacAddress, other.MacAddress) && h.IPAddress.Equal(other.IPAddress) && h.HostName == other.HostName
+	return bytes.Equal(h.MacAddress, other.MacAddress) && h.IPAddress.Equal(other.IPAddress) && h.HostName == other.HostName &&
+		slices.Equal(h.ExtraMacAddresses, other.ExtraMacAddresses) && h.IPv6Address.Equal(other.IPv6Address) &&
+		h.ClientID == other.ClientID && slices.Equal(h.SetTags, other.SetTags) && slices.Equal(h.MatchTags, other.MatchTags) &&
+		h.LeaseTime == other.LeaseTime && h.Ignore == other.Ignore
 }
@@ -0,0 +1,38 @@
+package model
+
+import "net"
+
+// StaticDhcpHostPatch is a partial change of a static host. Nil fields are left untouched, while a nil IP
+// address or an empty string removes the field from the host.
+//
+// The MAC address identifies the host, so it can't be changed.
+type StaticDhcpHostPatch struct {
+	IPAddress   *net.IP
+	IPv6Address *net.IP
+	HostName    *string
+	Description *string
+	Owner       *string
+	Labels      *[]string
+}
+
+// Apply changes the host fields set in the patch. The resulting host may be invalid (e.g. without any IP address).
+func (p *StaticDhcpHostPatch) Apply(host *StaticDhcpHost) {
+	if p.IPAddress != nil {
+		host.IPAddress = *p.IPAddress
+	}
+	if p.IPv6Address != nil {
+		host.IPv6Address = *p.IPv6Address
+	}
+	if p.HostName != nil {
+		host.HostName = *p.HostName
+	}
+	if p.Description != nil {
+		host.Metadata.Description = *p.Description
+	}
+	if p.Owner != nil {
+		host.Metadata.Owner = *p.Owner
+	}
+	if p.Labels != nil {
+		host.Metadata.Labels = *p.Labels
+	}
+}
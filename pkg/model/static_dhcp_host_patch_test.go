@@ -0,0 +1,32 @@
+package model
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticDhcpHostPatchApply(t *testing.T) {
+	hostName := "Bar"
+	owner := ""
+	labels := []string{"lan"}
+	var noIPAddress net.IP
+
+	host := StaticDhcpHost{
+		MacAddress:  net.HardwareAddr{0x02, 0x04, 0x06, 0xaa, 0xbb, 0xcc},
+		IPAddress:   net.ParseIP("1.1.1.1"),
+		IPv6Address: net.ParseIP("2001:db8::1"),
+		HostName:    "Foo",
+		Metadata:    HostMetadata{Description: "Living room NAS", Owner: "alice"},
+	}
+	patch := StaticDhcpHostPatch{IPv6Address: &noIPAddress, HostName: &hostName, Owner: &owner, Labels: &labels}
+	patch.Apply(&host)
+
+	assert.Equal(t, StaticDhcpHost{
+		MacAddress: net.HardwareAddr{0x02, 0x04, 0x06, 0xaa, 0xbb, 0xcc},
+		IPAddress:  net.ParseIP("1.1.1.1"),
+		HostName:   "Bar",
+		Metadata:   HostMetadata{Description: "Living room NAS", Labels: []string{"lan"}},
+	}, host, "StaticDhcpHostPatch.Apply() has generated an unexpected host")
+}
@@ -16,15 +16,42 @@ const (
 	InvalidBothAddressesConfig = `dhcp-host=ab:cd:ef:gh:ij:kl,11.1.1,Jung`
 	InvalidConfig              = `not-dhcp-config`
 	InvalidConfig2             = `02:04:06:aa:bb:cc,1.1.1.1,Jung`
-	MissingMacAddressConfig    = `dhcp-host=1.1.1.1,Foo`
+	MissingMacAddressConfig    = `dhcp-host=lap,192.168.0.199`
 	MissingIPAddressConfig     = `dhcp-host=02:04:06:aa:bb:cc,Foo`
 	MissingHostNameConfig      = `dhcp-host=02:04:06:aa:bb:cc,1.1.1.1`
+	MissingIdentifierConfig    = `dhcp-host=1.1.1.1,12h`
 	InvalidIPAddress           = `11.1.1`
 	InvalidMacAddress          = `ab:cd:ef:gh:ij:kl`
+	InvalidIPv6AddressConfig   = `dhcp-host=02:04:06:aa:bb:cc,[2001:db8::zz],Foo`
+	InvalidWildcardMacConfig   = `dhcp-host=02:04:06:*:*,1.1.1.1,Foo`
+	DuplicatedHostNameConfig   = `dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo,Bar`
+	EmptyTokenConfig           = `dhcp-host=02:04:06:aa:bb:cc,,1.1.1.1,Foo`
+	IgnoredHostConfig          = `dhcp-host=02:04:06:aa:bb:cc,ignore`
+	ClientIDHostConfig         = `dhcp-host=id:01:02:04:06:aa:bb:cc,1.1.1.1,Foo,infinite`
+	IPv6OnlyHostConfig         = `dhcp-host=02:04:06:aa:bb:cc,[2001:db8::10],Foo`
+	DualStackHostConfig        = `dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,[2001:db8::10],Foo`
+	BareIPv6AddressConfig      = `dhcp-host=02:04:06:aa:bb:cc,2001:db8::10,Foo`
+	FullHostConfig             = `dhcp-host=02:04:06:aa:bb:cc,02:04:06:dd:ee:ff,11:22:33:*:*:*,id:*,set:red,set:known,tag:lan,1.1.1.1,[2001:db8::10],Foo.lan,12h`
 )
 
+var FullHost = StaticDhcpHost{
+	MacAddress:        tests.ParseMAC("02:04:06:aa:bb:cc"),
+	IPAddress:         net.ParseIP("1.1.1.1"),
+	HostName:          "Foo.lan",
+	ExtraMacAddresses: []string{"02:04:06:dd:ee:ff", "11:22:33:*:*:*"},
+	IPv6Address:       net.ParseIP("2001:db8::10"),
+	ClientID:          "*",
+	SetTags:           []string{"red", "known"},
+	MatchTags:         []string{"lan"},
+	LeaseTime:         "12h",
+}
+
 var ValidHost = StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "Foo"}
 
+var IPv6OnlyHost = StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPv6Address: net.ParseIP("2001:db8::10"), HostName: "Foo"}
+
+var DualStackHost = StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), IPv6Address: net.ParseIP("2001:db8::10"), HostName: "Foo"}
+
 func TestStaticDhcpHostFromConfig(t *testing.T) {
 	testCases := []struct {
 		name   string
@@ -39,6 +66,133 @@ func TestStaticDhcpHostFromConfig(t *testing.T) {
 				assert.Equal(t, host, &ValidHost, "StaticDhcpHost.FromConfig() has generated an unexpected host")
 			},
 		},
+		{
+			name:   "FullSyntax",
+			config: FullHostConfig,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.Equal(t, &FullHost, host, "StaticDhcpHost.FromConfig() has generated an unexpected host")
+			},
+		},
+		{
+			name:   "IPv6Only",
+			config: IPv6OnlyHostConfig,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.Equal(t, &IPv6OnlyHost, host, "StaticDhcpHost.FromConfig() has generated an unexpected host")
+			},
+		},
+		{
+			name:   "DualStack",
+			config: DualStackHostConfig,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.Equal(t, &DualStackHost, host, "StaticDhcpHost.FromConfig() has generated an unexpected host")
+			},
+		},
+		{
+			name:   "BareIPv6Address",
+			config: BareIPv6AddressConfig,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.Error(t, err, "StaticDhcpHost.FromConfig() did NOT returned error")
+			},
+		},
+		{
+			name:   "IgnoredHost",
+			config: IgnoredHostConfig,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.Equal(t, &StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), Ignore: true}, host, "StaticDhcpHost.FromConfig() has generated an unexpected host")
+			},
+		},
+		{
+			name:   "ClientIDOnly",
+			config: ClientIDHostConfig,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.Equal(t, &StaticDhcpHost{ClientID: "01:02:04:06:aa:bb:cc", IPAddress: net.ParseIP("1.1.1.1"), HostName: "Foo", LeaseTime: "infinite"}, host, "StaticDhcpHost.FromConfig() has generated an unexpected host")
+			},
+		},
+		{
+			name:   "HyphenatedHostName",
+			config: `dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,db-01`,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.Equal(t, &StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "db-01"}, host, "StaticDhcpHost.FromConfig() has generated an unexpected host")
+			},
+		},
+		{
+			name:   "HexHyphenatedHostName",
+			config: `dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,ab-cd`,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.Equal(t, &StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "ab-cd"}, host, "StaticDhcpHost.FromConfig() has generated an unexpected host")
+			},
+		},
+		{
+			name:   "DashedMacAddress",
+			config: `dhcp-host=02-04-06-aa-bb-cc,1.1.1.1,Foo`,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.True(t, ValidHost.Equal(*host), "StaticDhcpHost.FromConfig() has generated an unexpected host")
+			},
+		},
+		{
+			name:   "NumericHostName",
+			config: `dhcp-host=02:04:06:aa:bb:cc,1234,1.1.1.1`,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.True(t, (&StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "1234"}).Equal(*host), "StaticDhcpHost.FromConfig() has generated an unexpected host")
+			},
+		},
+		{
+			name:   "NumericHostNameAndLeaseTime",
+			config: `dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,1234,3600`,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.Equal(t, &StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "1234", LeaseTime: "3600"}, host, "StaticDhcpHost.FromConfig() has generated an unexpected host")
+			},
+		},
+		{
+			name:   "LeaseTimeBeforeIgnore",
+			config: `dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,3600,ignore`,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.Equal(t, &StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), LeaseTime: "3600", Ignore: true}, host, "StaticDhcpHost.FromConfig() has generated an unexpected host")
+			},
+		},
+		{
+			name:   "InvalidIPv6Address",
+			config: InvalidIPv6AddressConfig,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.Error(t, err, "StaticDhcpHost.FromConfig() did NOT returned error")
+				assert.EqualError(t, err, "address 2001:db8::zz: invalid IPv6 address", "StaticDhcpHost.FromConfig() returned an unexpected error")
+			},
+		},
+		{
+			name:   "InvalidWildcardMacAddress",
+			config: InvalidWildcardMacConfig,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.Error(t, err, "StaticDhcpHost.FromConfig() did NOT returned error")
+				assert.EqualError(t, err, "address 02:04:06:*:*: invalid MAC address", "StaticDhcpHost.FromConfig() returned an unexpected error")
+			},
+		},
+		{
+			name:   "DuplicatedHostName",
+			config: DuplicatedHostNameConfig,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.Error(t, err, "StaticDhcpHost.FromConfig() did NOT returned error")
+				assert.EqualError(t, err, fmt.Sprintf(errInvalidDHCPHostConfig, DuplicatedHostNameConfig), "StaticDhcpHost.FromConfig() returned an unexpected error")
+			},
+		},
+		{
+			name:   "EmptyToken",
+			config: EmptyTokenConfig,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.Error(t, err, "StaticDhcpHost.FromConfig() did NOT returned error")
+				assert.EqualError(t, err, fmt.Sprintf(errInvalidDHCPHostConfig, EmptyTokenConfig), "StaticDhcpHost.FromConfig() returned an unexpected error")
+			},
+		},
 		{
 			name:   "InvalidIPAddress",
 			config: InvalidIPAddressConfig,
@@ -84,24 +238,32 @@ func TestStaticDhcpHostFromConfig(t *testing.T) {
 			name:   "MissingMacAddress",
 			config: MissingMacAddressConfig,
 			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
-				assert.Error(t, err, "StaticDhcpHost.FromConfig() did NOT returned error")
-				assert.EqualError(t, err, fmt.Sprintf(errInvalidDHCPHostConfig, MissingMacAddressConfig), "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.NoError(t, err, "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.True(t, (&StaticDhcpHost{IPAddress: net.ParseIP("192.168.0.199"), HostName: "lap"}).Equal(*host), "StaticDhcpHost.FromConfig() has generated an unexpected host")
 			},
 		},
 		{
 			name:   "MissingIPAddress",
 			config: MissingIPAddressConfig,
 			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
-				assert.Error(t, err, "StaticDhcpHost.FromConfig() did NOT returned error")
-				assert.EqualError(t, err, fmt.Sprintf(errInvalidDHCPHostConfig, MissingIPAddressConfig), "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.NoError(t, err, "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.Equal(t, &StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), HostName: "Foo"}, host, "StaticDhcpHost.FromConfig() has generated an unexpected host")
 			},
 		},
 		{
 			name:   "MissingHostName",
 			config: MissingHostNameConfig,
+			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.Equal(t, &StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1")}, host, "StaticDhcpHost.FromConfig() has generated an unexpected host")
+			},
+		},
+		{
+			name:   "MissingIdentifier",
+			config: MissingIdentifierConfig,
 			assert: func(t *testing.T, host *StaticDhcpHost, err error) {
 				assert.Error(t, err, "StaticDhcpHost.FromConfig() did NOT returned error")
-				assert.EqualError(t, err, fmt.Sprintf(errInvalidDHCPHostConfig, MissingHostNameConfig), "StaticDhcpHost.FromConfig() returned an unexpected error")
+				assert.EqualError(t, err, fmt.Sprintf(errInvalidDHCPHostConfig, MissingIdentifierConfig), "StaticDhcpHost.FromConfig() returned an unexpected error")
 			},
 		},
 	}
@@ -130,36 +292,82 @@ func TestStaticDhcpHostToConfig(t *testing.T) {
 			},
 		},
 		{
-			name: "MissingMacAddress",
-			host: StaticDhcpHost{IPAddress: net.ParseIP("1.1.1.1"), HostName: "FooBar"},
+			name: "FullSyntax",
+			host: FullHost,
+			assert: func(t *testing.T, config string, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.ToConfig() returned an unexpected error")
+				assert.Equal(t, FullHostConfig, config, "StaticDhcpHost.ToConfig() returned an unexpected config string")
+			},
+		},
+		{
+			name: "IPv6Only",
+			host: IPv6OnlyHost,
+			assert: func(t *testing.T, config string, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.ToConfig() returned an unexpected error")
+				assert.Equal(t, IPv6OnlyHostConfig, config, "StaticDhcpHost.ToConfig() returned an unexpected config string")
+			},
+		},
+		{
+			name: "DualStack",
+			host: DualStackHost,
+			assert: func(t *testing.T, config string, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.ToConfig() returned an unexpected error")
+				assert.Equal(t, DualStackHostConfig, config, "StaticDhcpHost.ToConfig() returned an unexpected config string")
+			},
+		},
+		{
+			name: "IPv6AddressAsIPv4",
+			host: StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("2001:db8::10"), HostName: "Foo"},
 			assert: func(t *testing.T, config string, err error) {
 				assert.Error(t, err, "StaticDhcpHost.ToConfig() did NOT returned an error")
-				assert.ErrorIs(t, err, ErrDHCPHostMissingMACAddress, "StaticDhcpHost.ToConfig returned an unexpected error")
+				assert.ErrorIs(t, err, ErrDHCPHostInvalidIPv4Address, "StaticDhcpHost.ToConfig returned an unexpected error")
 			},
 		},
 		{
-			name: "MissingIPAddress",
-			host: StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:ab:cd:ef"), HostName: "FooBar"},
+			name: "IPv4AddressAsIPv6",
+			host: StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPv6Address: net.ParseIP("1.1.1.1"), HostName: "Foo"},
 			assert: func(t *testing.T, config string, err error) {
 				assert.Error(t, err, "StaticDhcpHost.ToConfig() did NOT returned an error")
-				assert.ErrorIs(t, err, ErrDHCPHostMissingIPAddress, "StaticDhcpHost.ToConfig returned an unexpected error")
+				assert.ErrorIs(t, err, ErrDHCPHostInvalidIPv6Address, "StaticDhcpHost.ToConfig returned an unexpected error")
+			},
+		},
+		{
+			name: "IgnoredHost",
+			host: StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), Ignore: true},
+			assert: func(t *testing.T, config string, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.ToConfig() returned an unexpected error")
+				assert.Equal(t, IgnoredHostConfig, config, "StaticDhcpHost.ToConfig() returned an unexpected config string")
+			},
+		},
+		{
+			name: "MissingMacAddress",
+			host: StaticDhcpHost{IPAddress: net.ParseIP("192.168.0.199"), HostName: "lap"},
+			assert: func(t *testing.T, config string, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.ToConfig() returned an unexpected error")
+				assert.Equal(t, "dhcp-host=192.168.0.199,lap", config, "StaticDhcpHost.ToConfig() returned an unexpected config string")
+			},
+		},
+		{
+			name: "MissingIPAddress",
+			host: StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), HostName: "Foo"},
+			assert: func(t *testing.T, config string, err error) {
+				assert.NoError(t, err, "StaticDhcpHost.ToConfig() returned an unexpected error")
+				assert.Equal(t, MissingIPAddressConfig, config, "StaticDhcpHost.ToConfig() returned an unexpected config string")
 			},
 		},
 		{
 			name: "MissingHostName",
-			host: StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:ab:cd:ef"), IPAddress: net.ParseIP("1.1.1.1")},
+			host: StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1")},
 			assert: func(t *testing.T, config string, err error) {
-				assert.Error(t, err, "StaticDhcpHost.ToConfig() did NOT returned an error")
-				assert.ErrorIs(t, err, ErrDHCPHostMissingHostName, "StaticDhcpHost.ToConfig returned an unexpected error")
+				assert.NoError(t, err, "StaticDhcpHost.ToConfig() returned an unexpected error")
+				assert.Equal(t, MissingHostNameConfig, config, "StaticDhcpHost.ToConfig() returned an unexpected config string")
 			},
 		},
 		{
 			name: "EmptyHost",
 			assert: func(t *testing.T, config string, err error) {
 				assert.Error(t, err, "StaticDhcpHost.ToConfig() did NOT returned an error")
-				assert.ErrorIs(t, err, ErrDHCPHostMissingMACAddress, "StaticDhcpHost.ToConfig returned an unexpected error")
-				assert.ErrorIs(t, err, ErrDHCPHostMissingIPAddress, "StaticDhcpHost.ToConfig returned an unexpected error")
-				assert.ErrorIs(t, err, ErrDHCPHostMissingHostName, "StaticDhcpHost.ToConfig returned an unexpected error")
+				assert.ErrorIs(t, err, ErrDHCPHostMissingIdentifier, "StaticDhcpHost.ToConfig returned an unexpected error")
 			},
 		},
 	}
@@ -172,6 +380,64 @@ func TestStaticDhcpHostToConfig(t *testing.T) {
 	}
 }
 
+func TestStaticDhcpHostConfigRoundTrip(t *testing.T) {
+	configs := []string{
+		ValidHostConfig,
+		FullHostConfig,
+		IgnoredHostConfig,
+		ClientIDHostConfig,
+		`dhcp-host=11:22:33:*:*:*,set:iot,ignore`,
+		`dhcp-host=02:04:06:aa:bb:cc,tag:!known,[::10],Bar,45m`,
+		`dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,[2001:db8::10],Foo,3600`,
+		`dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo,set:red`,
+		`dhcp-host=Foo,id:*,02-04-06-AA-BB-CC,tag:lan,1.1.1.1`,
+		`dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,1234,12h`,
+	}
+
+	for _, config := range configs {
+		t.Run(config, func(t *testing.T) {
+			host := StaticDhcpHost{}
+			assert.NoError(t, host.FromConfig(config), "StaticDhcpHost.FromConfig() returned an unexpected error")
+
+			output, err := host.ToConfig()
+			assert.NoError(t, err, "StaticDhcpHost.ToConfig() returned an unexpected error")
+			assert.Equal(t, config, output, "StaticDhcpHost config did not survive the round trip")
+		})
+	}
+}
+
+func TestStaticDhcpHostChangedToConfig(t *testing.T) {
+	host := StaticDhcpHost{}
+	assert.NoError(t, host.FromConfig(`dhcp-host=02-04-06-AA-BB-CC,1.1.1.1,Foo,set:red`), "StaticDhcpHost.FromConfig() returned an unexpected error")
+
+	host.Metadata.Owner = "alice"
+	config, err := host.ToConfig()
+	assert.NoError(t, err, "StaticDhcpHost.ToConfig() returned an unexpected error")
+	assert.Equal(t, `dhcp-host=02-04-06-AA-BB-CC,1.1.1.1,Foo,set:red`, config, "the metadata must not change the line")
+
+	host.HostName = "Bar"
+	config, err = host.ToConfig()
+	assert.NoError(t, err, "StaticDhcpHost.ToConfig() returned an unexpected error")
+	assert.Equal(t, `dhcp-host=02:04:06:aa:bb:cc,set:red,1.1.1.1,Bar`, config, "a changed host must be written in the documented order")
+}
+
+func TestStaticDhcpHostHasMacAddress(t *testing.T) {
+	assert.True(t, FullHost.HasMacAddress(tests.ParseMAC("02:04:06:aa:bb:cc")))
+	assert.True(t, FullHost.HasMacAddress(tests.ParseMAC("02:04:06:dd:ee:ff")))
+	assert.False(t, FullHost.HasMacAddress(tests.ParseMAC("11:22:33:44:55:66")), "wildcards must not match as a host MAC address")
+	assert.False(t, ValidHost.HasMacAddress(tests.ParseMAC("02:04:06:dd:ee:ff")))
+}
+
+func TestStaticDhcpHostHasIPAddress(t *testing.T) {
+	assert.True(t, DualStackHost.HasIPAddress(net.ParseIP("1.1.1.1")))
+	assert.True(t, DualStackHost.HasIPAddress(net.ParseIP("2001:db8::10")))
+	assert.True(t, DualStackHost.HasIPAddress(net.ParseIP("2001:0db8:0000::0010")), "IPv6 addresses must match regardless of their notation")
+	assert.False(t, DualStackHost.HasIPAddress(net.ParseIP("2001:db8::11")))
+	assert.False(t, IPv6OnlyHost.HasIPAddress(nil), "a missing address must not match a host without IPv4 address")
+	assert.Equal(t, []net.IP{net.ParseIP("1.1.1.1"), net.ParseIP("2001:db8::10")}, DualStackHost.IPAddresses())
+	assert.Equal(t, []net.IP{net.ParseIP("2001:db8::10")}, IPv6OnlyHost.IPAddresses())
+}
+
 func TestStaticDhcpHostEqual(t *testing.T) {
 	testCases := []struct {
 		name   string
@@ -209,6 +475,12 @@ func TestStaticDhcpHostEqual(t *testing.T) {
 			b:      StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "Bar"},
 			result: false,
 		},
+		{
+			name:   "DifferentTags",
+			a:      StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "Foo", SetTags: []string{"red"}},
+			b:      StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "Foo", SetTags: []string{"blue"}},
+			result: false,
+		},
 		{
 			name:   "AllDifferent",
 			a:      StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "Foo"},
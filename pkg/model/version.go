@@ -0,0 +1,97 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+)
+
+// Version identifies the content of the host as it is written to the static hosts file, so it changes whenever
+// either its dnsmasq entry or its metadata change. A host that can't be rendered (i.e. an invalid one, which
+// never reaches the static hosts file) is identified by its metadata only.
+func (h *StaticDhcpHost) Version() string {
+	config, _ := h.ToConfig()
+	comment, _ := h.Metadata.ToComment()
+
+	hash := sha256.New()
+	fmt.Fprintln(hash, config)
+	fmt.Fprintln(hash, comment)
+	return version(hash.Sum(nil))
+}
+
+// HostsVersion identifies the content of a list of hosts, including their order. It hashes the versions of the
+// hosts, so that a collection never has the same version as a host.
+func HostsVersion(hosts []StaticDhcpHost) string {
+	hash := sha256.New()
+	for _, h := range hosts {
+		fmt.Fprintln(hash, h.Version())
+	}
+	return version(hash.Sum(nil))
+}
+
+// version shortens a hash to 128 bits, which is enough to tell apart the contents of a static hosts file.
+func version(sum []byte) string {
+	return hex.EncodeToString(sum[:16])
+}
+
+// Versions are the versions of a host (see StaticDhcpHost.Version), or of the whole collection (see
+// HostsVersion), a change is based on. The change only proceeds if the current version is one of them, while
+// no versions at all skip the check.
+type Versions []string
+
+// AnyVersion matches whatever the current version is, as long as the host exists
+const AnyVersion = "*"
+
+// VersionMismatchError is returned when a host, or the collection, was changed since the versions a change is
+// based on.
+type VersionMismatchError struct {
+	// Current version, empty when the host does not exist
+	Current string
+}
+
+const versionMismatchErrorMessage = "version mismatch, the current version is %q"
+
+func (e *VersionMismatchError) Error() string {
+	return fmt.Sprintf(versionMismatchErrorMessage, e.Current)
+}
+
+func (e *VersionMismatchError) Is(target error) bool {
+	return target == errkind.ErrVersionMismatch
+}
+
+// IsEmpty reports whether there are no versions to check.
+func (v Versions) IsEmpty() bool {
+	return len(v) == 0
+}
+
+// Check ensures that the host, nil when it does not exist, is at one of the versions.
+func (v Versions) Check(host *StaticDhcpHost) error {
+	if v.IsEmpty() {
+		return nil
+	}
+	if host == nil {
+		return &VersionMismatchError{}
+	}
+
+	return v.match(host.Version())
+}
+
+// CheckAll ensures that the collection of hosts is at one of the versions.
+func (v Versions) CheckAll(hosts []StaticDhcpHost) error {
+	if v.IsEmpty() {
+		return nil
+	}
+
+	return v.match(HostsVersion(hosts))
+}
+
+func (v Versions) match(current string) error {
+	if slices.Contains(v, AnyVersion) || slices.Contains(v, current) {
+		return nil
+	}
+
+	return &VersionMismatchError{Current: current}
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"net"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticDhcpHostVersion(t *testing.T) {
+	foo := StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "Foo"}
+	bar := StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:dd:ee:ff"), IPAddress: net.ParseIP("1.1.1.2"), HostName: "Bar"}
+
+	// The version only depends on what is written to the static hosts file
+	sameFoo := foo
+	sameFoo.IPAddress = net.ParseIP("1.1.1.1").To4()
+	sameFoo.Metadata.Labels = []string{}
+	assert.Equal(t, foo.Version(), sameFoo.Version(), "Version() changed for the same host")
+	assert.NotEqual(t, foo.Version(), bar.Version(), "Version() did not change for a different host")
+
+	changedFoo := foo
+	changedFoo.Metadata.Owner = "alice"
+	assert.NotEqual(t, foo.Version(), changedFoo.Version(), "Version() did not change for different metadata")
+
+	assert.Len(t, foo.Version(), 32, "Version() returned an unexpected version")
+	assert.Equal(t, HostsVersion([]StaticDhcpHost{foo, bar}), HostsVersion([]StaticDhcpHost{sameFoo, bar}), "HostsVersion() changed for the same hosts")
+	assert.NotEqual(t, HostsVersion([]StaticDhcpHost{foo, bar}), HostsVersion([]StaticDhcpHost{bar, foo}), "HostsVersion() did not change for a different order")
+	assert.NotEqual(t, HostsVersion([]StaticDhcpHost{foo}), HostsVersion([]StaticDhcpHost{foo, bar}), "HostsVersion() did not change for different hosts")
+	assert.NotEqual(t, HostsVersion(nil), HostsVersion([]StaticDhcpHost{foo}), "HostsVersion() did not change for different hosts")
+}
+
+func TestVersionsCheck(t *testing.T) {
+	foo := StaticDhcpHost{MacAddress: tests.ParseMAC("02:04:06:aa:bb:cc"), IPAddress: net.ParseIP("1.1.1.1"), HostName: "Foo"}
+	hosts := []StaticDhcpHost{foo}
+
+	testCases := []struct {
+		name        string
+		versions    Versions
+		host        *StaticDhcpHost
+		expectError bool
+	}{
+		{name: "NoVersions", versions: nil, host: &foo},
+		{name: "NoVersionsMissingHost", versions: nil, host: nil},
+		{name: "CurrentVersion", versions: Versions{"0123", foo.Version()}, host: &foo},
+		{name: "AnyVersion", versions: Versions{AnyVersion}, host: &foo},
+		{name: "OtherVersion", versions: Versions{"0123"}, host: &foo, expectError: true},
+		{name: "MissingHost", versions: Versions{AnyVersion}, host: nil, expectError: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.versions.Check(test.host)
+			if !test.expectError {
+				assert.NoError(t, err, "Versions.Check() returned an unexpected error")
+				return
+			}
+			var mismatchErr *VersionMismatchError
+			assert.ErrorAs(t, err, &mismatchErr, "Versions.Check() returned an unexpected error")
+			if test.host != nil {
+				assert.Equal(t, test.host.Version(), mismatchErr.Current, "Versions.Check() returned an unexpected current version")
+			}
+		})
+	}
+
+	assert.NoError(t, Versions{HostsVersion(hosts)}.CheckAll(hosts), "Versions.CheckAll() returned an unexpected error")
+	assert.NoError(t, Versions{}.CheckAll(hosts), "Versions.CheckAll() returned an unexpected error")
+	assert.Error(t, Versions{foo.Version()}.CheckAll(hosts), "Versions.CheckAll() did NOT returned an error")
+}
@@ -0,0 +1,181 @@
+package oui
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// Regenerates the embedded table out of the IEEE registry downloaded from
+// https://standards-oui.ieee.org/oui/oui.csv (the MA-M and MA-S registries, mam.csv and oui36.csv, can be
+// concatenated to it)
+//go:generate go run ../../cmd/oui-generate -input oui.csv -output vendors.csv
+
+// The embedded table is only a sample of common vendors, since the IEEE registries are several megabytes. The
+// deployments that need every vendor point host.vendors.file (DMM_HOST_VENDORS_FILE) at the downloaded oui.csv, or
+// regenerate the table before building.
+//
+//go:embed vendors.csv
+var embedded []byte
+
+// CSV columns, as named by the IEEE registry files
+const (
+	registryColumn     = "Registry"
+	assignmentColumn   = "Assignment"
+	organizationColumn = "Organization Name"
+)
+
+// Company IDs are not used in MAC addresses, they only identify organizations
+const companyIDRegistry = "CID"
+
+// Lengths, in hex digits, of the MA-L (OUI), MA-M and MA-S assignments, longest first
+var assignmentLengths = []int{9, 7, 6}
+
+// Bit of the first byte of the locally administered MAC addresses (e.g. randomized ones), which have no vendor
+const localBit = 0x02
+
+var ErrMalformedCSV = errors.New("malformed OUI CSV")
+
+// Database maps the IEEE assignments (OUIs) to the name of their vendors.
+type Database struct {
+	// Vendor names by the uppercase hex digits of the assignments
+	vendors map[string]string
+}
+
+// Read parses an IEEE registry CSV (e.g. oui.csv), or a table written by Database.Write. The columns are found
+// by their names, so the registry files of every assignment size can be read.
+func Read(r io.Reader) (*Database, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedCSV, err)
+	}
+	// Spreadsheet exports may start with a byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	registry := slices.Index(header, registryColumn)
+	assignment := slices.Index(header, assignmentColumn)
+	organization := slices.Index(header, organizationColumn)
+	if assignment < 0 || organization < 0 {
+		return nil, fmt.Errorf("%w: missing the %q or %q column", ErrMalformedCSV, assignmentColumn, organizationColumn)
+	}
+
+	database := &Database{vendors: map[string]string{}}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedCSV, err)
+		}
+		if len(record) <= max(assignment, organization) {
+			return nil, fmt.Errorf("%w: short record %q", ErrMalformedCSV, strings.Join(record, ","))
+		}
+		if registry >= 0 && registry < len(record) && record[registry] == companyIDRegistry {
+			continue
+		}
+
+		prefix := strings.ToUpper(strings.TrimSpace(record[assignment]))
+		if !slices.Contains(assignmentLengths, len(prefix)) || strings.Trim(prefix, "0123456789ABCDEF") != "" {
+			return nil, fmt.Errorf("%w: invalid assignment %q", ErrMalformedCSV, record[assignment])
+		}
+		if vendor := strings.TrimSpace(record[organization]); vendor != "" {
+			database.vendors[prefix] = vendor
+		}
+	}
+
+	return database, nil
+}
+
+// Write writes the database as a CSV table, sorted by assignment, that Read can parse.
+func (d *Database) Write(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{assignmentColumn, organizationColumn}); err != nil {
+		return err
+	}
+
+	prefixes := make([]string, 0, len(d.vendors))
+	for prefix := range d.vendors {
+		prefixes = append(prefixes, prefix)
+	}
+	slices.Sort(prefixes)
+	for _, prefix := range prefixes {
+		if err := writer.Write([]string{prefix, d.vendors[prefix]}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// Merge returns a database with the assignments of every given one, the later databases winning.
+func Merge(databases ...*Database) *Database {
+	merged := &Database{vendors: map[string]string{}}
+	for _, database := range databases {
+		maps.Copy(merged.vendors, database.vendors)
+	}
+	return merged
+}
+
+// Len returns the number of assignments of the database.
+func (d *Database) Len() int {
+	return len(d.vendors)
+}
+
+// Vendor returns the name of the vendor the MAC address was assigned to, the most specific assignment (MA-S,
+// then MA-M and finally MA-L) winning. It is empty for unknown and locally administered addresses.
+func (d *Database) Vendor(mac net.HardwareAddr) string {
+	if len(mac) == 0 || mac[0]&localBit != 0 {
+		return ""
+	}
+
+	digits := strings.ToUpper(hex.EncodeToString(mac))
+	for _, length := range assignmentLengths {
+		if len(digits) < length {
+			continue
+		}
+		if vendor, ok := d.vendors[digits[:length]]; ok {
+			return vendor
+		}
+	}
+
+	return ""
+}
+
+var defaultDatabase atomic.Pointer[Database]
+
+func init() {
+	database, err := Read(bytes.NewReader(embedded))
+	if err != nil {
+		panic(fmt.Sprintf("embedded OUI table: %s", err.Error()))
+	}
+	defaultDatabase.Store(database)
+}
+
+// Default returns the database used by Vendor, the embedded one unless replaced by SetDefault.
+func Default() *Database {
+	return defaultDatabase.Load()
+}
+
+// SetDefault replaces the database used by Vendor, e.g. by one read at startup from a newer IEEE registry.
+func SetDefault(database *Database) {
+	defaultDatabase.Store(database)
+}
+
+// Vendor returns the name of the vendor of the MAC address, as found in the default database.
+func Vendor(mac net.HardwareAddr) string {
+	return Default().Vendor(mac)
+}
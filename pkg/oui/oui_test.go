@@ -0,0 +1,98 @@
+package oui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const RegistryCSV = "\ufeffRegistry,Assignment,Organization Name,Organization Address\n" +
+	"MA-L,240AC4,Espressif Inc.,\"Room 204, Building 2, 690 Bibo Rd, Pudong New Area Shanghai  CN 201203 \"\n" +
+	"MA-L,70B3D5,IEEE Registration Authority,\"445 Hoes Lane Piscataway NJ US 08554 \"\n" +
+	"MA-M,70B3D51,Foo Medical Devices,\"1 Foo Street Foo City US 12345 \"\n" +
+	"MA-S,70B3D51F2,Bar Sensors GmbH,\"2 Bar Street Bar City DE 54321 \"\n" +
+	"CID,0A1B2C,Baz Company ID,\"3 Baz Street Baz City US 00000 \"\n" +
+	"MA-L,000393,\"Apple, Inc.\",1 Infinite Loop Cupertino CA US 95014 \n"
+
+func TestDatabaseVendor(t *testing.T) {
+	database, err := Read(strings.NewReader(RegistryCSV))
+	require.NoError(t, err, "Read() returned an unexpected error")
+	assert.Equal(t, 5, database.Len(), "Read() returned an unexpected number of assignments")
+
+	testCases := []struct {
+		name           string
+		mac            string
+		expectedVendor string
+	}{
+		{name: "MA-L", mac: "24:0a:c4:12:34:56", expectedVendor: "Espressif Inc."},
+		{name: "QuotedName", mac: "00:03:93:aa:bb:cc", expectedVendor: "Apple, Inc."},
+		{name: "MA-M", mac: "70:b3:d5:1a:bb:cc", expectedVendor: "Foo Medical Devices"},
+		{name: "MA-S", mac: "70:b3:d5:1f:2b:cc", expectedVendor: "Bar Sensors GmbH"},
+		{name: "ParentAssignment", mac: "70:b3:d5:ff:bb:cc", expectedVendor: "IEEE Registration Authority"},
+		{name: "CompanyID", mac: "08:1b:2c:dd:ee:ff", expectedVendor: ""},
+		{name: "LocallyAdministered", mac: "26:0a:c4:12:34:56", expectedVendor: ""},
+		{name: "Unknown", mac: "00:11:22:33:44:55", expectedVendor: ""},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedVendor, database.Vendor(tests.ParseMAC(test.mac)), "Vendor() returned an unexpected vendor")
+		})
+	}
+}
+
+func TestDatabaseWrite(t *testing.T) {
+	database, err := Read(strings.NewReader(RegistryCSV))
+	require.NoError(t, err, "Read() returned an unexpected error")
+
+	var table bytes.Buffer
+	require.NoError(t, database.Write(&table), "Write() returned an unexpected error")
+	assert.Equal(t, "Assignment,Organization Name\n"+
+		"000393,\"Apple, Inc.\"\n"+
+		"240AC4,Espressif Inc.\n"+
+		"70B3D5,IEEE Registration Authority\n"+
+		"70B3D51,Foo Medical Devices\n"+
+		"70B3D51F2,Bar Sensors GmbH\n", table.String(), "Write() wrote an unexpected table")
+
+	written, err := Read(&table)
+	require.NoError(t, err, "Read() could not read the written table")
+	assert.Equal(t, database, written, "Read() returned a different database")
+
+	merged := Merge(written, &Database{vendors: map[string]string{"240AC4": "Espressif Systems"}})
+	assert.Equal(t, "Espressif Systems", merged.Vendor(tests.ParseMAC("24:0a:c4:12:34:56")), "Merge() did not keep the last vendor")
+	assert.Equal(t, 5, merged.Len(), "Merge() returned an unexpected number of assignments")
+}
+
+func TestReadMalformed(t *testing.T) {
+	testCases := []struct {
+		name string
+		csv  string
+	}{
+		{name: "Empty", csv: ""},
+		{name: "MissingColumns", csv: "Registry,Assignment\nMA-L,240AC4\n"},
+		{name: "InvalidAssignment", csv: "Assignment,Organization Name\n240AC,Espressif Inc.\n"},
+		{name: "NonHexAssignment", csv: "Assignment,Organization Name\n240ACG,Espressif Inc.\n"},
+		{name: "ShortRecord", csv: "Assignment,Organization Name\n240AC4\n"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(test.csv))
+			assert.ErrorIs(t, err, ErrMalformedCSV, "Read() returned an unexpected error")
+		})
+	}
+}
+
+func TestDefaultDatabase(t *testing.T) {
+	assert.Equal(t, "Raspberry Pi Foundation", Vendor(tests.ParseMAC("b8:27:eb:12:34:56")), "Vendor() returned an unexpected vendor")
+
+	embedded := Default()
+	defer SetDefault(embedded)
+
+	SetDefault(&Database{vendors: map[string]string{"B827EB": "Raspberry Pi"}})
+	assert.Equal(t, "Raspberry Pi", Vendor(tests.ParseMAC("b8:27:eb:12:34:56")), "SetDefault() did not replace the database")
+}
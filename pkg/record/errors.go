@@ -0,0 +1,39 @@
+package record
+
+import (
+	"fmt"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// DuplicatedEntryError is returned when there is already a record with the key of a new record.
+type DuplicatedEntryError struct {
+	// The record that already has the key
+	Record *model.ResourceRecord
+}
+
+const duplicatedEntryErrorMessage = "Duplicated %s record: %s"
+
+func (e DuplicatedEntryError) Error() string {
+	return fmt.Sprintf(duplicatedEntryErrorMessage, e.Record.Type, e.Record.Name)
+}
+
+func (e DuplicatedEntryError) Is(target error) bool {
+	return target == errkind.ErrDuplicate
+}
+
+// NotFoundError is returned when the record to be changed does not exist.
+type NotFoundError struct {
+	Key model.ResourceRecordKey
+}
+
+const notFoundErrorMessage = "No %s record found with name: %s"
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf(notFoundErrorMessage, e.Key.Type, e.Key.Name)
+}
+
+func (e NotFoundError) Is(target error) bool {
+	return target == errkind.ErrNotFound
+}
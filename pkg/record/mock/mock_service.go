@@ -0,0 +1,44 @@
+package recordmock
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type ServiceMock struct {
+	mock.Mock
+}
+
+func (m *ServiceMock) Insert(record *model.ResourceRecord) error {
+	args := m.Called(record)
+	return args.Error(0)
+}
+
+func (m *ServiceMock) Update(key model.ResourceRecordKey, record *model.ResourceRecord) error {
+	args := m.Called(key, record)
+	return args.Error(0)
+}
+
+func (m *ServiceMock) FetchAll(recordType string) (*[]model.ResourceRecord, error) {
+	args := m.Called(recordType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*[]model.ResourceRecord), args.Error(1)
+}
+
+func (m *ServiceMock) FetchByKey(key model.ResourceRecordKey) (*model.ResourceRecord, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ResourceRecord), args.Error(1)
+}
+
+func (m *ServiceMock) RemoveByKey(key model.ResourceRecordKey) (*model.ResourceRecord, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ResourceRecord), args.Error(1)
+}
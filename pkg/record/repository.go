@@ -0,0 +1,122 @@
+package record
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+)
+
+type Repository interface {
+	DeleteByKey(key model.ResourceRecordKey) (*model.ResourceRecord, error)
+	FindAll() (*[]model.ResourceRecord, error)
+	// FindByKey looks the record up regardless of the case, as DNS names are
+	FindByKey(key model.ResourceRecordKey) (*model.ResourceRecord, error)
+	Save(record *model.ResourceRecord) error
+	// Transaction runs fn as a single unit of work, holding the exclusive lock of the records file. The changes
+	// made by fn are written at once when it returns nil, and thrown away otherwise.
+	Transaction(fn func(tx model.ResourceRecordTransaction) error) error
+}
+
+type repository struct {
+	document *storage.DocumentRepository[model.ResourceRecord]
+}
+
+// NewRepository returns the Repository of the SRV, MX, TXT and PTR records kept in the given file, which is created
+// by the first change when it does not exist yet.
+func NewRepository(recordsFilePath string, options storage.Options) Repository {
+	return &repository{
+		document: storage.NewDocumentRepository(recordsFilePath, options, storage.EntryHooks[model.ResourceRecord]{
+			Name: "resource record",
+			Is:   model.IsResourceRecord,
+			Parse: func(line string) (model.ResourceRecord, error) {
+				record := model.ResourceRecord{}
+				err := record.FromConfig(line)
+				return record, err
+			},
+			Format: (*model.ResourceRecord).ToConfig,
+			Keys: func(record *model.ResourceRecord) []string {
+				return []string{record.Key().String()}
+			},
+		}),
+	}
+}
+
+func (r *repository) FindAll() (*[]model.ResourceRecord, error) {
+	entries, err := r.document.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	records := entries.All()
+	return &records, nil
+}
+
+func (r *repository) FindByKey(key model.ResourceRecordKey) (*model.ResourceRecord, error) {
+	entries, err := r.document.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	_, record := entries.Lookup(key.String())
+	return record, nil
+}
+
+func (r *repository) Save(record *model.ResourceRecord) error {
+	return r.Transaction(func(tx model.ResourceRecordTransaction) error {
+		return tx.Save(record)
+	})
+}
+
+// DeleteByKey removes the record with the given key, returning it even if the change could not be written.
+func (r *repository) DeleteByKey(key model.ResourceRecordKey) (*model.ResourceRecord, error) {
+	var deleted *model.ResourceRecord
+	err := r.Transaction(func(tx model.ResourceRecordTransaction) error {
+		var err error
+		deleted, err = tx.DeleteByKey(key)
+		return err
+	})
+
+	return deleted, err
+}
+
+func (r *repository) Transaction(fn func(tx model.ResourceRecordTransaction) error) error {
+	return r.document.Transaction(func(tx *storage.DocumentTransaction[model.ResourceRecord]) error {
+		return fn(&fileTransaction{tx})
+	})
+}
+
+// fileTransaction looks the records up by key in a storage.DocumentTransaction.
+type fileTransaction struct {
+	*storage.DocumentTransaction[model.ResourceRecord]
+}
+
+func (tx *fileTransaction) FindAll() (*[]model.ResourceRecord, error) {
+	records := tx.Entries().All()
+	return &records, nil
+}
+
+func (tx *fileTransaction) FindByKey(key model.ResourceRecordKey) (*model.ResourceRecord, error) {
+	_, record := tx.Entries().Lookup(key.String())
+	return record, nil
+}
+
+func (tx *fileTransaction) Save(record *model.ResourceRecord) error {
+	return tx.Append(record)
+}
+
+func (tx *fileTransaction) ReplaceByKey(key model.ResourceRecordKey, record *model.ResourceRecord) error {
+	i, _ := tx.Entries().Lookup(key.String())
+	if i < 0 {
+		return nil
+	}
+
+	return tx.Replace(i, record)
+}
+
+func (tx *fileTransaction) DeleteByKey(key model.ResourceRecordKey) (*model.ResourceRecord, error) {
+	i, record := tx.Entries().Lookup(key.String())
+	if record == nil {
+		return nil, nil
+	}
+
+	return record, tx.Remove(i)
+}
@@ -0,0 +1,148 @@
+package record
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var AllRecords = []model.ResourceRecord{
+	{Type: model.ResourceRecordTypeSrv, Name: "_ldap._tcp.lan", Target: "ldap1.lan", Port: 389, Priority: 10, Weight: 50},
+	{Type: model.ResourceRecordTypeSrv, Name: "_ldap._tcp.lan", Target: "ldap2.lan", Port: 389, Priority: 20},
+	{Type: model.ResourceRecordTypeMx, Name: "example.lan", Target: "mail.example.lan", Priority: 10},
+	{Type: model.ResourceRecordTypeTxt, Name: "example.lan", Text: []string{"v=spf1 a -all"}},
+	{Type: model.ResourceRecordTypePtr, Name: "10.1.168.192.in-addr.arpa", Target: "nas.lan"},
+}
+
+var NewRecord = model.ResourceRecord{Type: model.ResourceRecordTypeMx, Name: "example.lan", Target: "backup-mail.example.lan", Priority: 20}
+
+const (
+	AllRecordsFileContent = `# Service discovery
+srv-host=_ldap._tcp.lan,ldap1.lan,389,10,50
+srv-host=_ldap._tcp.lan,ldap2.lan,389,20
+mx-host=example.lan,mail.example.lan,10
+cname=files.lan,nas.lan
+txt-record=example.lan,"v=spf1 a -all"
+ptr-record=10.1.168.192.in-addr.arpa,nas.lan
+`
+	AddedRecordFileContent = AllRecordsFileContent + `mx-host=example.lan,backup-mail.example.lan,20
+`
+	DeletedRecordFileContent = `# Service discovery
+srv-host=_ldap._tcp.lan,ldap2.lan,389,20
+mx-host=example.lan,mail.example.lan,10
+cname=files.lan,nas.lan
+txt-record=example.lan,"v=spf1 a -all"
+ptr-record=10.1.168.192.in-addr.arpa,nas.lan
+`
+	InvalidRecordFileContent = `srv-host=_ldap._tcp.lan,ldap1.lan,389,10,50
+srv-host=_ldap._tcp.lan,ldap2.lan,389,20
+mx-host=example.lan,mail.example.lan,10
+txt-record=example.lan,"v=spf1 a -all"
+ptr-record=10.1.168.192.in-addr.arpa,nas.lan
+txt-record=broken.lan,"v=spf1
+`
+)
+
+func setUpRecordsFile(t *testing.T, content string) string {
+	fileName := filepath.Join(t.TempDir(), "07-dns-records.conf")
+	require.NoError(t, os.WriteFile(fileName, []byte(content), 0644), "Failed to initialize the records file")
+	return fileName
+}
+
+func assertFileContent(t *testing.T, expectedFileContent string, fileName string) {
+	actualFileData, err := os.ReadFile(fileName)
+	require.NoError(t, err, "Failed to open test file for validation")
+	assert.Equal(t, expectedFileContent, string(actualFileData), "Records file doesn't match")
+}
+
+func TestRecordRepositoryFind(t *testing.T) {
+	fileName := setUpRecordsFile(t, AllRecordsFileContent)
+	repository := NewRepository(fileName, storage.Options{})
+
+	records, err := repository.FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.Equal(t, AllRecords, *records, "FindAll() returned unexpected records")
+
+	record, err := repository.FindByKey(model.ResourceRecordKey{Type: model.ResourceRecordTypeSrv, Name: "_LDAP._tcp.lan", Target: "LDAP2.lan", Port: 389})
+	assert.NoError(t, err, "FindByKey() returned an unexpected error")
+	assert.Equal(t, &AllRecords[1], record, "FindByKey() returned an unexpected record")
+
+	record, err = repository.FindByKey(model.ResourceRecordKey{Type: model.ResourceRecordTypeTxt, Name: "example.lan"})
+	assert.NoError(t, err, "FindByKey() returned an unexpected error")
+	assert.Equal(t, &AllRecords[3], record, "FindByKey() returned an unexpected record")
+
+	record, err = repository.FindByKey(model.ResourceRecordKey{Type: model.ResourceRecordTypeSrv, Name: "_ldap._tcp.lan", Target: "ldap2.lan", Port: 636})
+	assert.NoError(t, err, "FindByKey() returned an unexpected error")
+	assert.Nil(t, record, "FindByKey() found a record on another port")
+	assertFileContent(t, AllRecordsFileContent, fileName)
+}
+
+func TestRecordRepositoryMissingFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "07-dns-records.conf")
+	repository := NewRepository(fileName, storage.Options{})
+
+	records, err := repository.FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.Empty(t, *records, "FindAll() returned unexpected records")
+
+	assert.NoError(t, repository.Save(&NewRecord), "Save() returned an unexpected error")
+	assertFileContent(t, "mx-host=example.lan,backup-mail.example.lan,20", fileName)
+}
+
+func TestRecordRepositorySaveDelete(t *testing.T) {
+	fileName := setUpRecordsFile(t, AllRecordsFileContent)
+	repository := NewRepository(fileName, storage.Options{})
+
+	assert.NoError(t, repository.Save(&NewRecord), "Save() returned an unexpected error")
+	assertFileContent(t, AddedRecordFileContent, fileName)
+
+	deleted, err := repository.DeleteByKey(NewRecord.Key())
+	assert.NoError(t, err, "DeleteByKey() returned an unexpected error")
+	assert.Equal(t, &NewRecord, deleted, "DeleteByKey() returned an unexpected record")
+	assertFileContent(t, AllRecordsFileContent, fileName)
+
+	deleted, err = repository.DeleteByKey(AllRecords[0].Key())
+	assert.NoError(t, err, "DeleteByKey() returned an unexpected error")
+	assert.Equal(t, &AllRecords[0], deleted, "DeleteByKey() returned an unexpected record")
+	assertFileContent(t, DeletedRecordFileContent, fileName)
+
+	deleted, err = repository.DeleteByKey(AllRecords[0].Key())
+	assert.NoError(t, err, "DeleteByKey() returned an unexpected error")
+	assert.Nil(t, deleted, "DeleteByKey() returned an unexpected record")
+	assertFileContent(t, DeletedRecordFileContent, fileName)
+}
+
+func TestRecordRepositoryTransactionRollback(t *testing.T) {
+	fileName := setUpRecordsFile(t, AllRecordsFileContent)
+	repository := NewRepository(fileName, storage.Options{})
+	rollback := errors.New("rollback")
+
+	err := repository.Transaction(func(tx model.ResourceRecordTransaction) error {
+		if _, err := tx.DeleteByKey(AllRecords[3].Key()); err != nil {
+			return err
+		}
+		record, err := tx.FindByKey(AllRecords[3].Key())
+		assert.NoError(t, err, "FindByKey() returned an unexpected error")
+		assert.Nil(t, record, "FindByKey() did NOT see the deletion")
+		return rollback
+	})
+	assert.ErrorIs(t, err, rollback, "Transaction() returned an unexpected error")
+	assertFileContent(t, AllRecordsFileContent, fileName)
+}
+
+func TestRecordRepositoryParseMode(t *testing.T) {
+	fileName := setUpRecordsFile(t, InvalidRecordFileContent)
+
+	_, err := NewRepository(fileName, storage.Options{}).FindAll()
+	assert.Error(t, err, "FindAll() did NOT returned an error in the strict mode")
+
+	records, err := NewRepository(fileName, storage.Options{Lenient: true}).FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error in the lenient mode")
+	assert.Equal(t, AllRecords, *records, "FindAll() returned unexpected records")
+}
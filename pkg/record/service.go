@@ -0,0 +1,133 @@
+package record
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+type Service interface {
+	Insert(record *model.ResourceRecord) error
+	Update(key model.ResourceRecordKey, record *model.ResourceRecord) error
+	// FetchAll returns the records of the given type, in file order
+	FetchAll(recordType string) (*[]model.ResourceRecord, error)
+	FetchByKey(key model.ResourceRecordKey) (*model.ResourceRecord, error)
+	RemoveByKey(key model.ResourceRecordKey) (*model.ResourceRecord, error)
+}
+
+type service struct {
+	repository Repository
+	reloader   dnsmasq.Reloader
+}
+
+// NewService returns the SRV, MX, TXT and PTR records Service. The reloader is triggered after every change written
+// to the records file, so dnsmasq picks it up.
+//
+// Every error matches one of the error kinds (errkind.ErrNotFound, errkind.ErrDuplicate, errkind.ErrValidation,
+// errkind.ErrStorageUnavailable or errkind.ErrLockTimeout), except the dnsmasq.ReloadError of a change that was saved.
+func NewService(repository Repository, reloader dnsmasq.Reloader) Service {
+	return &service{
+		repository: repository,
+		reloader:   reloader,
+	}
+}
+
+// Insert adds a new record. It fails with a DuplicatedEntryError if there is already a record with its key.
+func (s *service) Insert(record *model.ResourceRecord) error {
+	if err := record.Validate(); err != nil {
+		return &errkind.ValidationError{Err: err}
+	}
+
+	err := s.repository.Transaction(func(tx model.ResourceRecordTransaction) error {
+		if err := s.checkKey(tx, record, nil); err != nil {
+			return err
+		}
+		return tx.Save(record)
+	})
+	if err != nil {
+		return errkind.Classify(err)
+	}
+
+	return s.reloader.Reload()
+}
+
+// Update replaces the existing record with the given key, which may change along with the record. It fails with a
+// NotFoundError if there is no such record, and with a DuplicatedEntryError if the new key is another record's.
+func (s *service) Update(key model.ResourceRecordKey, record *model.ResourceRecord) error {
+	if err := record.Validate(); err != nil {
+		return &errkind.ValidationError{Err: err}
+	}
+
+	err := s.repository.Transaction(func(tx model.ResourceRecordTransaction) error {
+		existing, err := tx.FindByKey(key)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return &NotFoundError{Key: key}
+		}
+
+		if err := s.checkKey(tx, record, existing); err != nil {
+			return err
+		}
+		return tx.ReplaceByKey(key, record)
+	})
+	if err != nil {
+		return errkind.Classify(err)
+	}
+
+	return s.reloader.Reload()
+}
+
+// checkKey ensures that no other record than the existing one (nil for a new record) has the key of the given one.
+func (s *service) checkKey(tx model.ResourceRecordTransaction, record *model.ResourceRecord, existing *model.ResourceRecord) error {
+	sameKeyRecord, err := tx.FindByKey(record.Key())
+	if err != nil {
+		return err
+	}
+	if sameKeyRecord != nil && (existing == nil || sameKeyRecord.Key().String() != existing.Key().String()) {
+		return &DuplicatedEntryError{Record: sameKeyRecord}
+	}
+
+	return nil
+}
+
+func (s *service) FetchAll(recordType string) (*[]model.ResourceRecord, error) {
+	records, err := s.repository.FindAll()
+	if err != nil {
+		return nil, errkind.Classify(err)
+	}
+
+	typeRecords := []model.ResourceRecord{}
+	for _, record := range *records {
+		if record.Type == recordType {
+			typeRecords = append(typeRecords, record)
+		}
+	}
+
+	return &typeRecords, nil
+}
+
+func (s *service) FetchByKey(key model.ResourceRecordKey) (*model.ResourceRecord, error) {
+	record, err := s.repository.FindByKey(key)
+	return record, errkind.Classify(err)
+}
+
+// RemoveByKey deletes the record with the given key, and reloads dnsmasq when a record was actually removed. The
+// removed record is returned even if the reload fails, since it is already gone from the records file.
+func (s *service) RemoveByKey(key model.ResourceRecordKey) (*model.ResourceRecord, error) {
+	var removed *model.ResourceRecord
+	err := s.repository.Transaction(func(tx model.ResourceRecordTransaction) error {
+		var err error
+		removed, err = tx.DeleteByKey(key)
+		return err
+	})
+	if err != nil {
+		return nil, errkind.Classify(err)
+	}
+	if removed == nil {
+		return nil, nil
+	}
+
+	return removed, s.reloader.Reload()
+}
@@ -0,0 +1,162 @@
+package record
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	dnsmasqmock "github.com/gringolito/dnsmasq-manager/pkg/dnsmasq/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	recordmock "github.com/gringolito/dnsmasq-manager/pkg/record/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var ValidRecord = model.ResourceRecord{Type: model.ResourceRecordTypeSrv, Name: "_ldap._tcp.lan", Target: "ldap.lan", Port: 389, Priority: 10}
+var MovedRecord = model.ResourceRecord{Type: model.ResourceRecordTypeSrv, Name: "_ldap._tcp.lan", Target: "ldap.lan", Port: 636, Priority: 10}
+var SameKeyRecord = model.ResourceRecord{Type: model.ResourceRecordTypeSrv, Name: "_LDAP._tcp.lan", Target: "ldap.lan", Port: 389, Priority: 20}
+var InvalidRecord = model.ResourceRecord{Type: model.ResourceRecordTypeSrv, Name: "_ldap._tcp.lan", Target: "ldap.lan"}
+
+func TestRecordServiceChanges(t *testing.T) {
+	Insert := func(service Service) error { return service.Insert(&ValidRecord) }
+	Update := func(service Service) error { return service.Update(ValidRecord.Key(), &MovedRecord) }
+	Remove := func(service Service) error {
+		record, err := service.RemoveByKey(ValidRecord.Key())
+		if err == nil && record == nil {
+			return errors.New("nothing removed")
+		}
+		return err
+	}
+
+	var testCases = []struct {
+		name        string
+		method      func(service Service) error
+		on          func(mock *recordmock.RepositoryMock)
+		expectedErr error
+	}{
+		{
+			name:   "InsertSuccess",
+			method: Insert,
+			on: func(mock *recordmock.RepositoryMock) {
+				mock.On("FindByKey", ValidRecord.Key()).Once().Return(nil, nil)
+				mock.On("Save", &ValidRecord).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "InsertDuplicatedKey",
+			method: Insert,
+			on: func(mock *recordmock.RepositoryMock) {
+				mock.On("FindByKey", ValidRecord.Key()).Once().Return(&SameKeyRecord, nil)
+			},
+			expectedErr: &DuplicatedEntryError{Record: &SameKeyRecord},
+		},
+		{
+			name:        "InsertInvalidRecord",
+			method:      func(service Service) error { return service.Insert(&InvalidRecord) },
+			on:          func(mock *recordmock.RepositoryMock) {},
+			expectedErr: &errkind.ValidationError{Err: InvalidRecord.Validate()},
+		},
+		{
+			name:   "UpdateSuccess",
+			method: Update,
+			on: func(mock *recordmock.RepositoryMock) {
+				mock.On("FindByKey", ValidRecord.Key()).Once().Return(&ValidRecord, nil)
+				mock.On("FindByKey", MovedRecord.Key()).Once().Return(nil, nil)
+				mock.On("ReplaceByKey", ValidRecord.Key(), &MovedRecord).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "UpdateNotFound",
+			method: Update,
+			on: func(mock *recordmock.RepositoryMock) {
+				mock.On("FindByKey", ValidRecord.Key()).Once().Return(nil, nil)
+			},
+			expectedErr: &NotFoundError{Key: ValidRecord.Key()},
+		},
+		{
+			name:   "UpdateDuplicatedKey",
+			method: Update,
+			on: func(mock *recordmock.RepositoryMock) {
+				mock.On("FindByKey", ValidRecord.Key()).Once().Return(&ValidRecord, nil)
+				mock.On("FindByKey", MovedRecord.Key()).Once().Return(&MovedRecord, nil)
+			},
+			expectedErr: &DuplicatedEntryError{Record: &MovedRecord},
+		},
+		{
+			name:   "RemoveSuccess",
+			method: Remove,
+			on: func(mock *recordmock.RepositoryMock) {
+				mock.On("DeleteByKey", ValidRecord.Key()).Once().Return(&ValidRecord, nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "RemoveNothing",
+			method: Remove,
+			on: func(mock *recordmock.RepositoryMock) {
+				mock.On("DeleteByKey", ValidRecord.Key()).Once().Return(nil, nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			expectedErr: errors.New("nothing removed"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := &recordmock.RepositoryMock{}
+			test.on(repositoryMock)
+
+			service := NewService(repositoryMock, dnsmasq.NoReload())
+			err := test.method(service)
+			assert.Equal(t, test.expectedErr, err, "unexpected error")
+			repositoryMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRecordServiceFetch(t *testing.T) {
+	txtRecord := model.ResourceRecord{Type: model.ResourceRecordTypeTxt, Name: "example.lan", Text: []string{"v=spf1 a -all"}}
+	repositoryMock := &recordmock.RepositoryMock{}
+	repositoryMock.On("FindAll").Twice().Return(&[]model.ResourceRecord{ValidRecord, txtRecord}, nil)
+	repositoryMock.On("FindByKey", ValidRecord.Key()).Once().Return(&ValidRecord, nil)
+	repositoryMock.On("FindByKey", txtRecord.Key()).Once().Return(nil, errors.New("an error"))
+	service := NewService(repositoryMock, dnsmasq.NoReload())
+
+	records, err := service.FetchAll(model.ResourceRecordTypeTxt)
+	assert.NoError(t, err, "FetchAll() returned an unexpected error")
+	assert.Equal(t, &[]model.ResourceRecord{txtRecord}, records, "FetchAll() returned unexpected records")
+
+	records, err = service.FetchAll(model.ResourceRecordTypePtr)
+	assert.NoError(t, err, "FetchAll() returned an unexpected error")
+	assert.Equal(t, &[]model.ResourceRecord{}, records, "FetchAll() returned records of other types")
+
+	record, err := service.FetchByKey(ValidRecord.Key())
+	assert.NoError(t, err, "FetchByKey() returned an unexpected error")
+	assert.Equal(t, &ValidRecord, record, "FetchByKey() returned an unexpected record")
+
+	_, err = service.FetchByKey(txtRecord.Key())
+	assert.ErrorIs(t, err, errkind.ErrStorageUnavailable, "FetchByKey() returned an unexpected error")
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestRecordServiceReload(t *testing.T) {
+	reloadErr := &dnsmasq.ReloadError{Err: errors.New("exit status 1")}
+	repositoryMock := &recordmock.RepositoryMock{}
+	repositoryMock.On("DeleteByKey", ValidRecord.Key()).Once().Return(&ValidRecord, nil)
+	repositoryMock.On("Transaction").Once().Return(nil)
+	reloaderMock := &dnsmasqmock.ReloaderMock{}
+	reloaderMock.On("Reload").Once().Return(reloadErr)
+
+	service := NewService(repositoryMock, reloaderMock)
+	record, err := service.RemoveByKey(ValidRecord.Key())
+	assert.Equal(t, &ValidRecord, record, "RemoveByKey() did NOT return the removed record")
+	assert.Equal(t, reloadErr, err, "RemoveByKey() returned an unexpected error")
+	repositoryMock.AssertExpectations(t)
+	reloaderMock.AssertExpectations(t)
+}
+
+func TestRecordServiceErrorKinds(t *testing.T) {
+	assert.ErrorIs(t, NotFoundError{Key: ValidRecord.Key()}, errkind.ErrNotFound, "a NotFoundError is NOT an errkind.ErrNotFound")
+}
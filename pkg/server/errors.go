@@ -0,0 +1,32 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// DuplicatedEntryError is returned when a new server is already in the servers file, or when it would both keep
+// a domain local and forward it.
+type DuplicatedEntryError struct {
+	// The server already in the servers file
+	Server *model.DnsServer
+	// The domain kept local by one of the servers and forwarded by the other, empty when both are the same
+	Domain string
+}
+
+const duplicatedEntryErrorMessage = "Duplicated DNS server: %s"
+const localDomainErrorMessage = "DNS server domain kept local and forwarded: %s"
+
+func (e DuplicatedEntryError) Error() string {
+	if e.Domain != "" {
+		return fmt.Sprintf(localDomainErrorMessage, e.Domain)
+	}
+	config, _ := e.Server.ToConfig()
+	return fmt.Sprintf(duplicatedEntryErrorMessage, config)
+}
+
+func (e DuplicatedEntryError) Is(target error) bool {
+	return target == errkind.ErrDuplicate
+}
@@ -0,0 +1,45 @@
+package servermock
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type RepositoryMock struct {
+	mock.Mock
+}
+
+func (m *RepositoryMock) Delete(server *model.DnsServer) error {
+	args := m.Called(server)
+	return args.Error(0)
+}
+
+func (m *RepositoryMock) FindAll() (*[]model.DnsServer, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*[]model.DnsServer), args.Error(1)
+}
+
+func (m *RepositoryMock) Replace(server *model.DnsServer, replacement *model.DnsServer) error {
+	args := m.Called(server, replacement)
+	return args.Error(0)
+}
+
+func (m *RepositoryMock) Save(server *model.DnsServer) error {
+	args := m.Called(server)
+	return args.Error(0)
+}
+
+// Transaction runs fn against the mock itself, so the calls made within the transaction are expected as any
+// other. The "Transaction" call stands for the commit, it is only made when fn succeeds.
+func (m *RepositoryMock) Transaction(fn func(tx model.DnsServerTransaction) error) error {
+	err := fn(m)
+	if err != nil {
+		return err
+	}
+
+	args := m.Called()
+	return args.Error(0)
+}
@@ -0,0 +1,31 @@
+package servermock
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type ServiceMock struct {
+	mock.Mock
+}
+
+func (m *ServiceMock) Insert(server *model.DnsServer) error {
+	args := m.Called(server)
+	return args.Error(0)
+}
+
+func (m *ServiceMock) FetchAll(domain string) (*[]model.DnsServer, error) {
+	args := m.Called(domain)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*[]model.DnsServer), args.Error(1)
+}
+
+func (m *ServiceMock) Remove(domain string, address string) (*[]model.DnsServer, error) {
+	args := m.Called(domain, address)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*[]model.DnsServer), args.Error(1)
+}
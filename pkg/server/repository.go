@@ -0,0 +1,101 @@
+package server
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+)
+
+type Repository interface {
+	Delete(server *model.DnsServer) error
+	FindAll() (*[]model.DnsServer, error)
+	Save(server *model.DnsServer) error
+	// Transaction runs fn as a single unit of work, holding the exclusive lock of the servers file. The changes
+	// made by fn are written at once when it returns nil, and thrown away otherwise.
+	Transaction(fn func(tx model.DnsServerTransaction) error) error
+}
+
+type repository struct {
+	document *storage.DocumentRepository[model.DnsServer]
+}
+
+// NewRepository returns the Repository of the DNS servers kept in the given file, which is created by the first
+// change when it does not exist yet.
+func NewRepository(serversFilePath string, options storage.Options) Repository {
+	return &repository{
+		document: storage.NewDocumentRepository(serversFilePath, options, storage.EntryHooks[model.DnsServer]{
+			Name: "DNS server",
+			Is:   model.IsDnsServer,
+			Parse: func(line string) (model.DnsServer, error) {
+				server := model.DnsServer{}
+				err := server.FromConfig(line)
+				return server, err
+			},
+			Format: (*model.DnsServer).ToConfig,
+		}),
+	}
+}
+
+func (r *repository) FindAll() (*[]model.DnsServer, error) {
+	entries, err := r.document.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	servers := entries.All()
+	return &servers, nil
+}
+
+func (r *repository) Save(server *model.DnsServer) error {
+	return r.Transaction(func(tx model.DnsServerTransaction) error {
+		return tx.Save(server)
+	})
+}
+
+func (r *repository) Delete(server *model.DnsServer) error {
+	return r.Transaction(func(tx model.DnsServerTransaction) error {
+		return tx.Delete(server)
+	})
+}
+
+func (r *repository) Transaction(fn func(tx model.DnsServerTransaction) error) error {
+	return r.document.Transaction(func(tx *storage.DocumentTransaction[model.DnsServer]) error {
+		return fn(&fileTransaction{tx})
+	})
+}
+
+// fileTransaction looks the servers up by value in a storage.DocumentTransaction, as they have no key.
+type fileTransaction struct {
+	*storage.DocumentTransaction[model.DnsServer]
+}
+
+func (tx *fileTransaction) FindAll() (*[]model.DnsServer, error) {
+	servers := tx.Entries().All()
+	return &servers, nil
+}
+
+func (tx *fileTransaction) Save(server *model.DnsServer) error {
+	return tx.Append(server)
+}
+
+func (tx *fileTransaction) Replace(server *model.DnsServer, replacement *model.DnsServer) error {
+	i := tx.lookup(server)
+	if i < 0 {
+		return nil
+	}
+
+	return tx.DocumentTransaction.Replace(i, replacement)
+}
+
+func (tx *fileTransaction) Delete(server *model.DnsServer) error {
+	i := tx.lookup(server)
+	if i < 0 {
+		return nil
+	}
+
+	return tx.Remove(i)
+}
+
+// lookup returns the index of the first server equal to the given one, or -1 if there is none.
+func (tx *fileTransaction) lookup(server *model.DnsServer) int {
+	return tx.Entries().IndexFunc(func(other model.DnsServer) bool { return other.Equal(*server) })
+}
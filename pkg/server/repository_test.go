@@ -0,0 +1,136 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var AllServers = []model.DnsServer{
+	{Address: "1.1.1.1"},
+	{Domains: []string{"corp.example", "3.168.192.in-addr.arpa"}, Address: "10.0.0.53"},
+	{Domains: []string{"www.corp.example"}, Address: "#"},
+	{Domains: []string{"lan"}},
+}
+
+var NewServer = model.DnsServer{Domains: []string{"vpn.example"}, Address: "10.8.0.1", Port: 5353}
+
+const (
+	AllServersFileContent = `# Upstream resolvers
+server=1.1.1.1
+no-resolv
+server=/corp.example/3.168.192.in-addr.arpa/10.0.0.53
+server=/www.corp.example/#
+server=/lan/
+`
+	AddedServerFileContent = AllServersFileContent + `server=/vpn.example/10.8.0.1#5353
+`
+	ReplacedServerFileContent = `# Upstream resolvers
+server=1.1.1.1
+no-resolv
+server=/3.168.192.in-addr.arpa/10.0.0.53
+server=/www.corp.example/#
+server=/lan/
+`
+	DeletedServerFileContent = `# Upstream resolvers
+no-resolv
+server=/3.168.192.in-addr.arpa/10.0.0.53
+server=/www.corp.example/#
+server=/lan/
+`
+	InvalidServerFileContent = AllServersFileContent + `server=/vpn.example/vpn-dns.example
+`
+)
+
+func setUpServersFile(t *testing.T, content string) string {
+	fileName := filepath.Join(t.TempDir(), "08-dns-servers.conf")
+	require.NoError(t, os.WriteFile(fileName, []byte(content), 0644), "Failed to initialize the servers file")
+	return fileName
+}
+
+func assertFileContent(t *testing.T, expectedFileContent string, fileName string) {
+	actualFileData, err := os.ReadFile(fileName)
+	require.NoError(t, err, "Failed to open test file for validation")
+	assert.Equal(t, expectedFileContent, string(actualFileData), "Servers file doesn't match")
+}
+
+func TestServerRepositoryFindAll(t *testing.T) {
+	fileName := setUpServersFile(t, AllServersFileContent)
+	repository := NewRepository(fileName, storage.Options{})
+
+	servers, err := repository.FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.Equal(t, AllServers, *servers, "FindAll() returned unexpected servers")
+	assertFileContent(t, AllServersFileContent, fileName)
+}
+
+func TestServerRepositoryMissingFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "08-dns-servers.conf")
+	repository := NewRepository(fileName, storage.Options{})
+
+	servers, err := repository.FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error")
+	assert.Empty(t, *servers, "FindAll() returned unexpected servers")
+
+	assert.NoError(t, repository.Save(&NewServer), "Save() returned an unexpected error")
+	assertFileContent(t, "server=/vpn.example/10.8.0.1#5353", fileName)
+}
+
+func TestServerRepositoryChanges(t *testing.T) {
+	fileName := setUpServersFile(t, AllServersFileContent)
+	repository := NewRepository(fileName, storage.Options{})
+
+	assert.NoError(t, repository.Save(&NewServer), "Save() returned an unexpected error")
+	assertFileContent(t, AddedServerFileContent, fileName)
+
+	assert.NoError(t, repository.Delete(&NewServer), "Delete() returned an unexpected error")
+	assertFileContent(t, AllServersFileContent, fileName)
+
+	err := repository.Transaction(func(tx model.DnsServerTransaction) error {
+		remaining := AllServers[1].WithoutDomain("corp.example")
+		return tx.Replace(&AllServers[1], &remaining)
+	})
+	assert.NoError(t, err, "Replace() returned an unexpected error")
+	assertFileContent(t, ReplacedServerFileContent, fileName)
+
+	assert.NoError(t, repository.Delete(&AllServers[0]), "Delete() returned an unexpected error")
+	assertFileContent(t, DeletedServerFileContent, fileName)
+
+	assert.NoError(t, repository.Delete(&AllServers[0]), "Delete() returned an unexpected error")
+	assertFileContent(t, DeletedServerFileContent, fileName)
+}
+
+func TestServerRepositoryTransactionRollback(t *testing.T) {
+	fileName := setUpServersFile(t, AllServersFileContent)
+	repository := NewRepository(fileName, storage.Options{})
+	rollback := errors.New("rollback")
+
+	err := repository.Transaction(func(tx model.DnsServerTransaction) error {
+		if err := tx.Delete(&AllServers[0]); err != nil {
+			return err
+		}
+		servers, err := tx.FindAll()
+		assert.NoError(t, err, "FindAll() returned an unexpected error")
+		assert.Equal(t, AllServers[1:], *servers, "FindAll() did NOT see the deletion")
+		return rollback
+	})
+	assert.ErrorIs(t, err, rollback, "Transaction() returned an unexpected error")
+	assertFileContent(t, AllServersFileContent, fileName)
+}
+
+func TestServerRepositoryParseMode(t *testing.T) {
+	fileName := setUpServersFile(t, InvalidServerFileContent)
+
+	_, err := NewRepository(fileName, storage.Options{}).FindAll()
+	assert.Error(t, err, "FindAll() did NOT returned an error in the strict mode")
+
+	servers, err := NewRepository(fileName, storage.Options{Lenient: true}).FindAll()
+	assert.NoError(t, err, "FindAll() returned an unexpected error in the lenient mode")
+	assert.Equal(t, AllServers, *servers, "FindAll() returned unexpected servers")
+}
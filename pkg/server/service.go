@@ -0,0 +1,142 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+var errMissingFilter = errors.New("invalid DNS server removal: a domain or an address is required")
+
+type Service interface {
+	Insert(server *model.DnsServer) error
+	// FetchAll returns the servers in file order, only the ones used for the given domain unless it is empty
+	FetchAll(domain string) (*[]model.DnsServer, error)
+	// Remove stops using the servers with the given address for the given domain, either of them may be empty to
+	// match any. It returns the removed servers, or nil when none matched
+	Remove(domain string, address string) (*[]model.DnsServer, error)
+}
+
+type service struct {
+	repository Repository
+	reloader   dnsmasq.Reloader
+}
+
+// NewService returns the DNS servers Service. The reloader is triggered after every change written to the
+// servers file, so dnsmasq picks it up.
+//
+// Every error matches one of the error kinds (errkind.ErrDuplicate, errkind.ErrValidation,
+// errkind.ErrStorageUnavailable or errkind.ErrLockTimeout), except the dnsmasq.ReloadError of a change that was saved.
+func NewService(repository Repository, reloader dnsmasq.Reloader) Service {
+	return &service{
+		repository: repository,
+		reloader:   reloader,
+	}
+}
+
+// Insert adds a new server. It fails with a DuplicatedEntryError if the same server is already there, or if one of
+// its domains is kept local by another server while it would be forwarded by this one, or the other way round.
+func (s *service) Insert(server *model.DnsServer) error {
+	if err := server.Validate(); err != nil {
+		return &errkind.ValidationError{Err: err}
+	}
+
+	err := s.repository.Transaction(func(tx model.DnsServerTransaction) error {
+		servers, err := tx.FindAll()
+		if err != nil {
+			return err
+		}
+		if err := checkServer(servers, server); err != nil {
+			return err
+		}
+		return tx.Save(server)
+	})
+	if err != nil {
+		return errkind.Classify(err)
+	}
+
+	return s.reloader.Reload()
+}
+
+// checkServer ensures that the new server neither is one of the servers nor contradicts them.
+func checkServer(servers *[]model.DnsServer, server *model.DnsServer) error {
+	for _, existing := range *servers {
+		if existing.Equal(*server) {
+			return &DuplicatedEntryError{Server: &existing}
+		}
+		for _, domain := range server.Domains {
+			if existing.HasDomain(domain) && existing.IsLocal() != server.IsLocal() {
+				return &DuplicatedEntryError{Server: &existing, Domain: domain}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *service) FetchAll(domain string) (*[]model.DnsServer, error) {
+	servers, err := s.repository.FindAll()
+	if err != nil {
+		return nil, errkind.Classify(err)
+	}
+	if domain == "" {
+		return servers, nil
+	}
+
+	domainServers := []model.DnsServer{}
+	for _, server := range *servers {
+		if server.HasDomain(domain) {
+			domainServers = append(domainServers, server)
+		}
+	}
+
+	return &domainServers, nil
+}
+
+// Remove deletes the matching servers, and reloads dnsmasq when something was actually removed. A server used for
+// other domains as well is kept for them, so only the given domain is removed from it. The removed servers are
+// returned with the removed domain only, even if the reload fails, since they are already gone from the servers
+// file, or nil when nothing matched.
+func (s *service) Remove(domain string, address string) (*[]model.DnsServer, error) {
+	if domain == "" && address == "" {
+		return nil, &errkind.ValidationError{Err: errMissingFilter}
+	}
+
+	removed := []model.DnsServer{}
+	err := s.repository.Transaction(func(tx model.DnsServerTransaction) error {
+		servers, err := tx.FindAll()
+		if err != nil {
+			return err
+		}
+
+		for _, server := range *servers {
+			if (domain != "" && !server.HasDomain(domain)) || (address != "" && !server.HasAddress(address)) {
+				continue
+			}
+
+			if domain == "" || len(server.Domains) == 1 {
+				err = tx.Delete(&server)
+				removed = append(removed, server)
+			} else {
+				remaining := server.WithoutDomain(domain)
+				err = tx.Replace(&server, &remaining)
+				removed = append(removed, model.DnsServer{Domains: []string{domain}, Address: server.Address,
+					Port: server.Port, Source: server.Source})
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errkind.Classify(err)
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	return &removed, s.reloader.Reload()
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	dnsmasqmock "github.com/gringolito/dnsmasq-manager/pkg/dnsmasq/mock"
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	servermock "github.com/gringolito/dnsmasq-manager/pkg/server/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var Upstream = model.DnsServer{Address: "1.1.1.1"}
+var CorpServer = model.DnsServer{Domains: []string{"corp.example", "3.168.192.in-addr.arpa"}, Address: "10.0.0.53"}
+var LocalServer = model.DnsServer{Domains: []string{"lan"}}
+var ExistingServers = []model.DnsServer{Upstream, CorpServer, LocalServer}
+var InvalidServer = model.DnsServer{Domains: []string{"corp.example"}, Address: "dns.corp.example"}
+
+func TestServerServiceInsert(t *testing.T) {
+	vpnServer := model.DnsServer{Domains: []string{"vpn.example"}, Address: "10.8.0.1"}
+	sameServer := model.DnsServer{Domains: []string{"3.168.192.in-addr.arpa", "CORP.example"}, Address: "10.0.0.53"}
+	forwardedLocal := model.DnsServer{Domains: []string{"vpn.example", "LAN"}, Address: "10.8.0.1"}
+	localCorp := model.DnsServer{Domains: []string{"corp.example"}}
+
+	var testCases = []struct {
+		name        string
+		server      model.DnsServer
+		on          func(mock *servermock.RepositoryMock)
+		expectedErr error
+	}{
+		{
+			name:   "Success",
+			server: vpnServer,
+			on: func(mock *servermock.RepositoryMock) {
+				mock.On("FindAll").Once().Return(&ExistingServers, nil)
+				mock.On("Save", &vpnServer).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "SecondForwarder",
+			server: model.DnsServer{Domains: []string{"corp.example"}, Address: "10.0.0.54"},
+			on: func(mock *servermock.RepositoryMock) {
+				mock.On("FindAll").Once().Return(&ExistingServers, nil)
+				mock.On("Save", &model.DnsServer{Domains: []string{"corp.example"}, Address: "10.0.0.54"}).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:   "Duplicated",
+			server: sameServer,
+			on: func(mock *servermock.RepositoryMock) {
+				mock.On("FindAll").Once().Return(&ExistingServers, nil)
+			},
+			expectedErr: &DuplicatedEntryError{Server: &CorpServer},
+		},
+		{
+			name:   "ForwardingLocalDomain",
+			server: forwardedLocal,
+			on: func(mock *servermock.RepositoryMock) {
+				mock.On("FindAll").Once().Return(&ExistingServers, nil)
+			},
+			expectedErr: &DuplicatedEntryError{Server: &LocalServer, Domain: "LAN"},
+		},
+		{
+			name:   "KeepingForwardedDomainLocal",
+			server: localCorp,
+			on: func(mock *servermock.RepositoryMock) {
+				mock.On("FindAll").Once().Return(&ExistingServers, nil)
+			},
+			expectedErr: &DuplicatedEntryError{Server: &CorpServer, Domain: "corp.example"},
+		},
+		{
+			name:        "InvalidServer",
+			server:      InvalidServer,
+			on:          func(mock *servermock.RepositoryMock) {},
+			expectedErr: &errkind.ValidationError{Err: InvalidServer.Validate()},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := &servermock.RepositoryMock{}
+			test.on(repositoryMock)
+
+			service := NewService(repositoryMock, dnsmasq.NoReload())
+			err := service.Insert(&test.server)
+			assert.Equal(t, test.expectedErr, err, "unexpected error")
+			repositoryMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServerServiceRemove(t *testing.T) {
+	reverseServer := CorpServer.WithoutDomain("corp.example")
+
+	var testCases = []struct {
+		name            string
+		domain          string
+		address         string
+		on              func(mock *servermock.RepositoryMock)
+		expectedRemoved *[]model.DnsServer
+		expectedErr     error
+	}{
+		{
+			name:   "SharedServerDomain",
+			domain: "CORP.example",
+			on: func(mock *servermock.RepositoryMock) {
+				mock.On("FindAll").Once().Return(&ExistingServers, nil)
+				mock.On("Replace", &CorpServer, &reverseServer).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			expectedRemoved: &[]model.DnsServer{{Domains: []string{"CORP.example"}, Address: "10.0.0.53"}},
+		},
+		{
+			name:   "LocalDomain",
+			domain: "lan",
+			on: func(mock *servermock.RepositoryMock) {
+				mock.On("FindAll").Once().Return(&ExistingServers, nil)
+				mock.On("Delete", &LocalServer).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			expectedRemoved: &[]model.DnsServer{LocalServer},
+		},
+		{
+			name:    "Address",
+			address: "10.0.0.53",
+			on: func(mock *servermock.RepositoryMock) {
+				mock.On("FindAll").Once().Return(&ExistingServers, nil)
+				mock.On("Delete", &CorpServer).Once().Return(nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+			expectedRemoved: &[]model.DnsServer{CorpServer},
+		},
+		{
+			name:    "DomainOfAnotherAddress",
+			domain:  "corp.example",
+			address: "10.0.0.54",
+			on: func(mock *servermock.RepositoryMock) {
+				mock.On("FindAll").Once().Return(&ExistingServers, nil)
+				mock.On("Transaction").Once().Return(nil)
+			},
+		},
+		{
+			name:        "MissingFilter",
+			on:          func(mock *servermock.RepositoryMock) {},
+			expectedErr: &errkind.ValidationError{Err: errMissingFilter},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := &servermock.RepositoryMock{}
+			test.on(repositoryMock)
+
+			service := NewService(repositoryMock, dnsmasq.NoReload())
+			removed, err := service.Remove(test.domain, test.address)
+			assert.Equal(t, test.expectedErr, err, "unexpected error")
+			assert.Equal(t, test.expectedRemoved, removed, "Remove() returned unexpected servers")
+			repositoryMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServerServiceFetchAll(t *testing.T) {
+	repositoryMock := &servermock.RepositoryMock{}
+	repositoryMock.On("FindAll").Times(3).Return(&ExistingServers, nil)
+	service := NewService(repositoryMock, dnsmasq.NoReload())
+
+	servers, err := service.FetchAll("")
+	assert.NoError(t, err, "FetchAll() returned an unexpected error")
+	assert.Equal(t, &ExistingServers, servers, "FetchAll() returned unexpected servers")
+
+	servers, err = service.FetchAll("Corp.Example")
+	assert.NoError(t, err, "FetchAll() returned an unexpected error")
+	assert.Equal(t, &[]model.DnsServer{CorpServer}, servers, "FetchAll() returned unexpected servers")
+
+	servers, err = service.FetchAll("vpn.example")
+	assert.NoError(t, err, "FetchAll() returned an unexpected error")
+	assert.Equal(t, &[]model.DnsServer{}, servers, "FetchAll() returned servers of other domains")
+	repositoryMock.AssertExpectations(t)
+}
+
+func TestServerServiceReload(t *testing.T) {
+	reloadErr := &dnsmasq.ReloadError{Err: errors.New("exit status 1")}
+	repositoryMock := &servermock.RepositoryMock{}
+	repositoryMock.On("FindAll").Once().Return(&ExistingServers, nil)
+	repositoryMock.On("Delete", &Upstream).Once().Return(nil)
+	repositoryMock.On("Transaction").Once().Return(nil)
+	reloaderMock := &dnsmasqmock.ReloaderMock{}
+	reloaderMock.On("Reload").Once().Return(reloadErr)
+
+	service := NewService(repositoryMock, reloaderMock)
+	removed, err := service.Remove("", "1.1.1.1")
+	assert.Equal(t, &[]model.DnsServer{Upstream}, removed, "Remove() did NOT return the removed servers")
+	assert.Equal(t, reloadErr, err, "Remove() returned an unexpected error")
+	repositoryMock.AssertExpectations(t)
+	reloaderMock.AssertExpectations(t)
+}
+
+func TestServerServiceErrorKinds(t *testing.T) {
+	assert.ErrorIs(t, DuplicatedEntryError{Server: &CorpServer}, errkind.ErrDuplicate, "a DuplicatedEntryError is NOT an errkind.ErrDuplicate")
+}
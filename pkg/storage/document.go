@@ -0,0 +1,393 @@
+package storage
+
+import (
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// EntryHooks tell a DocumentRepository how its entries are written as dnsmasq lines.
+type EntryHooks[T any] struct {
+	// Name of the entries in the logs, e.g. "DNS server"
+	Name string
+	// Is reports whether a line holds an entry, the other lines are kept as they are
+	Is     func(line string) bool
+	Parse  func(line string) (T, error)
+	Format func(entry *T) (string, error)
+	// Keys returns the keys an entry is looked up by, see Entries.Lookup. Nil when the entries have no keys.
+	Keys func(entry *T) []string
+	// IsComment reports whether a line is the comment of the entry on the next line, which goes along with it.
+	// Nil when the entries have no comments.
+	IsComment    func(line string) bool
+	ParseComment func(entry *T, comment string) error
+	// FormatComment returns the comment of an entry, empty when it has none
+	FormatComment func(entry *T) (string, error)
+	// Whether a missing file is an error (os.ErrNotExist), instead of a file without entries
+	Required bool
+}
+
+// DocumentRepository keeps the entries of a managed file which is parsed as a dnsmasq.Document, so it can be
+// written back changing only the lines that were touched.
+type DocumentRepository[T any] struct {
+	file  *File
+	hooks EntryHooks[T]
+	mutex sync.RWMutex
+}
+
+// NewDocumentRepository returns the DocumentRepository of the entries kept in the given file, which is created by
+// the first change when it does not exist yet.
+func NewDocumentRepository[T any](path string, options Options, hooks EntryHooks[T]) *DocumentRepository[T] {
+	return &DocumentRepository[T]{
+		file:  NewFile(path, options),
+		hooks: hooks,
+	}
+}
+
+func (r *DocumentRepository[T]) Path() string {
+	return r.file.Path()
+}
+
+// Load reads the entries, holding the shared lock of the file.
+func (r *DocumentRepository[T]) Load() (*Entries[T], error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	lock, err := r.lock(sharedLock)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	return r.read(r.file.options.Lenient)
+}
+
+// Diagnose reads the entries leniently, whatever the options, so every line that can't be parsed is reported at
+// once by Entries.Diagnostics.
+func (r *DocumentRepository[T]) Diagnose() (*Entries[T], error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	lock, err := r.lock(sharedLock)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	return r.read(true)
+}
+
+// Transaction runs fn as a single unit of work, holding the exclusive lock of the file. The changes made by fn are
+// written at once when it returns nil, and thrown away otherwise.
+func (r *DocumentRepository[T]) Transaction(fn func(tx *DocumentTransaction[T]) error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	lock, err := r.lock(exclusiveLock)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	entries, err := r.read(r.file.options.Lenient)
+	if err != nil {
+		return err
+	}
+
+	tx := &DocumentTransaction[T]{repository: r, entries: entries}
+	err = fn(tx)
+	if err != nil || !tx.changed {
+		return err
+	}
+
+	return r.save(tx.entries.document)
+}
+
+const (
+	sharedLock    = false
+	exclusiveLock = true
+)
+
+// lock takes the file lock shared with other processes, see File.Lock.
+func (r *DocumentRepository[T]) lock(exclusive bool) (*Lock, error) {
+	var lock *Lock
+	var err error
+	if exclusive {
+		lock, err = r.file.Lock()
+	} else {
+		lock, err = r.file.RLock()
+	}
+	if err != nil {
+		slog.Error("Error locking file",
+			slog.String("file", r.file.Path()),
+			slog.Bool("exclusive", exclusive),
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+// read parses the file, a missing file has no entries unless required. An unparsable entry makes it fail, unless
+// lenient, in which case the line is kept as is and reported as a model.Diagnostic.
+func (r *DocumentRepository[T]) read(lenient bool) (*Entries[T], error) {
+	file, err := r.file.Open()
+	if os.IsNotExist(err) && !r.hooks.Required {
+		return newEntries[T](dnsmasq.NewDocument()), nil
+	}
+	if err != nil {
+		slog.Error("Error reading file",
+			slog.String("file", r.file.Path()),
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer file.Close()
+
+	document, err := dnsmasq.ParseDocument(file)
+	if err != nil {
+		slog.Error("Error reading file",
+			slog.String("file", r.file.Path()),
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return r.index(document, lenient)
+}
+
+// index parses the entries out of a document, see read.
+func (r *DocumentRepository[T]) index(document *dnsmasq.Document, lenient bool) (*Entries[T], error) {
+	entries := newEntries[T](document)
+	for i := 0; i < document.Len(); i++ {
+		line := strings.TrimSpace(document.Line(i))
+		if !r.hooks.Is(line) {
+			slog.Debug("Skipping line", slog.String("line", line))
+			continue
+		}
+
+		entry, err := r.hooks.Parse(line)
+		if err != nil && lenient {
+			slog.Warn("Skipping invalid "+r.hooks.Name+" entry",
+				slog.String("entry", line),
+				slog.String("error", err.Error()),
+			)
+			entries.diagnostics = append(entries.diagnostics, model.Diagnostic{File: r.file.Path(), Line: i + 1, Text: document.Line(i), Err: err})
+			continue
+		}
+		if err != nil {
+			slog.Error("Failed to parse "+r.hooks.Name+" entry",
+				slog.String("entry", line),
+				slog.String("error", err.Error()),
+			)
+			return nil, err
+		}
+
+		if r.hasComment(document, i) {
+			err := r.hooks.ParseComment(&entry, document.Line(i-1))
+			if err != nil {
+				// A broken comment must not make the whole file unusable, the entry is just left without it
+				slog.Warn("Ignoring invalid "+r.hooks.Name+" comment",
+					slog.String("entry", line),
+					slog.String("error", err.Error()),
+				)
+				entries.diagnostics = append(entries.diagnostics, model.Diagnostic{File: r.file.Path(), Line: i, Text: document.Line(i - 1), Err: err})
+			}
+		}
+
+		entries.add(entry, i, r.hooks.Keys)
+	}
+
+	return entries, nil
+}
+
+// hasComment reports whether the entry at the given document line has a comment on the previous one.
+func (r *DocumentRepository[T]) hasComment(document *dnsmasq.Document, line int) bool {
+	return r.hooks.IsComment != nil && line > 0 && r.hooks.IsComment(document.Line(line-1))
+}
+
+func (r *DocumentRepository[T]) save(document *dnsmasq.Document) error {
+	err := r.file.Write(document.Bytes())
+	if err != nil {
+		slog.Error("Error writing into the file",
+			slog.String("file", r.file.Path()),
+			slog.String("error", err.Error()),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// format returns the dnsmasq line of the entry, along with its comment, empty when it has none.
+func (r *DocumentRepository[T]) format(entry *T) (string, string, error) {
+	config, err := r.hooks.Format(entry)
+	if err != nil {
+		slog.Debug("Invalid "+r.hooks.Name,
+			slog.Any("entry", entry),
+			slog.String("error", err.Error()),
+		)
+		return "", "", err
+	}
+
+	if r.hooks.FormatComment == nil {
+		return "", config, nil
+	}
+	comment, err := r.hooks.FormatComment(entry)
+	if err != nil {
+		return "", "", err
+	}
+	return comment, config, nil
+}
+
+// Entries keeps the parsed entries of a file along with the document they came from.
+type Entries[T any] struct {
+	document *dnsmasq.Document
+	entries  []T
+	// Document line index of each entry
+	lines []int
+	// Index from the keys to the entries, in file order
+	byKey map[string][]int
+	// Problems found while parsing, the lines are left untouched in the document
+	diagnostics []model.Diagnostic
+}
+
+func newEntries[T any](document *dnsmasq.Document) *Entries[T] {
+	return &Entries[T]{
+		document:    document,
+		entries:     []T{},
+		lines:       []int{},
+		byKey:       map[string][]int{},
+		diagnostics: []model.Diagnostic{},
+	}
+}
+
+func (e *Entries[T]) add(entry T, line int, keys func(entry *T) []string) {
+	i := len(e.entries)
+	e.entries = append(e.entries, entry)
+	e.lines = append(e.lines, line)
+	if keys == nil {
+		return
+	}
+	for _, key := range keys(&entry) {
+		// The same key must not point twice to the same entry
+		if indexes := e.byKey[key]; len(indexes) == 0 || indexes[len(indexes)-1] != i {
+			e.byKey[key] = append(indexes, i)
+		}
+	}
+}
+
+// All returns a copy of the entries, in file order.
+func (e *Entries[T]) All() []T {
+	return slices.Clone(e.entries)
+}
+
+// Lookup returns the first entry with the key, along with its index, or nil if there is none.
+func (e *Entries[T]) Lookup(key string) (int, *T) {
+	indexes := e.byKey[key]
+	if len(indexes) == 0 {
+		return -1, nil
+	}
+
+	entry := e.entries[indexes[0]]
+	return indexes[0], &entry
+}
+
+// LookupFunc returns the first entry with the key satisfying f, along with its index, or nil if there is none.
+func (e *Entries[T]) LookupFunc(key string, f func(entry T) bool) (int, *T) {
+	for _, i := range e.byKey[key] {
+		entry := e.entries[i]
+		if f(entry) {
+			return i, &entry
+		}
+	}
+
+	return -1, nil
+}
+
+// IndexFunc returns the index of the first entry satisfying f, or -1 if there is none.
+func (e *Entries[T]) IndexFunc(f func(entry T) bool) int {
+	return slices.IndexFunc(e.entries, f)
+}
+
+// Diagnostics returns the problems found while parsing the entries, see DocumentRepository.Diagnose.
+func (e *Entries[T]) Diagnostics() []model.Diagnostic {
+	return slices.Clone(e.diagnostics)
+}
+
+// DocumentTransaction applies the changes to a copy of the document, which is parsed again after each change so the
+// following calls see it.
+type DocumentTransaction[T any] struct {
+	repository *DocumentRepository[T]
+	entries    *Entries[T]
+	// Whether the document must be written back
+	changed bool
+}
+
+// Entries returns the entries as changed so far by the transaction.
+func (tx *DocumentTransaction[T]) Entries() *Entries[T] {
+	return tx.entries
+}
+
+// Append adds the entry at the end of the file.
+func (tx *DocumentTransaction[T]) Append(entry *T) error {
+	comment, config, err := tx.repository.format(entry)
+	if err != nil {
+		return err
+	}
+
+	document := tx.entries.document.Clone()
+	if comment != "" {
+		document.Append(comment)
+	}
+	document.Append(config)
+	return tx.update(document)
+}
+
+// Replace changes the i-th entry, see Entries.Lookup, keeping its place in the file. Its comment is updated along
+// with it.
+func (tx *DocumentTransaction[T]) Replace(i int, entry *T) error {
+	comment, config, err := tx.repository.format(entry)
+	if err != nil {
+		return err
+	}
+
+	line := tx.entries.lines[i]
+	document := tx.entries.document.Clone()
+	document.Set(line, config)
+	hasComment := tx.repository.hasComment(document, line)
+	switch {
+	case hasComment && comment != "":
+		document.Set(line-1, comment)
+	case hasComment:
+		document.Remove(line - 1)
+	case comment != "":
+		document.Insert(line, comment)
+	}
+	return tx.update(document)
+}
+
+// Remove deletes the i-th entry, see Entries.Lookup, along with its comment.
+func (tx *DocumentTransaction[T]) Remove(i int) error {
+	line := tx.entries.lines[i]
+	document := tx.entries.document.Clone()
+	document.Remove(line)
+	if tx.repository.hasComment(document, line) {
+		document.Remove(line - 1)
+	}
+	return tx.update(document)
+}
+
+// update replaces the transaction document by a changed one.
+func (tx *DocumentTransaction[T]) update(document *dnsmasq.Document) error {
+	entries, err := tx.repository.index(document, tx.repository.file.options.Lenient)
+	if err != nil {
+		return err
+	}
+
+	tx.entries = entries
+	tx.changed = true
+	return nil
+}
@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test entries are the values of the "test" options, keyed by their lower case names
+var testEntryHooks = EntryHooks[string]{
+	Name: "test entry",
+	Is:   func(line string) bool { return strings.HasPrefix(line, "test=") },
+	Parse: func(line string) (string, error) {
+		value := strings.TrimPrefix(line, "test=")
+		if value == "" {
+			return "", errors.New("empty test entry")
+		}
+		return value, nil
+	},
+	Format: func(entry *string) (string, error) {
+		if *entry == "" {
+			return "", errors.New("empty test entry")
+		}
+		return "test=" + *entry, nil
+	},
+	Keys: func(entry *string) []string { return []string{strings.ToLower(*entry)} },
+}
+
+const testDocumentContent = `# Test entries
+test=Foo
+server=1.1.1.1
+test=Bar
+`
+
+func TestDocumentRepositoryLoad(t *testing.T) {
+	repository := NewDocumentRepository(setUpFile(t, testDocumentContent, 0644), Options{}, testEntryHooks)
+
+	entries, err := repository.Load()
+	require.NoError(t, err, "DocumentRepository.Load() returned an unexpected error")
+	assert.Equal(t, []string{"Foo", "Bar"}, entries.All(), "DocumentRepository.Load() returned unexpected entries")
+
+	i, entry := entries.Lookup("bar")
+	require.NotNil(t, entry, "Entries.Lookup() has not found the entry")
+	assert.Equal(t, 1, i, "Entries.Lookup() returned an unexpected index")
+	assert.Equal(t, "Bar", *entry, "Entries.Lookup() returned an unexpected entry")
+
+	i, entry = entries.Lookup("baz")
+	assert.Equal(t, -1, i, "Entries.Lookup() returned an unexpected index")
+	assert.Nil(t, entry, "Entries.Lookup() has found a missing entry")
+
+	// A missing file has no entries
+	repository = NewDocumentRepository(t.TempDir()+"/missing.conf", Options{}, testEntryHooks)
+	entries, err = repository.Load()
+	require.NoError(t, err, "DocumentRepository.Load() returned an unexpected error")
+	assert.Empty(t, entries.All(), "DocumentRepository.Load() returned unexpected entries")
+}
+
+func TestDocumentRepositoryLenient(t *testing.T) {
+	content := "test=\ntest=Foo\n"
+
+	_, err := NewDocumentRepository(setUpFile(t, content, 0644), Options{}, testEntryHooks).Load()
+	assert.Error(t, err, "DocumentRepository.Load() has not failed on an invalid entry")
+
+	entries, err := NewDocumentRepository(setUpFile(t, content, 0644), Options{Lenient: true}, testEntryHooks).Load()
+	require.NoError(t, err, "DocumentRepository.Load() returned an unexpected error")
+	assert.Equal(t, []string{"Foo"}, entries.All(), "DocumentRepository.Load() returned unexpected entries")
+}
+
+func TestDocumentRepositoryTransaction(t *testing.T) {
+	testCases := []struct {
+		name            string
+		change          func(tx *DocumentTransaction[string]) error
+		expectedContent string
+		expectError     bool
+	}{
+		{
+			name: "Append",
+			change: func(tx *DocumentTransaction[string]) error {
+				entry := "Baz"
+				return tx.Append(&entry)
+			},
+			expectedContent: testDocumentContent + "test=Baz\n",
+		},
+		{
+			name: "Replace",
+			change: func(tx *DocumentTransaction[string]) error {
+				i, _ := tx.Entries().Lookup("foo")
+				entry := "Baz"
+				return tx.Replace(i, &entry)
+			},
+			expectedContent: strings.Replace(testDocumentContent, "test=Foo", "test=Baz", 1),
+		},
+		{
+			name: "Remove",
+			change: func(tx *DocumentTransaction[string]) error {
+				i, _ := tx.Entries().Lookup("foo")
+				return tx.Remove(i)
+			},
+			expectedContent: strings.Replace(testDocumentContent, "test=Foo\n", "", 1),
+		},
+		{
+			name: "ChangesSeenByTheNextCalls",
+			change: func(tx *DocumentTransaction[string]) error {
+				entry := "Baz"
+				require.NoError(t, tx.Append(&entry), "DocumentTransaction.Append() returned an unexpected error")
+				i, _ := tx.Entries().Lookup("baz")
+				return tx.Remove(i)
+			},
+			expectedContent: testDocumentContent,
+		},
+		{
+			name: "InvalidEntry",
+			change: func(tx *DocumentTransaction[string]) error {
+				entry := ""
+				return tx.Append(&entry)
+			},
+			expectedContent: testDocumentContent,
+			expectError:     true,
+		},
+		{
+			name: "ThrownAwayOnError",
+			change: func(tx *DocumentTransaction[string]) error {
+				entry := "Baz"
+				require.NoError(t, tx.Append(&entry), "DocumentTransaction.Append() returned an unexpected error")
+				return errors.New("rolled back")
+			},
+			expectedContent: testDocumentContent,
+			expectError:     true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := setUpFile(t, testDocumentContent, 0644)
+			repository := NewDocumentRepository(fileName, Options{}, testEntryHooks)
+
+			err := repository.Transaction(test.change)
+			if test.expectError {
+				assert.Error(t, err, "DocumentRepository.Transaction() has not failed")
+			} else {
+				assert.NoError(t, err, "DocumentRepository.Transaction() returned an unexpected error")
+			}
+
+			content, err := os.ReadFile(fileName)
+			require.NoError(t, err, "Failed to read the managed file")
+			assert.Equal(t, test.expectedContent, string(content), "Unexpected managed file content")
+		})
+	}
+}
+
+// Commented test entries keep a "# note: " comment on the line before them
+var commentedEntryHooks = func() EntryHooks[string] {
+	hooks := testEntryHooks
+	hooks.IsComment = func(line string) bool { return strings.HasPrefix(line, "# note: ") }
+	hooks.ParseComment = func(entry *string, comment string) error {
+		note := strings.TrimPrefix(comment, "# note: ")
+		if note == "" {
+			return errors.New("empty note")
+		}
+		*entry += "/" + note
+		return nil
+	}
+	hooks.Format = func(entry *string) (string, error) {
+		value, _, _ := strings.Cut(*entry, "/")
+		return testEntryHooks.Format(&value)
+	}
+	hooks.FormatComment = func(entry *string) (string, error) {
+		_, note, found := strings.Cut(*entry, "/")
+		if !found {
+			return "", nil
+		}
+		return "# note: " + note, nil
+	}
+	hooks.Keys = func(entry *string) []string {
+		value, _, _ := strings.Cut(*entry, "/")
+		return []string{strings.ToLower(value)}
+	}
+	return hooks
+}()
+
+const commentedDocumentContent = `# Test entries
+# note: first
+test=Foo
+test=Bar
+`
+
+func TestDocumentRepositoryComments(t *testing.T) {
+	entries, err := NewDocumentRepository(setUpFile(t, commentedDocumentContent, 0644), Options{}, commentedEntryHooks).Load()
+	require.NoError(t, err, "DocumentRepository.Load() returned an unexpected error")
+	assert.Equal(t, []string{"Foo/first", "Bar"}, entries.All(), "DocumentRepository.Load() returned unexpected entries")
+
+	testCases := []struct {
+		name            string
+		change          func(tx *DocumentTransaction[string]) error
+		expectedContent string
+	}{
+		{
+			name: "Append",
+			change: func(tx *DocumentTransaction[string]) error {
+				entry := "Baz/last"
+				return tx.Append(&entry)
+			},
+			expectedContent: commentedDocumentContent + "# note: last\ntest=Baz\n",
+		},
+		{
+			name: "ReplaceComment",
+			change: func(tx *DocumentTransaction[string]) error {
+				i, _ := tx.Entries().Lookup("foo")
+				entry := "Foo/changed"
+				return tx.Replace(i, &entry)
+			},
+			expectedContent: strings.Replace(commentedDocumentContent, "first", "changed", 1),
+		},
+		{
+			name: "RemoveComment",
+			change: func(tx *DocumentTransaction[string]) error {
+				i, _ := tx.Entries().Lookup("foo")
+				entry := "Foo"
+				return tx.Replace(i, &entry)
+			},
+			expectedContent: strings.Replace(commentedDocumentContent, "# note: first\n", "", 1),
+		},
+		{
+			name: "AddComment",
+			change: func(tx *DocumentTransaction[string]) error {
+				i, _ := tx.Entries().Lookup("bar")
+				entry := "Bar/second"
+				return tx.Replace(i, &entry)
+			},
+			expectedContent: strings.Replace(commentedDocumentContent, "test=Bar\n", "# note: second\ntest=Bar\n", 1),
+		},
+		{
+			name: "Remove",
+			change: func(tx *DocumentTransaction[string]) error {
+				i, _ := tx.Entries().Lookup("foo")
+				return tx.Remove(i)
+			},
+			expectedContent: "# Test entries\ntest=Bar\n",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := setUpFile(t, commentedDocumentContent, 0644)
+			repository := NewDocumentRepository(fileName, Options{}, commentedEntryHooks)
+
+			err := repository.Transaction(test.change)
+			assert.NoError(t, err, "DocumentRepository.Transaction() returned an unexpected error")
+
+			content, err := os.ReadFile(fileName)
+			require.NoError(t, err, "Failed to read the managed file")
+			assert.Equal(t, test.expectedContent, string(content), "Unexpected managed file content")
+		})
+	}
+}
+
+func TestDocumentRepositoryDiagnose(t *testing.T) {
+	fileName := setUpFile(t, "test=\n# note: \ntest=Foo\n", 0644)
+
+	// A broken comment is reported, but never makes the file unusable
+	entries, err := NewDocumentRepository(fileName, Options{}, commentedEntryHooks).Diagnose()
+	require.NoError(t, err, "DocumentRepository.Diagnose() returned an unexpected error")
+	assert.Equal(t, []string{"Foo"}, entries.All(), "DocumentRepository.Diagnose() returned unexpected entries")
+
+	diagnostics := entries.Diagnostics()
+	require.Len(t, diagnostics, 2, "Entries.Diagnostics() returned unexpected diagnostics")
+	assert.Equal(t, 1, diagnostics[0].Line, "Entries.Diagnostics() reported an unexpected line")
+	assert.Equal(t, "test=", diagnostics[0].Text, "Entries.Diagnostics() reported an unexpected text")
+	assert.Equal(t, 2, diagnostics[1].Line, "Entries.Diagnostics() reported an unexpected line")
+	assert.Equal(t, "# note: ", diagnostics[1].Text, "Entries.Diagnostics() reported an unexpected text")
+	assert.Equal(t, fileName, diagnostics[1].File, "Entries.Diagnostics() reported an unexpected file")
+}
+
+func TestEntriesLookupFunc(t *testing.T) {
+	entries, err := NewDocumentRepository(setUpFile(t, "test=Foo\ntest=foo\n", 0644), Options{}, testEntryHooks).Load()
+	require.NoError(t, err, "DocumentRepository.Load() returned an unexpected error")
+
+	i, entry := entries.LookupFunc("foo", func(entry string) bool { return entry == "foo" })
+	require.NotNil(t, entry, "Entries.LookupFunc() has not found the entry")
+	assert.Equal(t, 1, i, "Entries.LookupFunc() returned an unexpected index")
+	assert.Equal(t, "foo", *entry, "Entries.LookupFunc() returned an unexpected entry")
+
+	i, entry = entries.LookupFunc("foo", func(entry string) bool { return entry == "Bar" })
+	assert.Equal(t, -1, i, "Entries.LookupFunc() returned an unexpected index")
+	assert.Nil(t, entry, "Entries.LookupFunc() has found a missing entry")
+}
+
+func TestDocumentRepositoryRequired(t *testing.T) {
+	hooks := testEntryHooks
+	hooks.Required = true
+	repository := NewDocumentRepository(t.TempDir()+"/missing.conf", Options{}, hooks)
+
+	_, err := repository.Load()
+	assert.ErrorIs(t, err, os.ErrNotExist, "DocumentRepository.Load() returned an unexpected error")
+
+	err = repository.Transaction(func(tx *DocumentTransaction[string]) error {
+		entry := "Foo"
+		return tx.Append(&entry)
+	})
+	assert.ErrorIs(t, err, os.ErrNotExist, "DocumentRepository.Transaction() returned an unexpected error")
+}
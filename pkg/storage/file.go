@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// Mode used when the managed file does not exist yet
+	DefaultFileMode = os.FileMode(0644)
+	// Backups timestamp layout, it must sort lexicographically in chronological order
+	backupTimestampLayout = "20060102T150405.000000000"
+)
+
+type Options struct {
+	// Number of backups to keep next to the managed file, zero disables the backups
+	Backups int
+	// How long to wait for a lock held by another process, zero means not waiting at all
+	LockTimeout time.Duration
+	// Checks the new content before it replaces the live file, nil disables the validation
+	Validator Validator
+	// Lenient parsing, the lines that can't be parsed are kept verbatim and reported instead of making
+	// the whole file unusable
+	Lenient bool
+}
+
+// File is a dnsmasq configuration file managed by this application.
+//
+// Writes are crash-safe: the new content goes to a temporary file that is fsynced and then renamed over
+// the original one, so dnsmasq never sees a truncated configuration. The temporary and backup files are
+// hidden (dot) files, which dnsmasq ignores when reading its conf-dir.
+type File struct {
+	path    string
+	options Options
+}
+
+func NewFile(path string, options Options) *File {
+	return &File{
+		path:    path,
+		options: options,
+	}
+}
+
+func (f *File) Path() string {
+	return f.path
+}
+
+func (f *File) Open() (*os.File, error) {
+	return os.Open(f.path)
+}
+
+// Write atomically replaces the file content, preserving the original file owner and mode.
+func (f *File) Write(data []byte) error {
+	mode, uid, gid, exists, err := f.attributes()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), "."+filepath.Base(f.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = writeAndSync(tmp, data, mode, uid, gid, exists)
+	if err != nil {
+		return err
+	}
+
+	// The live file is only replaced by a valid candidate, a rejected one is simply thrown away
+	err = f.validate(tmp.Name())
+	if err != nil {
+		return err
+	}
+
+	if exists && f.options.Backups > 0 {
+		err = f.backup()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.Rename(tmp.Name(), f.path)
+	if err != nil {
+		return err
+	}
+
+	return syncDir(filepath.Dir(f.path))
+}
+
+func (f *File) validate(candidate string) error {
+	if f.options.Validator == nil {
+		return nil
+	}
+
+	err := f.options.Validator.Validate(candidate)
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		validationErr.File = f.path
+	}
+	return err
+}
+
+// attributes returns the mode and ownership the new file must have. Renaming over a read-only file
+// would succeed anyway, so its permissions are honored by checking that it can be opened for writing.
+func (f *File) attributes() (os.FileMode, int, int, bool, error) {
+	info, err := os.Stat(f.path)
+	if os.IsNotExist(err) {
+		return DefaultFileMode, -1, -1, false, nil
+	}
+	if err != nil {
+		return 0, -1, -1, false, err
+	}
+
+	file, err := os.OpenFile(f.path, os.O_WRONLY, 0)
+	if err != nil {
+		return 0, -1, -1, false, err
+	}
+	file.Close()
+
+	uid, gid := -1, -1
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		uid, gid = int(stat.Uid), int(stat.Gid)
+	}
+
+	return info.Mode().Perm(), uid, gid, true, nil
+}
+
+func writeAndSync(file *os.File, data []byte, mode os.FileMode, uid int, gid int, chown bool) error {
+	defer file.Close()
+
+	_, err := file.Write(data)
+	if err != nil {
+		return err
+	}
+
+	err = file.Chmod(mode)
+	if err != nil {
+		return err
+	}
+
+	if chown {
+		err = file.Chown(uid, gid)
+		if err != nil {
+			// Not fatal, an unprivileged process can't give away the file, it will be owned by us
+			slog.Warn("Could not preserve the file ownership",
+				slog.String("file", file.Name()),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	return file.Sync()
+}
+
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
+
+func (f *File) backupPrefix() string {
+	return filepath.Join(filepath.Dir(f.path), "."+filepath.Base(f.path)+".")
+}
+
+// backup keeps the current file content as a timestamped backup and removes the oldest backups
+// exceeding the configured limit.
+func (f *File) backup() error {
+	backup := fmt.Sprintf("%s%s.bak", f.backupPrefix(), time.Now().UTC().Format(backupTimestampLayout))
+
+	// The current file is about to be replaced, so a hard link is enough to keep its content around
+	err := os.Link(f.path, backup)
+	if err != nil {
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(backup, data, DefaultFileMode)
+		if err != nil {
+			return err
+		}
+	}
+
+	return f.rotateBackups()
+}
+
+// Backups returns the path of the existing backups, from the oldest to the newest one.
+func (f *File) Backups() ([]string, error) {
+	backups, err := filepath.Glob(f.backupPrefix() + "*.bak")
+	if err != nil {
+		return nil, err
+	}
+
+	backups = slices.DeleteFunc(backups, func(backup string) bool {
+		timestamp := strings.TrimSuffix(strings.TrimPrefix(backup, f.backupPrefix()), ".bak")
+		_, err := time.Parse(backupTimestampLayout, timestamp)
+		return err != nil
+	})
+	slices.Sort(backups)
+
+	return backups, nil
+}
+
+func (f *File) rotateBackups() error {
+	backups, err := f.Backups()
+	if err != nil {
+		return err
+	}
+
+	for len(backups) > f.options.Backups {
+		err = os.Remove(backups[0])
+		if err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setUpFile(t *testing.T, content string, mode os.FileMode) string {
+	fileName := filepath.Join(t.TempDir(), "04-dhcp-static-leases.conf")
+	require.NoError(t, os.WriteFile(fileName, []byte(content), mode), "Failed to create the managed file")
+	require.NoError(t, os.Chmod(fileName, mode), "Failed to set the managed file mode")
+	return fileName
+}
+
+func assertDirContent(t *testing.T, dir string, expected int) {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err, "Failed to read the managed file directory")
+	assert.Len(t, entries, expected, "Unexpected files left in the managed file directory")
+}
+
+func TestFileWrite(t *testing.T) {
+	testCases := []struct {
+		name   string
+		mode   os.FileMode
+		create bool
+	}{
+		{name: "ExistingFile", mode: 0644, create: true},
+		{name: "PreserveMode", mode: 0600, create: true},
+		{name: "NewFile", mode: DefaultFileMode, create: false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := filepath.Join(t.TempDir(), "04-dhcp-static-leases.conf")
+			if test.create {
+				fileName = setUpFile(t, "dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo", test.mode)
+			}
+
+			file := NewFile(fileName, Options{})
+			err := file.Write([]byte("dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar"))
+			require.NoError(t, err, "File.Write() returned an unexpected error")
+
+			data, err := os.ReadFile(fileName)
+			require.NoError(t, err, "Failed to read the managed file")
+			assert.Equal(t, "dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar", string(data), "File.Write() has written an unexpected content")
+
+			info, err := os.Stat(fileName)
+			require.NoError(t, err, "Failed to stat the managed file")
+			assert.Equal(t, test.mode, info.Mode().Perm(), "File.Write() has not preserved the file mode")
+
+			// No temporary files must be left behind
+			assertDirContent(t, filepath.Dir(fileName), 1)
+		})
+	}
+}
+
+func TestFileWriteReadOnly(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions are not enforced for root")
+	}
+
+	fileName := setUpFile(t, "dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo", 0444)
+
+	file := NewFile(fileName, Options{Backups: 1})
+	err := file.Write([]byte("dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar"))
+	assert.ErrorIs(t, err, os.ErrPermission, "File.Write() returned an unexpected error")
+
+	data, err := os.ReadFile(fileName)
+	require.NoError(t, err, "Failed to read the managed file")
+	assert.Equal(t, "dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo", string(data), "File.Write() has changed a read-only file")
+	assertDirContent(t, filepath.Dir(fileName), 1)
+}
+
+func TestFileWriteBackups(t *testing.T) {
+	testCases := []struct {
+		name            string
+		backups         int
+		expectedBackups []string
+	}{
+		{name: "Disabled", backups: 0, expectedBackups: []string{}},
+		{name: "KeepOne", backups: 1, expectedBackups: []string{"version 3"}},
+		{name: "Rotate", backups: 2, expectedBackups: []string{"version 2", "version 3"}},
+		{name: "KeepAll", backups: 5, expectedBackups: []string{"version 0", "version 1", "version 2", "version 3"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := setUpFile(t, "version 0", 0640)
+			file := NewFile(fileName, Options{Backups: test.backups})
+
+			for _, content := range []string{"version 1", "version 2", "version 3", "version 4"} {
+				require.NoError(t, file.Write([]byte(content)), "File.Write() returned an unexpected error")
+			}
+
+			backups, err := file.Backups()
+			require.NoError(t, err, "File.Backups() returned an unexpected error")
+
+			contents := []string{}
+			for _, backup := range backups {
+				assert.Equal(t, "."+filepath.Base(fileName), filepath.Base(backup)[:len(filepath.Base(fileName))+1], "Backups must be hidden files")
+				data, err := os.ReadFile(backup)
+				require.NoError(t, err, "Failed to read a backup file")
+				contents = append(contents, string(data))
+			}
+			assert.Equal(t, test.expectedBackups, contents, "File.Write() has kept unexpected backups")
+			assertDirContent(t, filepath.Dir(fileName), len(test.expectedBackups)+1)
+		})
+	}
+}
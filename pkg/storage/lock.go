@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+)
+
+// Interval between attempts to take a lock held by another process
+const lockRetryInterval = 10 * time.Millisecond
+
+// LockTimeoutError is returned when the file lock could not be taken within the configured timeout,
+// because another process (e.g. another manager instance or an operator script) is holding it.
+type LockTimeoutError struct {
+	File    string
+	Timeout time.Duration
+}
+
+const lockTimeoutErrorMessage = "timed out after %s waiting for the lock on %s"
+
+func (e *LockTimeoutError) Error() string {
+	return fmt.Sprintf(lockTimeoutErrorMessage, e.Timeout, e.File)
+}
+
+func (e *LockTimeoutError) Is(target error) bool {
+	return target == errkind.ErrLockTimeout || target == errkind.ErrStorageUnavailable
+}
+
+// Lock is an advisory (flock) lock taken on behalf of a File.
+type Lock struct {
+	file *os.File
+}
+
+// LockPath returns the path of the lock file.
+//
+// Atomic writes replace the managed file, so the lock is taken on a separate (hidden) file instead,
+// which is never replaced. Other processes can cooperate by locking the same file, e.g. with flock(1).
+func (f *File) LockPath() string {
+	return filepath.Join(filepath.Dir(f.path), "."+filepath.Base(f.path)+".lock")
+}
+
+// Lock takes an exclusive lock, to be held during a whole load-modify-save cycle.
+func (f *File) Lock() (*Lock, error) {
+	return f.lock(syscall.LOCK_EX)
+}
+
+// RLock takes a shared lock, to be held while reading the file.
+func (f *File) RLock() (*Lock, error) {
+	return f.lock(syscall.LOCK_SH)
+}
+
+func (f *File) lock(how int) (*Lock, error) {
+	file, err := os.OpenFile(f.LockPath(), os.O_RDWR|os.O_CREATE, DefaultFileMode)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(f.options.LockTimeout)
+	for {
+		err = syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB)
+		if err == nil {
+			return &Lock{file: file}, nil
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) && !errors.Is(err, syscall.EINTR) {
+			file.Close()
+			return nil, &os.PathError{Op: "flock", Path: file.Name(), Err: err}
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, &LockTimeoutError{File: f.path, Timeout: f.options.LockTimeout}
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// Unlock releases the lock.
+func (l *Lock) Unlock() error {
+	// Closing the lock file descriptor releases the lock
+	return l.file.Close()
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLockTimeout = 50 * time.Millisecond
+
+func TestFileLock(t *testing.T) {
+	testCases := []struct {
+		name        string
+		held        func(f *File) (*Lock, error)
+		requested   func(f *File) (*Lock, error)
+		expectError bool
+	}{
+		{name: "SharedAndShared", held: (*File).RLock, requested: (*File).RLock, expectError: false},
+		{name: "SharedAndExclusive", held: (*File).RLock, requested: (*File).Lock, expectError: true},
+		{name: "ExclusiveAndShared", held: (*File).Lock, requested: (*File).RLock, expectError: true},
+		{name: "ExclusiveAndExclusive", held: (*File).Lock, requested: (*File).Lock, expectError: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := setUpFile(t, "dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo", 0644)
+			// Two File instances behave like two processes, flock locks are bound to the open file
+			holder := NewFile(fileName, Options{LockTimeout: testLockTimeout})
+			requester := NewFile(fileName, Options{LockTimeout: testLockTimeout})
+
+			held, err := test.held(holder)
+			require.NoError(t, err, "Failed to take the first lock")
+
+			start := time.Now()
+			lock, err := test.requested(requester)
+			if !test.expectError {
+				require.NoError(t, err, "Lock() returned an unexpected error")
+				assert.NoError(t, lock.Unlock(), "Unlock() returned an unexpected error")
+				assert.NoError(t, held.Unlock(), "Unlock() returned an unexpected error")
+				return
+			}
+
+			var lockErr *LockTimeoutError
+			require.ErrorAs(t, err, &lockErr, "Lock() returned an unexpected error")
+			assert.Equal(t, fileName, lockErr.File, "LockTimeoutError has an unexpected file")
+			assert.Equal(t, testLockTimeout, lockErr.Timeout, "LockTimeoutError has an unexpected timeout")
+			assert.GreaterOrEqual(t, time.Since(start), testLockTimeout, "Lock() has not waited for the lock")
+
+			// Once released, the lock can be taken again
+			require.NoError(t, held.Unlock(), "Unlock() returned an unexpected error")
+			lock, err = test.requested(requester)
+			require.NoError(t, err, "Lock() returned an unexpected error")
+			assert.NoError(t, lock.Unlock(), "Unlock() returned an unexpected error")
+		})
+	}
+}
+
+func TestFileLockPath(t *testing.T) {
+	file := NewFile("/etc/dnsmasq.d/04-dhcp-static-leases.conf", Options{})
+	assert.Equal(t, "/etc/dnsmasq.d/.04-dhcp-static-leases.conf.lock", file.LockPath(), "Lock file must be a hidden file next to the managed one")
+
+	// The lock file must never be taken as a backup
+	fileName := setUpFile(t, "version 0", 0644)
+	file = NewFile(fileName, Options{Backups: 5})
+	lock, err := file.Lock()
+	require.NoError(t, err, "Lock() returned an unexpected error")
+	defer lock.Unlock()
+	require.NoError(t, file.Write([]byte("version 1")), "File.Write() returned an unexpected error")
+
+	backups, err := file.Backups()
+	require.NoError(t, err, "File.Backups() returned an unexpected error")
+	assert.Len(t, backups, 1, "File.Backups() returned unexpected backups")
+	assert.NotContains(t, backups, filepath.Join(filepath.Dir(fileName), "."+filepath.Base(fileName)+".lock"))
+}
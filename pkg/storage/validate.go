@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+)
+
+const (
+	// Placeholder replaced by the path of the candidate file in a validator command line
+	CandidatePlaceholder = "{file}"
+	// Default validator command, it checks the syntax of the candidate file with dnsmasq itself
+	DefaultValidatorCommand = "dnsmasq --test -C " + CandidatePlaceholder
+)
+
+// Validator checks the new content of a managed file before it replaces the live one.
+type Validator interface {
+	// Validate checks the candidate file at the given path, returning a *ValidationError when it is rejected, and
+	// any other error when it could not be checked at all.
+	Validate(candidate string) error
+}
+
+// ValidationError is returned when the validator rejected the new content of a managed file. The live
+// file is left untouched.
+type ValidationError struct {
+	File string
+	Err  error
+	// Combined output of the validator command
+	Output string
+}
+
+const validationErrorMessage = "new content of %s rejected by the validator: %s"
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf(validationErrorMessage, e.File, e.Err.Error())
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == errkind.ErrValidation
+}
+
+// CommandValidator validates the candidate file by running a command, which must exit with a non-zero
+// status to reject it. A command that can't be run (e.g. it is not installed) rejects nothing, its error is
+// returned as it is.
+type CommandValidator struct {
+	command []string
+}
+
+// NewCommandValidator returns a validator running the given command line, split on spaces (it is not
+// interpreted by a shell). Every CandidatePlaceholder argument is replaced by the candidate file path,
+// which is appended as the last argument when there is no placeholder at all. An empty command line
+// disables the validation (nil Validator).
+func NewCommandValidator(command string) Validator {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+
+	return &CommandValidator{command: args}
+}
+
+func (v *CommandValidator) Validate(candidate string) error {
+	args := make([]string, 0, len(v.command)+1)
+	placeholder := false
+	for _, arg := range v.command[1:] {
+		if strings.Contains(arg, CandidatePlaceholder) {
+			placeholder = true
+			arg = strings.ReplaceAll(arg, CandidatePlaceholder, candidate)
+		}
+		args = append(args, arg)
+	}
+	if !placeholder {
+		args = append(args, candidate)
+	}
+
+	var output bytes.Buffer
+	cmd := exec.Command(v.command[0], args...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ValidationError{Err: err, Output: strings.TrimSpace(output.String())}
+	}
+	if err != nil {
+		return fmt.Errorf("running the validator %s: %w", v.command[0], err)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/errkind"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Fake dnsmasq --test: rejects any file containing the "invalid" word
+const fakeValidatorScript = `#!/bin/sh
+if [ "$1" != "--test" ]; then
+	echo "unexpected arguments: $@"
+	exit 2
+fi
+if grep -q invalid "$2"; then
+	echo "dnsmasq: bad option at line 1 of $2"
+	exit 1
+fi
+echo "dnsmasq: syntax check OK."
+`
+
+func setUpFakeValidator(t *testing.T) string {
+	script := filepath.Join(t.TempDir(), "fake-dnsmasq")
+	require.NoError(t, os.WriteFile(script, []byte(fakeValidatorScript), 0755), "Failed to create the fake validator")
+	return script
+}
+
+func TestNewCommandValidator(t *testing.T) {
+	assert.Nil(t, NewCommandValidator(""), "NewCommandValidator() must disable the validation for an empty command")
+	assert.Nil(t, NewCommandValidator("   "), "NewCommandValidator() must disable the validation for an empty command")
+	assert.NotNil(t, NewCommandValidator(DefaultValidatorCommand), "NewCommandValidator() unexpectedly returned a nil validator")
+}
+
+func TestCommandValidator(t *testing.T) {
+	script := setUpFakeValidator(t)
+
+	testCases := []struct {
+		name           string
+		command        string
+		content        string
+		expectError    bool
+		expectedOutput string
+	}{
+		{name: "Valid", command: script + " --test {file}", content: "dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo"},
+		{name: "Invalid", command: script + " --test {file}", content: "invalid", expectError: true, expectedOutput: "dnsmasq: bad option at line 1 of "},
+		{name: "AppendedCandidate", command: script + " --test", content: "dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo"},
+		{name: "AppendedCandidateInvalid", command: script + " --test", content: "invalid", expectError: true, expectedOutput: "bad option"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			candidate := setUpFile(t, test.content, 0644)
+
+			err := NewCommandValidator(test.command).Validate(candidate)
+			if !test.expectError {
+				assert.NoError(t, err, "Validate() returned an unexpected error")
+				return
+			}
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr, "Validate() returned an unexpected error")
+			assert.Contains(t, validationErr.Output, test.expectedOutput, "ValidationError has an unexpected output")
+		})
+	}
+}
+
+func TestCommandValidatorNotRun(t *testing.T) {
+	candidate := setUpFile(t, "", 0644)
+
+	// A validator that can't be run rejects nothing, the file can't be checked at all
+	err := NewCommandValidator("dnsmasq-manager-nonexistent-validator --test -C {file}").Validate(candidate)
+	assert.ErrorIs(t, err, exec.ErrNotFound, "Validate() returned an unexpected error")
+	assert.NotErrorIs(t, err, errkind.ErrValidation, "Validate() rejected the candidate")
+
+	err = NewCommandValidator("/nonexistent/dnsmasq --test -C {file}").Validate(candidate)
+	assert.ErrorIs(t, err, fs.ErrNotExist, "Validate() returned an unexpected error")
+	assert.NotErrorIs(t, err, errkind.ErrValidation, "Validate() rejected the candidate")
+}
+
+func TestFileWriteValidation(t *testing.T) {
+	script := setUpFakeValidator(t)
+
+	testCases := []struct {
+		name            string
+		content         string
+		expectError     bool
+		expectedContent string
+	}{
+		{name: "Accepted", content: "dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar", expectedContent: "dhcp-host=02:04:06:dd:ee:ff,1.1.1.2,Bar"},
+		{name: "Rejected", content: "dhcp-host=invalid", expectError: true, expectedContent: "dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := setUpFile(t, "dhcp-host=02:04:06:aa:bb:cc,1.1.1.1,Foo", 0644)
+			file := NewFile(fileName, Options{Backups: 5, Validator: NewCommandValidator(script + " --test {file}")})
+
+			err := file.Write([]byte(test.content))
+			if test.expectError {
+				var validationErr *ValidationError
+				require.ErrorAs(t, err, &validationErr, "File.Write() returned an unexpected error")
+				assert.Equal(t, fileName, validationErr.File, "ValidationError has an unexpected file")
+				assert.Contains(t, validationErr.Output, "bad option", "ValidationError has an unexpected output")
+			} else {
+				require.NoError(t, err, "File.Write() returned an unexpected error")
+			}
+
+			data, err := os.ReadFile(fileName)
+			require.NoError(t, err, "Failed to read the managed file")
+			assert.Equal(t, test.expectedContent, string(data), "File.Write() has left an unexpected content")
+
+			// A rejected candidate leaves neither temporary files nor backups behind
+			backups, err := file.Backups()
+			require.NoError(t, err, "File.Backups() returned an unexpected error")
+			if test.expectError {
+				assert.Empty(t, backups, "File.Write() has created a backup for a rejected content")
+				assertDirContent(t, filepath.Dir(fileName), 1)
+			} else {
+				assert.Len(t, backups, 1, "File.Write() has not created a backup")
+			}
+		})
+	}
+}
@@ -37,9 +37,10 @@ func GetBodyJSON(response *http.Response) (map[string]any, error) {
 }
 
 func JSONMatches(expected string, actual string) bool {
-	expectedJSON, _ := UnmarshalJSON([]byte(expected))
-	actualJSON, _ := UnmarshalJSON([]byte(actual))
-	return JSONMapMatches(expectedJSON, actualJSON)
+	var expectedJSON, actualJSON any
+	_ = json.Unmarshal([]byte(expected), &expectedJSON)
+	_ = json.Unmarshal([]byte(actual), &actualJSON)
+	return jsonValueMatches(expectedJSON, actualJSON)
 }
 
 func JSONMapMatches(expected map[string]any, actual map[string]any) bool {
@@ -49,52 +50,84 @@ func JSONMapMatches(expected map[string]any, actual map[string]any) bool {
 
 	for key, expected_value := range expected {
 		actual_value, exists := actual[key]
-		if !exists {
+		if !exists || !jsonValueMatches(expected_value, actual_value) {
 			return false
 		}
+	}
 
-		switch v := expected_value.(type) {
-		case []interface{}:
-			for _, expected_inner_value := range v {
-				match := false
-				for _, actual_inner_value := range actual_value.([]interface{}) {
-					if JSONMapMatches(expected_inner_value.(map[string]any), actual_inner_value.(map[string]any)) {
-						match = true
-						break
-					}
-				}
+	return true
+}
 
-				if !match {
-					return false
+// jsonValueMatches compares any JSON value, the expected strings are regular expressions and the array items
+// may be in any order
+func jsonValueMatches(expected any, actual any) bool {
+	switch v := expected.(type) {
+	case nil:
+		return actual == nil
+	case map[string]any:
+		actual_map, ok := actual.(map[string]any)
+		return ok && JSONMapMatches(v, actual_map)
+	case []interface{}:
+		actual_values, ok := actual.([]interface{})
+		if !ok || len(v) != len(actual_values) {
+			return false
+		}
+		for _, expected_inner_value := range v {
+			match := false
+			for _, actual_inner_value := range actual_values {
+				if jsonValueMatches(expected_inner_value, actual_inner_value) {
+					match = true
+					break
 				}
 			}
-		case string:
-			match, _ := regexp.MatchString(v, actual_value.(string))
+
 			if !match {
 				return false
 			}
-		default:
-			log.Fatalf("Un-expected type: %T", v)
 		}
+		return true
+	case string:
+		actual_string, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		match, _ := regexp.MatchString(v, actual_string)
+		return match
+	case float64:
+		return v == actual
+	default:
+		log.Fatalf("Un-expected type: %T", v)
 	}
 
-	return true
+	return false
 }
 
-func errorJSON(statusCode int, message string, details string) string {
+func errorJSON(statusCode int, code string, message string, details string) string {
 	return fmt.Sprintf(`{
 		"error": "%s",
+		"code": "%s",
 		"message": "%s",
 		"details": %s
-	}`, http.StatusText(statusCode), message, details)
+	}`, http.StatusText(statusCode), code, message, details)
+}
+
+func ErrorJSON(statusCode int, code string, message string, details string) string {
+	return errorJSON(statusCode, code, message, fmt.Sprintf(`"%s"`, details))
 }
 
-func ErrorJSON(statusCode int, message string, details string) string {
-	return errorJSON(statusCode, message, fmt.Sprintf(`"%s"`, details))
+// ReloadFailedJSON is the error response of a change saved before dnsmasq failed to reload, along with the resource.
+func ReloadFailedJSON(message string, details string, resource string) string {
+	return fmt.Sprintf(`{
+		"error": "%s",
+		"code": "reload_failed",
+		"message": "%s",
+		"details": "%s",
+		"resource": %s
+	}`, http.StatusText(http.StatusBadGateway), message, details, resource)
 }
 
 func ValidationErrorJSON(message string, field string, reason string, value string) string {
-	return errorJSON(http.StatusUnprocessableEntity, message, fmt.Sprintf(`[{
+	return errorJSON(http.StatusUnprocessableEntity, "validation_failed", message, fmt.Sprintf(`[{
 		"field": "%s",
 		"reason": "%s",
 		"value": "%s"